@@ -0,0 +1,256 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/machine/libmachine/state"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/addons"
+	"k8s.io/minikube/pkg/minikube/clusterfile"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/delete"
+	"k8s.io/minikube/pkg/minikube/driver"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/node"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/out/register"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var applyFile string
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply -f <cluster file>",
+	Short: "Creates or updates a profile to match a cluster file",
+	Long: `Creates or updates a profile to match a declarative cluster file.
+
+The file is validated before anything is changed. A missing profile is created, an existing profile has its nodes added or removed, its addons toggled, and is restarted if its Kubernetes settings changed. Settings which only take effect when a profile is created, such as the driver, resources, registries and mounts, are rejected if they differ from the profile.
+Use "minikube export" to write the cluster file of an existing profile.`,
+	Example: "minikube apply -f cluster.yaml",
+	Run:     runApply,
+}
+
+func runApply(cmd *cobra.Command, args []string) {
+	if applyFile == "" || len(args) != 0 {
+		exit.Message(reason.Usage, "Usage: minikube apply -f <cluster file>")
+	}
+
+	c, err := clusterfile.Load(applyFile)
+	if err != nil {
+		exit.Message(reason.Usage, "Unable to read cluster file {{.file}}: {{.error}}", out.V{"file": applyFile, "error": err})
+	}
+	exitIfInvalidClusterFile(clusterfile.Validate(c))
+
+	if c.Metadata.Name != "" {
+		if RootCmd.PersistentFlags().Changed(config.ProfileName) && ClusterFlagValue() != c.Metadata.Name {
+			exit.Message(reason.Usage, "The --profile flag ({{.flag}}) conflicts with metadata.name ({{.name}}) of the cluster file", out.V{"flag": ClusterFlagValue(), "name": c.Metadata.Name})
+		}
+		viper.Set(config.ProfileName, c.Metadata.Name)
+	}
+	cname := ClusterFlagValue()
+
+	existing, err := config.Load(cname)
+	if err != nil && !config.IsNotExist(err) {
+		exit.Error(reason.HostConfigLoad, "Unable to load config", err)
+	}
+
+	if existing == nil {
+		out.Step(style.New, "Creating profile {{.profile}} from {{.file}}", out.V{"profile": cname, "file": applyFile})
+		setStartFlagsFromClusterFile(c)
+		runStart(startCmd, nil)
+	} else {
+		exitIfInvalidClusterFile(clusterfile.ValidateUpdate(c, existing))
+		changed := c.UpdateConfig(existing)
+		if changed {
+			if err := config.SaveProfile(cname, existing); err != nil {
+				exit.Error(reason.HostSaveProfile, "Failed to save config", err)
+			}
+		}
+		if changed || !profileRunning(existing) {
+			out.Step(style.Restarting, "Starting profile {{.profile}} to apply the Kubernetes settings of {{.file}}", out.V{"profile": cname, "file": applyFile})
+			runStart(startCmd, nil)
+		}
+	}
+
+	co := mustload.Healthy(cname)
+	reconcileNodes(c, co.Config)
+	reconcileAddons(c, cname)
+
+	out.Step(style.Ready, "Profile {{.profile}} matches {{.file}}", out.V{"profile": cname, "file": applyFile})
+}
+
+// exitIfInvalidClusterFile prints every validation error of a cluster file and exits
+func exitIfInvalidClusterFile(errs field.ErrorList) {
+	if len(errs) == 0 {
+		return
+	}
+	for _, err := range errs {
+		out.ErrT(style.Failure, "{{.error}}", out.V{"error": err.Error()})
+	}
+	exit.Message(reason.Usage, "{{.file}} is not a valid cluster file ({{.count}} error(s))", out.V{"file": applyFile, "count": len(errs)})
+}
+
+// profileRunning returns whether the primary control plane of a profile is running
+func profileRunning(cc *config.ClusterConfig) bool {
+	api, err := machine.NewAPIClient()
+	if err != nil {
+		klog.Warningf("failed to get machine api client: %v", err)
+		return false
+	}
+	defer api.Close()
+
+	cp, err := config.PrimaryControlPlane(cc)
+	if err != nil {
+		return false
+	}
+	st, err := machine.Status(api, config.MachineName(*cc, cp))
+	if err != nil {
+		klog.Warningf("failed to get status of %s: %v", cc.Name, err)
+		return false
+	}
+	return st == state.Running.String()
+}
+
+// setStartFlagsFromClusterFile sets the start flags for a profile created from a cluster file
+func setStartFlagsFromClusterFile(c *clusterfile.Cluster) {
+	s := c.Spec
+	k8s := s.Kubernetes
+
+	flags := map[string]string{
+		"driver":              s.Driver,
+		memory:                s.Memory,
+		humanReadableDiskSize: s.DiskSize,
+		kubernetesVersion:     k8s.Version,
+		containerRuntime:      k8s.ContainerRuntime,
+		cniFlag:               k8s.CNI,
+		featureGates:          k8s.FeatureGates,
+		serviceCIDR:           k8s.ServiceCIDR,
+		dnsDomain:             k8s.DNSDomain,
+		imageRepository:       k8s.ImageRepository,
+		"apiserver-names":     strings.Join(k8s.APIServerNames, ","),
+		"registry-mirror":     strings.Join(s.RegistryMirrors, ","),
+		"insecure-registry":   strings.Join(s.InsecureRegistries, ","),
+	}
+	if s.CPUs != 0 {
+		flags[cpus] = strconv.Itoa(s.CPUs)
+	}
+
+	enabled := []string{}
+	for _, name := range clusterfile.SortedAddons(s.Addons) {
+		if s.Addons[name] {
+			enabled = append(enabled, name)
+		}
+	}
+	flags[config.AddonListFlag] = strings.Join(enabled, ",")
+
+	if len(s.Mounts) == 1 {
+		m := s.Mounts[0]
+		flags[createMount] = "true"
+		flags[mountString] = m.MountString()
+		flags[mountTypeFlag] = m.Type
+		flags[mountUID] = m.UID
+		flags[mountGID] = m.GID
+		flags[mountOptions] = strings.Join(m.Options, ",")
+	}
+
+	for name, value := range flags {
+		if value == "" {
+			continue
+		}
+		if err := startCmd.Flags().Set(name, value); err != nil {
+			exit.Error(reason.InternalBindFlags, fmt.Sprintf("Unable to set flag %s", name), err)
+		}
+	}
+	for _, eo := range k8s.ExtraOptions {
+		if err := startCmd.Flags().Set("extra-config", fmt.Sprintf("%s.%s=%s", eo.Component, eo.Key, eo.Value)); err != nil {
+			exit.Error(reason.InternalBindFlags, "Unable to set flag extra-config", err)
+		}
+	}
+}
+
+// reconcileNodes adds and removes nodes so that the profile matches the cluster file
+func reconcileNodes(c *clusterfile.Cluster, cc *config.ClusterConfig) {
+	add, remove := c.NodeChanges(cc)
+	if len(add)+len(remove) > 0 && driver.BareMetal(cc.Driver) {
+		exit.Message(reason.DrvUnsupportedMulti, "The none driver is not compatible with multi-node clusters.")
+	}
+
+	for _, name := range remove {
+		out.Step(style.DeletingHost, "Deleting node {{.name}} from cluster {{.cluster}}", out.V{"name": name, "cluster": cc.Name})
+		n, err := node.Delete(*cc, name)
+		if err != nil {
+			exit.Error(reason.GuestNodeDelete, "deleting node", err)
+		}
+		if driver.IsKIC(cc.Driver) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			delete.PossibleLeftOvers(ctx, config.MachineName(*cc, *n), cc.Driver)
+			cancel()
+		}
+	}
+
+	if len(remove) > 0 {
+		reloaded, err := config.Load(cc.Name)
+		if err != nil {
+			exit.Error(reason.HostConfigLoad, "Unable to load config", err)
+		}
+		*cc = *reloaded
+	}
+
+	for _, n := range add {
+		out.Step(style.Happy, "Adding node {{.name}} to cluster {{.cluster}}", out.V{"name": n.Name, "cluster": cc.Name})
+		register.Reg.SetStep(register.InitialSetup)
+		if err := node.Add(cc, n, false); err != nil {
+			exit.Error(reason.GuestNodeAdd, "failed to add node", err)
+		}
+	}
+}
+
+// reconcileAddons enables and disables addons so that the profile matches the cluster file
+func reconcileAddons(c *clusterfile.Cluster, cname string) {
+	cc, err := config.Load(cname)
+	if err != nil {
+		exit.Error(reason.HostConfigLoad, "Unable to load config", err)
+	}
+
+	changes := c.AddonChanges(cc)
+	for _, name := range clusterfile.SortedAddons(changes) {
+		enabled := changes[name]
+		if err := addons.SetAndSave(cname, name, strconv.FormatBool(enabled)); err != nil {
+			if enabled {
+				exit.Error(reason.InternalAddonEnable, "enable failed", err)
+			}
+			exit.Error(reason.InternalAddonDisable, "disable failed", err)
+		}
+	}
+}
+
+func init() {
+	applyCmd.Flags().StringVarP(&applyFile, "file", "f", "", "Path to the cluster file to apply")
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/audit"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var (
+	auditCommand string
+	auditSince   string
+	auditFailed  bool
+	auditOutput  string
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Query the log of executed minikube commands",
+	Long: `Query the log of executed minikube commands, including rotated logs.
+Each entry records the user, arguments, duration, exit code and failure reason of a command.
+Entries are filtered by profile when --profile is given.`,
+	Example: `minikube audit --command=delete -p shared
+minikube audit --failed --since=2022-05-31 -o json
+minikube audit --since=24h -o csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		f := audit.Filter{
+			Command: auditCommand,
+			Failed:  auditFailed,
+		}
+		if RootCmd.PersistentFlags().Changed(config.ProfileName) {
+			f.Profile = ClusterFlagValue()
+		}
+		if auditSince != "" {
+			since, err := parseSince(auditSince, time.Now())
+			if err != nil {
+				exit.Message(reason.Usage, "invalid --since value {{.since}}: use a duration such as 24h or a date such as 2006-01-02", out.V{"since": auditSince})
+			}
+			f.Since = since
+		}
+
+		r, err := audit.Query(f)
+		if err != nil {
+			exit.Error(reason.InternalAuditQuery, "Failed to read the audit log", err)
+		}
+
+		switch strings.ToLower(auditOutput) {
+		case "json":
+			b, err := r.JSON()
+			if err != nil {
+				exit.Error(reason.InternalJSONMarshal, "json encoding failure", err)
+			}
+			out.String("%s", b)
+		case "csv":
+			s, err := r.CSV()
+			if err != nil {
+				exit.Error(reason.InternalAuditQuery, "csv encoding failure", err)
+			}
+			out.String("%s", s)
+		case "table":
+			if r.Len() == 0 {
+				out.Styled(style.Empty, "No matching audit log entries found.")
+				return
+			}
+			out.Styled(style.Empty, r.ASCIITable())
+		default:
+			exit.Message(reason.Usage, fmt.Sprintf("invalid output format: %s. Valid values: 'table', 'json', 'csv'", auditOutput))
+		}
+	},
+}
+
+// parseSince parses a --since value, either a duration before now or a date or timestamp in local time
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse %q", s)
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditCommand, "command", "", "Only show entries of the given command, such as 'start' or 'delete'")
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "Only show entries started after the given duration ago (24h) or date (2006-01-02, 2006-01-02T15:04 or RFC3339)")
+	auditCmd.Flags().BoolVar(&auditFailed, "failed", false, "Only show entries of commands which failed")
+	auditCmd.Flags().StringVarP(&auditOutput, "output", "o", "table", "The output format. One of 'table', 'json', 'csv'")
+}
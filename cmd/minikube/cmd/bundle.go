@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	cmdConfig "k8s.io/minikube/cmd/minikube/cmd/config"
+	"k8s.io/minikube/pkg/minikube/bundle"
+	"k8s.io/minikube/pkg/minikube/constants"
+	"k8s.io/minikube/pkg/minikube/download"
+	"k8s.io/minikube/pkg/minikube/driver"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+	"k8s.io/minikube/pkg/version"
+)
+
+var (
+	bundleK8sVersion   string
+	bundleDriver       string
+	bundleRuntime      string
+	bundleAddons       []string
+	bundleOutput       string
+	bundleBinaryMirror string
+	bundleSignKey      string
+	bundleVerifyOnly   bool
+)
+
+// bundleCmd represents the bundle command
+var bundleCmd = &cobra.Command{
+	Use:   "bundle COMMAND",
+	Short: "Create and import offline bundles",
+	Long:  "Create a bundle of the files minikube start downloads, to import it on a host without network access",
+}
+
+// bundleCreateCmd represents the bundle create command
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a bundle of the files needed to start a cluster offline",
+	Long: `Downloads the files minikube start needs for a Kubernetes version, driver and container runtime: the preload or the images and binaries of Kubernetes, the ISO or the base image, the executable of the kvm2 or hyperkit driver, and the images of the addons.
+They are bundled into a single archive, whose manifest lists the checksum of every file.
+With --sign-key, the manifest is signed, so that the files are trusted by hosts whose download-public-key is the matching public key.`,
+	Example: `minikube bundle create --kubernetes-version=v1.24.1 --driver=docker --container-runtime=containerd --addons=metrics-server,dashboard`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := bundle.Options{
+			KubernetesVersion: resolveKubernetesVersion(bundleK8sVersion),
+			Driver:            bundleDriver,
+			ContainerRuntime:  bundleRuntime,
+			Bootstrapper:      viper.GetString(cmdConfig.Bootstrapper),
+			BinaryMirror:      bundleBinaryMirror,
+			Addons:            bundleAddons,
+		}
+		if !driver.Supported(opts.Driver) {
+			exit.Message(reason.DrvUnsupportedOS, "The driver '{{.driver}}' is not supported on {{.os}}/{{.arch}}", out.V{"driver": opts.Driver, "os": runtime.GOOS, "arch": runtime.GOARCH})
+		}
+		if !validBundleRuntime(opts.ContainerRuntime) {
+			exit.Message(reason.Usage, "Invalid container runtime {{.runtime}}: expected one of docker, containerd or crio", out.V{"runtime": opts.ContainerRuntime})
+		}
+		output := bundleOutput
+		if output == "" {
+			output = fmt.Sprintf("minikube-bundle-%s-%s-%s.tar", opts.KubernetesVersion, opts.Driver, opts.ContainerRuntime)
+		}
+
+		out.Step(style.FileDownload, "Downloading the files of Kubernetes {{.version}} ...", out.V{"version": opts.KubernetesVersion})
+		m, err := bundle.Create(opts, output, bundleSignKey)
+		if err != nil {
+			exit.Error(reason.HostBundle, "Failed to create the bundle", err)
+		}
+		renderBundle(m)
+		out.Step(style.Check, "Created {{.path}} ({{.size}})", out.V{"path": output, "size": humanSize(m.Size())})
+	},
+}
+
+// bundleImportCmd represents the bundle import command
+var bundleImportCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Import a bundle, to start clusters without network access",
+	Long: `Verifies the files of a bundle against its manifest, and adds them to the minikube home, so minikube start needs no network access with the Kubernetes version, driver and container runtime of the bundle.
+The images of its addons are added to the images minikube cache loads on start.`,
+	Example: `minikube bundle import minikube-bundle-v1.24.1-docker-containerd.tar
+minikube start --kubernetes-version=v1.24.1 --driver=docker --container-runtime=containerd`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if bundleVerifyOnly {
+			m, err := bundle.Verify(args[0])
+			if err != nil {
+				exit.Error(reason.HostBundle, "Failed to verify the bundle", err)
+			}
+			renderBundle(m)
+			out.Step(style.Check, "Verified the bundle of {{.bundle}}", out.V{"bundle": m.String()})
+			return
+		}
+
+		m, err := bundle.Import(args[0])
+		if err != nil {
+			exit.Error(reason.HostBundle, "Failed to import the bundle", err)
+		}
+		if len(m.AddonImages) > 0 {
+			if err := cmdConfig.AddToConfigMap(cacheImageConfigKey, m.AddonImages); err != nil {
+				exit.Error(reason.InternalAddConfig, "Failed to update config", err)
+			}
+		}
+		renderBundle(m)
+		if !m.Signed && download.StrictPolicy() {
+			out.WarningT("The manifest of the bundle is not signed by the download public key, so its files are refused by the strict download policy")
+		}
+		if m.MinikubeVersion != version.GetVersion() {
+			out.WarningT("The bundle was created by minikube {{.bundle}}, which may download other files than minikube {{.version}}", out.V{"bundle": m.MinikubeVersion, "version": version.GetVersion()})
+		}
+		out.Step(style.Check, "Imported the bundle of {{.bundle}}", out.V{"bundle": m.String()})
+		out.Styled(style.Tip, "Start a cluster with: minikube start --kubernetes-version={{.version}} --driver={{.driver}} --container-runtime={{.runtime}}",
+			out.V{"version": m.KubernetesVersion, "driver": m.Driver, "runtime": m.ContainerRuntime})
+	},
+}
+
+// resolveKubernetesVersion returns the Kubernetes version v stands for, as chosen by minikube start
+func resolveKubernetesVersion(v string) string {
+	switch strings.ToLower(v) {
+	case "", "stable":
+		v = constants.DefaultKubernetesVersion
+	case "latest", "newest":
+		v = constants.NewestKubernetesVersion
+	}
+	nvs, err := semver.Make(strings.TrimPrefix(v, version.VersionPrefix))
+	if err != nil {
+		exit.Message(reason.Usage, `Unable to parse "{{.kubernetes_version}}": {{.error}}`, out.V{"kubernetes_version": v, "error": err})
+	}
+	return version.VersionPrefix + nvs.String()
+}
+
+func validBundleRuntime(cr string) bool {
+	switch cr {
+	case "docker", "containerd", "crio", "cri-o":
+		return true
+	}
+	return false
+}
+
+// renderBundle prints the files of a bundle
+func renderBundle(m *bundle.Manifest) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Kind", "File", "Size", "SHA256"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("|")
+	for _, f := range m.Files {
+		table.Append([]string{f.Kind, f.Path, humanSize(f.Size), f.SHA256[:12]})
+	}
+	table.Render()
+}
+
+func init() {
+	bundleCreateCmd.Flags().StringVar(&bundleK8sVersion, "kubernetes-version", "", fmt.Sprintf("The Kubernetes version of the bundle, e.g. %s (stable)", constants.DefaultKubernetesVersion))
+	bundleCreateCmd.Flags().StringVar(&bundleDriver, "driver", driver.Docker, "The driver of the clusters started with the bundle")
+	bundleCreateCmd.Flags().StringVar(&bundleRuntime, "container-runtime", constants.Docker, "The container runtime of the clusters started with the bundle. Valid options: docker, containerd, crio")
+	bundleCreateCmd.Flags().StringSliceVar(&bundleAddons, "addons", nil, "Addons whose images are added to the bundle")
+	bundleCreateCmd.Flags().StringVarP(&bundleOutput, "output", "o", "", "Path of the bundle (default: minikube-bundle-<kubernetes version>-<driver>-<container runtime>.tar)")
+	bundleCreateCmd.Flags().StringVar(&bundleBinaryMirror, "binary-mirror", "", "Location to fetch kubectl, kubelet, & kubeadm binaries from.")
+	bundleCreateCmd.Flags().StringVar(&bundleSignKey, "sign-key", "", "Path of the PEM encoded ECDSA or RSA private key signing the manifest of the bundle")
+	bundleCmd.AddCommand(bundleCreateCmd)
+
+	bundleImportCmd.Flags().BoolVar(&bundleVerifyOnly, "verify", false, "Only verify the files of the bundle against its manifest, without importing them")
+	bundleCmd.AddCommand(bundleImportCmd)
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/klog/v2"
+
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/detect"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/registrycache"
+)
+
+var (
+	cacheStatsFormat string
+	cacheServePort   int
+	cacheServeAddrs  []string
+	cacheServeSize   int64
+)
+
+// imageCacheStats is the content of the image cache, which stores the images of minikube cache add and the images of the bootstrapper
+type imageCacheStats struct {
+	Dir    string
+	Images int
+	Size   int64
+}
+
+// registryCacheStats is the state of the registry cache along with its counters
+type registryCacheStats struct {
+	Dir       string
+	Running   bool
+	Port      int
+	MaxSize   int64
+	Profiles  []string
+	Blobs     int
+	Manifests int
+	Size      int64
+	*registrycache.Stats
+	HitRatio float64
+}
+
+type cacheStats struct {
+	ImageCache    imageCacheStats
+	RegistryCache registryCacheStats
+}
+
+// statsCacheCmd represents the cache stats command
+var statsCacheCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the usage of the image and registry caches",
+	Long: `Show the usage of the image cache, along with the state and counters of the registry cache which the profiles started with --registry-cache pull the images of docker hub through.
+The hits are the blobs and manifests served from the registry cache, the misses the ones pulled from docker hub.`,
+	Example: `
+$ minikube cache stats
+
+$ minikube cache stats --format=json
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := collectCacheStats()
+		if err != nil {
+			exit.Error(reason.HostRegistryCache, "Failed to get the cache statistics", err)
+		}
+		switch cacheStatsFormat {
+		case "json":
+			data, err := json.Marshal(st)
+			if err != nil {
+				exit.Error(reason.InternalJSONMarshal, "Failed to marshal the cache statistics", err)
+			}
+			fmt.Println(string(data))
+		case "table":
+			renderCacheStats(st)
+		default:
+			exit.Message(reason.Usage, "Invalid --format {{.format}}: expected one of table or json", out.V{"format": cacheStatsFormat})
+		}
+	},
+}
+
+// serveCacheCmd runs the server of the registry cache, started in the background by minikube start --registry-cache
+var serveCacheCmd = &cobra.Command{
+	Use:    "serve",
+	Short:  "Serve the registry cache",
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := registrycache.Serve(cacheServeAddrs, cacheServePort, cacheServeSize); err != nil {
+			exit.Error(reason.HostRegistryCache, "Failed to serve the registry cache", err)
+		}
+	},
+}
+
+func collectCacheStats() (*cacheStats, error) {
+	st := &cacheStats{ImageCache: imageCacheStats{Dir: detect.ImageCacheDir()}}
+	err := filepath.Walk(st.ImageCache.Dir, func(p string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !strings.HasSuffix(p, ".tmp") {
+			st.ImageCache.Images++
+			st.ImageCache.Size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &st.RegistryCache
+	rc.Dir = registrycache.Dir()
+	server, err := registrycache.Running()
+	if err != nil {
+		return nil, err
+	}
+	if server != nil {
+		rc.Running = true
+		rc.Port = server.Port
+		rc.MaxSize = server.MaxSize
+	} else {
+		rc.Port = viper.GetInt(config.RegistryCachePort)
+		if rc.MaxSize, err = units.RAMInBytes(viper.GetString(config.RegistryCacheSize)); err != nil {
+			klog.Warningf("invalid registry cache size: %v", err)
+		}
+	}
+	if rc.Profiles, err = registrycache.Profiles(); err != nil {
+		return nil, err
+	}
+	usage, err := registrycache.NewStore(rc.Dir, 0).Usage()
+	if err != nil {
+		return nil, err
+	}
+	rc.Blobs, rc.Manifests, rc.Size = usage.Blobs, usage.Manifests, usage.Size
+	if rc.Stats, err = registrycache.LoadStats(rc.Dir); err != nil {
+		return nil, err
+	}
+	rc.HitRatio = rc.Stats.HitRatio()
+	return st, nil
+}
+
+func renderCacheStats(st *cacheStats) {
+	rc := st.RegistryCache
+	caches := imagesTable([]string{"Cache", "Location", "Entries", "Size", "Max Size"})
+	caches.Append([]string{"images", st.ImageCache.Dir, strconv.Itoa(st.ImageCache.Images), humanSize(st.ImageCache.Size), ""})
+	caches.Append([]string{"registry", rc.Dir, fmt.Sprintf("%d blobs, %d manifests", rc.Blobs, rc.Manifests), humanSize(rc.Size), humanSize(rc.MaxSize)})
+	caches.Render()
+
+	status := "Stopped"
+	if rc.Running {
+		status = fmt.Sprintf("Running on port %d", rc.Port)
+	}
+	since := ""
+	if !rc.Since.IsZero() {
+		since = fmt.Sprintf("%s ago", units.HumanDuration(time.Since(rc.Since)))
+	}
+	server := imagesTable([]string{"Registry Cache", "Profiles", "Since", "Hits", "Misses", "Hit Ratio", "Served", "Fetched", "Evicted"})
+	server.Append([]string{status, strings.Join(rc.Profiles, ", "), since, strconv.FormatInt(rc.Hits, 10), strconv.FormatInt(rc.Misses, 10),
+		fmt.Sprintf("%.1f%%", rc.HitRatio*100), humanSize(rc.ServedBytes), humanSize(rc.FetchedBytes), strconv.FormatInt(rc.Evicted, 10)})
+	server.Render()
+}
+
+func init() {
+	statsCacheCmd.Flags().StringVar(&cacheStatsFormat, "format", "table", "Format output. One of: table|json")
+	cacheCmd.AddCommand(statsCacheCmd)
+
+	serveCacheCmd.Flags().IntVar(&cacheServePort, "port", registrycache.DefaultPort, "Port to serve the registry cache on")
+	serveCacheCmd.Flags().StringSliceVar(&cacheServeAddrs, "listen-address", []string{"127.0.0.1"}, "IPs to serve the registry cache on")
+	serveCacheCmd.Flags().Int64Var(&cacheServeSize, "max-size", 0, "Size in bytes at which the least recently used blobs are evicted")
+	cacheCmd.AddCommand(serveCacheCmd)
+}
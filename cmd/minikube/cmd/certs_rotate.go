@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/klog/v2"
+
+	cmdcfg "k8s.io/minikube/cmd/minikube/cmd/config"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/certs"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var (
+	certsRotateCA     bool
+	certsRotateCACert string
+	certsRotateCAKey  string
+)
+
+var certsRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Rotate the certificates of a running profile",
+	Long: `Generates new apiserver, proxy-client and client certificates for a running profile, and renews the certificates kubeadm manages.
+The certificates are copied to every node, the control plane is restarted, and the kubeconfig is updated, without deleting the cluster.
+
+With --ca, the CAs shared among profiles are generated again too, and the workers join the cluster again.
+The certificates of the other profiles must then be rotated as well. Profiles with an external CA copy it again instead.
+Use --ca-cert and --ca-key with --ca to switch to another external CA, or to the minikube CA when empty.`,
+	Example: "minikube certs rotate --ca --ca-cert=corp-intermediate.crt --ca-key=corp-intermediate.key",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			exit.Message(reason.Usage, "Usage: minikube certs rotate [--ca]")
+		}
+
+		if certsRotateCA && viper.GetString(cmdcfg.Bootstrapper) == bootstrapper.K3s {
+			exit.Message(reason.Usage, "Sorry, the k3s bootstrapper cannot rotate the CA of an existing cluster")
+		}
+
+		co := mustload.Running(ClusterFlagValue())
+		if cmd.Flags().Changed(caCert) || cmd.Flags().Changed(caKey) {
+			if !certsRotateCA {
+				exit.Message(reason.Usage, "Sorry, --ca-cert and --ca-key require --ca")
+			}
+			validateExternalCA(certsRotateCACert, certsRotateCAKey)
+			co.Config.CACertFile = absPath(certsRotateCACert)
+			co.Config.CAKeyFile = absPath(certsRotateCAKey)
+			if err := config.SaveProfile(co.Config.Name, co.Config); err != nil {
+				exit.Error(reason.HostSaveProfile, "Failed to save config", err)
+			}
+		}
+		if err := certs.Rotate(co.API, co.Config, viper.GetString(cmdcfg.Bootstrapper), certsRotateCA); err != nil {
+			exit.Error(reason.KubernetesCertsRotate, "Failed to rotate the certificates", err)
+		}
+		out.Step(style.Ready, "Rotated the certificates of {{.profile}}", out.V{"profile": co.Config.Name})
+
+		// an external CA is not shared among profiles
+		if !certsRotateCA || co.Config.CACertFile != "" {
+			return
+		}
+		profiles, _, err := config.ListProfiles()
+		if err != nil {
+			klog.Warningf("unable to list profiles: %v", err)
+			return
+		}
+		others := []string{}
+		for _, p := range profiles {
+			if p.Name != co.Config.Name {
+				others = append(others, p.Name)
+			}
+		}
+		if len(others) > 0 {
+			out.WarningT("The CA is shared among profiles. Rotate the certificates of {{.profiles}} too with: minikube certs rotate -p <profile>",
+				out.V{"profiles": strings.Join(others, ", ")})
+		}
+	},
+}
+
+func init() {
+	certsRotateCmd.Flags().BoolVar(&certsRotateCA, "ca", false, "Also generate new CAs. They are shared among profiles, so the other profiles must be rotated too.")
+	certsRotateCmd.Flags().StringVar(&certsRotateCACert, caCert, "", "Path to an external CA, or intermediate CA, certificate signing the cluster certificates from now on. Requires --ca and --ca-key.")
+	certsRotateCmd.Flags().StringVar(&certsRotateCAKey, caKey, "", "Path to the private key of the CA certificate given with --ca-cert")
+	certsCmd.AddCommand(certsRotateCmd)
+}
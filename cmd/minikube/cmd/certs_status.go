@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/constants"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+// certsExpiryWarning is how long before their expiry certificates are reported as expiring soon
+const certsExpiryWarning = 30 * 24 * time.Hour
+
+var certsStatusOutput string
+
+var certsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List the certificates of a profile and their expiry",
+	Long:  "Lists the CAs shared among profiles and the apiserver, proxy-client and client certificates of a profile, with their subject, SANs and expiry.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cname := ClusterFlagValue()
+		_, cc := mustload.Partial(cname)
+		infos, err := bootstrapper.Certs(*cc)
+		if err != nil {
+			exit.Error(reason.HostCerts, "Failed to read the certificates", err)
+		}
+
+		switch strings.ToLower(certsStatusOutput) {
+		case "json":
+			b, err := json.Marshal(infos)
+			if err != nil {
+				exit.Error(reason.InternalJSONMarshal, "json encoding failure", err)
+			}
+			out.String(string(b))
+		case "table":
+			if len(infos) == 0 {
+				out.Styled(style.Empty, "No certificates found. Generate them using \"minikube start\".")
+				return
+			}
+			renderCertsTable(infos)
+		default:
+			exit.Message(reason.Usage, fmt.Sprintf("invalid output format: %s. Valid values: 'table', 'json'", certsStatusOutput))
+		}
+
+		profileArg := ""
+		if cname != constants.DefaultClusterName {
+			profileArg = " -p " + cname
+		}
+		for _, c := range infos {
+			if time.Until(c.NotAfter) < certsExpiryWarning {
+				out.WarningT("Certificate {{.name}} expires on {{.date}}, rotate it with: minikube certs rotate{{.profile}}",
+					out.V{"name": c.Name, "date": c.NotAfter.Format("2006-01-02"), "profile": profileArg})
+			}
+		}
+	},
+}
+
+func renderCertsTable(infos []bootstrapper.CertInfo) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Subject", "SANs", "Expires", "Days Left"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+	for _, c := range infos {
+		days := int(time.Until(c.NotAfter).Hours() / 24)
+		table.Append([]string{c.Name, c.Subject, strings.Join(c.SANs, ", "), c.NotAfter.Format("2006-01-02 15:04:05"), strconv.Itoa(days)})
+	}
+	table.Render()
+}
+
+func init() {
+	certsStatusCmd.Flags().StringVarP(&certsStatusOutput, "output", "o", "table", "The output format. One of 'json', 'table'")
+	certsCmd.AddCommand(certsStatusCmd)
+}
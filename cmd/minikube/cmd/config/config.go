@@ -122,6 +122,30 @@ var settings = []Setting{
 		name: config.ReminderWaitPeriodInHours,
 		set:  SetInt,
 	},
+	{
+		name: config.MaxAuditSizeInMB,
+		set:  SetInt,
+	},
+	{
+		name:        config.RegistryCacheSize,
+		set:         SetString,
+		validations: []setFn{IsValidDiskSize},
+	},
+	{
+		name:        config.RegistryCachePort,
+		set:         SetInt,
+		validations: []setFn{IsPositive},
+	},
+	{
+		name:        config.DownloadPolicy,
+		set:         SetString,
+		validations: []setFn{IsValidDownloadPolicy},
+	},
+	{
+		name:        config.DownloadPublicKey,
+		set:         SetString,
+		validations: []setFn{IsValidPath},
+	},
 	{
 		name: config.WantNoneDriverWarning,
 		set:  SetBool,
@@ -255,6 +255,21 @@ var addonsConfigureCmd = &cobra.Command{
 					out.ErrT(style.Fatal, "Failed to configure registry-aliases {{.profile}}", out.V{"profile": profile})
 				}
 			}
+		case "storage-provisioner":
+			profile := ClusterFlagValue()
+			_, cfg := mustload.Partial(profile)
+			cfg.KubernetesConfig.StorageQuotas = AskForYesNoConfirmation("-- Do you want to enforce the size of volumes with quotas? The provisioner then runs as a privileged container", posResponses, negResponses)
+
+			if err := config.SaveProfile(profile, cfg); err != nil {
+				out.ErrT(style.Fatal, "Failed to save config {{.profile}}", out.V{"profile": profile})
+			}
+			addon := assets.Addons["storage-provisioner"]
+			if addon.IsEnabled(cfg) {
+				// Re-enable storage-provisioner addon in order to generate its manifest with the privileges required by quotas
+				if err := addons.EnableOrDisableAddon(cfg, "storage-provisioner", "true"); err != nil {
+					out.ErrT(style.Fatal, "Failed to configure storage-provisioner {{.profile}}", out.V{"profile": profile})
+				}
+			}
 
 		default:
 			out.FailureT("{{.name}} has no available configuration options", out.V{"name": addon})
@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/docker/machine/libmachine/state"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+	"k8s.io/minikube/pkg/util"
+)
+
+const (
+	// minimums enforced by minikube start
+	minResizeCPUs   = 2
+	minResizeMemory = 1800
+)
+
+var (
+	resizeCPUs     int
+	resizeMemory   string
+	resizeDiskSize string
+)
+
+var configResizeCmd = &cobra.Command{
+	Use:   "resize",
+	Short: "Changes the CPUs, memory or disk size of an existing profile",
+	Long: `Changes the CPUs, memory or disk size of an existing profile and applies the change where the driver allows it.
+Containers of the docker and podman drivers are updated in place. Virtual machines of the kvm2, qemu2 and hyperkit drivers use the new CPUs and memory on their next start, and kvm2 and qemu2 grow their disk then.`,
+	Example: "minikube config resize --cpus=4 --memory=8g --disk-size=40g",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			exit.Message(reason.Usage, "usage: minikube config resize [--cpus=N] [--memory=SIZE] [--disk-size=SIZE]")
+		}
+		api, cc := mustload.Partial(ClusterFlagValue())
+		defer api.Close()
+
+		r := resizeResources(cmd, cc)
+		results, err := machine.Resize(api, *cc, r)
+		if err != nil {
+			exit.Error(reason.GuestResize, "Failed to resize the cluster", err)
+		}
+		if len(results) == 0 {
+			out.Step(style.Check, "The {{.name}} cluster already has the requested resources", out.V{"name": cc.Name})
+			return
+		}
+
+		if r.CPUs != 0 {
+			cc.CPUs = r.CPUs
+		}
+		if r.Memory != 0 {
+			cc.Memory = r.Memory
+		}
+		if r.DiskSize != 0 {
+			cc.DiskSize = r.DiskSize
+		}
+		if err := config.SaveProfile(cc.Name, cc); err != nil {
+			exit.Error(reason.HostSaveProfile, "Failed to save config", err)
+		}
+
+		renderResizeTable(results)
+		restart := false
+		for _, res := range results {
+			restart = restart || res.Mode == machine.ResizeRestart
+		}
+		if !restart {
+			return
+		}
+		cp, err := config.PrimaryControlPlane(cc)
+		if err != nil {
+			exit.Error(reason.GuestCpConfig, "error getting primary control plane", err)
+		}
+		st, err := machine.Status(api, config.MachineName(*cc, cp))
+		if err != nil || st != state.Running.String() {
+			out.Styled(style.Tip, "The new resources will be used the next time the cluster starts")
+			return
+		}
+		out.Styled(style.Tip, "Restart the cluster for the changes to take effect: {{.stop}} && {{.start}}", out.V{"stop": mustload.ExampleCmd(cc.Name, "stop"), "start": mustload.ExampleCmd(cc.Name, "start")})
+	},
+}
+
+// resizeResources parses and validates the requested resources against the profile,
+// leaving those which were not requested or are unchanged at zero.
+func resizeResources(cmd *cobra.Command, cc *config.ClusterConfig) machine.Resources {
+	r := machine.Resources{}
+	if cmd.Flags().Changed("cpus") {
+		if resizeCPUs < minResizeCPUs {
+			exit.Message(reason.RsrcInsufficientCores, "Requested cpu count {{.requested_cpus}} is less than the minimum allowed of {{.minimum_cpus}}", out.V{"requested_cpus": resizeCPUs, "minimum_cpus": minResizeCPUs})
+		}
+		r.CPUs = resizeCPUs
+	}
+	if cmd.Flags().Changed("memory") {
+		mem, err := util.CalculateSizeInMB(resizeMemory)
+		if err != nil {
+			exit.Message(reason.Usage, "Validation unable to parse memory '{{.memory}}': {{.error}}", out.V{"memory": resizeMemory, "error": err})
+		}
+		if mem < minResizeMemory {
+			exit.Message(reason.RsrcInsufficientReqMemory, "Requested memory allocation {{.requested}}MiB is less than the usable minimum of {{.minimum_memory}}MB", out.V{"requested": mem, "minimum_memory": minResizeMemory})
+		}
+		r.Memory = mem
+	}
+	if cmd.Flags().Changed("disk-size") {
+		disk, err := util.CalculateSizeInMB(resizeDiskSize)
+		if err != nil {
+			exit.Message(reason.Usage, "Validation unable to parse disk size '{{.diskSize}}': {{.error}}", out.V{"diskSize": resizeDiskSize, "error": err})
+		}
+		if disk < cc.DiskSize {
+			exit.Message(reason.Usage, "The disk of an existing cluster cannot shrink below {{.size}}MB", out.V{"size": cc.DiskSize})
+		}
+		r.DiskSize = disk
+	}
+	if r == (machine.Resources{}) {
+		exit.Message(reason.Usage, "usage: minikube config resize [--cpus=N] [--memory=SIZE] [--disk-size=SIZE]")
+	}
+
+	requested := []struct {
+		resource string
+		value    int
+	}{{machine.ResourceCPUs, r.CPUs}, {machine.ResourceMemory, r.Memory}, {machine.ResourceDisk, r.DiskSize}}
+	for _, req := range requested {
+		if req.value != 0 && machine.ResizeModeFor(cc.Driver, req.resource) == machine.ResizeUnsupported {
+			exit.Message(reason.GuestResizeUnsupported, "The {{.driver}} driver does not support changing the {{.resource}} of an existing cluster. Please first delete the cluster.", out.V{"driver": cc.Driver, "resource": req.resource})
+		}
+	}
+	return r
+}
+
+func renderResizeTable(results []machine.ResizeResult) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Node", "Resource", "From", "To", "Applied"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+	for _, res := range results {
+		from, to := strconv.Itoa(res.From), strconv.Itoa(res.To)
+		if res.Resource != machine.ResourceCPUs {
+			from, to = fmt.Sprintf("%dMB", res.From), fmt.Sprintf("%dMB", res.To)
+		}
+		applied := "now"
+		if res.Mode == machine.ResizeRestart {
+			applied = "on restart"
+		}
+		table.Append([]string{res.Machine, res.Resource, from, to, applied})
+	}
+	table.Render()
+}
+
+func init() {
+	configResizeCmd.Flags().IntVar(&resizeCPUs, "cpus", 0, "Number of CPUs allocated to each node of the cluster.")
+	configResizeCmd.Flags().StringVar(&resizeMemory, "memory", "", "Amount of RAM allocated to each node of the cluster (format: <number>[<unit>], where unit = b, k, m or g).")
+	configResizeCmd.Flags().StringVar(&resizeDiskSize, "disk-size", "", "Disk size allocated to each node of the cluster, it can only grow (format: <number>[<unit>], where unit = b, k, m or g).")
+	ConfigCmd.AddCommand(configResizeCmd)
+}
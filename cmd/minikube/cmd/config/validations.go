@@ -27,6 +27,7 @@ import (
 	units "github.com/docker/go-units"
 	"k8s.io/minikube/pkg/minikube/constants"
 	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/download"
 	"k8s.io/minikube/pkg/minikube/driver"
 	"k8s.io/minikube/pkg/minikube/out"
 )
@@ -155,3 +156,8 @@ func IsValidRuntime(name string, runtime string) error {
 	}
 	return nil
 }
+
+// IsValidDownloadPolicy checks if a string is a valid download policy
+func IsValidDownloadPolicy(name string, policy string) error {
+	return download.ValidatePolicy(policy)
+}
@@ -37,6 +37,7 @@ import (
 	cmdcfg "k8s.io/minikube/cmd/minikube/cmd/config"
 	"k8s.io/minikube/pkg/drivers/kic"
 	"k8s.io/minikube/pkg/drivers/kic/oci"
+	"k8s.io/minikube/pkg/minikube/audit"
 	"k8s.io/minikube/pkg/minikube/cluster"
 	"k8s.io/minikube/pkg/minikube/config"
 	"k8s.io/minikube/pkg/minikube/constants"
@@ -45,12 +46,14 @@ import (
 	"k8s.io/minikube/pkg/minikube/download"
 	"k8s.io/minikube/pkg/minikube/driver"
 	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/filesync"
 	"k8s.io/minikube/pkg/minikube/kubeconfig"
 	"k8s.io/minikube/pkg/minikube/localpath"
 	"k8s.io/minikube/pkg/minikube/machine"
 	"k8s.io/minikube/pkg/minikube/out"
 	"k8s.io/minikube/pkg/minikube/out/register"
 	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/registrycache"
 	"k8s.io/minikube/pkg/minikube/style"
 )
 
@@ -326,6 +329,7 @@ func deleteProfile(ctx context.Context, profile *config.Profile) error {
 	register.Reg.SetStep(register.Deleting)
 
 	viper.Set(config.ProfileName, profile.Name)
+	audit.AddProfile(profile.Name)
 	if profile.Config != nil {
 		klog.Infof("%s configuration: %+v", profile.Name, profile.Config)
 
@@ -369,6 +373,10 @@ func deleteProfile(ctx context.Context, profile *config.Profile) error {
 		}
 	}
 
+	if err := registrycache.Release(profile.Name); err != nil {
+		out.WarningT("Unable to release the registry cache: {{.error}}", out.V{"error": err})
+	}
+
 	if err := hostAndDirsDeleter(api, cc, profile.Name); err != nil {
 		return err
 	}
@@ -598,7 +606,11 @@ func killMountProcess() error {
 		}
 	}
 
-	return nil
+	stopped, err := filesync.Stop(profile, "")
+	for _, st := range stopped {
+		klog.Infof("stopped the sync of %s to %s", st.HostDir, st.NodeDir)
+	}
+	return err
 }
 
 func killProcess(path string) error {
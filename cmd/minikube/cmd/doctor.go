@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/doctor"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var doctorOutput string
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Checks the host and existing clusters for problems",
+	Long: `Checks the prerequisites of minikube on the host (drivers, container engines, free disk space and proxy settings) and the health of existing clusters (kubeconfig, port conflicts and Kubernetes components), with advice on how to fix any problem found.
+
+Checks all profiles, or only the given one with --profile. Exits with a non-zero code if any check fails.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := strings.ToLower(doctorOutput)
+		if format != "text" && format != "json" {
+			exit.Message(reason.Usage, fmt.Sprintf("invalid output format: %s. Valid values: 'text', 'json'", doctorOutput))
+		}
+		if format == "text" {
+			out.Step(style.HealthCheck, "Running checks ...")
+		}
+
+		rs := doctor.Run(doctorProfiles())
+		switch format {
+		case "json":
+			b, err := json.Marshal(rs)
+			if err != nil {
+				exit.Error(reason.InternalJSONMarshal, "json encoding failure", err)
+			}
+			out.String("%s\n", b)
+		case "text":
+			doctor.SortByStatus(rs)
+			outputDoctorResults(rs)
+		}
+		if doctor.Failed(rs) {
+			exit.Code(reason.ExFailure)
+		}
+	},
+}
+
+// doctorProfiles returns the profile given with --profile, or all valid profiles
+func doctorProfiles() []*config.Profile {
+	if RootCmd.PersistentFlags().Changed(config.ProfileName) {
+		p, err := config.LoadProfile(ClusterFlagValue())
+		if err != nil {
+			exit.Message(reason.Usage, `profile "{{.name}}" not found`, out.V{"name": ClusterFlagValue()})
+		}
+		return []*config.Profile{p}
+	}
+	validProfiles, _, err := config.ListProfiles()
+	if err != nil {
+		klog.Warningf("error loading profiles: %v", err)
+	}
+	return validProfiles
+}
+
+func outputDoctorResults(rs []doctor.Result) {
+	for _, r := range rs {
+		st := style.Check
+		switch r.Status {
+		case doctor.Warn:
+			st = style.Warning
+		case doctor.Fail:
+			st = style.Failure
+		}
+		out.Styled(st, "{{.check}} {{.target}}: {{.message}}", out.V{"check": r.Check, "target": r.Target, "message": r.Message})
+		if r.Advice != "" {
+			out.Styled(style.Tip, "Suggestion: {{.advice}}", out.V{"advice": r.Advice})
+		}
+		if r.Doc != "" {
+			out.Styled(style.Documentation, "Documentation: {{.url}}", out.V{"url": r.Doc})
+		}
+	}
+	sum := doctor.Summary(rs)
+	out.Ln("")
+	out.Styled(style.Empty, "{{.pass}} passed, {{.warn}} warnings, {{.fail}} failed", out.V{"pass": sum[doctor.Pass], "warn": sum[doctor.Warn], "fail": sum[doctor.Fail]})
+}
+
+func init() {
+	doctorCmd.Flags().StringVarP(&doctorOutput, "output", "o", "text", "The output format. One of 'text', 'json'")
+}
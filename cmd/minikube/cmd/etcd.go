@@ -0,0 +1,42 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/etcd"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/reason"
+)
+
+// etcdCmd represents the set of etcd subcommands
+var etcdCmd = &cobra.Command{
+	Use:   "etcd",
+	Short: "Back up, restore and defragment the etcd of a profile",
+	Long:  "Manages the etcd of the control plane of a running profile, with the etcdctl and certificates of its static pod. Backups are stored under the profile directory.",
+	Run: func(cmd *cobra.Command, args []string) {
+		exit.Message(reason.Usage, "Usage: minikube etcd [backup|restore|defrag|status]")
+	},
+}
+
+// mustEtcdSupported exits if minikube cannot manage the etcd of the cluster
+func mustEtcdSupported(cc *config.ClusterConfig) {
+	if err := etcd.Supported(cc); err != nil {
+		exit.Error(reason.GuestEtcdUnsupported, "The etcd commands are not supported by this cluster", err)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/etcd"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var etcdBackupRetain int
+
+var etcdBackupCmd = &cobra.Command{
+	Use:   "backup [name]",
+	Short: "Back up the etcd of a running profile",
+	Long: `Takes a snapshot of the etcd of a running profile with etcdctl, and stores it under the profile directory.
+The oldest backups of the profile are then removed, so that at most --retain are kept.`,
+	Example: "minikube etcd backup before-migration",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 1 {
+			exit.Message(reason.Usage, "Usage: minikube etcd backup [name]")
+		}
+		if etcdBackupRetain < 0 {
+			exit.Message(reason.Usage, "--retain must be 0 or more, got {{.retain}}", out.V{"retain": etcdBackupRetain})
+		}
+		co := mustload.Running(ClusterFlagValue())
+		mustEtcdSupported(co.Config)
+
+		name := fmt.Sprintf("etcd-%s", time.Now().Format("20060102-150405"))
+		if len(args) == 1 {
+			name = args[0]
+		}
+		if !etcd.NameValid(name) {
+			exit.Message(reason.Usage, "Backup name {{.name}} is not valid", out.V{"name": name})
+		}
+		if etcd.Exists(co.Config.Name, name) {
+			exit.Message(reason.Usage, "Backup {{.name}} already exists", out.V{"name": name})
+		}
+
+		out.Step(style.Caching, "Backing up the etcd of {{.profile}} to {{.name}} ...", out.V{"name": name, "profile": co.Config.Name})
+		b, removed, err := etcd.Save(co.API, co.Config, name, etcdBackupRetain)
+		if err != nil {
+			exit.Error(reason.GuestEtcdBackup, "Failed to back up etcd", err)
+		}
+		out.Step(style.Success, "Saved etcd backup {{.name}} ({{.size}}) to {{.path}}", out.V{"name": b.Name, "size": units.HumanSize(float64(b.Size)), "path": b.Path})
+		for _, r := range removed {
+			out.Step(style.Deleted, "Removed etcd backup {{.name}}, keeping the latest {{.retain}}", out.V{"name": r, "retain": etcdBackupRetain})
+		}
+	},
+}
+
+func init() {
+	etcdBackupCmd.Flags().IntVar(&etcdBackupRetain, "retain", etcd.DefaultRetain, "The number of backups of the profile to keep, removing the oldest ones. 0 keeps every backup.")
+	etcdCmd.AddCommand(etcdBackupCmd)
+}
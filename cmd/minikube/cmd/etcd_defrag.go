@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	units "github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/etcd"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var etcdDefragCmd = &cobra.Command{
+	Use:   "defrag",
+	Short: "Defragment the etcd of a running profile",
+	Long: `Defragments the database of every etcd member of a running profile, releasing the space freed by compactions to the filesystem.
+Each member blocks reads and writes while it is defragmented.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			exit.Message(reason.Usage, "Usage: minikube etcd defrag")
+		}
+		co := mustload.Running(ClusterFlagValue())
+		mustEtcdSupported(co.Config)
+
+		out.Step(style.Waiting, "Defragmenting the etcd of {{.profile}} ...", out.V{"profile": co.Config.Name})
+		before, after, err := etcd.Defrag(co.API, co.Config)
+		if err != nil {
+			exit.Error(reason.GuestEtcdDefrag, "Failed to defragment etcd", err)
+		}
+
+		sizes := map[string]int64{}
+		for _, m := range before {
+			sizes[m.Endpoint] = m.DBSize
+		}
+		for _, m := range after {
+			out.Step(style.Success, "Defragmented {{.endpoint}}: {{.before}} -> {{.after}}", out.V{"endpoint": m.Endpoint, "before": units.HumanSize(float64(sizes[m.Endpoint])), "after": units.HumanSize(float64(m.DBSize))})
+		}
+	},
+}
+
+func init() {
+	etcdCmd.AddCommand(etcdDefragCmd)
+}
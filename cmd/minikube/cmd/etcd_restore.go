@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/etcd"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var etcdRestoreWait time.Duration
+
+var etcdRestoreCmd = &cobra.Command{
+	Use:   "restore [name]",
+	Short: "Restore the etcd of a running profile from a backup",
+	Long: `Replaces the etcd data of a running profile with one of its backups, the latest one if no name is given.
+The control plane is stopped while the data is replaced, then the kubelet re-initializes the etcd static pod.
+Changes made to the cluster since the backup are lost. Only clusters with a single control plane are supported.`,
+	Example: "minikube etcd restore before-migration",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 1 {
+			exit.Message(reason.Usage, "Usage: minikube etcd restore [name]")
+		}
+		co := mustload.Running(ClusterFlagValue())
+		mustEtcdSupported(co.Config)
+
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+		}
+		b, err := etcd.Find(co.Config.Name, name)
+		if err != nil {
+			if etcd.IsNotExist(err) && name == "" {
+				exit.Message(reason.HostEtcdBackupNotFound, `No etcd backup found for {{.profile}}. Create one using "minikube etcd backup".`, out.V{"profile": co.Config.Name})
+			}
+			if etcd.IsNotExist(err) {
+				exit.Message(reason.HostEtcdBackupNotFound, `Backup "{{.name}}" not found. Run "minikube etcd status" to view the backups of the profile.`, out.V{"name": name})
+			}
+			exit.Error(reason.HostEtcdBackup, "Failed to list etcd backups", err)
+		}
+
+		out.Step(style.Resetting, "Restoring the etcd of {{.profile}} from {{.name}} ...", out.V{"name": b.Name, "profile": co.Config.Name})
+		if _, err := etcd.Restore(co.API, co.Config, b.Name, etcdRestoreWait); err != nil {
+			exit.Error(reason.GuestEtcdRestore, "Failed to restore etcd", err)
+		}
+		out.Step(style.Ready, "Restored the etcd of {{.profile}} from {{.name}}", out.V{"name": b.Name, "profile": co.Config.Name})
+	},
+}
+
+func init() {
+	etcdRestoreCmd.Flags().DurationVar(&etcdRestoreWait, "wait-timeout", 4*time.Minute, "How long to wait for etcd and the apiserver to be ready after the restore")
+	etcdCmd.AddCommand(etcdRestoreCmd)
+}
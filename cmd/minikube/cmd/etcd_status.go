@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/etcd"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var etcdStatusOutput string
+
+var etcdStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status and backups of the etcd of a running profile",
+	Long:  "Lists the etcd members of a running profile, with their version, role and database size, and the etcd backups stored under the profile directory.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			exit.Message(reason.Usage, "Usage: minikube etcd status")
+		}
+		output := strings.ToLower(etcdStatusOutput)
+		if output != "table" && output != "json" {
+			exit.Message(reason.Usage, fmt.Sprintf("invalid output format: %s. Valid values: 'table', 'json'", etcdStatusOutput))
+		}
+		co := mustload.Running(ClusterFlagValue())
+		mustEtcdSupported(co.Config)
+
+		ms, err := etcd.Status(co.API, co.Config)
+		if err != nil {
+			exit.Error(reason.GuestEtcdStatus, "Failed to get the status of etcd", err)
+		}
+		bs, err := etcd.List(co.Config.Name)
+		if err != nil {
+			exit.Error(reason.HostEtcdBackup, "Failed to list etcd backups", err)
+		}
+
+		if output == "json" {
+			b, err := json.Marshal(struct {
+				Members []etcd.MemberStatus
+				Backups []etcd.Backup
+			}{ms, bs})
+			if err != nil {
+				exit.Error(reason.InternalJSONMarshal, "json encoding failure", err)
+			}
+			out.String(string(b))
+			return
+		}
+
+		renderEtcdMembersTable(ms)
+		if len(bs) == 0 {
+			out.Styled(style.Empty, "No etcd backups found. Create one using \"minikube etcd backup\".")
+			return
+		}
+		renderEtcdBackupsTable(bs)
+	},
+}
+
+func renderEtcdMembersTable(ms []etcd.MemberStatus) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Endpoint", "ID", "Version", "Leader", "DB Size", "In Use", "Raft Term", "Raft Index"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+	for _, m := range ms {
+		table.Append([]string{m.Endpoint, m.ID, m.Version, strconv.FormatBool(m.Leader), units.HumanSize(float64(m.DBSize)), units.HumanSize(float64(m.DBSizeInUse)),
+			strconv.FormatUint(m.RaftTerm, 10), strconv.FormatUint(m.RaftIndex, 10)})
+	}
+	table.Render()
+}
+
+func renderEtcdBackupsTable(bs []etcd.Backup) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Backup", "Size", "Created"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+	for _, b := range bs {
+		table.Append([]string{b.Name, units.HumanSize(float64(b.Size)), b.Created.Format("2006-01-02 15:04:05")})
+	}
+	table.Render()
+}
+
+func init() {
+	etcdStatusCmd.Flags().StringVarP(&etcdStatusOutput, "output", "o", "table", "The output format. One of 'json', 'table'")
+	etcdCmd.AddCommand(etcdStatusCmd)
+}
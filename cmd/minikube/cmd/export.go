@@ -0,0 +1,46 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/clusterfile"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:     "export",
+	Short:   "Writes the cluster file of a profile",
+	Long:    `Writes a declarative cluster file describing an existing profile, which can be used with "minikube apply".`,
+	Example: "minikube export -p dev > cluster.yaml",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			exit.Message(reason.Usage, "Usage: minikube export")
+		}
+
+		_, cc := mustload.Partial(ClusterFlagValue())
+		b, err := clusterfile.Marshal(clusterfile.FromConfig(cc))
+		if err != nil {
+			exit.Error(reason.InternalYamlMarshal, "Failed to marshal cluster file", err)
+		}
+		out.String("%s", b)
+	},
+}
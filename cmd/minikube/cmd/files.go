@@ -0,0 +1,230 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+
+	"github.com/docker/machine/libmachine/state"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/filesync"
+	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var (
+	filesWatch    bool
+	filesDryRun   bool
+	filesSyncDirs []string
+)
+
+// filesCmd represents the files command
+var filesCmd = &cobra.Command{
+	Use:   "files COMMAND",
+	Short: "Manage the files synced to the nodes",
+	Long:  "Manage the files of $MINIKUBE_HOME/files and of the sync directories of the cluster, which are copied to its nodes on start",
+}
+
+// filesSyncCmd represents the files sync command
+var filesSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync the local files to all nodes",
+	Long: `Copies the files of $MINIKUBE_HOME/files to the root directory of all running nodes, and the files of the sync directories of the cluster to their node directory, as done on start.
+Files removed from the host since the previous sync are removed from the nodes, and the files changed on the nodes are overwritten.
+With --watch, the files are kept in sync until interrupted.`,
+	Example: `minikube files sync --watch
+minikube files sync --sync-dirs=$HOME/kubelet:/etc/kubernetes/kubelet.d --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if filesWatch && filesDryRun {
+			exit.Message(reason.Usage, "The --watch and --dry-run flags can not be used together")
+		}
+		co := mustload.Running(ClusterFlagValue())
+		profile := co.Config.Name
+		if cmd.Flags().Changed(syncDirsFlag) {
+			co.Config.SyncDirs = absSyncDirs(filesSyncDirs)
+			if err := config.SaveProfile(profile, co.Config); err != nil {
+				exit.Error(reason.HostSaveProfile, "Failed to save config", err)
+			}
+		}
+
+		manifest, err := filesync.LoadManifest(profile)
+		if err != nil {
+			exit.Error(reason.HostConfigLoad, "Unable to load the previously synced files", err)
+		}
+		syncers, nodes := filesSyncers(co, manifest)
+
+		if filesDryRun {
+			printFilesPlan(syncers, nodes)
+			return
+		}
+
+		synced := 0
+		report := func(s *filesync.Syncer, actions []filesync.Action, err error) {
+			cfg := s.Config()
+			for _, a := range actions {
+				out.Styled(style.Option, "{{.node}}: {{.op}} {{.path}}", out.V{"node": nodes[s], "op": a.Op, "path": path.Join(cfg.NodeDir, a.Path)})
+			}
+			if err != nil {
+				out.FailureT("Syncing {{.dir}} to {{.node}} failed: {{.error}}", out.V{"dir": cfg.HostDir, "node": nodes[s], "error": err})
+			}
+			synced += len(actions)
+			manifest[filesync.ManifestKey(nodes[s], cfg)] = s.Synced()
+		}
+		saveManifest := func() {
+			if err := filesync.SaveManifest(profile, manifest); err != nil {
+				klog.Warningf("unable to save the synced files: %v", err)
+			}
+		}
+
+		out.Step(style.Copying, "Syncing the local files to {{.count}} nodes ...", out.V{"count": countNodes(nodes)})
+		filesync.SyncAll(syncers, report)
+		saveManifest()
+		out.Step(style.Success, "Synced {{.count}} files", out.V{"count": synced})
+		if !filesWatch {
+			return
+		}
+
+		out.Styled(style.Notice, "NOTE: This process must stay alive for the files to stay in sync ...")
+		ctx, cancel := context.WithCancel(context.Background())
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-c
+			cancel()
+		}()
+		err = filesync.Watch(ctx, syncers, filesync.DefaultDebounce, filesync.DefaultPollInterval, func(s *filesync.Syncer, actions []filesync.Action, err error) {
+			report(s, actions, err)
+			if len(actions) > 0 {
+				saveManifest()
+			}
+		})
+		if err != nil {
+			exit.Error(reason.GuestFileSync, "watching the local files failed", err)
+		}
+		out.Step(style.Stopped, "Stopped syncing the local files")
+	},
+}
+
+// filesSyncers returns a syncer for every sync directory and running node, and the name of the node of every syncer
+func filesSyncers(co mustload.ClusterController, manifest filesync.Manifest) ([]*filesync.Syncer, map[*filesync.Syncer]string) {
+	dirs, err := machine.SyncDirs(*co.Config)
+	if err != nil {
+		exit.Message(reason.Usage, "{{.err}}", out.V{"err": err})
+	}
+	// the files directory is created along with the minikube home, the other ones are up to the user
+	if err := os.MkdirAll(dirs[0].HostDir, 0755); err != nil {
+		exit.Error(reason.HostHomeMkdir, "Error creating the files directory", err)
+	}
+	var existing []machine.SyncDir
+	for _, d := range dirs {
+		if _, err := os.Stat(d.HostDir); err != nil {
+			out.WarningT("Skipping sync directory {{.dir}}: {{.error}}", out.V{"dir": d.HostDir, "error": err})
+			continue
+		}
+		existing = append(existing, d)
+	}
+
+	var syncers []*filesync.Syncer
+	nodes := map[*filesync.Syncer]string{}
+	for _, n := range co.Config.Nodes {
+		m := config.MachineName(*co.Config, n)
+		st, err := machine.Status(co.API, m)
+		if err != nil || st != state.Running.String() {
+			out.WarningT("Skipping node {{.node}}, which is not running", out.V{"node": m})
+			continue
+		}
+		h, err := co.API.Load(m)
+		if err != nil {
+			exit.Error(reason.GuestLoadHost, "Error getting host", err)
+		}
+		runner, err := machine.CommandRunner(h)
+		if err != nil {
+			exit.Error(reason.InternalCommandRunner, "Failed to get command runner", err)
+		}
+		for _, d := range existing {
+			cfg := filesync.Config{HostDir: d.HostDir, NodeDir: d.NodeDir, Direction: filesync.ToNode}
+			cfg.Known = manifest[filesync.ManifestKey(m, cfg)]
+			s, err := filesync.New(runner, cfg)
+			if err != nil {
+				exit.Error(reason.GuestFileSync, "sync failed", err)
+			}
+			syncers = append(syncers, s)
+			nodes[s] = m
+		}
+	}
+	if len(syncers) == 0 {
+		exit.Message(reason.GuestStatus, "No running node to sync the files to")
+	}
+	return syncers, nodes
+}
+
+// printFilesPlan prints what syncing the files would change on every node
+func printFilesPlan(syncers []*filesync.Syncer, nodes map[*filesync.Syncer]string) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Node", "Action", "Path", "Host Path"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+	changes := 0
+	for _, s := range syncers {
+		actions, err := s.Plan()
+		if err != nil {
+			exit.Error(reason.GuestFileSync, "listing the files to sync failed", err)
+		}
+		cfg := s.Config()
+		for _, a := range actions {
+			hostPath := ""
+			if a.Op == filesync.Push {
+				hostPath = path.Join(cfg.HostDir, a.Path)
+			}
+			table.Append([]string{nodes[s], string(a.Op), path.Join(cfg.NodeDir, a.Path), hostPath})
+			changes++
+		}
+	}
+	if changes == 0 {
+		out.Styled(style.Empty, "The files of the nodes are up to date")
+		return
+	}
+	table.Render()
+}
+
+// countNodes returns the number of nodes with a syncer
+func countNodes(nodes map[*filesync.Syncer]string) int {
+	seen := map[string]bool{}
+	for _, n := range nodes {
+		seen[n] = true
+	}
+	return len(seen)
+}
+
+func init() {
+	filesSyncCmd.Flags().BoolVar(&filesWatch, "watch", false, "Keep syncing the files as they change, until interrupted")
+	filesSyncCmd.Flags().BoolVar(&filesDryRun, "dry-run", false, "List what would change on the nodes, without syncing")
+	filesSyncCmd.Flags().StringSliceVar(&filesSyncDirs, syncDirsFlag, nil, "Set the host directories synced to the nodes besides $MINIKUBE_HOME/files, as <host dir>:<node dir>. Saved in the cluster config.")
+	filesCmd.AddCommand(filesSyncCmd)
+}
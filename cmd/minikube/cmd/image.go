@@ -17,20 +17,33 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	units "github.com/docker/go-units"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
 	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
 	"k8s.io/minikube/pkg/minikube/exit"
 	"k8s.io/minikube/pkg/minikube/image"
 	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/out"
 	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
 	docker "k8s.io/minikube/third_party/go-dockerclient"
 )
 
@@ -57,6 +70,17 @@ var (
 	format     string
 )
 
+var (
+	buildPlatforms []string
+	buildTarget    string
+	buildArgs      []string
+	buildArgFiles  []string
+	buildSecrets   []string
+	buildCacheFrom string
+	buildCacheTo   string
+	buildOnce      bool
+)
+
 func saveFile(r io.Reader) (string, error) {
 	tmp, err := os.CreateTemp("", "build.*.tar")
 	if err != nil {
@@ -266,6 +290,121 @@ func createTar(dir string) (string, error) {
 	return saveFile(tar)
 }
 
+// buildOptions returns the options of the build from the flags
+func buildOptions() (cruntime.BuildOptions, error) {
+	opts := cruntime.BuildOptions{
+		Dockerfile: dockerFile,
+		Tag:        tag,
+		Push:       push,
+		Env:        buildEnv,
+		Opts:       buildOpt,
+		Platforms:  buildPlatforms,
+		Target:     buildTarget,
+	}
+	for _, file := range buildArgFiles {
+		args, err := readBuildArgFile(file)
+		if err != nil {
+			return opts, err
+		}
+		opts.BuildArgs = append(opts.BuildArgs, args...)
+	}
+	// the build args of the command line take precedence over the ones of the files
+	opts.BuildArgs = append(opts.BuildArgs, buildArgs...)
+	for _, spec := range buildSecrets {
+		secret, err := parseBuildSecret(spec)
+		if err != nil {
+			return opts, err
+		}
+		opts.Secrets = append(opts.Secrets, secret)
+	}
+	for _, dir := range []*string{&buildCacheFrom, &buildCacheTo} {
+		if *dir == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*dir)
+		if err != nil {
+			return opts, err
+		}
+		*dir = abs
+	}
+	opts.CacheFrom = buildCacheFrom
+	opts.CacheTo = buildCacheTo
+	return opts, nil
+}
+
+// validateBuildOnce checks that an image built once can be loaded into the other nodes
+func validateBuildOnce(opts cruntime.BuildOptions, all bool, once bool) error {
+	if !once {
+		return nil
+	}
+	if !all {
+		return errors.New("--build-once requires --all")
+	}
+	if opts.Tag == "" {
+		return errors.New("--build-once requires --tag, to load the image into the other nodes")
+	}
+	// an image of multiple platforms is pushed to a registry rather than kept on the node it is built on
+	if len(opts.Platforms) > 1 {
+		return errors.New("--build-once cannot be used with more than one --platform, as the image is pushed instead of loaded")
+	}
+	return nil
+}
+
+// readBuildArgFile reads a file of build args, one key=value per line.
+// Blank lines and comments are skipped, and a key alone takes its value from the environment.
+func readBuildArgFile(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading build arg file")
+	}
+	args := []string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, _, found := strings.Cut(line, "=")
+		if strings.TrimSpace(key) == "" {
+			return nil, errors.Errorf("%s:%d: missing build arg name", file, i+1)
+		}
+		if !found {
+			value, ok := os.LookupEnv(key)
+			if !ok {
+				continue
+			}
+			line = key + "=" + value
+		}
+		args = append(args, line)
+	}
+	return args, nil
+}
+
+// parseBuildSecret parses a secret of the host, as id=ID,src=PATH
+func parseBuildSecret(spec string) (cruntime.BuildSecret, error) {
+	secret := cruntime.BuildSecret{}
+	for _, field := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "id":
+			secret.ID = value
+		case "src", "source":
+			secret.Src = value
+		default:
+			return secret, errors.Errorf("invalid secret %q: unknown field %q", spec, key)
+		}
+	}
+	if secret.ID == "" || strings.ContainsAny(secret.ID, `/\`) {
+		return secret, errors.Errorf("invalid secret %q: the id is missing or contains a slash", spec)
+	}
+	if secret.Src == "" {
+		return secret, errors.Errorf("invalid secret %q: the src is missing", spec)
+	}
+	if _, err := os.Stat(secret.Src); err != nil {
+		return secret, errors.Wrapf(err, "invalid secret %q", spec)
+	}
+	return secret, nil
+}
+
 // buildImageCmd represents the image build command
 var buildImageCmd = &cobra.Command{
 	Use:     "build PATH | URL | -",
@@ -310,7 +449,14 @@ var buildImageCmd = &cobra.Command{
 				// Otherwise, assume it's a tar
 			}
 		}
-		if err := machine.BuildImage(img, dockerFile, tag, push, buildEnv, buildOpt, []*config.Profile{profile}, allNodes, nodeName); err != nil {
+		opts, err := buildOptions()
+		if err != nil {
+			exit.Message(reason.Usage, "{{.err}}", out.V{"err": err})
+		}
+		if err := validateBuildOnce(opts, allNodes, buildOnce); err != nil {
+			exit.Message(reason.Usage, "{{.err}}", out.V{"err": err})
+		}
+		if err := machine.BuildImage(img, opts, []*config.Profile{profile}, allNodes, nodeName, buildOnce); err != nil {
 			exit.Error(reason.GuestImageBuild, "Failed to build image", err)
 		}
 		if tmp != "" {
@@ -319,6 +465,170 @@ var buildImageCmd = &cobra.Command{
 	},
 }
 
+var (
+	pruneUnused    bool
+	pruneOlderThan time.Duration
+	pruneRegex     string
+	pruneDryRun    bool
+	duFormat       string
+)
+
+var pruneImageCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove unused images",
+	Long: `Remove the dangling images, which have no tag, from all nodes. With --unused, all the images which no container uses are removed instead.
+The images used by a container, whatever its state, and the images of the Kubernetes version of the cluster are never removed.`,
+	Example: `
+$ minikube image prune
+
+$ minikube image prune --unused --older-than=168h --dry-run
+
+$ minikube image prune --unused --regex='^localhost/'
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		profile, err := config.LoadProfile(viper.GetString(config.ProfileName))
+		if err != nil {
+			exit.Error(reason.Usage, "loading profile", err)
+		}
+		opts := machine.PruneOptions{Unused: pruneUnused, OlderThan: pruneOlderThan, DryRun: pruneDryRun}
+		if pruneRegex != "" {
+			if opts.Regex, err = regexp.Compile(pruneRegex); err != nil {
+				exit.Message(reason.Usage, "Invalid --regex {{.regex}}: {{.error}}", out.V{"regex": pruneRegex, "error": err})
+			}
+		}
+
+		results, err := machine.PruneImages(profile, opts)
+		renderPruneResults(results, pruneDryRun)
+		if err != nil {
+			exit.Error(reason.GuestImagePrune, "Failed to prune images", err)
+		}
+	},
+}
+
+// renderPruneResults prints the images pruned from every node
+func renderPruneResults(results []machine.PruneResult, dryRun bool) {
+	var data [][]string
+	for _, res := range results {
+		for _, img := range res.Images {
+			data = append(data, []string{res.Node, imageName(img), shortImageID(img.ID), humanSize(img.Size), humanSize(img.UniqueSize()), humanCreated(img.Created)})
+		}
+	}
+	if len(data) == 0 {
+		out.Styled(style.Empty, "No image to prune")
+		return
+	}
+	table := imagesTable([]string{"Node", "Image", "Image ID", "Size", "Unique Size", "Created"})
+	table.AppendBulk(data)
+	table.Render()
+
+	for _, res := range results {
+		if dryRun {
+			out.Step(style.Empty, "{{.node}}: would remove {{.count}} images, reclaiming at least {{.size}}", out.V{"node": res.Node, "count": len(res.Images), "size": humanSize(res.Reclaimed)})
+			continue
+		}
+		out.Step(style.Deleted, "{{.node}}: removed {{.count}} images, reclaiming {{.size}}", out.V{"node": res.Node, "count": len(res.Images), "size": humanSize(res.Reclaimed)})
+	}
+}
+
+var duImageCmd = &cobra.Command{
+	Use:   "du",
+	Short: "Show the disk usage of images",
+	Long: `Show the disk space used by the images of all nodes. The shared size of an image is the size of its layers which other images use too, its unique size being the space freed by removing it.
+The reclaimable space of a node is the unique size of the images which no container uses.`,
+	Example: `
+$ minikube image du
+
+$ minikube image du --format=json
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		profile, err := config.LoadProfile(viper.GetString(config.ProfileName))
+		if err != nil {
+			exit.Error(reason.Usage, "loading profile", err)
+		}
+		usage, err := machine.ImagesDiskUsage(profile)
+		if err != nil {
+			exit.Error(reason.GuestImageList, "Failed to get the disk usage of images", err)
+		}
+
+		switch duFormat {
+		case "json":
+			data, err := json.Marshal(usage)
+			if err != nil {
+				exit.Error(reason.InternalJSONMarshal, "Failed to marshal the disk usage of images", err)
+			}
+			fmt.Println(string(data))
+		case "yaml":
+			data, err := yaml.Marshal(usage)
+			if err != nil {
+				exit.Error(reason.InternalYamlMarshal, "Failed to marshal the disk usage of images", err)
+			}
+			fmt.Print(string(data))
+		case "table":
+			renderDiskUsage(usage)
+		default:
+			exit.Message(reason.Usage, "Invalid --format {{.format}}: expected one of table, json or yaml", out.V{"format": duFormat})
+		}
+	},
+}
+
+// renderDiskUsage prints the images of every node by decreasing unique size, then the totals of every node
+func renderDiskUsage(usage []machine.NodeDiskUsage) {
+	images := imagesTable([]string{"Node", "Image", "Image ID", "Created", "Size", "Shared Size", "Unique Size", "Containers"})
+	totals := imagesTable([]string{"Node", "Images", "Layers Size", "Unused Images", "Reclaimable"})
+	for _, nu := range usage {
+		imgs := append([]cruntime.ImageUsage{}, nu.Images...)
+		sort.SliceStable(imgs, func(i, j int) bool { return imgs[i].UniqueSize() > imgs[j].UniqueSize() })
+		unused := 0
+		var reclaimable int64
+		for _, img := range imgs {
+			images.Append([]string{nu.Node, imageName(img), shortImageID(img.ID), humanCreated(img.Created), humanSize(img.Size), humanSize(img.SharedSize), humanSize(img.UniqueSize()), strconv.Itoa(img.Containers)})
+			if img.Containers == 0 {
+				unused++
+				reclaimable += img.UniqueSize()
+			}
+		}
+		totals.Append([]string{nu.Node, strconv.Itoa(len(imgs)), humanSize(nu.LayersSize), strconv.Itoa(unused), humanSize(reclaimable)})
+	}
+	images.Render()
+	totals.Render()
+}
+
+func imagesTable(header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("|")
+	return table
+}
+
+// imageName returns the tags of an image, or <none> for a dangling one
+func imageName(img cruntime.ImageUsage) string {
+	if len(img.RepoTags) == 0 {
+		return "<none>"
+	}
+	return strings.Join(img.RepoTags, ", ")
+}
+
+func shortImageID(id string) string {
+	if len(id) > 13 {
+		return id[:13]
+	}
+	return id
+}
+
+func humanSize(size int64) string {
+	return units.HumanSizeWithPrecision(float64(size), 3)
+}
+
+func humanCreated(created time.Time) string {
+	if created.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%s ago", units.HumanDuration(time.Since(created)))
+}
+
 var listImageCmd = &cobra.Command{
 	Use:   "ls",
 	Short: "List images",
@@ -385,6 +695,13 @@ func init() {
 	loadImageCmd.Flags().BoolVar(&overwrite, "overwrite", true, "Overwrite image even if same image:tag name exists")
 	imageCmd.AddCommand(loadImageCmd)
 	imageCmd.AddCommand(removeImageCmd)
+	pruneImageCmd.Flags().BoolVar(&pruneUnused, "unused", false, "Remove all the images which no container uses, instead of only the dangling ones")
+	pruneImageCmd.Flags().DurationVar(&pruneOlderThan, "older-than", 0, "Only remove the images created longer ago than this duration (e.g. 168h)")
+	pruneImageCmd.Flags().StringVar(&pruneRegex, "regex", "", "Only remove the images with a tag or an ID matching this regular expression")
+	pruneImageCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "List the images which would be removed, without removing them")
+	imageCmd.AddCommand(pruneImageCmd)
+	duImageCmd.Flags().StringVar(&duFormat, "format", "table", "Format output. One of: table|json|yaml")
+	imageCmd.AddCommand(duImageCmd)
 	imageCmd.AddCommand(pullImageCmd)
 	buildImageCmd.Flags().StringVarP(&tag, "tag", "t", "", "Tag to apply to the new image (optional)")
 	buildImageCmd.Flags().BoolVarP(&push, "push", "", false, "Push the new image (requires tag)")
@@ -393,6 +710,14 @@ func init() {
 	buildImageCmd.Flags().StringArrayVar(&buildOpt, "build-opt", nil, "Specify arbitrary flags to pass to the build. (format: key=value)")
 	buildImageCmd.Flags().StringVarP(&nodeName, "node", "n", "", "The node to build on. Defaults to the primary control plane.")
 	buildImageCmd.Flags().BoolVarP(&allNodes, "all", "", false, "Build image on all nodes.")
+	buildImageCmd.Flags().StringSliceVar(&buildPlatforms, "platform", nil, "Platforms to build the image for, such as linux/amd64,linux/arm64. Defaults to the platform of the node.")
+	buildImageCmd.Flags().StringVar(&buildTarget, "target", "", "Stage of a multi-stage Dockerfile to build")
+	buildImageCmd.Flags().StringArrayVar(&buildArgs, "build-arg", nil, "Build-time variables. (format: key=value)")
+	buildImageCmd.Flags().StringArrayVar(&buildArgFiles, "build-arg-file", nil, "Files of build-time variables, one key=value per line")
+	buildImageCmd.Flags().StringArrayVar(&buildSecrets, "secret", nil, "Files exposed to the build as secrets. (format: id=mysecret,src=/local/secret)")
+	buildImageCmd.Flags().StringVar(&buildCacheFrom, "cache-from", "", "Local directory to import the build cache from")
+	buildImageCmd.Flags().StringVar(&buildCacheTo, "cache-to", "", "Local directory to export the build cache to")
+	buildImageCmd.Flags().BoolVar(&buildOnce, "build-once", false, "With --all, build the image on a single node and load it into the other nodes")
 	imageCmd.AddCommand(buildImageCmd)
 	saveImageCmd.Flags().BoolVar(&imgDaemon, "daemon", false, "Cache image to docker daemon")
 	saveImageCmd.Flags().BoolVar(&imgRemote, "remote", false, "Cache image to remote registry")
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+)
+
+func TestReadBuildArgFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "args")
+	data := "# versions\nVERSION=1.2\n\nEMPTY=\nFROM_ENV\nUNSET_VAR\n  SPACED=a b  \n"
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FROM_ENV", "env")
+
+	got, err := readBuildArgFile(file)
+	if err != nil {
+		t.Fatalf("readBuildArgFile() error = %v", err)
+	}
+	want := []string{"VERSION=1.2", "EMPTY=", "FROM_ENV=env", "SPACED=a b"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("readBuildArgFile() returned diff (-want +got):\n%s", diff)
+	}
+
+	if err := os.WriteFile(file, []byte("=value\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBuildArgFile(file); err == nil {
+		t.Errorf("readBuildArgFile() expected an error for a missing name")
+	}
+}
+
+func TestParseBuildSecret(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "npmrc")
+	if err := os.WriteFile(src, []byte("token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := parseBuildSecret("id=npm,src=" + src)
+	if err != nil {
+		t.Fatalf("parseBuildSecret() error = %v", err)
+	}
+	if want := (cruntime.BuildSecret{ID: "npm", Src: src}); got != want {
+		t.Errorf("parseBuildSecret() = %v, want %v", got, want)
+	}
+
+	for _, spec := range []string{"src=" + src, "id=npm", "id=a/b,src=" + src, "id=npm,src=" + src + ".missing", "id=npm,type=file,src=" + src} {
+		if _, err := parseBuildSecret(spec); err == nil {
+			t.Errorf("parseBuildSecret(%q) expected an error", spec)
+		}
+	}
+}
+
+func TestValidateBuildOnce(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    cruntime.BuildOptions
+		all     bool
+		once    bool
+		wantErr bool
+	}{
+		{"not built once", cruntime.BuildOptions{}, false, false, false},
+		{"built once", cruntime.BuildOptions{Tag: "my_image"}, true, true, false},
+		{"single platform", cruntime.BuildOptions{Tag: "my_image", Platforms: []string{"linux/arm64"}}, true, true, false},
+		{"without --all", cruntime.BuildOptions{Tag: "my_image"}, false, true, true},
+		{"without --tag", cruntime.BuildOptions{}, true, true, true},
+		{"multiple platforms", cruntime.BuildOptions{Tag: "my_image", Platforms: []string{"linux/amd64", "linux/arm64"}}, true, true, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if err := validateBuildOnce(tc.opts, tc.all, tc.once); (err != nil) != tc.wantErr {
+				t.Errorf("validateBuildOnce() error = %v, wantErr %t", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/docker/machine/libmachine/state"
 	"github.com/spf13/cobra"
@@ -52,6 +55,8 @@ var (
 	fileOutput string
 	// auditLogs only shows the audit logs
 	auditLogs bool
+	// problemsOutput is the format of the --problems report
+	problemsOutput string
 )
 
 // logsCmd represents the logs command
@@ -82,7 +87,17 @@ var logsCmd = &cobra.Command{
 			}
 			return
 		}
-		logs.OutputOffline(numberOfLines, logOutput)
+		problemsJSON := false
+		switch strings.ToLower(problemsOutput) {
+		case "json":
+			problemsJSON = true
+		case "text":
+		default:
+			exit.Message(reason.Usage, fmt.Sprintf("invalid output format: %s. Valid values: 'text', 'json'", problemsOutput))
+		}
+		if !showProblems || !problemsJSON {
+			logs.OutputOffline(numberOfLines, logOutput)
+		}
 
 		if shouldSilentFail() {
 			return
@@ -107,8 +122,16 @@ var logsCmd = &cobra.Command{
 			return
 		}
 		if showProblems {
-			problems := logs.FindProblems(cr, bs, *co.Config, co.CP.Runner)
-			logs.OutputProblems(problems, numberOfProblems, logOutput)
+			problems := logs.DetectProblems(cr, bs, *co.Config, co.CP.Runner)
+			if problemsJSON {
+				b, err := json.Marshal(problems)
+				if err != nil {
+					exit.Error(reason.InternalJSONMarshal, "json encoding failure", err)
+				}
+				fmt.Fprintln(logOutput, string(b))
+				return
+			}
+			logs.OutputReport(problems, numberOfProblems, logOutput)
 			return
 		}
 		err = logs.Output(cr, bs, *co.Config, co.CP.Runner, numberOfLines, logOutput)
@@ -145,9 +168,10 @@ func shouldSilentFail() bool {
 
 func init() {
 	logsCmd.Flags().BoolVarP(&followLogs, "follow", "f", false, "Show only the most recent journal entries, and continuously print new entries as they are appended to the journal.")
-	logsCmd.Flags().BoolVar(&showProblems, "problems", false, "Show only log entries which point to known problems")
+	logsCmd.Flags().BoolVar(&showProblems, "problems", false, "Show only a ranked report of log entries which point to known problems. Additional problem detectors can be defined in detectors.yaml in the minikube home directory")
 	logsCmd.Flags().IntVarP(&numberOfLines, "length", "n", 60, "Number of lines back to go within the log")
 	logsCmd.Flags().StringVar(&nodeName, "node", "", "The node to get logs from. Defaults to the primary control plane.")
 	logsCmd.Flags().StringVar(&fileOutput, "file", "", "If present, writes to the provided file instead of stdout.")
 	logsCmd.Flags().BoolVar(&auditLogs, "audit", false, "Show only the audit logs")
+	logsCmd.Flags().StringVarP(&problemsOutput, "output", "o", "text", "The format of the --problems report. One of 'text', 'json'")
 }
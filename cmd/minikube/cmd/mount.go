@@ -44,7 +44,9 @@ import (
 
 const (
 	// nineP is the value of --type used for the 9p filesystem.
-	nineP                     = "9p"
+	nineP = "9p"
+	// syncMount is the value of --type used to sync the files instead of mounting them.
+	syncMount                 = "sync"
 	defaultMount9PVersion     = "9p2000.L"
 	mount9PVersionDescription = "Specify the 9p version that the mount should use"
 	defaultMountGID           = "docker"
@@ -57,7 +59,7 @@ const (
 	defaultMountPort          = 0
 	mountPortDescription      = "Specify the port that the mount should be setup on, where 0 means any free port."
 	defaultMountType          = nineP
-	mountTypeDescription      = "Specify the mount filesystem type (supported types: 9p)"
+	mountTypeDescription      = "Specify the mount filesystem type (supported types: 9p, sync)"
 	defaultMountUID           = "docker"
 	mountUIDDescription       = "Default user id used for the mount"
 )
@@ -73,6 +75,7 @@ var (
 	mountVersion string
 	mountType    string
 	isKill       bool
+	isList       bool
 	uid          string
 	gid          string
 	mSize        int
@@ -80,7 +83,7 @@ var (
 )
 
 // supportedFilesystems is a map of filesystem types to not warn against.
-var supportedFilesystems = map[string]bool{nineP: true}
+var supportedFilesystems = map[string]bool{nineP: true, syncMount: true}
 
 // mountCmd represents the mount command
 var mountCmd = &cobra.Command{
@@ -95,6 +98,11 @@ var mountCmd = &cobra.Command{
 			os.Exit(0)
 		}
 
+		if isList {
+			listSyncs(ClusterFlagValue())
+			return
+		}
+
 		if len(args) != 1 {
 			exit.Message(reason.Usage, `Please specify the directory to be mounted: 
 	minikube mount <source directory>:<target directory>   (example: "/host-home:/vm-home")`)
@@ -126,6 +134,11 @@ var mountCmd = &cobra.Command{
 			exit.Message(reason.Usage, `'none' driver does not support 'minikube mount' command`)
 		}
 
+		if mountType == syncMount {
+			startSync(co, hostPath, vmPath)
+			return
+		}
+
 		var ip net.IP
 		var err error
 		if mountIP == "" {
@@ -237,7 +250,8 @@ func init() {
 	mountCmd.Flags().Uint16Var(&mountPort, constants.MountPortFlag, defaultMountPort, mountPortDescription)
 	mountCmd.Flags().StringVar(&mountType, constants.MountTypeFlag, defaultMountType, mountTypeDescription)
 	mountCmd.Flags().StringVar(&mountVersion, constants.Mount9PVersionFlag, defaultMount9PVersion, mount9PVersionDescription)
-	mountCmd.Flags().BoolVar(&isKill, "kill", false, "Kill the mount process spawned by minikube start")
+	mountCmd.Flags().BoolVar(&isKill, "kill", false, "Kill the mount process spawned by minikube start, and stop the syncs of the profile")
+	mountCmd.Flags().BoolVar(&isList, "list", false, "List the syncs running for the profile")
 	mountCmd.Flags().StringVar(&uid, constants.MountUIDFlag, defaultMountUID, mountUIDDescription)
 	mountCmd.Flags().StringVar(&gid, constants.MountGIDFlag, defaultMountGID, mountGIDDescription)
 	mountCmd.Flags().StringSliceVar(&options, constants.MountOptionsFlag, defaultMountOptions(), mountOptionsDescription)
@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/filesync"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+// startSync copies hostPath to vmPath, then keeps them in sync both ways until interrupted
+func startSync(co mustload.ClusterController, hostPath, vmPath string) {
+	hostPath, err := filepath.Abs(hostPath)
+	if err != nil {
+		exit.Error(reason.HostPathStat, "abs failed", err)
+	}
+	profile := co.Config.Name
+
+	syncer, err := filesync.New(co.CP.Runner, filesync.Config{
+		HostDir:   hostPath,
+		NodeDir:   vmPath,
+		Owner:     uid + ":" + gid,
+		Direction: filesync.Bidirectional,
+	})
+	if err != nil {
+		exit.Error(reason.GuestMount, "sync failed", err)
+	}
+
+	out.Step(style.Mounting, "Syncing host path {{.sourcePath}} with {{.destinationPath}} in the node ...", out.V{"sourcePath": hostPath, "destinationPath": vmPath})
+	actions, err := syncer.Sync()
+	if err != nil {
+		exit.Error(reason.GuestMount, "sync failed", err)
+	}
+	st := &filesync.Status{PID: os.Getpid(), HostDir: hostPath, NodeDir: vmPath, Started: time.Now()}
+	st.Count(actions, nil)
+	if err := filesync.SaveStatus(profile, st); err != nil {
+		klog.Warningf("unable to save the sync status: %v", err)
+	}
+	reportConflicts(actions)
+	out.Step(style.Success, "Synced {{.count}} paths between {{.sourcePath}} and {{.destinationPath}}", out.V{"count": len(actions), "sourcePath": hostPath, "destinationPath": vmPath})
+	out.Ln("")
+	out.Styled(style.Notice, "NOTE: This process must stay alive for the files to stay in sync ...")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	var received os.Signal
+	go func() {
+		received = <-c
+		cancel()
+	}()
+
+	err = syncer.Watch(ctx, filesync.DefaultDebounce, filesync.DefaultPollInterval, func(actions []filesync.Action, err error) {
+		if err != nil {
+			klog.Warningf("sync failed: %v", err)
+		}
+		for _, a := range actions {
+			klog.Infof("synced %s: %s", a.Path, a.Op)
+		}
+		reportConflicts(actions)
+		st.Count(actions, err)
+		if err := filesync.SaveStatus(profile, st); err != nil {
+			klog.Warningf("unable to save the sync status: %v", err)
+		}
+	})
+	if rerr := filesync.RemoveStatus(profile, vmPath); rerr != nil {
+		klog.Warningf("unable to remove the sync status: %v", rerr)
+	}
+	if err != nil {
+		exit.Error(reason.GuestMount, "sync failed", err)
+	}
+	out.Step(style.Stopped, "Stopped syncing {{.sourcePath}} with {{.destinationPath}}", out.V{"sourcePath": hostPath, "destinationPath": vmPath})
+	exit.Message(reason.Interrupted, "Received {{.name}} signal", out.V{"name": received})
+}
+
+func reportConflicts(actions []filesync.Action) {
+	for _, a := range actions {
+		if !a.Conflict {
+			continue
+		}
+		kept, other := "host", "node"
+		if a.Op == filesync.Pull {
+			kept, other = "node", "host"
+		}
+		out.WarningT("{{.path}} was changed on both sides: kept the {{.kept}} version, the {{.other}} version was saved as {{.copy}} on the host", out.V{"path": a.Path, "kept": kept, "other": other, "copy": a.Path + ".sync-conflict"})
+	}
+}
+
+// listSyncs prints the syncs running for profile
+func listSyncs(profile string) {
+	statuses, err := filesync.ListStatus(profile)
+	if err != nil {
+		exit.Error(reason.HostMountPid, "Error listing syncs", err)
+	}
+	if len(statuses) == 0 {
+		out.Styled(style.Empty, "No sync is running for profile {{.profile}}", out.V{"profile": profile})
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Host Path", "Node Path", "PID", "Running For", "Pushed", "Pulled", "Deleted", "Conflicts", "Last Error"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+	for _, st := range statuses {
+		table.Append([]string{st.HostDir, st.NodeDir, strconv.Itoa(st.PID), time.Since(st.Started).Round(time.Second).String(),
+			strconv.Itoa(st.Pushed), strconv.Itoa(st.Pulled), strconv.Itoa(st.Deleted), strconv.Itoa(st.Conflicts), st.LastError})
+	}
+	table.Render()
+	out.Ln("")
+	out.Styled(style.Tip, "To stop them, run: {{.command}}", out.V{"command": fmt.Sprintf("minikube mount --kill -p %s", profile)})
+}
@@ -33,14 +33,17 @@ import (
 	configCmd "k8s.io/minikube/cmd/minikube/cmd/config"
 	"k8s.io/minikube/pkg/drivers/kic/oci"
 	"k8s.io/minikube/pkg/minikube/audit"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
 	"k8s.io/minikube/pkg/minikube/config"
 	"k8s.io/minikube/pkg/minikube/constants"
 	"k8s.io/minikube/pkg/minikube/detect"
+	"k8s.io/minikube/pkg/minikube/download"
 	"k8s.io/minikube/pkg/minikube/exit"
 	"k8s.io/minikube/pkg/minikube/localpath"
 	"k8s.io/minikube/pkg/minikube/notify"
 	"k8s.io/minikube/pkg/minikube/out"
 	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/registrycache"
 	"k8s.io/minikube/pkg/minikube/translate"
 	"k8s.io/minikube/pkg/version"
 )
@@ -72,6 +75,10 @@ var RootCmd = &cobra.Command{
 			out.WarningT("User name '{{.username}}' is not valid", out.V{"username": userName})
 			exit.Message(reason.Usage, "User name must be 60 chars or less.")
 		}
+		if err := download.ValidatePolicy(viper.GetString(config.DownloadPolicy)); err != nil {
+			exit.Message(reason.Usage, "{{.err}}", out.V{"err": err})
+		}
+		useProfileBootstrapper(cmd)
 		// viper maps $MINIKUBE_ROOTLESS to "rootless" property automatically, but it does not do vice versa,
 		// so we map "rootless" property to $MINIKUBE_ROOTLESS expliclity here.
 		// $MINIKUBE_ROOTLESS is referred by KIC runner, which is decoupled from viper.
@@ -84,7 +91,8 @@ var RootCmd = &cobra.Command{
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	defer audit.Log(time.Now())
+	audit.Start(time.Now())
+	defer audit.Log(0)
 
 	// Check whether this is a windows binary (.exe) running inisde WSL.
 	if runtime.GOOS == "windows" && detect.IsMicrosoftWSL() {
@@ -210,9 +218,11 @@ func init() {
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine) // avoid `generate-docs_test.go` complaining about "Docs are not updated"
 
 	RootCmd.PersistentFlags().StringP(config.ProfileName, "p", constants.DefaultClusterName, `The name of the minikube VM being used. This can be set to allow having multiple instances of minikube independently.`)
-	RootCmd.PersistentFlags().StringP(configCmd.Bootstrapper, "b", "kubeadm", "The name of the cluster bootstrapper that will set up the Kubernetes cluster.")
+	RootCmd.PersistentFlags().StringP(configCmd.Bootstrapper, "b", "kubeadm", "The name of the cluster bootstrapper that will set up the Kubernetes cluster. Valid options: kubeadm, k3s")
 	RootCmd.PersistentFlags().String(config.UserFlag, "", "Specifies the user executing the operation. Useful for auditing operations executed by 3rd party tools. Defaults to the operating system username.")
 	RootCmd.PersistentFlags().Bool(config.Rootless, false, "Force to use rootless driver (docker and podman driver only)")
+	RootCmd.PersistentFlags().String(config.DownloadPolicy, download.PolicyDefault, fmt.Sprintf("Policy for downloads that cannot be verified against a SHA-256 checksum or signature. Valid values: %s. 'strict' refuses them.", strings.Join(download.Policies, ", ")))
+	RootCmd.PersistentFlags().String(config.DownloadPublicKey, "", "Path to a PEM encoded ECDSA or RSA public key used to verify the detached signature (<url>.sig) of each download")
 
 	groups := templates.CommandGroups{
 		{
@@ -234,6 +244,7 @@ func init() {
 				podmanEnvCmd,
 				cacheCmd,
 				imageCmd,
+				bundleCmd,
 			},
 		},
 		{
@@ -243,6 +254,13 @@ func init() {
 				configCmd.ConfigCmd,
 				configCmd.ProfileCmd,
 				updateContextCmd,
+				snapshotCmd,
+				etcdCmd,
+				upgradeCmd,
+				certsCmd,
+				scheduleCmd,
+				applyCmd,
+				exportCmd,
 			},
 		},
 		{
@@ -260,6 +278,7 @@ func init() {
 				kubectlCmd,
 				nodeCmd,
 				cpCmd,
+				filesCmd,
 			},
 		},
 		{
@@ -269,6 +288,9 @@ func init() {
 				sshHostCmd,
 				ipCmd,
 				logsCmd,
+				auditCmd,
+				doctorCmd,
+				topCmd,
 				updateCheckCmd,
 				versionCmd,
 				optionsCmd,
@@ -313,6 +335,9 @@ func setupViper() {
 	viper.RegisterAlias(config.EmbedCerts, embedCerts)
 	viper.SetDefault(config.WantUpdateNotification, true)
 	viper.SetDefault(config.ReminderWaitPeriodInHours, 24)
+	viper.SetDefault(config.MaxAuditSizeInMB, 10)
+	viper.SetDefault(config.RegistryCacheSize, registrycache.DefaultMaxSize)
+	viper.SetDefault(config.RegistryCachePort, registrycache.DefaultPort)
 	viper.SetDefault(config.WantNoneDriverWarning, true)
 	viper.SetDefault(config.WantVirtualBoxDriverWarning, true)
 }
@@ -323,6 +348,22 @@ func addToPath(dir string) {
 	os.Setenv("PATH", new)
 }
 
+// useProfileBootstrapper makes commands use the bootstrapper the profile was started with, which cannot be changed afterwards
+func useProfileBootstrapper(cmd *cobra.Command) {
+	if cc, err := config.Load(ClusterFlagValue()); err == nil && cc.KubernetesConfig.Bootstrapper != "" {
+		bs := cc.KubernetesConfig.Bootstrapper
+		if cmd.Flags().Changed(configCmd.Bootstrapper) && viper.GetString(configCmd.Bootstrapper) != bs {
+			out.WarningT("You cannot change the bootstrapper of an existing minikube cluster. Using {{.bootstrapper}}, which {{.profile}} was started with.", out.V{"bootstrapper": bs, "profile": cc.Name})
+		}
+		viper.Set(configCmd.Bootstrapper, bs)
+	}
+
+	// the preload holds the kubeadm images and binaries, while k3s bundles the control plane in its binary
+	if viper.GetString(configCmd.Bootstrapper) == bootstrapper.K3s {
+		viper.Set(preload, false)
+	}
+}
+
 func validateUsername(name string) bool {
 	return len(name) <= 60
 }
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/schedule"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+// scheduleCmd represents the set of schedule subcommands
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Schedule recurring stops and starts of a cluster",
+	Long: `Schedule recurring stops and starts of a cluster using 5-field cron expressions (minute hour day-of-month month day-of-week), evaluated in the local time zone.
+
+For example, to stop the cluster at 19:00 and start it again at 08:00 on weekdays:
+
+  minikube schedule stop "0 19 * * mon-fri"
+  minikube schedule start "0 8 * * mon-fri"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		exit.Message(reason.Usage, "Usage: minikube schedule [stop|start|list|cancel]")
+	},
+}
+
+// addRecurringSchedule adds a recurring schedule of the given action to the current profile
+func addRecurringSchedule(action string, expr string) {
+	if _, err := schedule.ParseCron(expr); err != nil {
+		exit.Message(reason.Usage, "Invalid schedule {{.schedule}}: {{.err}}", out.V{"schedule": expr, "err": err})
+	}
+	cname := ClusterFlagValue()
+	_, cc := mustload.Partial(cname)
+	s, err := schedule.AddRecurring(cname, cc, action, expr)
+	if err != nil {
+		exit.Error(reason.DaemonizeError, "Failed to start the schedule daemon", err)
+	}
+	out.Step(style.Waiting, `Scheduled {{.action}} {{.id}} of "{{.profile}}", next run at {{.next}}`, out.V{"action": s.Action, "id": s.ID, "profile": cname, "next": schedule.NextRun(*s, time.Now()).Format(time.RFC1123)})
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/schedule"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var scheduleCancelAll bool
+
+var scheduleCancelCmd = &cobra.Command{
+	Use:   "cancel [ID...]",
+	Short: "Cancel scheduled stops and starts",
+	Long:  "Cancels the given recurring schedules of the current profile, or the scheduled stop and all recurring schedules with --all.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 && !scheduleCancelAll {
+			exit.Message(reason.Usage, "Usage: minikube schedule cancel [ID...] or minikube schedule cancel --all")
+		}
+		if len(args) > 0 && scheduleCancelAll {
+			exit.Message(reason.Usage, "IDs cannot be combined with --all")
+		}
+		ids := []int{}
+		for _, a := range args {
+			id, err := strconv.Atoi(a)
+			if err != nil {
+				exit.Message(reason.Usage, "Invalid schedule ID {{.id}}, see \"minikube schedule list\"", out.V{"id": a})
+			}
+			ids = append(ids, id)
+		}
+
+		cname := ClusterFlagValue()
+		if scheduleCancelAll {
+			schedule.KillExisting([]string{cname})
+		}
+		_, cc := mustload.Partial(cname)
+		if err := schedule.CancelRecurring(cname, cc, ids...); err != nil {
+			if _, ok := err.(*schedule.ErrScheduleNotFound); ok {
+				exit.Message(reason.HostScheduleNotFound, "{{.err}}, see \"minikube schedule list\"", out.V{"err": err})
+			}
+			exit.Error(reason.HostSaveProfile, "Failed to cancel schedules", err)
+		}
+		if scheduleCancelAll {
+			out.Step(style.Stopped, `All schedules of "{{.profile}}" cancelled`, out.V{"profile": cname})
+			return
+		}
+		out.Step(style.Stopped, `{{.count}} schedule{{if gt .count 1}}s{{end}} of "{{.profile}}" cancelled`, out.V{"count": len(ids), "profile": cname})
+	},
+}
+
+func init() {
+	scheduleCancelCmd.Flags().BoolVar(&scheduleCancelAll, "all", false, "Cancel the scheduled stop and all recurring schedules of the profile")
+	scheduleCmd.AddCommand(scheduleCancelCmd)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/schedule"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var (
+	scheduleListOutput string
+	scheduleListAll    bool
+)
+
+// scheduleEntry is a scheduled stop or a recurring schedule of a profile
+type scheduleEntry struct {
+	Profile  string
+	ID       int `json:",omitempty"`
+	Action   string
+	Schedule string
+	Next     time.Time `json:",omitempty"`
+	// Inactive is set for the recurring schedules of a profile whose schedule daemon is not running
+	Inactive bool `json:",omitempty"`
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled stops and starts",
+	Long:  "Lists the pending scheduled stop and the recurring schedules of the current profile, or of all profiles with --all.",
+	Run: func(cmd *cobra.Command, args []string) {
+		var ccs []*config.ClusterConfig
+		if scheduleListAll {
+			validProfiles, _, err := config.ListProfiles()
+			if err != nil {
+				klog.Warningf("error loading profiles: %v", err)
+			}
+			for _, p := range validProfiles {
+				ccs = append(ccs, p.Config)
+			}
+		} else {
+			_, cc := mustload.Partial(ClusterFlagValue())
+			ccs = append(ccs, cc)
+		}
+		entries := scheduleEntries(ccs, time.Now())
+
+		switch strings.ToLower(scheduleListOutput) {
+		case "json":
+			b, err := json.Marshal(entries)
+			if err != nil {
+				exit.Error(reason.InternalJSONMarshal, "json encoding failure", err)
+			}
+			out.String("%s", b)
+		case "table":
+			if len(entries) == 0 {
+				out.Styled(style.Empty, "No schedules found. Create one using \"minikube schedule stop\" or \"minikube stop --schedule\".")
+				return
+			}
+			renderScheduleTable(entries)
+		default:
+			exit.Message(reason.Usage, fmt.Sprintf("invalid output format: %s. Valid values: 'table', 'json'", scheduleListOutput))
+		}
+	},
+}
+
+func scheduleEntries(ccs []*config.ClusterConfig, now time.Time) []scheduleEntry {
+	entries := []scheduleEntry{}
+	for _, cc := range ccs {
+		if cc.ScheduledStop != nil {
+			at := time.Unix(cc.ScheduledStop.InitiationTime, 0).Add(cc.ScheduledStop.Duration)
+			if at.After(now) {
+				entries = append(entries, scheduleEntry{Profile: cc.Name, Action: schedule.ActionStop, Schedule: "once", Next: at})
+			}
+		}
+		running := len(cc.RecurringSchedules) > 0 && schedule.DaemonRunning(cc.Name)
+		for _, s := range cc.RecurringSchedules {
+			e := scheduleEntry{Profile: cc.Name, ID: s.ID, Action: s.Action, Schedule: s.Cron, Inactive: !running}
+			if running {
+				e.Next = schedule.NextRun(s, now)
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func renderScheduleTable(entries []scheduleEntry) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Profile", "ID", "Action", "Schedule", "Next"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+	for _, e := range entries {
+		id := "-"
+		if e.ID != 0 {
+			id = strconv.Itoa(e.ID)
+		}
+		next := "never"
+		if e.Inactive {
+			next = "inactive, run minikube start"
+		} else if !e.Next.IsZero() {
+			next = e.Next.Format("2006-01-02 15:04:05")
+		}
+		table.Append([]string{e.Profile, id, e.Action, e.Schedule, next})
+	}
+	table.Render()
+}
+
+func init() {
+	scheduleListCmd.Flags().StringVarP(&scheduleListOutput, "output", "o", "table", "The output format. One of 'json', 'table'")
+	scheduleListCmd.Flags().BoolVar(&scheduleListAll, "all", false, "List the schedules of all profiles")
+	scheduleCmd.AddCommand(scheduleListCmd)
+}
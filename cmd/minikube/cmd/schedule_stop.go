@@ -0,0 +1,36 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/schedule"
+)
+
+var scheduleStopCmd = &cobra.Command{
+	Use:   "stop CRON",
+	Short: "Stop the cluster on a recurring schedule",
+	Long:  `Stop the cluster on a recurring schedule, given as a 5-field cron expression (e.g. "0 19 * * mon-fri") or one of @hourly, @daily, @weekly or @monthly.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		addRecurringSchedule(schedule.ActionStop, args[0])
+	},
+}
+
+func init() {
+	scheduleCmd.AddCommand(scheduleStopCmd)
+}
@@ -23,7 +23,6 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -35,9 +34,9 @@ import (
 	"k8s.io/minikube/pkg/drivers/kic/oci"
 	"k8s.io/minikube/pkg/kapi"
 	"k8s.io/minikube/pkg/minikube/browser"
+	"k8s.io/minikube/pkg/minikube/command"
 	"k8s.io/minikube/pkg/minikube/driver"
 	"k8s.io/minikube/pkg/minikube/exit"
-	"k8s.io/minikube/pkg/minikube/localpath"
 	"k8s.io/minikube/pkg/minikube/mustload"
 	"k8s.io/minikube/pkg/minikube/out"
 	"k8s.io/minikube/pkg/minikube/reason"
@@ -147,7 +146,7 @@ You may select another namespace by using 'minikube service {{.service}} -n <nam
 		}
 
 		if driver.NeedsPortForward(co.Config.Driver) && driver.IsKIC(co.Config.Driver) && services != nil {
-			startKicServiceTunnel(services, cname, co.Config.Driver)
+			startKicServiceTunnel(services, cname, co.Config.Driver, co.CP.Runner)
 		} else if driver.NeedsPortForward(co.Config.Driver) && driver.IsQEMU(co.Config.Driver) && services != nil {
 			startQemuServiceTunnel(services, cname, co.Config.Driver)
 		} else if !serviceURLMode {
@@ -167,7 +166,7 @@ func init() {
 	serviceCmd.PersistentFlags().StringVar(&serviceURLFormat, "format", defaultServiceFormatTemplate, "Format to output service URL in. This format will be applied to each url individually and they will be printed one at a time.")
 }
 
-func startKicServiceTunnel(services service.URLs, configName, driverName string) {
+func startKicServiceTunnel(services service.URLs, configName, driverName string, runner command.Runner) {
 	ctrlC := make(chan os.Signal, 1)
 	signal.Notify(ctrlC, os.Interrupt)
 
@@ -176,16 +175,16 @@ func startKicServiceTunnel(services service.URLs, configName, driverName string)
 		exit.Error(reason.InternalKubernetesClient, "error creating clientset", err)
 	}
 
+	port, err := oci.ForwardedPort(driverName, configName, 22)
+	if err != nil {
+		exit.Error(reason.DrvPortForward, "error getting ssh port", err)
+	}
+	sshClient := kicSSHClient(port, configName, runner)
+	defer sshClient.Close()
+
 	var data [][]string
 	for _, svc := range services {
-		port, err := oci.ForwardedPort(driverName, configName, 22)
-		if err != nil {
-			exit.Error(reason.DrvPortForward, "error getting ssh port", err)
-		}
-		sshPort := strconv.Itoa(port)
-		sshKey := filepath.Join(localpath.MiniPath(), "machines", configName, "id_rsa")
-
-		serviceTunnel := kic.NewServiceTunnel(sshPort, sshKey, clientset.CoreV1(), serviceURLMode)
+		serviceTunnel := kic.NewServiceTunnel(sshClient, clientset.CoreV1(), serviceURLMode)
 		urls, err := serviceTunnel.Start(svc.Name, namespace)
 
 		if err != nil {
@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/snapshot"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var snapshotDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a profile snapshot",
+	Long:  "Removes a snapshot and all of its data from the minikube home.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			exit.Message(reason.Usage, "Usage: minikube snapshot delete <name>")
+		}
+
+		name := args[0]
+		if err := snapshot.Delete(name); err != nil {
+			if snapshot.IsNotExist(err) {
+				exit.Message(reason.HostSnapshotNotFound, `Snapshot "{{.name}}" not found. Run "minikube snapshot list" to view all snapshots.`, out.V{"name": name})
+			}
+			exit.Error(reason.HostSnapshot, "Failed to delete snapshot", err)
+		}
+		out.Step(style.Deleted, "Removed snapshot {{.name}}", out.V{"name": name})
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotDeleteCmd)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/snapshot"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var snapshotListOutput string
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profile snapshots",
+	Long:  "Lists all snapshots stored under the minikube home.",
+	Run: func(cmd *cobra.Command, args []string) {
+		ms, err := snapshot.List()
+		if err != nil {
+			exit.Error(reason.HostSnapshot, "Failed to list snapshots", err)
+		}
+
+		switch strings.ToLower(snapshotListOutput) {
+		case "json":
+			b, err := json.Marshal(ms)
+			if err != nil {
+				exit.Error(reason.InternalJSONMarshal, "json encoding failure", err)
+			}
+			out.String(string(b))
+		case "table":
+			if len(ms) == 0 {
+				out.Styled(style.Empty, "No snapshots found. Create one using \"minikube snapshot save\".")
+				return
+			}
+			renderSnapshotsTable(ms)
+		default:
+			exit.Message(reason.Usage, fmt.Sprintf("invalid output format: %s. Valid values: 'table', 'json'", snapshotListOutput))
+		}
+	},
+}
+
+func renderSnapshotsTable(ms []*snapshot.Manifest) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Profile", "Driver", "Runtime", "Version", "Nodes", "Created"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+	for _, m := range ms {
+		table.Append([]string{m.Name, m.Profile, m.Driver, m.ContainerRuntime, m.KubernetesVersion, strconv.Itoa(len(m.Nodes)), m.Created.Format("2006-01-02 15:04:05")})
+	}
+	table.Render()
+}
+
+func init() {
+	snapshotListCmd.Flags().StringVarP(&snapshotListOutput, "output", "o", "table", "The output format. One of 'json', 'table'")
+	snapshotCmd.AddCommand(snapshotListCmd)
+}
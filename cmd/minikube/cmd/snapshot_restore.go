@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/snapshot"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var snapshotRestoreWait time.Duration
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Restore a snapshot into a new or running profile",
+	Long: `Restores the etcd data and container images of a snapshot into a profile.
+If the profile does not exist, it is created with the driver, Kubernetes version, container runtime, resources and nodes of the snapshotted profile.
+An existing profile must be running the same Kubernetes version and container runtime, and have the same nodes as the snapshotted profile.
+The etcd member is restored with the name and IP of the control plane of the profile. Only clusters with a single control plane are supported.`,
+	Example: "minikube snapshot restore seeded-db -p review",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			exit.Message(reason.Usage, "Usage: minikube snapshot restore <name>")
+		}
+
+		name := args[0]
+		m, err := snapshot.Load(name)
+		if err != nil {
+			if snapshot.IsNotExist(err) {
+				exit.Message(reason.HostSnapshotNotFound, `Snapshot "{{.name}}" not found. Run "minikube snapshot list" to view all snapshots.`, out.V{"name": name})
+			}
+			exit.Error(reason.HostSnapshot, "Failed to load snapshot", err)
+		}
+
+		cname := ClusterFlagValue()
+		if !config.ProfileExists(cname) {
+			sc, err := snapshot.LoadConfig(name)
+			if err != nil {
+				exit.Error(reason.HostSnapshot, "Failed to load snapshot", err)
+			}
+			// refuse snapshots which could not be restored before creating the profile
+			if err := snapshot.Compatible(m, sc); err != nil {
+				exit.Error(reason.GuestSnapshotIncompatible, "Snapshot can not be restored", err)
+			}
+			out.Step(style.New, "Creating profile {{.profile}} from snapshot {{.name}}", out.V{"profile": cname, "name": name})
+			setStartFlagsFromSnapshot(m, sc)
+			runStart(startCmd, nil)
+		}
+
+		co := mustload.Running(cname)
+		if err := snapshot.Compatible(m, co.Config); err != nil {
+			exit.Error(reason.GuestSnapshotIncompatible, "Snapshot can not be restored into this profile", err)
+		}
+
+		out.Step(style.Resetting, "Restoring snapshot {{.name}} into {{.profile}} ...", out.V{"name": name, "profile": cname})
+		if err := snapshot.Restore(co.API, co.Config, name, snapshotRestoreWait); err != nil {
+			exit.Error(reason.GuestSnapshotRestore, "Failed to restore snapshot", err)
+		}
+		out.Step(style.Ready, "Restored snapshot {{.name}} into {{.profile}}", out.V{"name": name, "profile": cname})
+	},
+}
+
+// setStartFlagsFromSnapshot sets the start flags for a profile created from a snapshot
+func setStartFlagsFromSnapshot(m *snapshot.Manifest, sc *config.ClusterConfig) {
+	k8s := sc.KubernetesConfig
+
+	flags := map[string]string{
+		"driver":          m.Driver,
+		kubernetesVersion: m.KubernetesVersion,
+		containerRuntime:  m.ContainerRuntime,
+		nodes:             strconv.Itoa(len(m.Nodes)),
+		cniFlag:           k8s.CNI,
+		featureGates:      k8s.FeatureGates,
+		serviceCIDR:       k8s.ServiceCIDR,
+		dnsDomain:         k8s.DNSDomain,
+		imageRepository:   k8s.ImageRepository,
+	}
+	if sc.CPUs != 0 {
+		flags[cpus] = strconv.Itoa(sc.CPUs)
+	}
+	if sc.Memory != 0 {
+		flags[memory] = fmt.Sprintf("%dmb", sc.Memory)
+	}
+	if sc.DiskSize != 0 {
+		flags[humanReadableDiskSize] = fmt.Sprintf("%dmb", sc.DiskSize)
+	}
+
+	enabled := []string{}
+	for name, on := range sc.Addons {
+		if on {
+			enabled = append(enabled, name)
+		}
+	}
+	sort.Strings(enabled)
+	flags[config.AddonListFlag] = strings.Join(enabled, ",")
+
+	for name, value := range flags {
+		if value == "" {
+			continue
+		}
+		if err := startCmd.Flags().Set(name, value); err != nil {
+			exit.Error(reason.InternalBindFlags, fmt.Sprintf("Unable to set flag %s", name), err)
+		}
+	}
+}
+
+func init() {
+	snapshotRestoreCmd.Flags().DurationVar(&snapshotRestoreWait, "wait-timeout", 4*time.Minute, "How long to wait for etcd and the apiserver to be ready after the restore")
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+}
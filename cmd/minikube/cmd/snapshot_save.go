@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/snapshot"
+	"k8s.io/minikube/pkg/minikube/style"
+)
+
+var snapshotSaveCmd = &cobra.Command{
+	Use:     "save [name]",
+	Short:   "Save a snapshot of a running profile",
+	Long:    "Captures the cluster config, the etcd data and the container images of every node of a running profile into the versioned archive of a snapshot under the minikube home.",
+	Example: "minikube snapshot save seeded-db",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 1 {
+			exit.Message(reason.Usage, "Usage: minikube snapshot save [name]")
+		}
+
+		co := mustload.Running(ClusterFlagValue())
+		name := fmt.Sprintf("%s-%s", co.Config.Name, time.Now().Format("20060102-150405"))
+		if len(args) == 1 {
+			name = args[0]
+		}
+		if !snapshot.NameValid(name) {
+			exit.Message(reason.Usage, "Snapshot name {{.name}} is not valid", out.V{"name": name})
+		}
+		if snapshot.Exists(name) {
+			exit.Message(reason.Usage, "Snapshot {{.name}} already exists", out.V{"name": name})
+		}
+
+		out.Step(style.Caching, "Saving snapshot {{.name}} of {{.profile}} ...", out.V{"name": name, "profile": co.Config.Name})
+		m, err := snapshot.Save(co.API, co.Config, name)
+		if err != nil {
+			exit.Error(reason.GuestSnapshotSave, "Failed to save snapshot", err)
+		}
+		out.Step(style.Success, "Saved snapshot {{.name}} ({{.nodes}} node(s)) to {{.path}}", out.V{"name": m.Name, "nodes": len(m.Nodes), "path": localpath.Snapshot(m.Name)})
+	},
+}
+
+func init() {
+	snapshotCmd.AddCommand(snapshotSaveCmd)
+}
@@ -35,6 +35,8 @@ import (
 	"github.com/Delta456/box-cli-maker/v2"
 	"github.com/blang/semver/v4"
 	"github.com/docker/go-connections/nat"
+	units "github.com/docker/go-units"
+	"github.com/docker/machine/libmachine/host"
 	"github.com/docker/machine/libmachine/ssh"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -50,8 +52,10 @@ import (
 	"k8s.io/klog/v2"
 	cmdcfg "k8s.io/minikube/cmd/minikube/cmd/config"
 	"k8s.io/minikube/pkg/drivers/kic/oci"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
 	"k8s.io/minikube/pkg/minikube/bootstrapper/bsutil"
 	"k8s.io/minikube/pkg/minikube/bootstrapper/images"
+	"k8s.io/minikube/pkg/minikube/cluster"
 	"k8s.io/minikube/pkg/minikube/config"
 	"k8s.io/minikube/pkg/minikube/constants"
 	"k8s.io/minikube/pkg/minikube/cruntime"
@@ -69,6 +73,8 @@ import (
 	"k8s.io/minikube/pkg/minikube/out"
 	"k8s.io/minikube/pkg/minikube/out/register"
 	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/registrycache"
+	"k8s.io/minikube/pkg/minikube/schedule"
 	"k8s.io/minikube/pkg/minikube/style"
 	pkgtrace "k8s.io/minikube/pkg/trace"
 
@@ -277,6 +283,11 @@ func runStart(cmd *cobra.Command, args []string) {
 	if err := showKubectlInfo(kubeconfig, starter.Node.KubernetesVersion, starter.Node.ContainerRuntime, starter.Cfg.Name); err != nil {
 		klog.Errorf("kubectl info: %v", err)
 	}
+
+	// the schedule daemon does not survive a reboot of the host
+	if err := schedule.EnsureDaemon(starter.Cfg.Name); err != nil {
+		out.WarningT("Unable to start the daemon running the recurring schedules of {{.profile}}: {{.error}}", out.V{"profile": starter.Cfg.Name, "error": err})
+	}
 }
 
 func provisionWithDriver(cmd *cobra.Command, ds registry.DriverState, existing *config.ClusterConfig) (node.Starter, error) {
@@ -350,6 +361,8 @@ func provisionWithDriver(cmd *cobra.Command, ds registry.DriverState, existing *
 		return node.Starter{}, err
 	}
 
+	updateRegistryCache(&cc, host)
+
 	return node.Starter{
 		Runner:         mRunner,
 		PreExists:      preExists,
@@ -362,6 +375,44 @@ func provisionWithDriver(cmd *cobra.Command, ds registry.DriverState, existing *
 	}, nil
 }
 
+// updateRegistryCache starts the registry cache if the cluster uses it, or releases it otherwise
+func updateRegistryCache(cc *config.ClusterConfig, h *host.Host) {
+	if !cc.RegistryCache {
+		if err := registrycache.Release(cc.Name); err != nil {
+			klog.Warningf("unable to release the registry cache: %v", err)
+		}
+		return
+	}
+	size, err := units.RAMInBytes(viper.GetString(config.RegistryCacheSize))
+	if err != nil {
+		exit.Message(reason.Usage, "Invalid registry cache size {{.size}}: {{.error}}", out.V{"size": viper.GetString(config.RegistryCacheSize), "error": err})
+	}
+	// the nodes reach the cache at the IP of the host on their network
+	hostIP, err := cluster.HostIP(h, cc.Name)
+	if err != nil {
+		klog.Warningf("unable to get the host IP, the registry cache only listens on the loopback interface: %v", err)
+	}
+	requested := viper.GetInt(config.RegistryCachePort)
+	port, err := registrycache.Start(cc.Name, registrycache.ListenAddresses(hostIP), requested, size)
+	if err != nil {
+		// the runtimes fall back to docker hub when the mirror is unreachable
+		out.WarningT("Unable to start the registry cache, images will be pulled from docker hub: {{.error}}", out.V{"error": err})
+		return
+	}
+	// the server is shared by the profiles, so it may already be running on another port
+	if port != requested {
+		out.WarningT("The registry cache is already running on port {{.port}} rather than {{.requested}}, {{.profile}} uses it on port {{.port}}", out.V{"port": port, "requested": requested, "profile": cc.Name})
+	}
+	mirrors := strings.Join(cc.RegistryMirror, ",")
+	setRegistryCacheMirror(cc, port)
+	if strings.Join(cc.RegistryMirror, ",") == mirrors {
+		return
+	}
+	if err := config.SaveProfile(cc.Name, cc); err != nil {
+		out.WarningT("Unable to save the registry cache mirror of {{.profile}}: {{.error}}", out.V{"profile": cc.Name, "error": err})
+	}
+}
+
 func startWithDriver(cmd *cobra.Command, starter node.Starter, existing *config.ClusterConfig) (*kubeconfig.Settings, error) {
 	kubeconfig, err := node.Start(starter, true)
 	if err != nil {
@@ -1248,10 +1299,38 @@ func validateFlags(cmd *cobra.Command, drvName string) {
 		exit.Message(reason.Usage, "Sorry, please set the --output flag to one of the following valid options: [text,json]")
 	}
 
+	if cmd.Flags().Changed(caCert) || cmd.Flags().Changed(caKey) {
+		validateExternalCA(viper.GetString(caCert), viper.GetString(caKey))
+	}
+
+	validateBootstrapper()
 	validateRegistryMirror()
 	validateInsecureRegistry()
 }
 
+// validateExternalCA validates that the --ca-cert and --ca-key CA can sign the cluster certificates
+func validateExternalCA(certPath, keyPath string) {
+	if certPath == "" && keyPath == "" {
+		return
+	}
+	if certPath == "" || keyPath == "" {
+		exit.Message(reason.Usage, "Sorry, --ca-cert and --ca-key must be specified together")
+	}
+	if err := util.ValidateCA(certPath, keyPath); err != nil {
+		exit.Message(reason.Usage, "The CA {{.cert}} cannot sign the cluster certificates: {{.error}}", out.V{"cert": certPath, "error": err})
+	}
+}
+
+// validateBootstrapper validates the --bootstrapper flag
+func validateBootstrapper() {
+	bs := viper.GetString(cmdcfg.Bootstrapper)
+	switch bs {
+	case bootstrapper.Kubeadm, bootstrapper.K3s:
+	default:
+		exit.Message(reason.Usage, "Sorry, {{.bootstrapper}} is not a supported bootstrapper. Valid options: {{.valid}}", out.V{"bootstrapper": bs, "valid": strings.Join(bootstrapper.Bootstrappers, ", ")})
+	}
+}
+
 // validatePorts validates that the --ports are not below 1024 for the host and not outside range
 func validatePorts(ports []string) error {
 	_, portBindingsMap, err := nat.ParsePortSpecs(ports)
@@ -18,6 +18,9 @@ package cmd
 
 import (
 	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,6 +30,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"k8s.io/klog/v2"
+	cmdcfg "k8s.io/minikube/cmd/minikube/cmd/config"
 	"k8s.io/minikube/pkg/drivers/kic"
 	"k8s.io/minikube/pkg/drivers/kic/oci"
 	"k8s.io/minikube/pkg/minikube/bootstrapper/bsutil"
@@ -39,9 +43,11 @@ import (
 	"k8s.io/minikube/pkg/minikube/download"
 	"k8s.io/minikube/pkg/minikube/driver"
 	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/machine"
 	"k8s.io/minikube/pkg/minikube/out"
 	"k8s.io/minikube/pkg/minikube/proxy"
 	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/registrycache"
 	"k8s.io/minikube/pkg/minikube/style"
 	pkgutil "k8s.io/minikube/pkg/util"
 	"k8s.io/minikube/pkg/version"
@@ -91,6 +97,7 @@ const (
 	mountPortFlag           = "mount-port"
 	mountTypeFlag           = "mount-type"
 	mountUID                = "mount-uid"
+	syncDirsFlag            = "sync-dirs"
 	disableDriverMounts     = "disable-driver-mounts"
 	cacheImages             = "cache-images"
 	uuid                    = "uuid"
@@ -133,9 +140,12 @@ const (
 	listenAddress           = "listen-address"
 	extraDisks              = "extra-disks"
 	certExpiration          = "cert-expiration"
+	caCert                  = "ca-cert"
+	caKey                   = "ca-key"
 	binaryMirror            = "binary-mirror"
 	disableOptimizations    = "disable-optimizations"
 	disableMetrics          = "disable-metrics"
+	registryCache           = "registry-cache"
 )
 
 var (
@@ -173,6 +183,7 @@ func initMinikubeFlags() {
 	startCmd.Flags().Uint16(mountPortFlag, defaultMountPort, mountPortDescription)
 	startCmd.Flags().String(mountTypeFlag, defaultMountType, mountTypeDescription)
 	startCmd.Flags().String(mountUID, defaultMountUID, mountUIDDescription)
+	startCmd.Flags().StringSlice(syncDirsFlag, nil, "Host directories whose files are copied to the nodes on start and by 'minikube files sync', as <host dir>:<node dir>.")
 	startCmd.Flags().StringSlice(config.AddonListFlag, nil, "Enable addons. see `minikube addons list` for a list of valid addon names.")
 	startCmd.Flags().String(criSocket, "", "The cri socket path to be used.")
 	startCmd.Flags().String(networkPlugin, "", "Kubelet network plug-in to use (default: auto)")
@@ -190,9 +201,11 @@ func initMinikubeFlags() {
 	startCmd.Flags().Bool(forceSystemd, false, "If set, force the container runtime to use systemd as cgroup manager. Defaults to false.")
 	startCmd.Flags().StringP(network, "", "", "network to run minikube with. Now it is used by docker/podman and KVM drivers. If left empty, minikube will create a new network.")
 	startCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Format to print stdout in. Options include: [text,json]")
-	startCmd.Flags().StringP(trace, "", "", "Send trace events. Options include: [gcp]")
+	startCmd.Flags().StringP(trace, "", "", "Send trace events. Options include: [gcp otlp file]. The otlp tracer is configured via the standard OTEL_EXPORTER_OTLP_* env variables, the file tracer writes to $MINIKUBE_TRACE_FILE or $MINIKUBE_HOME/logs/trace.json")
 	startCmd.Flags().Int(extraDisks, 0, "Number of extra disks created and attached to the minikube VM (currently only implemented for hyperkit and kvm2 drivers)")
 	startCmd.Flags().Duration(certExpiration, constants.DefaultCertExpiration, "Duration until minikube certificate expiration, defaults to three years (26280h).")
+	startCmd.Flags().String(caCert, "", "Path to an external CA, or intermediate CA, certificate signing the cluster certificates instead of the minikube CA. Requires --ca-key.")
+	startCmd.Flags().String(caKey, "", "Path to the private key of the CA certificate given with --ca-cert")
 	startCmd.Flags().String(binaryMirror, "", "Location to fetch kubectl, kubelet, & kubeadm binaries from.")
 	startCmd.Flags().Bool(disableOptimizations, false, "If set, disables optimizations that are set for local Kubernetes. Including decreasing CoreDNS replicas from 2 to 1. Defaults to false.")
 	startCmd.Flags().Bool(disableMetrics, false, "If set, disables metrics reporting (CPU and memory usage), this can improve CPU usage. Defaults to false.")
@@ -259,6 +272,7 @@ func initDriverFlags() {
 func initNetworkingFlags() {
 	startCmd.Flags().StringSliceVar(&insecureRegistry, "insecure-registry", nil, "Insecure Docker registries to pass to the Docker daemon.  The default service CIDR range will automatically be added.")
 	startCmd.Flags().StringSliceVar(&registryMirror, "registry-mirror", nil, "Registry mirrors to pass to the Docker daemon")
+	startCmd.Flags().Bool(registryCache, false, "If true, pull the images of docker hub through a registry cache on the host, shared by all the profiles using it. Its size is set with 'minikube config set registry-cache-size'.")
 	startCmd.Flags().String(imageRepository, "", "Alternative image repository to pull docker images from. This can be used when you have limited access to gcr.io. Set it to \"auto\" to let minikube decide one for you. For Chinese mainland users, you may use local gcr.io mirrors such as registry.cn-hangzhou.aliyuncs.com/google_containers")
 	startCmd.Flags().String(imageMirrorCountry, "", "Country code of the image mirror to be used. Leave empty to use the global one. For Chinese mainland users, set it to cn.")
 	startCmd.Flags().String(serviceCIDR, constants.DefaultServiceCIDR, "The CIDR to be used for service cluster IPs.")
@@ -510,6 +524,8 @@ func generateNewConfigFromFlags(cmd *cobra.Command, k8sVersion string, rtime str
 		SSHPort:                 viper.GetInt(sshSSHPort),
 		ExtraDisks:              viper.GetInt(extraDisks),
 		CertExpiration:          viper.GetDuration(certExpiration),
+		CACertFile:              absPath(viper.GetString(caCert)),
+		CAKeyFile:               absPath(viper.GetString(caKey)),
 		Mount:                   viper.GetBool(createMount),
 		MountString:             viper.GetString(mountString),
 		Mount9PVersion:          viper.GetString(mount9PVersion),
@@ -520,9 +536,11 @@ func generateNewConfigFromFlags(cmd *cobra.Command, k8sVersion string, rtime str
 		MountPort:               uint16(viper.GetUint(mountPortFlag)),
 		MountType:               viper.GetString(mountTypeFlag),
 		MountUID:                viper.GetString(mountUID),
+		SyncDirs:                absSyncDirs(viper.GetStringSlice(syncDirsFlag)),
 		BinaryMirror:            viper.GetString(binaryMirror),
 		DisableOptimizations:    viper.GetBool(disableOptimizations),
 		DisableMetrics:          viper.GetBool(disableMetrics),
+		RegistryCache:           viper.GetBool(registryCache),
 		KubernetesConfig: config.KubernetesConfig{
 			KubernetesVersion:      k8sVersion,
 			ClusterName:            ClusterFlagValue(),
@@ -534,6 +552,7 @@ func generateNewConfigFromFlags(cmd *cobra.Command, k8sVersion string, rtime str
 			FeatureGates:           viper.GetString(featureGates),
 			ContainerRuntime:       rtime,
 			CRISocket:              viper.GetString(criSocket),
+			Bootstrapper:           viper.GetString(cmdcfg.Bootstrapper),
 			NetworkPlugin:          chosenNetworkPlugin,
 			ServiceCIDR:            viper.GetString(serviceCIDR),
 			ImageRepository:        getRepository(cmd, k8sVersion),
@@ -545,6 +564,7 @@ func generateNewConfigFromFlags(cmd *cobra.Command, k8sVersion string, rtime str
 		MultiNodeRequested: viper.GetInt(nodes) > 1,
 	}
 	cc.VerifyComponents = interpretWaitFlag(*cmd)
+	setRegistryCacheMirror(&cc, viper.GetInt(config.RegistryCachePort))
 	if viper.GetBool(createMount) && driver.IsKIC(drvName) {
 		cc.ContainerVolumeMounts = []string{viper.GetString(mountString)}
 	}
@@ -668,18 +688,18 @@ func updateExistingConfigFromFlags(cmd *cobra.Command, existing *config.ClusterC
 	cc := *existing
 
 	if cmd.Flags().Changed(memory) && getMemorySize(cmd, cc.Driver) != cc.Memory {
-		out.WarningT("You cannot change the memory size for an existing minikube cluster. Please first delete the cluster.")
+		out.WarningT("You cannot change the memory size of an existing minikube cluster with start. Use \"minikube config resize --memory\" instead.")
 	}
 
 	if cmd.Flags().Changed(cpus) && viper.GetInt(cpus) != cc.CPUs {
-		out.WarningT("You cannot change the CPUs for an existing minikube cluster. Please first delete the cluster.")
+		out.WarningT("You cannot change the CPUs of an existing minikube cluster with start. Use \"minikube config resize --cpus\" instead.")
 	}
 
 	// validate the memory size in case user changed their system memory limits (example change docker desktop or upgraded memory.)
 	validateRequestedMemorySize(cc.Memory, cc.Driver)
 
 	if cmd.Flags().Changed(humanReadableDiskSize) && getDiskSize() != existing.DiskSize {
-		out.WarningT("You cannot change the disk size for an existing minikube cluster. Please first delete the cluster.")
+		out.WarningT("You cannot change the disk size of an existing minikube cluster with start. Use \"minikube config resize --disk-size\" instead.")
 	}
 
 	checkExtraDiskOptions(cmd, cc.Driver)
@@ -687,6 +707,10 @@ func updateExistingConfigFromFlags(cmd *cobra.Command, existing *config.ClusterC
 		out.WarningT("You cannot add or remove extra disks for an existing minikube cluster. Please first delete the cluster.")
 	}
 
+	if (cmd.Flags().Changed(caCert) && absPath(viper.GetString(caCert)) != existing.CACertFile) || (cmd.Flags().Changed(caKey) && absPath(viper.GetString(caKey)) != existing.CAKeyFile) {
+		out.WarningT("You cannot change the CA of an existing minikube cluster with start. Use \"minikube certs rotate --ca --ca-cert --ca-key\" instead.")
+	}
+
 	updateBoolFromFlag(cmd, &cc.KeepContext, keepContext)
 	updateBoolFromFlag(cmd, &cc.EmbedCerts, embedCerts)
 	updateStringFromFlag(cmd, &cc.MinikubeISO, isoURL)
@@ -739,8 +763,15 @@ func updateExistingConfigFromFlags(cmd *cobra.Command, existing *config.ClusterC
 	updateUint16FromFlag(cmd, &cc.MountPort, mountPortFlag)
 	updateStringFromFlag(cmd, &cc.MountType, mountTypeFlag)
 	updateStringFromFlag(cmd, &cc.MountUID, mountUID)
+	if cmd.Flags().Changed(syncDirsFlag) {
+		cc.SyncDirs = absSyncDirs(viper.GetStringSlice(syncDirsFlag))
+	}
 	updateStringFromFlag(cmd, &cc.BinaryMirror, binaryMirror)
 	updateBoolFromFlag(cmd, &cc.DisableOptimizations, disableOptimizations)
+	if cmd.Flags().Changed(registryCache) {
+		cc.RegistryCache = viper.GetBool(registryCache)
+		setRegistryCacheMirror(&cc, viper.GetInt(config.RegistryCachePort))
+	}
 
 	if cmd.Flags().Changed(kubernetesVersion) {
 		cc.KubernetesConfig.KubernetesVersion = getKubernetesVersion(existing)
@@ -883,3 +914,53 @@ func checkExtraDiskOptions(cmd *cobra.Command, driverName string) {
 		}
 	}
 }
+
+// setRegistryCacheMirror adds the registry cache listening on port to the registry mirrors of the cluster,
+// replacing the one of any other port, or removes it if disabled
+func setRegistryCacheMirror(cc *config.ClusterConfig, port int) {
+	cc.RegistryMirror = removePrefix(cc.RegistryMirror, "http://"+constants.HostAlias+":")
+	cc.InsecureRegistry = removePrefix(cc.InsecureRegistry, constants.HostAlias+":")
+	if cc.RegistryCache {
+		cc.RegistryMirror = append([]string{registrycache.MirrorURL(port)}, cc.RegistryMirror...)
+		// the mirror is plain http
+		cc.InsecureRegistry = append(cc.InsecureRegistry, net.JoinHostPort(constants.HostAlias, strconv.Itoa(port)))
+	}
+}
+
+// removePrefix returns the elements of list which don't start with prefix
+func removePrefix(list []string, prefix string) []string {
+	var kept []string
+	for _, e := range list {
+		if !strings.HasPrefix(e, prefix) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// absPath returns the absolute path of a file given as a flag, if set
+func absPath(p string) string {
+	if p == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return p
+}
+
+// absSyncDirs validates sync directories, making their host directories absolute
+func absSyncDirs(syncDirs []string) []string {
+	var dirs []string
+	for _, s := range syncDirs {
+		d, err := machine.ParseSyncDir(s)
+		if err != nil {
+			exit.Message(reason.Usage, "{{.err}}", out.V{"err": err})
+		}
+		if abs, err := filepath.Abs(d.HostDir); err == nil {
+			d.HostDir = abs
+		}
+		dirs = append(dirs, d.HostDir+":"+d.NodeDir)
+	}
+	return dirs
+}
@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/blang/semver/v4"
+	"github.com/google/go-cmp/cmp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
@@ -593,3 +594,31 @@ func TestValidatePorts(t *testing.T) {
 		})
 	}
 }
+
+func TestSetRegistryCacheMirror(t *testing.T) {
+	cc := &cfg.ClusterConfig{
+		RegistryCache:    true,
+		RegistryMirror:   []string{"http://host.minikube.internal:5000", "https://mirror.gcr.io"},
+		InsecureRegistry: []string{"host.minikube.internal:5000", "10.0.0.0/24"},
+	}
+
+	// the mirror of the port the server actually listens on replaces the one of the requested port
+	setRegistryCacheMirror(cc, 5001)
+	wantMirrors := []string{"http://host.minikube.internal:5001", "https://mirror.gcr.io"}
+	wantInsecure := []string{"10.0.0.0/24", "host.minikube.internal:5001"}
+	if diff := cmp.Diff(wantMirrors, cc.RegistryMirror); diff != "" {
+		t.Errorf("RegistryMirror mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantInsecure, cc.InsecureRegistry); diff != "" {
+		t.Errorf("InsecureRegistry mismatch (-want +got):\n%s", diff)
+	}
+
+	cc.RegistryCache = false
+	setRegistryCacheMirror(cc, 5001)
+	if diff := cmp.Diff([]string{"https://mirror.gcr.io"}, cc.RegistryMirror); diff != "" {
+		t.Errorf("RegistryMirror mismatch once disabled (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"10.0.0.0/24"}, cc.InsecureRegistry); diff != "" {
+		t.Errorf("InsecureRegistry mismatch once disabled (-want +got):\n%s", diff)
+	}
+}
@@ -48,6 +48,7 @@ import (
 	"k8s.io/minikube/pkg/minikube/out"
 	"k8s.io/minikube/pkg/minikube/out/register"
 	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/schedule"
 	"k8s.io/minikube/pkg/version"
 )
 
@@ -138,6 +139,7 @@ type Status struct {
 	Kubeconfig string
 	Worker     bool
 	TimeToStop string `json:",omitempty"`
+	StopAt     string `json:",omitempty"`
 	DockerEnv  string `json:",omitempty"`
 	PodManEnv  string `json:",omitempty"`
 }
@@ -148,6 +150,7 @@ type ClusterState struct {
 
 	BinaryVersion string
 	TimeToStop    string `json:",omitempty"`
+	StopAt        string `json:",omitempty"`
 	Components    map[string]BaseState
 	Nodes         []NodeState
 }
@@ -188,6 +191,7 @@ apiserver: {{.APIServer}}
 kubeconfig: {{.Kubeconfig}}
 {{- if .TimeToStop }}
 timeToStop: {{.TimeToStop}}
+stopAt: {{.StopAt}}
 {{- end }}
 {{- if .DockerEnv }}
 docker-env: {{.DockerEnv}}
@@ -381,9 +385,10 @@ func nodeStatus(api libmachine.API, cc config.ClusterConfig, n config.Node) (*St
 
 	stk := kverify.ServiceStatus(cr, "kubelet")
 	st.Kubelet = stk.String()
-	if cc.ScheduledStop != nil {
-		initiationTime := time.Unix(cc.ScheduledStop.InitiationTime, 0)
-		st.TimeToStop = time.Until(initiationTime.Add(cc.ScheduledStop.Duration)).String()
+	now := time.Now()
+	if stopAt, ok := schedule.NextStop(&cc, now); ok {
+		st.TimeToStop = stopAt.Sub(now).Round(time.Second).String()
+		st.StopAt = stopAt.Format(time.RFC3339)
 	}
 	if os.Getenv(constants.MinikubeActiveDockerdEnv) != "" {
 		st.DockerEnv = "in-use"
@@ -415,7 +420,7 @@ func nodeStatus(api libmachine.API, cc config.ClusterConfig, n config.Node) (*St
 		}
 	}
 
-	sta, err := kverify.APIServerStatus(cr, hostname, port)
+	sta, err := kverify.APIServerStatus(cr, cc.Name, hostname, port)
 	klog.Infof("%s apiserver status = %s (err=%v)", name, stk, err)
 
 	if err != nil {
@@ -521,6 +526,7 @@ func clusterState(sts []*Status) ClusterState {
 		},
 
 		TimeToStop: sts[0].TimeToStop,
+		StopAt:     sts[0].StopAt,
 
 		Components: map[string]BaseState{
 			"kubeconfig": {Name: "kubeconfig", StatusCode: statusCode(sts[0].Kubeconfig), StatusName: codeNames[statusCode(sts[0].Kubeconfig)]},
@@ -36,6 +36,7 @@ import (
 	"k8s.io/minikube/pkg/minikube/out"
 	"k8s.io/minikube/pkg/minikube/out/register"
 	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/registrycache"
 	"k8s.io/minikube/pkg/minikube/schedule"
 	"k8s.io/minikube/pkg/minikube/style"
 	"k8s.io/minikube/pkg/util/retry"
@@ -133,6 +134,9 @@ func stopProfile(profile string) int {
 	if err := killMountProcess(); err != nil {
 		out.WarningT("Unable to kill mount process: {{.error}}", out.V{"error": err})
 	}
+	if err := registrycache.Release(profile); err != nil {
+		out.WarningT("Unable to release the registry cache: {{.error}}", out.V{"error": err})
+	}
 
 	for _, n := range cc.Nodes {
 		machineName := config.MachineName(*cc, n)
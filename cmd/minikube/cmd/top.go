@@ -0,0 +1,213 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/docker/machine/libmachine"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+	"k8s.io/minikube/pkg/minikube/top"
+)
+
+var (
+	topOutput     string
+	topWatch      bool
+	topInterval   time.Duration
+	topNamespaces bool
+)
+
+// topReport is the JSON output of minikube top
+type topReport struct {
+	Nodes      []*top.NodeUsage      `json:"nodes"`
+	Profiles   []*top.NodeUsage      `json:"profiles"`
+	Namespaces []*top.NamespaceUsage `json:"namespaces,omitempty"`
+}
+
+// topCmd represents the top command
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Display the CPU, memory, disk and network usage of profiles",
+	Long: `Displays the CPU, memory, disk and network usage of the running nodes of all profiles, or only of the given one with --profile.
+
+CPU usage is a percentage of a single CPU, so it can exceed 100% on nodes with multiple CPUs. Network usage is the traffic since the node started.
+With --namespaces, the usage of the pods of each Kubernetes namespace is also displayed, which requires the metrics-server addon.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := strings.ToLower(topOutput)
+		if format != "table" && format != "json" {
+			exit.Message(reason.Usage, fmt.Sprintf("invalid output format: %s. Valid values: 'table', 'json'", topOutput))
+		}
+		if topInterval < time.Second {
+			exit.Message(reason.Usage, "--interval must be at least 1s")
+		}
+
+		api, err := machine.NewAPIClient()
+		if err != nil {
+			exit.Error(reason.NewAPIClient, "Error getting client", err)
+		}
+		defer api.Close()
+
+		profiles := topProfiles()
+		clear := topWatch && format == "table" && out.IsTerminal(os.Stdout)
+		for {
+			r := collectTop(api, profiles)
+			if clear {
+				// move the cursor home and clear the screen, like top(1)
+				out.String("\033[H\033[2J")
+			}
+			switch format {
+			case "json":
+				b, err := json.Marshal(r)
+				if err != nil {
+					exit.Error(reason.InternalJSONMarshal, "json encoding failure", err)
+				}
+				out.String("%s\n", b)
+			case "table":
+				renderTop(r)
+			}
+			if !topWatch {
+				return
+			}
+			time.Sleep(topInterval)
+		}
+	},
+}
+
+// topProfiles returns the profile given with --profile, or all valid profiles
+func topProfiles() []*config.Profile {
+	if RootCmd.PersistentFlags().Changed(config.ProfileName) {
+		p, err := config.LoadProfile(ClusterFlagValue())
+		if err != nil {
+			exit.Message(reason.Usage, `profile "{{.name}}" not found`, out.V{"name": ClusterFlagValue()})
+		}
+		return []*config.Profile{p}
+	}
+	validProfiles, _, err := config.ListProfiles()
+	if err != nil {
+		klog.Warningf("error loading profiles: %v", err)
+	}
+	return validProfiles
+}
+
+func collectTop(api libmachine.API, profiles []*config.Profile) topReport {
+	nodes, err := top.Nodes(api, profiles)
+	if err != nil {
+		exit.Error(reason.GuestStatus, "Unable to get resource usage", err)
+	}
+	r := topReport{Nodes: nodes, Profiles: top.Totals(nodes)}
+	if !topNamespaces {
+		return r
+	}
+	for _, p := range r.Profiles {
+		cc, err := config.Load(p.Profile)
+		if err != nil {
+			klog.Warningf("unable to load profile %s: %v", p.Profile, err)
+			continue
+		}
+		if !assets.Addons["metrics-server"].IsEnabled(cc) {
+			out.WarningT(`The metrics-server addon is not enabled in "{{.profile}}", enable it with: minikube addons enable metrics-server -p {{.profile}}`, out.V{"profile": p.Profile})
+			continue
+		}
+		ns, err := top.Namespaces(p.Profile)
+		if err != nil {
+			out.WarningT(`Unable to get the namespace usage of "{{.profile}}": {{.error}}`, out.V{"profile": p.Profile, "error": err})
+			continue
+		}
+		r.Namespaces = append(r.Namespaces, ns...)
+	}
+	return r
+}
+
+func renderTop(r topReport) {
+	if len(r.Nodes) == 0 {
+		out.Styled(style.Empty, "No running nodes found. Start a cluster using \"minikube start\".")
+		return
+	}
+	nodes := map[string]int{}
+	for _, n := range r.Nodes {
+		nodes[n.Profile]++
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Profile", "Node", "CPU", "CPUs", "Memory", "Disk", "Net Rx / Tx"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+	for _, p := range r.Profiles {
+		for _, n := range r.Nodes {
+			if n.Profile == p.Profile {
+				table.Append(topRow(n))
+			}
+		}
+		if nodes[p.Profile] > 1 {
+			table.Append(topRow(p))
+		}
+	}
+	table.Render()
+
+	if len(r.Namespaces) == 0 {
+		return
+	}
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Profile", "Namespace", "Pods", "CPU", "Memory"})
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetCenterSeparator("|")
+	for _, ns := range r.Namespaces {
+		table.Append([]string{ns.Profile, ns.Namespace, strconv.Itoa(ns.Pods), fmt.Sprintf("%dm", ns.CPUMillis), units.BytesSize(float64(ns.MemoryBytes))})
+	}
+	table.Render()
+}
+
+func topRow(u *top.NodeUsage) []string {
+	node := u.Node
+	if node == "" {
+		node = "total"
+	}
+	return []string{u.Profile, node, fmt.Sprintf("%.1f%%", u.CPUPercent), strconv.Itoa(u.CPUs), usedOf(u.MemoryUsed, u.MemoryTotal), usedOf(u.DiskUsed, u.DiskTotal),
+		fmt.Sprintf("%s / %s", units.HumanSize(float64(u.NetRx)), units.HumanSize(float64(u.NetTx)))}
+}
+
+// usedOf formats usage such as "1.5GiB / 4GiB (37%)"
+func usedOf(used int64, total int64) string {
+	if total == 0 {
+		return units.BytesSize(float64(used))
+	}
+	return fmt.Sprintf("%s / %s (%d%%)", units.BytesSize(float64(used)), units.BytesSize(float64(total)), used*100/total)
+}
+
+func init() {
+	topCmd.Flags().StringVarP(&topOutput, "output", "o", "table", "The output format. One of 'table', 'json'")
+	topCmd.Flags().BoolVarP(&topWatch, "watch", "w", false, "Keep refreshing the usage every --interval")
+	topCmd.Flags().DurationVar(&topInterval, "interval", 5*time.Second, "The refresh interval of --watch")
+	topCmd.Flags().BoolVar(&topNamespaces, "namespaces", false, "Also display the usage of each Kubernetes namespace, requires the metrics-server addon")
+}
@@ -28,6 +28,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/minikube/pkg/drivers/kic/oci"
 	"k8s.io/minikube/pkg/kapi"
+	"k8s.io/minikube/pkg/minikube/command"
 	"k8s.io/minikube/pkg/minikube/config"
 	"k8s.io/minikube/pkg/minikube/driver"
 	"k8s.io/minikube/pkg/minikube/exit"
@@ -89,11 +90,11 @@ var tunnelCmd = &cobra.Command{
 			if err != nil {
 				exit.Error(reason.DrvPortForward, "error getting ssh port", err)
 			}
-			sshPort := strconv.Itoa(port)
-			sshKey := filepath.Join(localpath.MiniPath(), "machines", cname, "id_rsa")
+			sshClient := kicSSHClient(port, cname, co.CP.Runner)
+			defer sshClient.Close()
 
 			outputTunnelStarted()
-			kicSSHTunnel := kic.NewSSHTunnel(ctx, sshPort, sshKey, clientset.CoreV1(), clientset.NetworkingV1())
+			kicSSHTunnel := kic.NewSSHTunnel(ctx, sshClient, clientset.CoreV1(), clientset.NetworkingV1())
 			err = kicSSHTunnel.Start()
 			if err != nil {
 				exit.Error(reason.SvcTunnelStart, "error starting tunnel", err)
@@ -110,6 +111,24 @@ var tunnelCmd = &cobra.Command{
 	},
 }
 
+// kicSSHClient connects to the SSH server of a kic node forwarded to port, pinning its host keys.
+func kicSSHClient(port int, machineName string, r command.Runner) *kic.SSHClient {
+	machineDir := filepath.Join(localpath.MiniPath(), "machines", machineName)
+	hostKeys, err := kic.HostKeys(r, filepath.Join(machineDir, "known_host_keys"))
+	if err != nil {
+		klog.Warningf("unable to read the host keys of %s: %v", machineName, err)
+	}
+	client, err := kic.NewSSHClient(strconv.Itoa(port), filepath.Join(machineDir, "id_rsa"), hostKeys)
+	if err != nil {
+		exit.Error(reason.SvcTunnelStart, "error creating ssh client", err)
+	}
+	// the TCP ports are still forwarded without the relay agent
+	if err := kic.InstallRelay(r); err != nil {
+		out.WarningT("Unable to install the tunnel relay agent in the node, UDP ports will not be forwarded: {{.error}}", out.V{"error": err})
+	}
+	return client
+}
+
 func outputTunnelStarted() {
 	out.Styled(style.Success, "Tunnel successfully started")
 	out.Ln("")
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	cmdcfg "k8s.io/minikube/cmd/minikube/cmd/config"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/constants"
+	"k8s.io/minikube/pkg/minikube/exit"
+	"k8s.io/minikube/pkg/minikube/mustload"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/reason"
+	"k8s.io/minikube/pkg/minikube/style"
+	"k8s.io/minikube/pkg/minikube/upgrade"
+)
+
+var (
+	upgradeK8sVersion string
+	upgradeDryRun     bool
+	upgradeForce      bool
+)
+
+// upgradeCmd represents the upgrade command
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade the Kubernetes version of a running cluster in place",
+	Long: `Upgrades the Kubernetes version of a running cluster with kubeadm upgrade, one minor version at a time.
+
+The images and binaries of the new version are installed on every node first, and kubeadm upgrade plan checks the control plane.
+Each node is then backed up, and upgraded in turn, the control plane first: kubeadm upgrade, drain, kubelet restart and uncordon.
+If any step fails, the upgraded nodes are rolled back to their backup, including the etcd data of the control plane.`,
+	Example: "minikube upgrade --kubernetes-version=v1.24.1",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			exit.Message(reason.Usage, "Usage: minikube upgrade --kubernetes-version=<version>")
+		}
+		if upgradeK8sVersion == "" {
+			exit.Message(reason.Usage, "Specify the Kubernetes version to upgrade to with --kubernetes-version")
+		}
+
+		cname := ClusterFlagValue()
+		co := mustload.Running(cname)
+		p, err := upgrade.NewPlan(co.Config, resolveKubernetesVersion(upgradeK8sVersion), upgradeForce)
+		if err != nil {
+			if upgrade.IsUnsupported(err) {
+				exit.Message(reason.KubernetesUpgradeUnsupported, "{{.error}}", out.V{"error": err})
+			}
+			exit.Error(reason.KubernetesUpgrade, "Failed to plan the upgrade", err)
+		}
+
+		var names []string
+		for _, n := range p.Nodes {
+			names = append(names, config.MachineName(*co.Config, n))
+		}
+		out.Step(style.Notice, "Upgrading {{.profile}} from Kubernetes {{.from}} to {{.to}}, one node at a time: {{.nodes}}",
+			out.V{"profile": cname, "from": p.From, "to": p.To, "nodes": strings.Join(names, ", ")})
+		if upgradeDryRun {
+			out.Step(style.DryRun, `dry-run validation complete!`)
+			return
+		}
+
+		err = upgrade.Upgrade(co.API, co.Config, viper.GetString(cmdcfg.Bootstrapper), p)
+		if rerr, ok := err.(*upgrade.RollbackError); ok {
+			profileArg := ""
+			if cname != constants.DefaultClusterName {
+				profileArg = " -p " + cname
+			}
+			exit.Message(reason.KubernetesUpgradeRollback, "Failed to upgrade Kubernetes, and to roll back: {{.error}}",
+				out.V{"error": rerr, "backup": upgrade.BackupPath(p.From), "profile": profileArg})
+		}
+		if err != nil {
+			exit.Error(reason.KubernetesUpgrade, "Failed to upgrade Kubernetes", err)
+		}
+		out.Step(style.Ready, "Upgraded {{.profile}} to Kubernetes {{.version}}. The pre-upgrade backup of each node is kept in {{.backup}}",
+			out.V{"profile": cname, "version": p.To, "backup": upgrade.BackupPath(p.From)})
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().StringVar(&upgradeK8sVersion, "kubernetes-version", "", "The Kubernetes version to upgrade to, at most one minor version newer than the current one, e.g. v1.24.1")
+	upgradeCmd.Flags().BoolVar(&upgradeDryRun, "dry-run", false, "Only check the versions, and print the order in which the nodes would be upgraded, without changing the cluster")
+	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "Allow upgrading to a version newer than the newest version supported by minikube")
+}
@@ -27,6 +27,8 @@ import (
 
 var pvDir = "/tmp/hostpath-provisioner"
 
+var quotas = flag.Bool("quotas", false, "Enforce the size of volumes with quotas, which requires a privileged container")
+
 func main() {
 	// Glog requires that /tmp exists.
 	if err := os.MkdirAll("/tmp", 0755); err != nil {
@@ -35,7 +37,7 @@ func main() {
 	}
 	flag.Parse()
 
-	if err := storage.StartStorageProvisioner(pvDir); err != nil {
+	if err := storage.StartStorageProvisioner(pvDir, *quotas); err != nil {
 		klog.Exit(err)
 	}
 
@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// tunnel-relay runs inside a node and forwards the datagrams framed on its stdin to a target,
+// writing the replies framed on stdout. minikube tunnel starts it over SSH for each UDP client.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"k8s.io/minikube/pkg/minikube/tunnel/relay"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s udp <host:port>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 || flag.Arg(0) != "udp" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := net.Dial(flag.Arg(0), flag.Arg(1))
+	if err != nil {
+		log.Fatalf("dial %s: %v", flag.Arg(1), err)
+	}
+	if err := relay.Serve(conn, os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("relay to %s: %v", flag.Arg(1), err)
+	}
+}
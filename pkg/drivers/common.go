@@ -130,6 +130,24 @@ func MakeDiskImage(d *drivers.BaseDriver, boot2dockerURL string, diskSize int) e
 	return nil
 }
 
+// GrowRawDiskImage extends a raw disk image to sizeMB, reporting whether it grew.
+// Images are never shrunk; the guest grows its filesystem into the new space on boot.
+func GrowRawDiskImage(diskPath string, sizeMB int) (bool, error) {
+	fi, err := os.Stat(diskPath)
+	if err != nil {
+		return false, errors.Wrap(err, "stat")
+	}
+	size := util.ConvertMBToBytes(sizeMB)
+	if fi.Size() >= size {
+		return false, nil
+	}
+	klog.Infof("Growing raw disk image %s from %d to %d bytes", diskPath, fi.Size(), size)
+	if err := os.Truncate(diskPath, size); err != nil {
+		return false, errors.Wrap(err, "truncate")
+	}
+	return true, nil
+}
+
 func fixMachinePermissions(path string) error {
 	klog.Infof("Fixing permissions on %s ...", path)
 	if err := os.Chown(path, syscall.Getuid(), syscall.Getegid()); err != nil {
@@ -46,3 +46,30 @@ func Test_createDiskImage(t *testing.T) {
 		t.Errorf("Disk size is %v, want %v", fi.Size(), sizeInBytes)
 	}
 }
+
+func TestGrowRawDiskImage(t *testing.T) {
+	diskPath := filepath.Join(t.TempDir(), "disk")
+	if _, err := GrowRawDiskImage(diskPath, 1); err == nil {
+		t.Fatalf("expected a missing disk image to fail")
+	}
+	if err := os.WriteFile(diskPath, make([]byte, 1024), 0644); err != nil {
+		t.Fatalf("writefile: %v", err)
+	}
+
+	grown, err := GrowRawDiskImage(diskPath, 2)
+	if err != nil || !grown {
+		t.Fatalf("GrowRawDiskImage() = %v, %v, want true, nil", grown, err)
+	}
+	fi, err := os.Stat(diskPath)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if fi.Size() != 2097152 {
+		t.Errorf("Disk size is %v, want %v", fi.Size(), 2097152)
+	}
+
+	grown, err = GrowRawDiskImage(diskPath, 1)
+	if err != nil || grown {
+		t.Errorf("GrowRawDiskImage() shrinking = %v, %v, want false, nil", grown, err)
+	}
+}
@@ -617,6 +617,33 @@ func ContainerStatus(ociBin string, name string, warnSlow ...bool) (state.State,
 	}
 }
 
+// UpdateContainer changes the CPU and memory limits of an existing container, running or not.
+// A zero value leaves the corresponding limit unchanged.
+func UpdateContainer(ociBin string, name string, cpus int, memoryMB int) error {
+	args := []string{"update"}
+	if cpus > 0 {
+		args = append(args, fmt.Sprintf("--cpus=%d", cpus))
+	}
+	if memoryMB > 0 {
+		memory := fmt.Sprintf("%dm", memoryMB)
+		if HasMemoryCgroup() {
+			args = append(args, fmt.Sprintf("--memory=%s", memory))
+		}
+		if hasMemorySwapCgroup() {
+			// Disable swap by setting the value to match, as when the container was created
+			args = append(args, fmt.Sprintf("--memory-swap=%s", memory))
+		}
+	}
+	if len(args) == 1 {
+		return nil
+	}
+	args = append(args, name)
+	if _, err := runCmd(exec.Command(ociBin, args...)); err != nil {
+		return errors.Wrapf(err, "update container %s", name)
+	}
+	return nil
+}
+
 // ShutDown will run command to shut down the container
 // to ensure the containers process and networking bindings are all closed
 // to avoid containers getting stuck before delete https://github.com/kubernetes/minikube/issues/7657
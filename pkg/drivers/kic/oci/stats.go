@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/pkg/errors"
+)
+
+// ContainerStats is the resource usage of a container, as reported by docker or podman stats
+type ContainerStats struct {
+	Name string
+	// CPUPercent is the CPU usage as a percentage of a single CPU, so it can exceed 100 on multiple CPUs
+	CPUPercent  float64
+	MemoryUsage int64
+	MemoryLimit int64
+	NetRx       int64
+	NetTx       int64
+	BlockRead   int64
+	BlockWrite  int64
+}
+
+// statsFormat is understood by both docker and podman
+const statsFormat = "{{.Name}}\t{{.CPUPerc}}\t{{.MemUsage}}\t{{.NetIO}}\t{{.BlockIO}}"
+
+// Stats returns a single sample of the resource usage of the given containers
+func Stats(ociBin string, names ...string) ([]ContainerStats, error) {
+	args := append([]string{"stats", "--no-stream", "--format", statsFormat}, names...)
+	rr, err := runCmd(exec.Command(ociBin, args...))
+	if err != nil {
+		return nil, err
+	}
+	return parseStats(rr.Stdout.Bytes())
+}
+
+func parseStats(b []byte) ([]ContainerStats, error) {
+	stats := []ContainerStats{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected stats line %q", line)
+		}
+		st := ContainerStats{Name: fields[0]}
+		var err error
+		if st.CPUPercent, err = strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(fields[1]), "%"), 64); err != nil {
+			return nil, errors.Wrapf(err, "parsing CPU %q", fields[1])
+		}
+		if st.MemoryUsage, st.MemoryLimit, err = parseSizePair(fields[2]); err != nil {
+			return nil, errors.Wrap(err, "parsing memory")
+		}
+		if st.NetRx, st.NetTx, err = parseSizePair(fields[3]); err != nil {
+			return nil, errors.Wrap(err, "parsing network")
+		}
+		if st.BlockRead, st.BlockWrite, err = parseSizePair(fields[4]); err != nil {
+			return nil, errors.Wrap(err, "parsing block IO")
+		}
+		stats = append(stats, st)
+	}
+	return stats, s.Err()
+}
+
+// parseSizePair parses a pair of sizes such as "1.5GiB / 7.7GiB" or "12kB / 3.4MB"
+func parseSizePair(s string) (int64, int64, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("unexpected size pair %q", s)
+	}
+	a, err := parseSize(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := parseSize(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return a, b, nil
+}
+
+// parseSize parses sizes in binary (MiB) or decimal (MB) units
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "--" {
+		return 0, nil
+	}
+	if strings.Contains(s, "i") {
+		return units.RAMInBytes(s)
+	}
+	return units.FromHumanSize(s)
+}
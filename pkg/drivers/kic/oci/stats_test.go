@@ -0,0 +1,42 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseStats(t *testing.T) {
+	b := []byte("minikube\t12.50%\t1.5GiB / 4GiB\t1.2kB / 3.4MB\t0B / 512kB\nminikube-m02\t210.03%\t512MiB / 2GiB\t-- / --\t1GB / 2GB\n")
+	got, err := parseStats(b)
+	if err != nil {
+		t.Fatalf("parseStats: %v", err)
+	}
+	want := []ContainerStats{
+		{Name: "minikube", CPUPercent: 12.5, MemoryUsage: 1536 * 1024 * 1024, MemoryLimit: 4 * 1024 * 1024 * 1024, NetRx: 1200, NetTx: 3400000, BlockRead: 0, BlockWrite: 512000},
+		{Name: "minikube-m02", CPUPercent: 210.03, MemoryUsage: 512 * 1024 * 1024, MemoryLimit: 2 * 1024 * 1024 * 1024, BlockRead: 1000000000, BlockWrite: 2000000000},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected stats (-want +got):\n%s", diff)
+	}
+
+	if _, err := parseStats([]byte("minikube\tlots\t1GiB / 2GiB\t0B / 0B\t0B / 0B\n")); err == nil {
+		t.Errorf("expected an error for an invalid CPU percentage")
+	}
+}
@@ -275,6 +275,11 @@ func (d *Driver) Start() (err error) {
 		}
 	}()
 
+	log.Info("Syncing domain resources...")
+	if err := d.syncResources(conn, dom); err != nil {
+		return errors.Wrap(err, "syncing domain resources")
+	}
+
 	log.Info("Creating domain...")
 	if err := dom.Create(); err != nil {
 		return errors.Wrap(err, "error creating VM")
@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/docker/machine/libmachine/log"
+	"github.com/pkg/errors"
+	"libvirt.org/go/libvirt"
+
+	pkgdrivers "k8s.io/minikube/pkg/drivers"
+)
+
+var (
+	memoryRe        = regexp.MustCompile(`<memory[^>]*>\d+</memory>`)
+	currentMemoryRe = regexp.MustCompile(`<currentMemory[^>]*>\d+</currentMemory>`)
+	vcpuRe          = regexp.MustCompile(`<vcpu([^>]*)>\d+</vcpu>`)
+	numaRe          = regexp.MustCompile(`(?s)<numa>.*</numa>`)
+)
+
+// syncResources redefines the stopped domain when its CPU or memory allocation
+// no longer matches the driver config, and grows the disk image if it is smaller
+// than the configured disk size, as happens after "minikube config resize".
+func (d *Driver) syncResources(conn *libvirt.Connect, dom *libvirt.Domain) error {
+	if _, err := pkgdrivers.GrowRawDiskImage(d.DiskPath, d.DiskSize); err != nil {
+		return errors.Wrap(err, "growing disk image")
+	}
+
+	info, err := dom.GetInfo()
+	if err != nil {
+		return errors.Wrap(err, "getting domain info")
+	}
+	if info.MaxMem == uint64(d.Memory)*1024 && info.NrVirtCpu == uint(d.CPU) {
+		return nil
+	}
+
+	xml, err := dom.GetXMLDesc(libvirt.DOMAIN_XML_INACTIVE)
+	if err != nil {
+		return errors.Wrap(err, "getting domain xml")
+	}
+	numa := ""
+	if d.NUMANodeCount > 1 {
+		if numa, err = numaXML(d.CPU, d.Memory, d.NUMANodeCount); err != nil {
+			return errors.Wrap(err, "creating NUMA XML")
+		}
+		d.NUMANodeXML = numa
+	}
+	xml = resizeDomainXML(xml, d.CPU, d.Memory, numa)
+
+	log.Infof("Redefining domain with %d CPUs and %dMiB of memory...", d.CPU, d.Memory)
+	if _, err := conn.DomainDefineXML(xml); err != nil {
+		return errors.Wrapf(err, "error redefining domain xml: %s", xml)
+	}
+	return nil
+}
+
+// resizeDomainXML sets the vCPU count and memory size (in MiB) of a domain definition,
+// keeping everything else, such as the MAC addresses, as it is.
+func resizeDomainXML(xml string, cpu, memory int, numa string) string {
+	xml = memoryRe.ReplaceAllString(xml, fmt.Sprintf("<memory unit='MiB'>%d</memory>", memory))
+	xml = currentMemoryRe.ReplaceAllString(xml, fmt.Sprintf("<currentMemory unit='MiB'>%d</currentMemory>", memory))
+	xml = vcpuRe.ReplaceAllString(xml, fmt.Sprintf("<vcpu${1}>%d</vcpu>", cpu))
+	if numa != "" {
+		xml = numaRe.ReplaceAllLiteralString(xml, strings.TrimSpace(numa))
+	}
+	return xml
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvm
+
+import (
+	"strings"
+	"testing"
+)
+
+const definedDomain = `<domain type='kvm'>
+  <name>minikube</name>
+  <memory unit='KiB'>2097152</memory>
+  <currentMemory unit='KiB'>2097152</currentMemory>
+  <vcpu placement='static'>2</vcpu>
+  <cpu mode='host-passthrough' check='none' migratable='on'>
+    <numa>
+      <cell id='0' cpus='0' memory='1048576' unit='KiB'/>
+      <cell id='1' cpus='1' memory='1048576' unit='KiB'/>
+    </numa>
+  </cpu>
+  <devices>
+    <interface type='network'>
+      <mac address='52:54:00:8a:3c:11'/>
+      <source network='mk-minikube'/>
+    </interface>
+  </devices>
+</domain>`
+
+func TestResizeDomainXML(t *testing.T) {
+	got := resizeDomainXML(definedDomain, 4, 4096, "")
+	for _, want := range []string{
+		"<memory unit='MiB'>4096</memory>",
+		"<currentMemory unit='MiB'>4096</currentMemory>",
+		"<vcpu placement='static'>4</vcpu>",
+		"<mac address='52:54:00:8a:3c:11'/>",
+		"<cell id='1' cpus='1' memory='1048576' unit='KiB'/>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("resized xml does not contain %q:\n%s", want, got)
+		}
+	}
+
+	numa, err := numaXML(4, 4096, 2)
+	if err != nil {
+		t.Fatalf("numaXML: %v", err)
+	}
+	got = resizeDomainXML(definedDomain, 4, 4096, numa)
+	if !strings.Contains(got, "<cell id='1' cpus='2,3' memory='2048' unit='MiB'/>") || strings.Contains(got, "1048576") {
+		t.Errorf("NUMA cells were not replaced:\n%s", got)
+	}
+}
@@ -337,6 +337,10 @@ func (d *Driver) Start() error {
 	// fmt.Printf("Init qemu %s\n", i.VM)
 	machineDir := filepath.Join(d.StorePath, "machines", d.GetMachineName())
 
+	if err := d.growDiskImage(); err != nil {
+		return errors.Wrap(err, "growing disk image")
+	}
+
 	var startCmd []string
 
 	if d.MachineType != "" {
@@ -556,6 +560,32 @@ func (d *Driver) diskPath() string {
 	return filepath.Join(machineDir, "disk.qcow2")
 }
 
+// growDiskImage extends the disk image when the configured disk size was raised
+// after the machine was created, as happens after "minikube config resize".
+func (d *Driver) growDiskImage() error {
+	stdout, stderr, err := cmdOutErr("qemu-img", "info", "--output=json", d.diskPath())
+	if err != nil {
+		fmt.Printf("ERROR: %s\n", stderr)
+		return err
+	}
+	var info struct {
+		VirtualSize int64 `json:"virtual-size"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &info); err != nil {
+		return errors.Wrap(err, "parsing qemu-img info")
+	}
+	if info.VirtualSize >= int64(d.DiskSize)*1024*1024 {
+		return nil
+	}
+	log.Infof("Growing disk image to %dMB...", d.DiskSize)
+	if stdout, stderr, err := cmdOutErr("qemu-img", "resize", d.diskPath(), fmt.Sprintf("%dM", d.DiskSize)); err != nil {
+		fmt.Printf("OUTPUT: %s\n", stdout)
+		fmt.Printf("ERROR: %s\n", stderr)
+		return err
+	}
+	return nil
+}
+
 func (d *Driver) monitorPath() string {
 	machineDir := filepath.Join(d.StorePath, "machines", d.GetMachineName())
 	return filepath.Join(machineDir, "monitor")
@@ -19,6 +19,7 @@ package audit
 import (
 	"os"
 	"os/user"
+	"strconv"
 	"strings"
 	"time"
 
@@ -51,12 +52,52 @@ func args() string {
 	return strings.Join(os.Args[2:], " ")
 }
 
-// Log details about the executed command.
-func Log(startTime time.Time) {
+var (
+	// startTime is when the executed command started, it is zero until Start is called and after the command has been logged
+	startTime time.Time
+	// failureReason is the reason.Kind ID the executed command failed with
+	failureReason string
+	// touchedProfiles are the profiles the executed command operated on, in addition to the --profile flag
+	touchedProfiles []string
+)
+
+// Start records the start time of the executed command, which is logged by Log.
+func Start(t time.Time) {
+	startTime = t
+	failureReason = ""
+	touchedProfiles = nil
+}
+
+// SetReason records the ID of the reason.Kind the executed command is failing with.
+func SetReason(id string) {
+	failureReason = id
+}
+
+// AddProfile records a profile the executed command operated on,
+// for commands such as `delete --all` which touch more than the profile given by --profile.
+func AddProfile(name string) {
+	for _, p := range touchedProfiles {
+		if p == name {
+			return
+		}
+	}
+	touchedProfiles = append(touchedProfiles, name)
+}
+
+// Log details about the executed command, which is exiting with the given exit code.
+func Log(exitCode int) {
+	if startTime.IsZero() {
+		return
+	}
+	defer func() { startTime = time.Time{} }()
+
 	if !shouldLog() {
 		return
 	}
 	r := newRow(pflag.Arg(0), args(), userName(), version.GetVersion(), startTime, time.Now())
+	r.touched = strings.Join(touchedProfiles, ",")
+	r.exitCode = strconv.Itoa(exitCode)
+	r.reason = failureReason
 	if err := appendToLog(r); err != nil {
 		klog.Warning(err)
 	}
@@ -74,7 +115,7 @@ func shouldLog() bool {
 	}
 
 	// commands that should not be logged.
-	no := []string{"status", "version"}
+	no := []string{"audit", "status", "version"}
 	a := pflag.Arg(0)
 	for _, c := range no {
 		if a == c {
@@ -189,7 +189,8 @@ func TestAudit(t *testing.T) {
 		}()
 		mockArgs(t, os.Args)
 
-		Log(time.Now())
+		Start(time.Now())
+		Log(0)
 	})
 }
 
@@ -20,16 +20,25 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/spf13/viper"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/config"
 	"k8s.io/minikube/pkg/minikube/localpath"
 	"k8s.io/minikube/pkg/minikube/out/register"
 )
 
+// maxBackups is the number of rotated audit logs kept next to the current one
+const maxBackups = 3
+
 // currentLogFile the file that's used to store audit logs
 var currentLogFile *os.File
 
 // setLogFile sets the logPath and creates the log file if it doesn't exist.
 func setLogFile() error {
 	lp := localpath.AuditLog()
+	if err := rotateLogFile(lp, int64(viper.GetInt(config.MaxAuditSizeInMB))*1024*1024); err != nil {
+		klog.Warningf("unable to rotate %s: %v", lp, err)
+	}
 	f, err := os.OpenFile(lp, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
 		return fmt.Errorf("unable to open %s: %v", lp, err)
@@ -55,3 +64,50 @@ func appendToLog(row *row) error {
 	}
 	return nil
 }
+
+// rotateLogFile moves the log file at lp to lp.1, lp.1 to lp.2 and so on once it reached maxSize bytes,
+// dropping the oldest backup. A maxSize of 0 or less disables rotation.
+func rotateLogFile(lp string, maxSize int64) error {
+	if maxSize <= 0 {
+		return nil
+	}
+	fi, err := os.Stat(lp)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if fi.Size() < maxSize {
+		return nil
+	}
+
+	if err := os.Remove(backupLogFile(lp, maxBackups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(backupLogFile(lp, i), backupLogFile(lp, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(lp, backupLogFile(lp, 1))
+}
+
+// backupLogFile returns the path of the i'th rotated log file.
+func backupLogFile(lp string, i int) string {
+	return fmt.Sprintf("%s.%d", lp, i)
+}
+
+// logFiles returns the paths of the existing log files, oldest first.
+func logFiles(lp string) []string {
+	paths := []string{}
+	for i := maxBackups; i > 0; i-- {
+		if _, err := os.Stat(backupLogFile(lp, i)); err == nil {
+			paths = append(paths, backupLogFile(lp, i))
+		}
+	}
+	if _, err := os.Stat(lp); err == nil {
+		paths = append(paths, lp)
+	}
+	return paths
+}
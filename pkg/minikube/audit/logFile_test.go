@@ -20,6 +20,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -58,4 +59,40 @@ func TestLogFile(t *testing.T) {
 			t.Errorf("Log was not appended to file: %v", err)
 		}
 	})
+
+	t.Run("RotateLogFile", func(t *testing.T) {
+		lp := filepath.Join(t.TempDir(), "audit.json")
+		write := func(content string) {
+			if err := os.WriteFile(lp, []byte(content), 0644); err != nil {
+				t.Fatalf("failed writing log: %v", err)
+			}
+		}
+
+		write("small")
+		if err := rotateLogFile(lp, 10); err != nil {
+			t.Fatalf("failed rotating: %v", err)
+		}
+		if got := logFiles(lp); len(got) != 1 {
+			t.Errorf("log below the maximum size was rotated: %v", got)
+		}
+
+		for i := 0; i < maxBackups+2; i++ {
+			write(strings.Repeat("x", 10+i))
+			if err := rotateLogFile(lp, 10); err != nil {
+				t.Fatalf("failed rotating: %v", err)
+			}
+		}
+		got := logFiles(lp)
+		if len(got) != maxBackups {
+			t.Fatalf("logFiles() = %v; want %d backups", got, maxBackups)
+		}
+		// the newest backup is the last file written
+		b, err := os.ReadFile(got[len(got)-1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) != 10+maxBackups+1 {
+			t.Errorf("newest backup %s has %d bytes; want %d", got[len(got)-1], len(b), 10+maxBackups+1)
+		}
+	})
 }
@@ -18,9 +18,20 @@ package audit
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/minikube/pkg/minikube/constants"
+	"k8s.io/minikube/pkg/minikube/localpath"
 )
 
+// headers are the column names of a report
+var headers = []string{"Command", "Args", "Profile", "User", "Version", "Start Time", "End Time", "Duration", "Exit Code", "Reason"}
+
 // RawReport contains the information required to generate formatted reports.
 type RawReport struct {
 	headers []string
@@ -54,13 +65,123 @@ func Report(lastNLines int) (*RawReport, error) {
 		return nil, fmt.Errorf("failed to convert logs to rows: %v", err)
 	}
 	r := &RawReport{
-		[]string{"Command", "Args", "Profile", "User", "Version", "Start Time", "End Time"},
+		headers,
 		rows,
 	}
 	return r, nil
 }
 
+// Filter selects the audit log entries included in a query, empty fields match every entry.
+type Filter struct {
+	// Profile matches entries which touched the profile
+	Profile string
+	// Command matches entries of the command, such as "start" or "delete"
+	Command string
+	// Since matches entries which started at or after the time
+	Since time.Time
+	// Failed matches entries which exited with a non-zero exit code
+	Failed bool
+}
+
+// matches returns whether the row is selected by the filter.
+func (f Filter) matches(r row) bool {
+	if f.Command != "" && r.command != f.Command {
+		return false
+	}
+	if f.Failed && !r.failed() {
+		return false
+	}
+	if f.Profile != "" {
+		found := false
+		for _, p := range r.profiles() {
+			if p == f.Profile {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.Since.IsZero() {
+		st, err := time.ParseInLocation(constants.TimeFormat, r.startTime, time.Local)
+		if err != nil || st.Before(f.Since.Truncate(time.Minute)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Query is created using the entries of the current and rotated log files which match the filter, oldest first.
+func Query(f Filter) (*RawReport, error) {
+	rows := []row{}
+	for _, lp := range logFiles(localpath.AuditLog()) {
+		logs, err := readLogs(lp)
+		if err != nil {
+			return nil, err
+		}
+		rs, err := logsToRows(logs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert logs of %s to rows: %v", lp, err)
+		}
+		for _, r := range rs {
+			if f.matches(r) {
+				rows = append(rows, r)
+			}
+		}
+	}
+	return &RawReport{headers, rows}, nil
+}
+
+// readLogs returns the lines of a log file.
+func readLogs(lp string) ([]string, error) {
+	f, err := os.Open(lp)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s: %v", lp, err)
+	}
+	defer f.Close()
+
+	var logs []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		logs = append(logs, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read from %s: %v", lp, err)
+	}
+	return logs, nil
+}
+
+// Len returns the number of entries in the report.
+func (rr *RawReport) Len() int {
+	return len(rr.rows)
+}
+
 // ASCIITable creates a formatted table using the headers and rows from the report.
 func (rr *RawReport) ASCIITable() string {
 	return rowsToASCIITable(rr.rows, rr.headers)
 }
+
+// JSON encodes the entries of the report as a JSON array of objects.
+func (rr *RawReport) JSON() ([]byte, error) {
+	entries := []map[string]string{}
+	for _, r := range rr.rows {
+		entries = append(entries, r.toMap())
+	}
+	return json.Marshal(entries)
+}
+
+// CSV encodes the headers and rows of the report as CSV.
+func (rr *RawReport) CSV() (string, error) {
+	b := new(bytes.Buffer)
+	w := csv.NewWriter(b)
+	if err := w.Write(rr.headers); err != nil {
+		return "", err
+	}
+	for _, r := range rr.rows {
+		if err := w.Write(r.toFields()); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
@@ -17,8 +17,15 @@ limitations under the License.
 package audit
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"k8s.io/minikube/pkg/minikube/constants"
+	"k8s.io/minikube/pkg/minikube/localpath"
 )
 
 func TestReport(t *testing.T) {
@@ -53,3 +60,86 @@ func TestReport(t *testing.T) {
 		t.Errorf("report has %d lines of logs, want %d", len(r.rows), wantedLines)
 	}
 }
+
+func TestQuery(t *testing.T) {
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+	lp := localpath.AuditLog()
+	if err := os.MkdirAll(filepath.Dir(lp), 0755); err != nil {
+		t.Fatalf("failed creating logs directory: %v", err)
+	}
+
+	tuesday := time.Date(2022, time.May, 31, 10, 0, 0, 0, time.Local)
+	monday := tuesday.Add(-24 * time.Hour)
+	entry := func(command, profile, touched string, st time.Time, exitCode, reason string) string {
+		r := newRow(command, "", "user1", "v1.26.0", st, st.Add(time.Minute), profile)
+		r.touched = touched
+		r.exitCode = exitCode
+		r.reason = reason
+		b, err := json.Marshal(map[string]interface{}{"data": r.toMap()})
+		if err != nil {
+			t.Fatalf("failed marshalling row: %v", err)
+		}
+		return string(b) + "\n"
+	}
+	// the legacy entry has no exit code, as written by older versions of minikube
+	legacy := `{"data":{"args":"","command":"delete","endTime":"` + monday.Format(constants.TimeFormat) + `","profile":"shared","startTime":"` + monday.Format(constants.TimeFormat) + `","user":"user2"}}` + "\n"
+	rotated := legacy + entry("start", "shared", "", monday, "0", "")
+	current := entry("start", "shared", "", tuesday, "80", "GUEST_PROVISION") + entry("delete", "minikube", "shared,other", tuesday, "0", "") + entry("start", "other", "", tuesday, "0", "")
+	if err := os.WriteFile(backupLogFile(lp, 1), []byte(rotated), 0644); err != nil {
+		t.Fatalf("failed writing rotated log: %v", err)
+	}
+	if err := os.WriteFile(lp, []byte(current), 0644); err != nil {
+		t.Fatalf("failed writing log: %v", err)
+	}
+
+	tests := []struct {
+		description string
+		filter      Filter
+		want        []string
+	}{
+		{"all", Filter{}, []string{"delete", "start", "start", "delete", "start"}},
+		{"profile", Filter{Profile: "other"}, []string{"delete", "start"}},
+		{"invoked profile", Filter{Profile: "minikube"}, []string{"delete"}},
+		{"command", Filter{Command: "delete", Profile: "shared"}, []string{"delete", "delete"}},
+		{"since", Filter{Since: tuesday}, []string{"start", "delete", "start"}},
+		{"failed", Filter{Failed: true}, []string{"start"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			r, err := Query(tc.filter)
+			if err != nil {
+				t.Fatalf("failed to query: %v", err)
+			}
+			got := []string{}
+			for _, row := range r.rows {
+				got = append(got, row.command)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("Query(%+v) = %v; want %v", tc.filter, got, tc.want)
+			}
+		})
+	}
+
+	r, err := Query(Filter{Failed: true})
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	c, err := r.CSV()
+	if err != nil {
+		t.Fatalf("failed to encode csv: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(c), "\n"); len(lines) != 2 || !strings.HasSuffix(lines[1], ",80,GUEST_PROVISION") {
+		t.Errorf("unexpected csv: %q", c)
+	}
+	b, err := r.JSON()
+	if err != nil {
+		t.Fatalf("failed to encode json: %v", err)
+	}
+	var entries []map[string]string
+	if err := json.Unmarshal(b, &entries); err != nil {
+		t.Fatalf("failed to decode json: %v", err)
+	}
+	if len(entries) != 1 || entries[0]["reason"] != "GUEST_PROVISION" || entries[0]["exitCode"] != "80" {
+		t.Errorf("unexpected json: %s", b)
+	}
+}
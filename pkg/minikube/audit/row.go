@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
@@ -32,9 +33,13 @@ import (
 type row struct {
 	args      string
 	command   string
+	duration  string
 	endTime   string
+	exitCode  string
 	profile   string
+	reason    string
 	startTime string
+	touched   string
 	user      string
 	version   string
 	Data      map[string]string `json:"data"`
@@ -50,9 +55,13 @@ func (e *row) Type() string {
 func (e *row) assignFields() {
 	e.args = e.Data["args"]
 	e.command = e.Data["command"]
+	e.duration = e.Data["duration"]
 	e.endTime = e.Data["endTime"]
+	e.exitCode = e.Data["exitCode"]
 	e.profile = e.Data["profile"]
+	e.reason = e.Data["reason"]
 	e.startTime = e.Data["startTime"]
+	e.touched = e.Data["touchedProfiles"]
 	e.user = e.Data["user"]
 	e.version = e.Data["version"]
 }
@@ -61,13 +70,17 @@ func (e *row) assignFields() {
 // to be used when converting to JSON Cloud Event format.
 func (e *row) toMap() map[string]string {
 	return map[string]string{
-		"args":      e.args,
-		"command":   e.command,
-		"endTime":   e.endTime,
-		"profile":   e.profile,
-		"startTime": e.startTime,
-		"user":      e.user,
-		"version":   e.version,
+		"args":            e.args,
+		"command":         e.command,
+		"duration":        e.duration,
+		"endTime":         e.endTime,
+		"exitCode":        e.exitCode,
+		"profile":         e.profile,
+		"reason":          e.reason,
+		"startTime":       e.startTime,
+		"touchedProfiles": e.touched,
+		"user":            e.user,
+		"version":         e.version,
 	}
 }
 
@@ -80,6 +93,7 @@ func newRow(command string, args string, user string, version string, startTime
 	return &row{
 		args:      args,
 		command:   command,
+		duration:  endTime.Sub(startTime).Round(time.Millisecond).String(),
 		endTime:   endTime.Format(constants.TimeFormat),
 		profile:   p,
 		startTime: startTime.Format(constants.TimeFormat),
@@ -91,7 +105,25 @@ func newRow(command string, args string, user string, version string, startTime
 // toFields converts a row to an array of fields,
 // to be used when converting to a table.
 func (e *row) toFields() []string {
-	return []string{e.command, e.args, e.profile, e.user, e.version, e.startTime, e.endTime}
+	return []string{e.command, e.args, e.profile, e.user, e.version, e.startTime, e.endTime, e.duration, e.exitCode, e.reason}
+}
+
+// failed returns whether the command exited with a non-zero exit code.
+// Rows written by older versions of minikube have no exit code and are not considered failed.
+func (e *row) failed() bool {
+	return e.exitCode != "" && e.exitCode != "0"
+}
+
+// profiles returns the profile given to the command, and the other profiles it touched.
+// Rows written by older versions of minikube may have several comma separated profiles in profile.
+func (e *row) profiles() []string {
+	var ps []string
+	for _, p := range []string{e.profile, e.touched} {
+		if p != "" {
+			ps = append(ps, strings.Split(p, ",")...)
+		}
+	}
+	return ps
 }
 
 // logsToRows converts audit logs into arrays of rows.
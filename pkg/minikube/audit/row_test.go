@@ -34,8 +34,9 @@ func TestRow(t *testing.T) {
 	v := "v0.17.1"
 	st := time.Now()
 	stFormatted := st.Format(constants.TimeFormat)
-	et := time.Now()
+	et := st.Add(2500 * time.Millisecond)
 	etFormatted := et.Format(constants.TimeFormat)
+	d := "2.5s"
 
 	r := newRow(c, a, u, v, st, et, p)
 
@@ -52,6 +53,7 @@ func TestRow(t *testing.T) {
 			{"version", r.version, v},
 			{"startTime", r.startTime, stFormatted},
 			{"endTime", r.endTime, etFormatted},
+			{"duration", r.duration, d},
 		}
 
 		for _, tt := range tests {
@@ -97,7 +99,7 @@ func TestRow(t *testing.T) {
 	t.Run("toFields", func(t *testing.T) {
 		got := r.toFields()
 		gotString := strings.Join(got, ",")
-		want := []string{c, a, p, u, v, stFormatted, etFormatted}
+		want := []string{c, a, p, u, v, stFormatted, etFormatted, d, "", ""}
 		wantString := strings.Join(want, ",")
 
 		if gotString != wantString {
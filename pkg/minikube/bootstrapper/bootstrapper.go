@@ -41,24 +41,42 @@ type Bootstrapper interface {
 	WaitForNode(config.ClusterConfig, config.Node, time.Duration) error
 	JoinCluster(config.ClusterConfig, config.Node, string) error
 	UpdateNode(config.ClusterConfig, config.Node, cruntime.Manager) error
+	// PrepareUpgrade installs the binaries and images of the Kubernetes version of the config on a node, and checks that the node can be upgraded to it
+	PrepareUpgrade(config.ClusterConfig, config.Node, cruntime.Manager) error
+	// UpgradeNode upgrades a node to the Kubernetes version of the config. The new kubelet is used once it is restarted.
+	UpgradeNode(config.ClusterConfig, config.Node, cruntime.Manager) error
 	GenerateToken(config.ClusterConfig) (string, error)
 	// LogCommands returns a map of log type to a command which will display that log.
 	LogCommands(config.ClusterConfig, LogOptions) map[string]string
 	SetupCerts(config.ClusterConfig, config.Node) error
+	// RenewCerts renews the certs the bootstrapper manages on a node once SetupCerts installed new minikube certs, and restarts the components using them.
+	// If the minikube CA was rotated, the certs signed by the previous CA are generated again.
+	RenewCerts(config.ClusterConfig, config.Node, bool) error
 	GetAPIServerStatus(string, int) (string, error)
 }
 
 const (
 	// Kubeadm is the kubeadm bootstrapper type
 	Kubeadm = "kubeadm"
+	// K3s is the k3s bootstrapper type, running Kubernetes from a single binary
+	K3s = "k3s"
 )
 
+// Bootstrappers are the supported bootstrapper types
+var Bootstrappers = []string{Kubeadm, K3s}
+
 // GetCachedBinaryList returns the list of binaries
 func GetCachedBinaryList(bootstrapper string) []string {
+	if bootstrapper == K3s {
+		return constants.K3sReleaseBinaries
+	}
 	return constants.KubernetesReleaseBinaries
 }
 
 // GetCachedImageList returns the list of images for a version
 func GetCachedImageList(imageRepository string, version string, bootstrapper string) ([]string, error) {
+	if bootstrapper == K3s {
+		return images.K3s(imageRepository, version)
+	}
 	return images.Kubeadm(imageRepository, version)
 }
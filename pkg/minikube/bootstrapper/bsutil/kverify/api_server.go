@@ -72,9 +72,9 @@ func WaitForAPIServerProcess(r cruntime.Manager, bs bootstrapper.Bootstrapper, c
 	return nil
 }
 
-// APIServerPID returns our best guess to the apiserver pid
+// APIServerPID returns our best guess to the apiserver pid, k3s running the apiserver within its own process
 func APIServerPID(cr command.Runner) (int, error) {
-	rr, err := cr.RunCmd(exec.Command("sudo", "pgrep", "-xnf", "kube-apiserver.*minikube.*"))
+	rr, err := cr.RunCmd(exec.Command("sudo", "pgrep", "-xnf", "kube-apiserver.*minikube.*|.*k3s server.*"))
 	if err != nil {
 		return 0, err
 	}
@@ -97,7 +97,7 @@ func WaitForHealthyAPIServer(r cruntime.Manager, bs bootstrapper.Bootstrapper, c
 			time.Sleep(kconst.APICallRetryInterval * 5)
 		}
 
-		status, err := apiServerHealthzNow(hostname, port)
+		status, err := apiServerHealthzNow(cfg.Name, hostname, port)
 		if err != nil {
 			klog.Warningf("status: %v", err)
 			return false, nil
@@ -147,11 +147,11 @@ func APIServerVersionMatch(client *kubernetes.Clientset, expected string) error
 // WaitForAPIServerStatus waits for 'to' duration to get apiserver pod running or stopped
 // this functions is intended to use in situations where apiserver process can be recreated
 // by container runtime restart for example and there is a gap before it comes back
-func WaitForAPIServerStatus(cr command.Runner, to time.Duration, hostname string, port int) (state.State, error) {
+func WaitForAPIServerStatus(cr command.Runner, to time.Duration, profile string, hostname string, port int) (state.State, error) {
 	var st state.State
 	err := wait.PollImmediate(200*time.Millisecond, to, func() (bool, error) {
 		var err error
-		st, err = APIServerStatus(cr, hostname, port)
+		st, err = APIServerStatus(cr, profile, hostname, port)
 		if st == state.Stopped {
 			return false, nil
 		}
@@ -161,7 +161,7 @@ func WaitForAPIServerStatus(cr command.Runner, to time.Duration, hostname string
 }
 
 // APIServerStatus returns apiserver status in libmachine style state.State
-func APIServerStatus(cr command.Runner, hostname string, port int) (state.State, error) {
+func APIServerStatus(cr command.Runner, profile string, hostname string, port int) (state.State, error) {
 	klog.Infof("Checking apiserver status ...")
 
 	pid, err := APIServerPID(cr)
@@ -174,7 +174,7 @@ func APIServerStatus(cr command.Runner, hostname string, port int) (state.State,
 	rr, err := cr.RunCmd(exec.Command("sudo", "egrep", "^[0-9]+:freezer:", fmt.Sprintf("/proc/%d/cgroup", pid)))
 	if err != nil {
 		klog.Warningf("unable to find freezer cgroup: %v", err)
-		return apiServerHealthz(hostname, port)
+		return apiServerHealthz(profile, hostname, port)
 
 	}
 	freezer := strings.TrimSpace(rr.Stdout.String())
@@ -182,7 +182,7 @@ func APIServerStatus(cr command.Runner, hostname string, port int) (state.State,
 	fparts := strings.Split(freezer, ":")
 	if len(fparts) != 3 {
 		klog.Warningf("unable to parse freezer - found %d parts: %s", len(fparts), freezer)
-		return apiServerHealthz(hostname, port)
+		return apiServerHealthz(profile, hostname, port)
 	}
 
 	rr, err = cr.RunCmd(exec.Command("sudo", "cat", path.Join("/sys/fs/cgroup/freezer", fparts[2], "freezer.state")))
@@ -196,7 +196,7 @@ func APIServerStatus(cr command.Runner, hostname string, port int) (state.State,
 			klog.Warningf("unable to get freezer state: %s", rr.Stderr.String())
 		}
 
-		return apiServerHealthz(hostname, port)
+		return apiServerHealthz(profile, hostname, port)
 	}
 
 	fs := strings.TrimSpace(rr.Stdout.String())
@@ -204,17 +204,17 @@ func APIServerStatus(cr command.Runner, hostname string, port int) (state.State,
 	if fs == "FREEZING" || fs == "FROZEN" {
 		return state.Paused, nil
 	}
-	return apiServerHealthz(hostname, port)
+	return apiServerHealthz(profile, hostname, port)
 }
 
 // apiServerHealthz checks apiserver in a patient and tolerant manner
-func apiServerHealthz(hostname string, port int) (state.State, error) {
+func apiServerHealthz(profile string, hostname string, port int) (state.State, error) {
 	var st state.State
 	var err error
 
 	check := func() error {
 		// etcd gets upset sometimes and causes healthz to report a failure. Be tolerant of it.
-		st, err = apiServerHealthzNow(hostname, port)
+		st, err = apiServerHealthzNow(profile, hostname, port)
 		if err != nil {
 			return err
 		}
@@ -235,10 +235,10 @@ func apiServerHealthz(hostname string, port int) (state.State, error) {
 }
 
 // apiServerHealthzNow hits the /healthz endpoint and returns libmachine style state.State
-func apiServerHealthzNow(hostname string, port int) (state.State, error) {
+func apiServerHealthzNow(profile string, hostname string, port int) (state.State, error) {
 	url := fmt.Sprintf("https://%s/healthz", net.JoinHostPort(hostname, fmt.Sprint(port)))
 	klog.Infof("Checking apiserver healthz at %s ...", url)
-	cert, err := os.ReadFile(localpath.CACert())
+	cert, err := os.ReadFile(caCert(profile))
 	if err != nil {
 		klog.Infof("ca certificate: %v", err)
 		return state.Stopped, err
@@ -272,3 +272,12 @@ func apiServerHealthzNow(hostname string, port int) (state.State, error) {
 	}
 	return state.Running, nil
 }
+
+// caCert returns the path of the CA which signs the apiserver certificate of a profile:
+// its external CA if it has one, otherwise the minikube CA
+func caCert(profile string) string {
+	if _, err := os.Stat(localpath.ProfileCACert(profile)); err == nil {
+		return localpath.ProfileCACert(profile)
+	}
+	return localpath.CACert()
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrapper
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+// CertInfo describes a certificate minikube generated
+type CertInfo struct {
+	// Name is the role of the certificate, e.g. apiserver
+	Name     string
+	Path     string
+	Subject  string
+	Issuer   string
+	SANs     []string
+	NotAfter time.Time
+	// CA is true for the CAs, shared among profiles unless the profile has an external CA
+	CA bool
+}
+
+// certFile is a certificate, and its key, generated by minikube
+type certFile struct {
+	name     string
+	certPath string
+	keyPath  string
+	ca       bool
+}
+
+// certFiles returns the CA certs, then the certs of the profile of cc
+func certFiles(cc config.ClusterConfig) []certFile {
+	ccs := caCerts(cc)
+	profilePath := localpath.Profile(cc.Name)
+	return []certFile{
+		{name: "ca", certPath: ccs.caCert, keyPath: ccs.caKey, ca: true},
+		{name: "proxy-client-ca", certPath: ccs.proxyCert, keyPath: ccs.proxyKey, ca: true},
+		{name: "apiserver", certPath: filepath.Join(profilePath, "apiserver.crt"), keyPath: filepath.Join(profilePath, "apiserver.key")},
+		{name: "proxy-client", certPath: filepath.Join(profilePath, "proxy-client.crt"), keyPath: filepath.Join(profilePath, "proxy-client.key")},
+		{name: "client", certPath: localpath.ClientCert(cc.Name), keyPath: localpath.ClientKey(cc.Name)},
+	}
+}
+
+// Certs returns the CA certs and the certs of the profile of cc. Certs which were not generated yet are left out.
+func Certs(cc config.ClusterConfig) ([]CertInfo, error) {
+	infos := []CertInfo{}
+	for _, f := range certFiles(cc) {
+		cert, err := readCert(f.certPath)
+		if os.IsNotExist(errors.Cause(err)) {
+			klog.Infof("skipping %s: %v", f.name, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sans := append([]string{}, cert.DNSNames...)
+		for _, ip := range cert.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		infos = append(infos, CertInfo{
+			Name:     f.name,
+			Path:     f.certPath,
+			Subject:  cert.Subject.String(),
+			Issuer:   cert.Issuer.String(),
+			SANs:     sans,
+			NotAfter: cert.NotAfter,
+			CA:       f.ca,
+		})
+	}
+	return infos, nil
+}
+
+// certPaths returns the files of the certs of the profile of cc, and of its CA certs if ca is true
+func certPaths(cc config.ClusterConfig, ca bool) ([]string, error) {
+	paths := []string{}
+	for _, f := range certFiles(cc) {
+		if f.ca && !ca {
+			continue
+		}
+		paths = append(paths, f.certPath, f.keyPath)
+	}
+	// the apiserver cert is cached per IP and name combination
+	for _, p := range []string{"apiserver.crt.*", "apiserver.key.*"} {
+		cached, err := filepath.Glob(filepath.Join(localpath.Profile(cc.Name), p))
+		if err != nil {
+			return nil, errors.Wrap(err, "glob")
+		}
+		paths = append(paths, cached...)
+	}
+	return paths, nil
+}
+
+// RemoveCerts moves the certs of the profile of cc, and its CA certs if ca is true, into the backup directory, so that SetupCerts generates new ones.
+// An external CA is copied into the profile again instead. RestoreCerts moves the backed up certs back.
+func RemoveCerts(cc config.ClusterConfig, ca bool, backup string) error {
+	paths, err := certPaths(cc, ca)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		dst, err := backupPath(backup, p)
+		if err != nil {
+			return err
+		}
+		klog.Infof("moving %s -> %s", p, dst)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(p, dst); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "moving %s", p)
+		}
+	}
+	return nil
+}
+
+// RestoreCerts removes the certs generated since RemoveCerts was called with the same arguments, and moves the backed up certs back
+func RestoreCerts(cc config.ClusterConfig, ca bool, backup string) error {
+	paths, err := certPaths(cc, ca)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		klog.Infof("removing %s", p)
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "removing %s", p)
+		}
+	}
+	return filepath.WalkDir(backup, func(src string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(backup, src)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(localpath.MiniPath(), rel)
+		klog.Infof("moving %s -> %s", src, dst)
+		return os.Rename(src, dst)
+	})
+}
+
+// backupPath returns where the cert p is kept in the backup directory, relative to the minikube home directory
+func backupPath(backup string, p string) (string, error) {
+	rel, err := filepath.Rel(localpath.MiniPath(), p)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is not in %s", p, localpath.MiniPath())
+	}
+	return filepath.Join(backup, rel), nil
+}
+
+// readCert parses the first certificate of a PEM file
+func readCert(certPath string) (*x509.Certificate, error) {
+	b, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading cert")
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", certPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", certPath)
+	}
+	return cert, nil
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package bootstrapper
 
 import (
+	"bytes"
 	"crypto/sha1"
 	"crypto/x509"
 	"encoding/pem"
@@ -58,6 +59,13 @@ func SetupCerts(cmd command.Runner, k8s config.ClusterConfig, n config.Node) err
 		return errors.Wrap(err, "shared CA certs")
 	}
 
+	imported, err := importExternalCA(k8s)
+	if err != nil {
+		return errors.Wrap(err, "external CA")
+	}
+	regen = regen || imported
+	ccs = caCerts(k8s)
+
 	xfer, err := generateProfileCerts(k8s, n, ccs, regen)
 	if err != nil {
 		return errors.Wrap(err, "profile certs")
@@ -90,7 +98,7 @@ func SetupCerts(cmd command.Runner, k8s config.ClusterConfig, n config.Node) err
 		copyableFiles = append(copyableFiles, certFile)
 	}
 
-	caCerts, err := collectCACerts()
+	caCerts, err := collectCACerts(ccs.caCert)
 	if err != nil {
 		return err
 	}
@@ -149,16 +157,86 @@ type CACerts struct {
 	proxyKey  string
 }
 
-// generateSharedCACerts generates CA certs shared among profiles, but only if missing
-func generateSharedCACerts() (CACerts, bool, error) {
-	regenProfileCerts := false
+// sharedCACerts returns the paths of the CA certs shared among profiles
+func sharedCACerts() CACerts {
 	globalPath := localpath.MiniPath()
-	cc := CACerts{
+	return CACerts{
 		caCert:    localpath.CACert(),
 		caKey:     filepath.Join(globalPath, "ca.key"),
 		proxyCert: filepath.Join(globalPath, "proxy-client-ca.crt"),
 		proxyKey:  filepath.Join(globalPath, "proxy-client-ca.key"),
 	}
+}
+
+// caCerts returns the paths of the CA certs of the profile of cc: its external CA if it has one, otherwise the minikube CA
+func caCerts(cc config.ClusterConfig) CACerts {
+	ccs := sharedCACerts()
+	if cc.CACertFile != "" {
+		ccs.caCert = localpath.ProfileCACert(cc.Name)
+		ccs.caKey = localpath.ProfileCAKey(cc.Name)
+	}
+	return ccs
+}
+
+// ClusterCACert returns the path of the CA cert of the profile of cc, trusted by the kubeconfig
+func ClusterCACert(cc config.ClusterConfig) string {
+	return caCerts(cc).caCert
+}
+
+// importExternalCA validates the external CA of the profile of cc and copies it into the profile, or removes a previously imported CA
+// once the profile no longer has one. It returns true if the CA changed, so that the profile certs must be generated again.
+func importExternalCA(cc config.ClusterConfig) (bool, error) {
+	certPath := localpath.ProfileCACert(cc.Name)
+	keyPath := localpath.ProfileCAKey(cc.Name)
+
+	if cc.CACertFile == "" {
+		if !canRead(certPath) {
+			return false, nil
+		}
+		klog.Infof("removing the external CA of %s", cc.Name)
+		for _, p := range []string{certPath, keyPath} {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	if err := util.ValidateCA(cc.CACertFile, cc.CAKeyFile); err != nil {
+		return false, err
+	}
+	changed := false
+	for _, f := range []struct {
+		src  string
+		dst  string
+		perm os.FileMode
+	}{
+		{src: cc.CACertFile, dst: certPath, perm: 0644},
+		{src: cc.CAKeyFile, dst: keyPath, perm: 0600},
+	} {
+		b, err := os.ReadFile(f.src)
+		if err != nil {
+			return false, err
+		}
+		if old, err := os.ReadFile(f.dst); err == nil && bytes.Equal(old, b) {
+			continue
+		}
+		klog.Infof("copying external CA %s -> %s", f.src, f.dst)
+		if err := os.MkdirAll(filepath.Dir(f.dst), 0755); err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(f.dst, b, f.perm); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// generateSharedCACerts generates CA certs shared among profiles, but only if missing
+func generateSharedCACerts() (CACerts, bool, error) {
+	regenProfileCerts := false
+	cc := sharedCACerts()
 
 	caCertSpecs := []struct {
 		certPath string
@@ -355,8 +433,8 @@ func isValidPEMCertificate(filePath string) (bool, error) {
 }
 
 // collectCACerts looks up all PEM certificates with .crt or .pem extension in ~/.minikube/certs or ~/.minikube/files/etc/ssl/certs to copy to the host.
-// minikube root CA is also included but libmachine certificates (ca.pem/cert.pem) are excluded.
-func collectCACerts() (map[string]string, error) {
+// The CA of the cluster, minikube root CA or the external CA of the profile, is also included but libmachine certificates (ca.pem/cert.pem) are excluded.
+func collectCACerts(clusterCA string) (map[string]string, error) {
 	localPath := localpath.MiniPath()
 	certFiles := map[string]string{}
 
@@ -411,7 +489,7 @@ func collectCACerts() (map[string]string, error) {
 	}
 
 	// populates minikube CA
-	certFiles[filepath.Join(localPath, "ca.crt")] = path.Join(vmpath.GuestCertAuthDir, "minikubeCA.pem")
+	certFiles[clusterCA] = path.Join(vmpath.GuestCertAuthDir, "minikubeCA.pem")
 
 	filtered := map[string]string{}
 	for k, v := range certFiles {
@@ -17,13 +17,16 @@ limitations under the License.
 package bootstrapper
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"k8s.io/minikube/pkg/minikube/command"
 	"k8s.io/minikube/pkg/minikube/config"
 	"k8s.io/minikube/pkg/minikube/constants"
+	"k8s.io/minikube/pkg/minikube/localpath"
 	"k8s.io/minikube/pkg/minikube/tests"
 	"k8s.io/minikube/pkg/util"
 )
@@ -63,3 +66,169 @@ func TestSetupCerts(t *testing.T) {
 		t.Fatalf("Error starting cluster: %v", err)
 	}
 }
+
+func TestCertsAndRemoveCerts(t *testing.T) {
+	tempDir := tests.MakeTempDir(t)
+
+	cc := config.ClusterConfig{
+		Name:           "minikube",
+		CertExpiration: constants.DefaultCertExpiration,
+		KubernetesConfig: config.KubernetesConfig{
+			ClusterName:   "minikube",
+			APIServerName: constants.APIServerName,
+			DNSDomain:     constants.ClusterDNSDomain,
+			ServiceCIDR:   constants.DefaultServiceCIDR,
+		},
+	}
+	n := config.Node{IP: "192.168.49.2", Port: 8443, ControlPlane: true}
+
+	f := command.NewFakeCommandRunner()
+	f.SetCommandToOutput(map[string]string{
+		`sudo /bin/bash -c "test -s /usr/share/ca-certificates/minikubeCA.pem && ln -fs /usr/share/ca-certificates/minikubeCA.pem /etc/ssl/certs/minikubeCA.pem"`: "-",
+	})
+	if err := SetupCerts(f, cc, n); err != nil {
+		t.Fatalf("SetupCerts: %v", err)
+	}
+
+	infos, err := Certs(cc)
+	if err != nil {
+		t.Fatalf("Certs: %v", err)
+	}
+	names := []string{}
+	for _, i := range infos {
+		names = append(names, i.Name)
+	}
+	if diff := cmp.Diff([]string{"ca", "proxy-client-ca", "apiserver", "proxy-client", "client"}, names); diff != "" {
+		t.Errorf("Certs names mismatch (-want +got):\n%s", diff)
+	}
+	apiserver := infos[2]
+	if !contains(apiserver.SANs, "192.168.49.2") || !contains(apiserver.SANs, constants.ControlPlaneAlias) {
+		t.Errorf("apiserver SANs = %v, want the node IP and %s", apiserver.SANs, constants.ControlPlaneAlias)
+	}
+	if apiserver.Issuer != "CN=minikubeCA" || apiserver.CA || !infos[0].CA {
+		t.Errorf("unexpected apiserver cert: %+v", apiserver)
+	}
+
+	want, err := os.ReadFile(apiserver.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(tempDir, "backup")
+	if err := RemoveCerts(cc, false, backup); err != nil {
+		t.Fatalf("RemoveCerts: %v", err)
+	}
+	removed, err := Certs(cc)
+	if err != nil {
+		t.Fatalf("Certs: %v", err)
+	}
+	if len(removed) != 2 || !removed[0].CA || !removed[1].CA {
+		t.Errorf("Certs after RemoveCerts = %+v, want only the CAs", removed)
+	}
+	cached, err := filepath.Glob(filepath.Join(tempDir, "profiles", "minikube", "apiserver.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cached) != 0 {
+		t.Errorf("apiserver certs left after RemoveCerts: %v", cached)
+	}
+
+	if err := SetupCerts(f, cc, n); err != nil {
+		t.Fatalf("SetupCerts: %v", err)
+	}
+	if err := RestoreCerts(cc, false, backup); err != nil {
+		t.Fatalf("RestoreCerts: %v", err)
+	}
+	got, err := os.ReadFile(apiserver.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("apiserver cert was not restored")
+	}
+}
+
+func TestSetupCertsExternalCA(t *testing.T) {
+	tests.MakeTempDir(t)
+	corpDir := t.TempDir()
+
+	cc := config.ClusterConfig{
+		Name:           "minikube",
+		CertExpiration: constants.DefaultCertExpiration,
+		CACertFile:     filepath.Join(corpDir, "intermediate.crt"),
+		CAKeyFile:      filepath.Join(corpDir, "intermediate.key"),
+		KubernetesConfig: config.KubernetesConfig{
+			ClusterName:   "minikube",
+			APIServerName: constants.APIServerName,
+			DNSDomain:     constants.ClusterDNSDomain,
+			ServiceCIDR:   constants.DefaultServiceCIDR,
+		},
+	}
+	if err := util.GenerateCACert(cc.CACertFile, cc.CAKeyFile, "Corp Intermediate CA"); err != nil {
+		t.Fatalf("error generating certificate: %v", err)
+	}
+	n := config.Node{IP: "192.168.49.2", Port: 8443, ControlPlane: true}
+
+	issuers := func() map[string]string {
+		infos, err := Certs(cc)
+		if err != nil {
+			t.Fatalf("Certs: %v", err)
+		}
+		m := map[string]string{}
+		for _, i := range infos {
+			m[i.Name] = i.Issuer
+		}
+		return m
+	}
+
+	f := command.NewFakeCommandRunner()
+	f.SetCommandToOutput(map[string]string{
+		`sudo /bin/bash -c "test -s /usr/share/ca-certificates/minikubeCA.pem && ln -fs /usr/share/ca-certificates/minikubeCA.pem /etc/ssl/certs/minikubeCA.pem"`: "-",
+	})
+	if err := SetupCerts(f, cc, n); err != nil {
+		t.Fatalf("SetupCerts: %v", err)
+	}
+	if ClusterCACert(cc) != localpath.ProfileCACert(cc.Name) {
+		t.Errorf("ClusterCACert = %s, want the CA copied into the profile", ClusterCACert(cc))
+	}
+	got := issuers()
+	for _, name := range []string{"ca", "apiserver", "client"} {
+		if got[name] != "CN=Corp Intermediate CA" {
+			t.Errorf("%s issuer = %q, want the external CA", name, got[name])
+		}
+	}
+	if got["proxy-client"] != "CN=proxyClientCA" {
+		t.Errorf("proxy-client issuer = %q, want the minikube proxy client CA", got["proxy-client"])
+	}
+
+	// going back to the minikube CA signs the certs with it again
+	cc.CACertFile = ""
+	cc.CAKeyFile = ""
+	if err := SetupCerts(f, cc, n); err != nil {
+		t.Fatalf("SetupCerts: %v", err)
+	}
+	if _, err := os.Stat(localpath.ProfileCACert(cc.Name)); !os.IsNotExist(err) {
+		t.Errorf("the external CA was not removed from the profile: %v", err)
+	}
+	got = issuers()
+	for _, name := range []string{"ca", "apiserver", "client"} {
+		if got[name] != "CN=minikubeCA" {
+			t.Errorf("%s issuer = %q, want the minikube CA", name, got[name])
+		}
+	}
+
+	// a CA which cannot sign certs is refused
+	cc.CACertFile = localpath.ClientCert(cc.Name)
+	cc.CAKeyFile = localpath.ClientKey(cc.Name)
+	if err := SetupCerts(f, cc, n); err == nil {
+		t.Errorf("SetupCerts with a client cert as CA should have failed")
+	}
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
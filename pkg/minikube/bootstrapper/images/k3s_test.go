@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/minikube/pkg/version"
+)
+
+func TestK3sImages(t *testing.T) {
+	tests := []struct {
+		version string
+		mirror  string
+		invalid bool
+		want    []string
+	}{
+		{"invalid", "", true, nil},
+		{"v1.16.1", "", true, nil}, // too old
+		{"v2.0.0", "", true, nil},  // too new
+		{"v1.17.0", "", false, []string{
+			"k8s.gcr.io/pause:3.1",
+			"gcr.io/k8s-minikube/storage-provisioner:" + version.GetStorageProvisionerVersion(),
+		}},
+		{"v1.18.0", "mirror.k8s.io", false, []string{
+			"mirror.k8s.io/pause:3.2",
+			"mirror.k8s.io/k8s-minikube/storage-provisioner:" + version.GetStorageProvisionerVersion(),
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			got, err := K3s(tc.mirror, tc.version)
+			if err == nil && tc.invalid {
+				t.Fatalf("expected err (%s): %v", tc.version, got)
+			}
+			if err != nil && !tc.invalid {
+				t.Fatalf("unexpected err (%s): %v", tc.version, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s images mismatch (-want +got):\n%s", tc.version, diff)
+			}
+		})
+	}
+}
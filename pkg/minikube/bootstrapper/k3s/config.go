@@ -0,0 +1,352 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k3s
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os/exec"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/bsutil"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/images"
+	"k8s.io/minikube/pkg/minikube/cni"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/constants"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/download"
+	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/vmpath"
+	"k8s.io/minikube/pkg/util"
+	"k8s.io/minikube/pkg/version"
+	"k8s.io/minikube/third_party/kubeadm/app/features"
+)
+
+var (
+	// DataDir is where k3s keeps its state, including the sqlite datastore of the cluster
+	DataDir = path.Join(vmpath.GuestPersistentDir, "k3s")
+	// tlsDir is where k3s keeps its certs, and looks for the CAs to sign them with
+	tlsDir = path.Join(DataDir, "server", "tls")
+)
+
+const (
+	// configDir holds the k3s config file
+	configDir = "/etc/rancher/k3s"
+	// configFile is the k3s config file, holding the flags of k3s server or agent
+	configFile = configDir + "/config.yaml"
+	// dockershimSocket is the socket of the dockershim, which k3s runs itself with docker: true
+	dockershimSocket = "/var/run/dockershim.sock"
+)
+
+// disabledComponents are the packaged components of k3s minikube replaces with its own addons and CNI
+var disabledComponents = []string{"traefik", "servicelb", "metrics-server", "local-storage"}
+
+// k3sConfig is the config file of k3s, its keys being the flags of k3s server and agent
+type k3sConfig struct {
+	Server                   string   `yaml:"server,omitempty"`
+	Token                    string   `yaml:"token,omitempty"`
+	DataDir                  string   `yaml:"data-dir"`
+	NodeName                 string   `yaml:"node-name"`
+	NodeIP                   string   `yaml:"node-ip,omitempty"`
+	NodeLabel                []string `yaml:"node-label,omitempty"`
+	PauseImage               string   `yaml:"pause-image,omitempty"`
+	ContainerRuntimeEndpoint string   `yaml:"container-runtime-endpoint,omitempty"`
+	Docker                   bool     `yaml:"docker,omitempty"`
+	HTTPSListenPort          int      `yaml:"https-listen-port,omitempty"`
+	TLSSAN                   []string `yaml:"tls-san,omitempty"`
+	ClusterCIDR              string   `yaml:"cluster-cidr,omitempty"`
+	ServiceCIDR              string   `yaml:"service-cidr,omitempty"`
+	ClusterDNS               string   `yaml:"cluster-dns,omitempty"`
+	ClusterDomain            string   `yaml:"cluster-domain,omitempty"`
+	FlannelBackend           string   `yaml:"flannel-backend,omitempty"`
+	DisableNetworkPolicy     bool     `yaml:"disable-network-policy,omitempty"`
+	Disable                  []string `yaml:"disable,omitempty"`
+	KubeAPIServerArg         []string `yaml:"kube-apiserver-arg,omitempty"`
+	KubeControllerManagerArg []string `yaml:"kube-controller-manager-arg,omitempty"`
+	KubeSchedulerArg         []string `yaml:"kube-scheduler-arg,omitempty"`
+	KubeProxyArg             []string `yaml:"kube-proxy-arg,omitempty"`
+	KubeletArg               []string `yaml:"kubelet-arg,omitempty"`
+}
+
+// serviceTemplate runs k3s as the kubelet service, so that minikube manages it like the kubelet of kubeadm
+var serviceTemplate = template.Must(template.New("k3sServiceTemplate").Parse(`[Unit]
+Description=k3s: Lightweight Kubernetes
+Documentation=https://docs.k3s.io
+{{if or (eq .ContainerRuntime "cri-o") (eq .ContainerRuntime "crio")}}Wants=crio.service{{else if eq .ContainerRuntime "containerd"}}Wants=containerd.service{{else}}Wants=docker.socket{{end}}
+StartLimitIntervalSec=0
+
+[Service]
+Type=simple
+ExecStart={{.K3sPath}} {{.Role}} --config {{.ConfigFile}}
+KillMode=process
+Delegate=yes
+LimitNOFILE=1048576
+LimitNPROC=infinity
+LimitCORE=infinity
+TasksMax=infinity
+Restart=always
+RestartSec=5s
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// binRoot returns the persistent path binaries are stored in
+func binRoot(version string) string {
+	return path.Join(vmpath.GuestPersistentDir, "binaries", version)
+}
+
+// k3sPath returns the path to the k3s binary
+func k3sPath(cfg config.KubernetesConfig) string {
+	return path.Join(binRoot(cfg.KubernetesVersion), "k3s")
+}
+
+// kubectlPath returns the path to kubectl, a link to the k3s binary
+func kubectlPath(cfg config.KubernetesConfig) string {
+	return path.Join(binRoot(cfg.KubernetesVersion), "kubectl")
+}
+
+// newService returns the systemd unit running k3s server on control planes, and k3s agent on workers
+func newService(cfg config.ClusterConfig, n config.Node) ([]byte, error) {
+	role := "agent"
+	if n.ControlPlane {
+		role = "server"
+	}
+	opts := struct {
+		ContainerRuntime string
+		K3sPath          string
+		Role             string
+		ConfigFile       string
+	}{
+		ContainerRuntime: cfg.KubernetesConfig.ContainerRuntime,
+		K3sPath:          k3sPath(cfg.KubernetesConfig),
+		Role:             role,
+		ConfigFile:       configFile,
+	}
+	var b bytes.Buffer
+	if err := serviceTemplate.Execute(&b, opts); err != nil {
+		return nil, errors.Wrap(err, "template execute")
+	}
+	return b.Bytes(), nil
+}
+
+// cniManager returns the CNI of the cluster, the bridge CNI if it has none, as flannel is not run by k3s
+func cniManager(cfg config.ClusterConfig) (cni.Manager, error) {
+	cnm, err := cni.New(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cnm.(cni.Disabled); !ok {
+		return cnm, nil
+	}
+	cfg.KubernetesConfig.NetworkPlugin = "cni"
+	cfg.KubernetesConfig.CNI = "bridge"
+	return cni.New(&cfg)
+}
+
+// featureGates returns the feature gates of the Kubernetes components, leaving out the ones of kubeadm
+func featureGates(fg string) string {
+	gates := []string{}
+	for _, s := range strings.Split(fg, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if _, ok := features.InitFeatureGates[strings.TrimSpace(strings.SplitN(s, "=", 2)[0])]; ok {
+			continue
+		}
+		gates = append(gates, s)
+	}
+	return strings.Join(gates, ",")
+}
+
+// generateConfig returns the k3s config file of a node. Workers join the cluster of the primary control plane with token.
+func generateConfig(cfg config.ClusterConfig, n config.Node, r cruntime.Manager, token string) ([]byte, error) {
+	k8s := cfg.KubernetesConfig
+	v, err := util.ParseKubernetesVersion(k8s.KubernetesVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing Kubernetes version")
+	}
+	cp, err := config.PrimaryControlPlane(&cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "control plane")
+	}
+	// also sets up the CNI config directory of the runtime
+	cnm, err := cniManager(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "cni")
+	}
+
+	kc := k3sConfig{
+		DataDir:  DataDir,
+		NodeName: bsutil.KubeNodeName(cfg, n),
+		NodeIP:   n.IP,
+		NodeLabel: []string{
+			"minikube.k8s.io/name=" + cfg.Name,
+			"minikube.k8s.io/version=" + version.GetVersion(),
+			"minikube.k8s.io/commit=" + version.GetGitCommitID(),
+			"minikube.k8s.io/primary=" + strconv.FormatBool(n.Name == cp.Name),
+		},
+		PauseImage:   images.Pause(v, k8s.ImageRepository),
+		KubeProxyArg: []string{"conntrack-max-per-core=0"},
+	}
+
+	sp := r.SocketPath()
+	if k8s.ContainerRuntime == constants.Docker && sp == dockershimSocket {
+		kc.Docker = true
+		kc.KubeletArg = append(kc.KubeletArg, "cni-bin-dir=/opt/cni/bin", "cni-conf-dir="+cni.ConfDir)
+	} else {
+		kc.ContainerRuntimeEndpoint = "unix://" + sp
+	}
+	driver, err := r.CGroupDriver()
+	if err != nil {
+		return nil, errors.Wrap(err, "cgroup driver")
+	}
+	kc.KubeletArg = append(kc.KubeletArg, "cgroup-driver="+driver)
+
+	if n.ControlPlane {
+		if err := serverConfig(&kc, cfg, n, cnm); err != nil {
+			return nil, err
+		}
+	} else {
+		port := cp.Port
+		if port == 0 {
+			port = constants.APIServerPort
+		}
+		kc.Server = fmt.Sprintf("https://%s", net.JoinHostPort(constants.ControlPlaneAlias, strconv.Itoa(port)))
+		kc.Token = token
+	}
+
+	if fg := featureGates(k8s.FeatureGates); fg != "" {
+		gate := "feature-gates=" + fg
+		kc.KubeletArg = append(kc.KubeletArg, gate)
+		kc.KubeProxyArg = append(kc.KubeProxyArg, gate)
+		if n.ControlPlane {
+			kc.KubeAPIServerArg = append(kc.KubeAPIServerArg, gate)
+			kc.KubeControllerManagerArg = append(kc.KubeControllerManagerArg, gate)
+			kc.KubeSchedulerArg = append(kc.KubeSchedulerArg, gate)
+		}
+	}
+
+	for _, o := range k8s.ExtraOptions {
+		arg := fmt.Sprintf("%s=%s", o.Key, o.Value)
+		switch o.Component {
+		case bsutil.Kubelet:
+			kc.KubeletArg = append(kc.KubeletArg, arg)
+		case bsutil.Kubeproxy:
+			kc.KubeProxyArg = append(kc.KubeProxyArg, arg)
+		case bsutil.Apiserver, bsutil.ControllerManager, bsutil.Scheduler:
+			if !n.ControlPlane {
+				continue
+			}
+			switch o.Component {
+			case bsutil.Apiserver:
+				kc.KubeAPIServerArg = append(kc.KubeAPIServerArg, arg)
+			case bsutil.ControllerManager:
+				kc.KubeControllerManagerArg = append(kc.KubeControllerManagerArg, arg)
+			default:
+				kc.KubeSchedulerArg = append(kc.KubeSchedulerArg, arg)
+			}
+		case bsutil.Kubeadm:
+			if o.Key == "pod-network-cidr" {
+				continue
+			}
+			klog.Warningf("k3s does not use kubeadm, ignoring %s", o.String())
+		default:
+			klog.Warningf("k3s does not support configuring %s, ignoring %s", o.Component, o.String())
+		}
+	}
+
+	return yaml.Marshal(kc)
+}
+
+// serverConfig sets up the apiserver and cluster networking of a control plane
+func serverConfig(kc *k3sConfig, cfg config.ClusterConfig, n config.Node, cnm cni.Manager) error {
+	k8s := cfg.KubernetesConfig
+
+	kc.HTTPSListenPort = n.Port
+	if kc.HTTPSListenPort == 0 {
+		kc.HTTPSListenPort = constants.APIServerPort
+	}
+
+	sans := []string{constants.ControlPlaneAlias, "localhost", "127.0.0.1", n.IP, k8s.APIServerName}
+	sans = append(sans, k8s.APIServerNames...)
+	for _, ip := range k8s.APIServerIPs {
+		sans = append(sans, ip.String())
+	}
+	for _, s := range sans {
+		if s != "" && !config.ContainsParam(kc.TLSSAN, s) {
+			kc.TLSSAN = append(kc.TLSSAN, s)
+		}
+	}
+
+	kc.ClusterCIDR = cnm.CIDR()
+	if cidr := k8s.ExtraOptions.Get("pod-network-cidr", bsutil.Kubeadm); cidr != "" {
+		kc.ClusterCIDR = cidr
+	}
+	kc.ServiceCIDR = k8s.ServiceCIDR
+	if kc.ServiceCIDR == "" {
+		kc.ServiceCIDR = constants.DefaultServiceCIDR
+	}
+	dnsIP, err := util.GetDNSIP(kc.ServiceCIDR)
+	if err != nil {
+		return errors.Wrap(err, "getting DNS IP")
+	}
+	kc.ClusterDNS = dnsIP.String()
+	kc.ClusterDomain = k8s.DNSDomain
+
+	kc.FlannelBackend = "none"
+	kc.DisableNetworkPolicy = true
+	kc.Disable = disabledComponents
+	// lets minikube check the health of the apiserver, as it does with kubeadm
+	kc.KubeAPIServerArg = append(kc.KubeAPIServerArg, "anonymous-auth=true")
+	return nil
+}
+
+// transferBinaries installs the k3s binary of the Kubernetes version of cfg, along with kubectl linked to it
+func transferBinaries(cfg config.KubernetesConfig, c command.Runner, binariesURL string) error {
+	dir := binRoot(cfg.KubernetesVersion)
+	if _, err := c.RunCmd(exec.Command("sudo", "test", "-x", k3sPath(cfg), "-a", "-L", kubectlPath(cfg))); err == nil {
+		klog.Info("Found k3s binaries, skipping transfer")
+		return nil
+	}
+
+	if _, err := c.RunCmd(exec.Command("sudo", "mkdir", "-p", dir)); err != nil {
+		return err
+	}
+	src, err := download.Binary("k3s", cfg.KubernetesVersion, "linux", runtime.GOARCH, binariesURL)
+	if err != nil {
+		return errors.Wrap(err, "downloading k3s")
+	}
+	if err := machine.CopyBinary(c, src, k3sPath(cfg)); err != nil {
+		return errors.Wrapf(err, "copybinary %s -> %s", src, k3sPath(cfg))
+	}
+	// k3s runs as kubectl when called so
+	if _, err := c.RunCmd(exec.Command("sudo", "ln", "-sf", "k3s", kubectlPath(cfg))); err != nil {
+		return errors.Wrap(err, "linking kubectl")
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k3s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+)
+
+func TestGenerateConfig(t *testing.T) {
+	fcr := command.NewFakeCommandRunner()
+	fcr.SetCommandToOutput(map[string]string{
+		"docker info --format {{.CgroupDriver}}": "systemd\n",
+	})
+
+	cp := config.Node{Name: "", IP: "192.168.49.2", Port: 8443, ControlPlane: true}
+	worker := config.Node{Name: "m02", IP: "192.168.49.3", Port: 8443}
+	extra := config.ExtraOptionSlice{
+		{Component: "apiserver", Key: "v", Value: "5"},
+		{Component: "kubelet", Key: "max-pods", Value: "50"},
+		{Component: "kubeadm", Key: "pod-network-cidr", Value: "10.200.0.0/16"},
+		{Component: "kubeadm", Key: "ignore-preflight-errors", Value: "all"},
+	}
+
+	tests := []struct {
+		description string
+		node        config.Node
+		socket      string
+		token       string
+		extra       config.ExtraOptionSlice
+		gates       string
+		check       func(t *testing.T, kc k3sConfig)
+	}{
+		{
+			description: "server with dockershim",
+			node:        cp,
+			check: func(t *testing.T, kc k3sConfig) {
+				if !kc.Docker || kc.ContainerRuntimeEndpoint != "" {
+					t.Errorf("docker = %v, container-runtime-endpoint = %q, want the dockershim of k3s", kc.Docker, kc.ContainerRuntimeEndpoint)
+				}
+				if kc.Server != "" || kc.Token != "" {
+					t.Errorf("server = %q, token = %q, want none", kc.Server, kc.Token)
+				}
+				if kc.HTTPSListenPort != 8443 || kc.ClusterDNS != "10.96.0.10" || kc.ServiceCIDR != "10.96.0.0/12" {
+					t.Errorf("https-listen-port = %d, cluster-dns = %q, service-cidr = %q", kc.HTTPSListenPort, kc.ClusterDNS, kc.ServiceCIDR)
+				}
+				if diff := cmp.Diff([]string{"control-plane.minikube.internal", "localhost", "127.0.0.1", "192.168.49.2"}, kc.TLSSAN); diff != "" {
+					t.Errorf("tls-san mismatch (-want +got):\n%s", diff)
+				}
+				if diff := cmp.Diff([]string{"cni-bin-dir=/opt/cni/bin", "cni-conf-dir=/etc/cni/net.mk", "cgroup-driver=systemd"}, kc.KubeletArg); diff != "" {
+					t.Errorf("kubelet-arg mismatch (-want +got):\n%s", diff)
+				}
+				if diff := cmp.Diff(disabledComponents, kc.Disable); diff != "" {
+					t.Errorf("disable mismatch (-want +got):\n%s", diff)
+				}
+				if kc.FlannelBackend != "none" {
+					t.Errorf("flannel-backend = %q, want none", kc.FlannelBackend)
+				}
+				if !contains(kc.NodeLabel, "minikube.k8s.io/primary=true") {
+					t.Errorf("node-label = %v, want primary", kc.NodeLabel)
+				}
+			},
+		},
+		{
+			description: "agent with cri-dockerd",
+			node:        worker,
+			socket:      "/var/run/cri-dockerd.sock",
+			token:       "K10abc::server:def",
+			check: func(t *testing.T, kc k3sConfig) {
+				if kc.Docker || kc.ContainerRuntimeEndpoint != "unix:///var/run/cri-dockerd.sock" {
+					t.Errorf("docker = %v, container-runtime-endpoint = %q, want cri-dockerd", kc.Docker, kc.ContainerRuntimeEndpoint)
+				}
+				if kc.Server != "https://control-plane.minikube.internal:8443" || kc.Token != "K10abc::server:def" {
+					t.Errorf("server = %q, token = %q", kc.Server, kc.Token)
+				}
+				if kc.NodeName != "minikube-m02" || kc.NodeIP != "192.168.49.3" {
+					t.Errorf("node-name = %q, node-ip = %q", kc.NodeName, kc.NodeIP)
+				}
+				if kc.HTTPSListenPort != 0 || len(kc.TLSSAN) != 0 || len(kc.Disable) != 0 || len(kc.KubeAPIServerArg) != 0 {
+					t.Errorf("got server settings on an agent: %+v", kc)
+				}
+				if !contains(kc.NodeLabel, "minikube.k8s.io/primary=false") {
+					t.Errorf("node-label = %v, want not primary", kc.NodeLabel)
+				}
+			},
+		},
+		{
+			description: "extra options and feature gates",
+			node:        cp,
+			extra:       extra,
+			gates:       "EphemeralContainers=true,PublicKeysECDSA=true",
+			check: func(t *testing.T, kc k3sConfig) {
+				if kc.ClusterCIDR != "10.200.0.0/16" {
+					t.Errorf("cluster-cidr = %q, want the pod-network-cidr of kubeadm", kc.ClusterCIDR)
+				}
+				if diff := cmp.Diff([]string{"anonymous-auth=true", "feature-gates=EphemeralContainers=true", "v=5"}, kc.KubeAPIServerArg); diff != "" {
+					t.Errorf("kube-apiserver-arg mismatch (-want +got):\n%s", diff)
+				}
+				if diff := cmp.Diff([]string{"cni-bin-dir=/opt/cni/bin", "cni-conf-dir=/etc/cni/net.mk", "cgroup-driver=systemd", "feature-gates=EphemeralContainers=true", "max-pods=50"}, kc.KubeletArg); diff != "" {
+					t.Errorf("kubelet-arg mismatch (-want +got):\n%s", diff)
+				}
+				if diff := cmp.Diff([]string{"feature-gates=EphemeralContainers=true"}, kc.KubeSchedulerArg); diff != "" {
+					t.Errorf("kube-scheduler-arg mismatch (-want +got):\n%s", diff)
+				}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := config.ClusterConfig{
+				Name: "minikube",
+				KubernetesConfig: config.KubernetesConfig{
+					KubernetesVersion: "v1.23.6",
+					ContainerRuntime:  "docker",
+					DNSDomain:         "cluster.local",
+					ExtraOptions:      tc.extra,
+					FeatureGates:      tc.gates,
+				},
+				Nodes: []config.Node{cp, worker},
+			}
+			r, err := cruntime.New(cruntime.Config{Type: "docker", Runner: fcr, Socket: tc.socket})
+			if err != nil {
+				t.Fatalf("runtime: %v", err)
+			}
+
+			got, err := generateConfig(cfg, tc.node, r, tc.token)
+			if err != nil {
+				t.Fatalf("generateConfig: %v", err)
+			}
+			var kc k3sConfig
+			if err := yaml.Unmarshal(got, &kc); err != nil {
+				t.Fatalf("unmarshal: %v\n%s", err, got)
+			}
+			if kc.DataDir != "/var/lib/minikube/k3s" {
+				t.Errorf("data-dir = %q, want /var/lib/minikube/k3s", kc.DataDir)
+			}
+			tc.check(t, kc)
+		})
+	}
+}
+
+func TestNewService(t *testing.T) {
+	cfg := config.ClusterConfig{
+		KubernetesConfig: config.KubernetesConfig{KubernetesVersion: "v1.23.6", ContainerRuntime: "containerd"},
+	}
+	tests := []struct {
+		node config.Node
+		want string
+	}{
+		{config.Node{ControlPlane: true}, "ExecStart=/var/lib/minikube/binaries/v1.23.6/k3s server --config /etc/rancher/k3s/config.yaml\n"},
+		{config.Node{}, "ExecStart=/var/lib/minikube/binaries/v1.23.6/k3s agent --config /etc/rancher/k3s/config.yaml\n"},
+	}
+	for _, tc := range tests {
+		got, err := newService(cfg, tc.node)
+		if err != nil {
+			t.Fatalf("newService: %v", err)
+		}
+		if !strings.Contains(string(got), tc.want) || !strings.Contains(string(got), "Wants=containerd.service") {
+			t.Errorf("newService(%+v) = %s, want %q", tc.node, got, tc.want)
+		}
+	}
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,486 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package k3s bootstraps clusters with k3s, which runs the Kubernetes components of a node within a single process
+package k3s
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	// WARNING: Do not use path/filepath in this package unless you want bizarre Windows paths
+
+	"github.com/docker/machine/libmachine"
+	"github.com/docker/machine/libmachine/state"
+	"github.com/pkg/errors"
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/kapi"
+	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/bsutil"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/bsutil/kverify"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/images"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/kubeadm"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/constants"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/detect"
+	"k8s.io/minikube/pkg/minikube/driver"
+	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/out/register"
+	"k8s.io/minikube/pkg/minikube/style"
+	"k8s.io/minikube/pkg/minikube/sysinit"
+	"k8s.io/minikube/pkg/minikube/vmpath"
+	"k8s.io/minikube/pkg/util"
+	"k8s.io/minikube/pkg/util/retry"
+	kconst "k8s.io/minikube/third_party/kubeadm/app/constants"
+)
+
+// Bootstrapper is a bootstrapper using k3s
+type Bootstrapper struct {
+	c           command.Runner
+	k8sClient   *kubernetes.Clientset // Kubernetes client used to verify pods inside cluster
+	contextName string
+}
+
+// NewBootstrapper creates a new k3s.Bootstrapper
+func NewBootstrapper(api libmachine.API, cc config.ClusterConfig, r command.Runner) (*Bootstrapper, error) {
+	return &Bootstrapper{c: r, contextName: cc.Name, k8sClient: nil}, nil
+}
+
+// GetAPIServerStatus returns the api-server status
+func (k *Bootstrapper) GetAPIServerStatus(hostname string, port int) (string, error) {
+	s, err := kverify.APIServerStatus(k.c, k.contextName, hostname, port)
+	if err != nil {
+		return state.Error.String(), err
+	}
+	return s.String(), nil
+}
+
+// LogCommands returns a map of log type to a command which will display that log.
+func (k *Bootstrapper) LogCommands(cfg config.ClusterConfig, o bootstrapper.LogOptions) map[string]string {
+	var k3s strings.Builder
+	k3s.WriteString("sudo journalctl -u kubelet")
+	if o.Lines > 0 {
+		k3s.WriteString(fmt.Sprintf(" -n %d", o.Lines))
+	}
+	if o.Follow {
+		k3s.WriteString(" -f")
+	}
+
+	var dmesg strings.Builder
+	dmesg.WriteString("sudo dmesg -PH -L=never --level warn,err,crit,alert,emerg")
+	if o.Follow {
+		dmesg.WriteString(" --follow")
+	}
+	if o.Lines > 0 {
+		dmesg.WriteString(fmt.Sprintf(" | tail -n %d", o.Lines))
+	}
+
+	describeNodes := fmt.Sprintf("sudo %s describe nodes --kubeconfig=%s", kubectlPath(cfg.KubernetesConfig),
+		path.Join(vmpath.GuestPersistentDir, "kubeconfig"))
+
+	return map[string]string{
+		"k3s":            k3s.String(),
+		"dmesg":          dmesg.String(),
+		"describe nodes": describeNodes,
+	}
+}
+
+// StartCluster starts k3s server on the primary control plane, with the minikube CAs
+func (k *Bootstrapper) StartCluster(cfg config.ClusterConfig) error {
+	start := time.Now()
+	klog.Infof("StartCluster: %+v", cfg)
+	defer func() {
+		klog.Infof("StartCluster complete in %s", time.Since(start))
+	}()
+
+	// Before we start, ensure that no paused components are lurking around
+	if err := k.unpause(cfg); err != nil {
+		klog.Warningf("unpause failed: %v", err)
+	}
+
+	if err := k.installCAs(); err != nil {
+		return errors.Wrap(err, "installing CAs")
+	}
+
+	register.Reg.SetStep(register.PreparingKubernetesControlPlane)
+	out.Step(style.SubStep, "Booting up control plane ...")
+	if err := sysinit.New(k.c).Restart("kubelet"); err != nil {
+		return errors.Wrap(err, "starting k3s")
+	}
+	if err := k.waitForAPIServer(cfg, kconst.DefaultControlPlaneTimeout); err != nil {
+		return errors.Wrap(err, "waiting for apiserver")
+	}
+
+	if err := k.applyCNI(cfg); err != nil {
+		return errors.Wrap(err, "apply cni")
+	}
+	return nil
+}
+
+// installCAs installs the minikube CAs for k3s to sign the certs of the cluster with, so that the minikube certs are trusted
+func (k *Bootstrapper) installCAs() error {
+	// k3s signs the certs of clients with client-ca, and of servers with server-ca
+	cas := [][2]string{{"ca", "server-ca"}, {"ca", "client-ca"}, {"proxy-client-ca", "request-header-ca"}}
+	cmds := []string{fmt.Sprintf("sudo mkdir -p %s", tlsDir)}
+	for _, ca := range cas {
+		for _, ext := range []string{".crt", ".key"} {
+			cmds = append(cmds, fmt.Sprintf("sudo cp %s %s", path.Join(vmpath.GuestKubernetesCertsDir, ca[0]+ext), path.Join(tlsDir, ca[1]+ext)))
+		}
+	}
+	_, err := k.c.RunCmd(exec.Command("/bin/bash", "-c", strings.Join(cmds, " && ")))
+	return err
+}
+
+// waitForAPIServer waits for the apiserver of k3s to be ready, using the kubeconfig of the control plane
+func (k *Bootstrapper) waitForAPIServer(cfg config.ClusterConfig, timeout time.Duration) error {
+	ready := func() error {
+		c := exec.Command("sudo", kubectlPath(cfg.KubernetesConfig), "get", "--raw=/readyz",
+			fmt.Sprintf("--kubeconfig=%s", path.Join(vmpath.GuestPersistentDir, "kubeconfig")))
+		_, err := k.c.RunCmd(c)
+		return err
+	}
+	return retry.Local(ready, timeout)
+}
+
+// applyCNI applies the CNI of the cluster, the bridge CNI if it has none
+func (k *Bootstrapper) applyCNI(cfg config.ClusterConfig) error {
+	cnm, err := cniManager(cfg)
+	if err != nil {
+		return errors.Wrap(err, "cni config")
+	}
+
+	register.Reg.SetStep(register.ConfiguringCNI)
+	out.Step(style.CNI, "Configuring {{.name}} (Container Networking Interface) ...", out.V{"name": cnm.String()})
+
+	if err := cnm.Apply(k.c); err != nil {
+		return errors.Wrap(err, "cni apply")
+	}
+	return nil
+}
+
+// unpause unpauses any Kubernetes backplane components
+func (k *Bootstrapper) unpause(cfg config.ClusterConfig) error {
+	cr, err := cruntime.New(cruntime.Config{Type: cfg.KubernetesConfig.ContainerRuntime, Runner: k.c})
+	if err != nil {
+		return err
+	}
+
+	ids, err := cr.ListContainers(cruntime.ListContainersOptions{State: cruntime.Paused, Namespaces: []string{"kube-system"}})
+	if err != nil {
+		return errors.Wrap(err, "list paused")
+	}
+
+	if len(ids) > 0 {
+		if err := cr.UnpauseContainers(ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// client sets and returns a Kubernetes client to use to speak to a k3s launched apiserver
+func (k *Bootstrapper) client(ip string, port int) (*kubernetes.Clientset, error) {
+	if k.k8sClient != nil {
+		return k.k8sClient, nil
+	}
+
+	cc, err := kapi.ClientConfig(k.contextName)
+	if err != nil {
+		return nil, errors.Wrap(err, "client config")
+	}
+
+	endpoint := fmt.Sprintf("https://%s", net.JoinHostPort(ip, strconv.Itoa(port)))
+	if cc.Host != endpoint {
+		klog.Warningf("Overriding stale ClientConfig host %s with %s", cc.Host, endpoint)
+		cc.Host = endpoint
+	}
+	c, err := kubernetes.NewForConfig(cc)
+	if err == nil {
+		k.k8sClient = c
+	}
+	return c, err
+}
+
+// WaitForNode blocks until the node appears to be healthy
+func (k *Bootstrapper) WaitForNode(cfg config.ClusterConfig, n config.Node, timeout time.Duration) error {
+	start := time.Now()
+	register.Reg.SetStep(register.VerifyingKubernetes)
+	out.Step(style.HealthCheck, "Verifying Kubernetes components...")
+	// regardless if waiting is set or not, make sure k3s is not stopped
+	if st := kverify.ServiceStatus(k.c, "kubelet"); st != state.Running {
+		klog.Warningf("k3s service status was %s, will try to start it", st)
+		if err := sysinit.New(k.c).Start("kubelet"); err != nil {
+			klog.Warningf("Couldn't ensure k3s is started this might cause issues: %v", err)
+		}
+	}
+	cp, err := config.PrimaryControlPlane(&cfg)
+	if err != nil {
+		return errors.Wrap(err, "get primary control plane")
+	}
+	hostname, _, port, err := driver.ControlPlaneEndpoint(&cfg, &cp, cfg.Driver)
+	if err != nil {
+		return errors.Wrap(err, "get control plane endpoint")
+	}
+
+	client, err := k.client(hostname, port)
+	if err != nil {
+		return errors.Wrap(err, "kubernetes client")
+	}
+
+	if !kverify.ShouldWait(cfg.VerifyComponents) {
+		klog.Infof("skip waiting for components based on config.")
+		return errors.Wrap(kverify.NodePressure(client), "node pressure")
+	}
+
+	if cfg.VerifyComponents[kverify.NodeReadyKey] {
+		name := bsutil.KubeNodeName(cfg, n)
+		if err := kverify.WaitNodeCondition(client, name, core.NodeReady, timeout); err != nil {
+			return errors.Wrap(err, "waiting for node to be ready")
+		}
+	}
+
+	if n.ControlPlane {
+		// the version reported by k3s has a +k3s suffix, so only the health of the apiserver is checked
+		if cfg.VerifyComponents[kverify.APIServerWaitKey] {
+			if _, err := kverify.WaitForAPIServerStatus(k.c, timeout, k.contextName, hostname, port); err != nil {
+				return errors.Wrap(err, "wait for healthy API server")
+			}
+		}
+
+		if cfg.VerifyComponents[kverify.DefaultSAWaitKey] {
+			if err := kverify.WaitForDefaultSA(client, timeout); err != nil {
+				return errors.Wrap(err, "waiting for default service account")
+			}
+		}
+	}
+
+	if cfg.VerifyComponents[kverify.KubeletKey] {
+		if err := kverify.WaitForService(k.c, "kubelet", timeout); err != nil {
+			return errors.Wrap(err, "waiting for k3s")
+		}
+	}
+
+	klog.Infof("duration metric: took %s to wait for : %+v ...", time.Since(start), cfg.VerifyComponents)
+
+	if err := kverify.NodePressure(client); err != nil {
+		return errors.Wrap(err, "node pressure")
+	}
+	return nil
+}
+
+// JoinCluster joins a worker to the cluster with k3s agent, joinCmd being the token of the cluster
+func (k *Bootstrapper) JoinCluster(cc config.ClusterConfig, n config.Node, joinCmd string) error {
+	if n.ControlPlane {
+		return fmt.Errorf("the k3s bootstrapper does not support additional control planes")
+	}
+
+	r, err := k.runtime(cc.KubernetesConfig)
+	if err != nil {
+		return err
+	}
+	agentCfg, err := generateConfig(cc, n, r, joinCmd)
+	if err != nil {
+		return errors.Wrap(err, "generating k3s config")
+	}
+	if err := bsutil.CopyFiles(k.c, []assets.CopyableFile{assets.NewMemoryAssetTarget(agentCfg, configFile, "0600")}); err != nil {
+		return errors.Wrap(err, "copy")
+	}
+
+	if err := sysinit.New(k.c).Restart("kubelet"); err != nil {
+		return errors.Wrap(err, "starting k3s agent")
+	}
+	return nil
+}
+
+// GenerateToken returns the token of the cluster, which k3s agents join it with
+func (k *Bootstrapper) GenerateToken(cc config.ClusterConfig) (string, error) {
+	rr, err := k.c.RunCmd(exec.Command("sudo", "cat", path.Join(DataDir, "server", "node-token")))
+	if err != nil {
+		return "", errors.Wrap(err, "reading node token")
+	}
+	return strings.TrimSpace(rr.Stdout.String()), nil
+}
+
+// DeleteCluster stops k3s, and removes its state
+func (k *Bootstrapper) DeleteCluster(k8s config.KubernetesConfig) error {
+	cr, err := k.runtime(k8s)
+	if err != nil {
+		return err
+	}
+
+	kubeadm.StopKubernetes(k.c, cr)
+
+	if _, err := k.c.RunCmd(exec.Command("sudo", "rm", "-rf", DataDir, configDir)); err != nil {
+		return errors.Wrap(err, "removing k3s state")
+	}
+	return nil
+}
+
+// SetupCerts sets up certificates within the cluster.
+func (k *Bootstrapper) SetupCerts(k8s config.ClusterConfig, n config.Node) error {
+	return bootstrapper.SetupCerts(k.c, k8s, n)
+}
+
+// RenewCerts renews the certs k3s generated with k3s certificate rotate, and restarts k3s.
+// k3s keeps its CAs in its datastore, so they cannot be rotated.
+func (k *Bootstrapper) RenewCerts(cfg config.ClusterConfig, n config.Node, ca bool) error {
+	if ca {
+		return fmt.Errorf("the k3s bootstrapper cannot rotate the CA of an existing cluster")
+	}
+
+	sm := sysinit.New(k.c)
+	if !n.ControlPlane {
+		return errors.Wrap(sm.Restart("kubelet"), "restarting k3s agent")
+	}
+
+	if err := sm.Stop("kubelet"); err != nil {
+		return errors.Wrap(err, "stopping k3s")
+	}
+	c := exec.Command("sudo", k3sPath(cfg.KubernetesConfig), "certificate", "rotate", "--data-dir", DataDir)
+	if _, err := k.c.RunCmd(c); err != nil {
+		// older releases of k3s only renew the certs expiring soon, when starting
+		klog.Warningf("k3s certificate rotate failed, continuing anyway: %v", err)
+	}
+	if err := sm.Start("kubelet"); err != nil {
+		return errors.Wrap(err, "starting k3s")
+	}
+	return nil
+}
+
+// UpdateCluster updates the control plane with cluster-level info.
+func (k *Bootstrapper) UpdateCluster(cfg config.ClusterConfig) error {
+	r, err := k.runtime(cfg.KubernetesConfig)
+	if err != nil {
+		return err
+	}
+
+	if err := k.loadImages(cfg); err != nil {
+		out.FailureT("Unable to load cached images: {{.error}}", out.V{"error": err})
+	}
+
+	cp, err := config.PrimaryControlPlane(&cfg)
+	if err != nil {
+		return errors.Wrap(err, "getting control plane")
+	}
+
+	if err := k.UpdateNode(cfg, cp, r); err != nil {
+		return errors.Wrap(err, "updating control plane")
+	}
+	return nil
+}
+
+// UpdateNode installs k3s on a node, with its config on control planes. Workers get theirs once they join the cluster.
+func (k *Bootstrapper) UpdateNode(cfg config.ClusterConfig, n config.Node, r cruntime.Manager) error {
+	service, err := newService(cfg, n)
+	if err != nil {
+		return errors.Wrap(err, "generating k3s service")
+	}
+
+	sm := sysinit.New(k.c)
+
+	if err := transferBinaries(cfg.KubernetesConfig, k.c, cfg.BinaryMirror); err != nil {
+		return errors.Wrap(err, "downloading binaries")
+	}
+
+	// the kubeadm drop-in would run the kubelet instead of k3s
+	if _, err := k.c.RunCmd(exec.Command("sudo", "rm", "-f", bsutil.KubeletSystemdConfFile)); err != nil {
+		return errors.Wrap(err, "removing kubeadm drop-in")
+	}
+
+	files := []assets.CopyableFile{
+		assets.NewMemoryAssetTarget(service, bsutil.KubeletServiceFile, "0644"),
+	}
+
+	if n.ControlPlane {
+		serverCfg, err := generateConfig(cfg, n, r, "")
+		if err != nil {
+			return errors.Wrap(err, "generating k3s config")
+		}
+		klog.Infof("k3s config:\n%s", serverCfg)
+		files = append(files, assets.NewMemoryAssetTarget(serverCfg, configFile, "0600"))
+	}
+
+	// Installs compatibility shims for non-systemd environments
+	shims, err := sm.GenerateInitShim("kubelet", k3sPath(cfg.KubernetesConfig), bsutil.KubeletServiceFile)
+	if err != nil {
+		return errors.Wrap(err, "shim")
+	}
+	files = append(files, shims...)
+
+	if err := bsutil.CopyFiles(k.c, files); err != nil {
+		return errors.Wrap(err, "copy")
+	}
+
+	cp, err := config.PrimaryControlPlane(&cfg)
+	if err != nil {
+		return errors.Wrap(err, "control plane")
+	}
+
+	if err := machine.AddHostAlias(k.c, constants.ControlPlaneAlias, net.ParseIP(cp.IP)); err != nil {
+		return errors.Wrap(err, "host alias")
+	}
+
+	return nil
+}
+
+// PrepareUpgrade installs the k3s binary and images of the Kubernetes version of cfg
+func (k *Bootstrapper) PrepareUpgrade(cfg config.ClusterConfig, n config.Node, r cruntime.Manager) error {
+	if err := transferBinaries(cfg.KubernetesConfig, k.c, cfg.BinaryMirror); err != nil {
+		return errors.Wrap(err, "transferring binaries")
+	}
+	return errors.Wrap(k.loadImages(cfg), "loading cached images")
+}
+
+// UpgradeNode points the k3s service at the binary of the Kubernetes version of cfg. k3s migrates the cluster itself once it is restarted.
+func (k *Bootstrapper) UpgradeNode(cfg config.ClusterConfig, n config.Node, r cruntime.Manager) error {
+	return k.UpdateNode(cfg, n, r)
+}
+
+// loadImages loads the cached images k3s needs, if the cluster uses the image cache
+func (k *Bootstrapper) loadImages(cfg config.ClusterConfig) error {
+	if !cfg.KubernetesConfig.ShouldLoadCachedImages {
+		return nil
+	}
+	imgs, err := images.K3s(cfg.KubernetesConfig.ImageRepository, cfg.KubernetesConfig.KubernetesVersion)
+	if err != nil {
+		return errors.Wrap(err, "k3s images")
+	}
+	return machine.LoadCachedImages(&cfg, k.c, imgs, detect.ImageCacheDir(), false)
+}
+
+// runtime returns the container runtime of the node
+func (k *Bootstrapper) runtime(k8s config.KubernetesConfig) (cruntime.Manager, error) {
+	version, err := util.ParseKubernetesVersion(k8s.KubernetesVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing Kubernetes version")
+	}
+	cr, err := cruntime.New(cruntime.Config{Type: k8s.ContainerRuntime, Runner: k.c, Socket: k8s.CRISocket, KubernetesVersion: version})
+	if err != nil {
+		return nil, errors.Wrap(err, "runtime")
+	}
+	return cr, nil
+}
@@ -82,7 +82,7 @@ func NewBootstrapper(api libmachine.API, cc config.ClusterConfig, r command.Runn
 
 // GetAPIServerStatus returns the api-server status
 func (k *Bootstrapper) GetAPIServerStatus(hostname string, port int) (string, error) {
-	s, err := kverify.APIServerStatus(k.c, hostname, port)
+	s, err := kverify.APIServerStatus(k.c, k.contextName, hostname, port)
 	if err != nil {
 		return state.Error.String(), err
 	}
@@ -596,7 +596,7 @@ func (k *Bootstrapper) needsReconfigure(conf string, hostname string, port int,
 
 	// cruntime.Enable() may restart kube-apiserver but does not wait for it to return back
 	apiStatusTimeout := 3000 * time.Millisecond
-	st, err := kverify.WaitForAPIServerStatus(k.c, apiStatusTimeout, hostname, port)
+	st, err := kverify.WaitForAPIServerStatus(k.c, apiStatusTimeout, k.contextName, hostname, port)
 	if err != nil {
 		klog.Infof("needs reconfigure: apiserver error: %v", err)
 		return true
@@ -897,6 +897,84 @@ func (k *Bootstrapper) SetupCerts(k8s config.ClusterConfig, n config.Node) error
 	return bootstrapper.SetupCerts(k.c, k8s, n)
 }
 
+// renewableCerts are the certs and kubeconfigs kubeadm generates itself on control planes, the apiserver cert being generated by minikube
+var renewableCerts = []string{
+	"apiserver-etcd-client", "apiserver-kubelet-client", "front-proxy-client",
+	"etcd-healthcheck-client", "etcd-peer", "etcd-server",
+	"admin.conf", "controller-manager.conf", "scheduler.conf",
+}
+
+// RenewCerts renews the certs kubeadm generated on a control plane with kubeadm certs renew, and restarts the control plane.
+// If the minikube CA was rotated, the kubeconfigs and the apiserver-kubelet-client cert are generated again, as they are signed by the CA.
+// Workers only use the CA, their kubelet client cert being rotated by the kubelet itself.
+func (k *Bootstrapper) RenewCerts(cfg config.ClusterConfig, n config.Node, ca bool) error {
+	if !n.ControlPlane {
+		return nil
+	}
+
+	version, err := util.ParseKubernetesVersion(cfg.KubernetesConfig.KubernetesVersion)
+	if err != nil {
+		return errors.Wrap(err, "parsing Kubernetes version")
+	}
+	ka := bsutil.InvokeKubeadm(cfg.KubernetesConfig.KubernetesVersion)
+	conf := bsutil.KubeadmYamlPath
+
+	if ca {
+		stale := []string{
+			"/etc/kubernetes/admin.conf",
+			"/etc/kubernetes/super-admin.conf",
+			"/etc/kubernetes/kubelet.conf",
+			"/etc/kubernetes/controller-manager.conf",
+			"/etc/kubernetes/scheduler.conf",
+			path.Join(vmpath.GuestKubernetesCertsDir, "apiserver-kubelet-client.crt"),
+			path.Join(vmpath.GuestKubernetesCertsDir, "apiserver-kubelet-client.key"),
+		}
+		if _, err := k.c.RunCmd(exec.Command("sudo", append([]string{"rm", "-f"}, stale...)...)); err != nil {
+			return errors.Wrap(err, "removing certs signed by the previous CA")
+		}
+		for _, phase := range []string{"certs", "kubeconfig"} {
+			c := fmt.Sprintf("%s init phase %s all --config %s", ka, phase, conf)
+			if _, err := k.c.RunCmd(exec.Command("/bin/bash", "-c", c)); err != nil {
+				return errors.Wrapf(err, "kubeadm init phase %s", phase)
+			}
+		}
+	}
+
+	certs := "certs"
+	if version.LT(semver.MustParse("1.20.0")) {
+		certs = "alpha certs"
+	}
+	for _, name := range renewableCerts {
+		c := fmt.Sprintf("%s %s renew %s --config %s", ka, certs, name, conf)
+		if _, err := k.c.RunCmd(exec.Command("/bin/bash", "-c", c)); err != nil {
+			return errors.Wrapf(err, "renewing %s", name)
+		}
+	}
+
+	if err := sysinit.New(k.c).Restart("kubelet"); err != nil {
+		return errors.Wrap(err, "restarting kubelet")
+	}
+
+	// the static pods do not reload their certs, so stop them for the kubelet to start them again
+	cr, err := cruntime.New(cruntime.Config{Type: cfg.KubernetesConfig.ContainerRuntime, Runner: k.c, Socket: cfg.KubernetesConfig.CRISocket, KubernetesVersion: version})
+	if err != nil {
+		return errors.Wrap(err, "runtime")
+	}
+	for _, name := range []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler", "etcd"} {
+		ids, err := cr.ListContainers(cruntime.ListContainersOptions{Name: name, Namespaces: []string{meta.NamespaceSystem}})
+		if err != nil {
+			return errors.Wrapf(err, "listing %s containers", name)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		if err := cr.StopContainers(ids); err != nil {
+			return errors.Wrapf(err, "stopping %s", name)
+		}
+	}
+	return nil
+}
+
 // UpdateCluster updates the control plane with cluster-level info.
 func (k *Bootstrapper) UpdateCluster(cfg config.ClusterConfig) error {
 	images, err := images.Kubeadm(cfg.KubernetesConfig.ImageRepository, cfg.KubernetesConfig.KubernetesVersion)
@@ -999,6 +1077,67 @@ func (k *Bootstrapper) UpdateNode(cfg config.ClusterConfig, n config.Node, r cru
 	return nil
 }
 
+// PrepareUpgrade installs the binaries and images of the Kubernetes version of cfg, and on control planes, checks the upgrade with kubeadm upgrade plan
+func (k *Bootstrapper) PrepareUpgrade(cfg config.ClusterConfig, n config.Node, r cruntime.Manager) error {
+	if err := bsutil.TransferBinaries(cfg.KubernetesConfig, k.c, sysinit.New(k.c), cfg.BinaryMirror); err != nil {
+		return errors.Wrap(err, "transferring binaries")
+	}
+
+	if err := r.Preload(cfg); err != nil {
+		klog.Infof("preload failed, will try to load cached images: %v", err)
+	}
+	if cfg.KubernetesConfig.ShouldLoadCachedImages {
+		images, err := images.Kubeadm(cfg.KubernetesConfig.ImageRepository, cfg.KubernetesConfig.KubernetesVersion)
+		if err != nil {
+			return errors.Wrap(err, "kubeadm images")
+		}
+		if err := machine.LoadCachedImages(&cfg, k.c, images, detect.ImageCacheDir(), false); err != nil {
+			return errors.Wrap(err, "loading cached images")
+		}
+	}
+
+	if !n.ControlPlane {
+		return nil
+	}
+	c := exec.Command("/bin/bash", "-c", fmt.Sprintf("%s upgrade plan %s", bsutil.InvokeKubeadm(cfg.KubernetesConfig.KubernetesVersion), cfg.KubernetesConfig.KubernetesVersion))
+	rr, err := k.c.RunCmd(c)
+	if err != nil {
+		return errors.Wrap(err, "kubeadm upgrade plan")
+	}
+	klog.Infof("kubeadm upgrade plan:\n%s", rr.Stdout.String())
+	return nil
+}
+
+// UpgradeNode runs kubeadm upgrade apply on the primary control plane, or kubeadm upgrade node on the other nodes, then installs the new kubelet config
+func (k *Bootstrapper) UpgradeNode(cfg config.ClusterConfig, n config.Node, r cruntime.Manager) error {
+	ka := bsutil.InvokeKubeadm(cfg.KubernetesConfig.KubernetesVersion)
+	cp, err := config.PrimaryControlPlane(&cfg)
+	if err != nil {
+		return errors.Wrap(err, "control plane")
+	}
+
+	// minikube manages the certificates itself, so leave them alone
+	cmd := fmt.Sprintf("%s upgrade node --certificate-renewal=false", ka)
+	if n.Name == cp.Name {
+		cmd = fmt.Sprintf("%s upgrade apply %s --yes --certificate-renewal=false", ka, cfg.KubernetesConfig.KubernetesVersion)
+	}
+	if _, err := k.c.RunCmd(exec.Command("/bin/bash", "-c", cmd)); err != nil {
+		return errors.Wrap(err, "kubeadm upgrade")
+	}
+
+	if err := k.UpdateNode(cfg, n, r); err != nil {
+		return errors.Wrap(err, "updating node")
+	}
+	if !n.ControlPlane {
+		return nil
+	}
+	// the cluster was upgraded with this config, so that the next start does not reconfigure it
+	if _, err := k.c.RunCmd(exec.Command("sudo", "cp", bsutil.KubeadmYamlPath+".new", bsutil.KubeadmYamlPath)); err != nil {
+		return errors.Wrap(err, "cp")
+	}
+	return nil
+}
+
 // kubectlPath returns the path to the kubelet
 func kubectlPath(cfg config.ClusterConfig) string {
 	return path.Join(vmpath.GuestPersistentDir, "binaries", cfg.KubernetesConfig.KubernetesVersion, "kubectl")
@@ -0,0 +1,245 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"crypto"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+
+	"k8s.io/minikube/pkg/drivers/kic"
+	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/constants"
+	"k8s.io/minikube/pkg/minikube/detect"
+	"k8s.io/minikube/pkg/minikube/download"
+	"k8s.io/minikube/pkg/minikube/driver"
+	"k8s.io/minikube/pkg/minikube/image"
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"k8s.io/minikube/pkg/version"
+)
+
+// Options are the parameters of the clusters a bundle starts
+type Options struct {
+	KubernetesVersion string
+	Driver            string
+	ContainerRuntime  string
+	Bootstrapper      string
+	BinaryMirror      string
+	Addons            []string
+}
+
+// Create downloads the files needed to start a cluster with opts to the minikube home, and writes their bundle to output.
+// The manifest is signed with the private key at signKey, if not empty.
+func Create(opts Options, output string, signKey string) (*Manifest, error) {
+	var key crypto.Signer
+	if signKey != "" {
+		var err error
+		if key, err = loadSigningKey(signKey); err != nil {
+			return nil, err
+		}
+	}
+	files, images, addonImages, err := fetch(opts)
+	if err != nil {
+		return nil, err
+	}
+	m, err := newManifest(opts, images, addonImages, files)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(output), filepath.Base(output)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(m, tmp, key); err != nil {
+		tmp.Close()
+		return nil, errors.Wrapf(err, "writing %s", output)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	return m, os.Rename(tmp.Name(), output)
+}
+
+// Import verifies the bundle at src and adds its files to the minikube home
+func Import(src string) (*Manifest, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f, localpath.MiniPath())
+}
+
+// Verify verifies the bundle at src, without importing it
+func Verify(src string) (*Manifest, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f, "")
+}
+
+// fetch downloads the files minikube start downloads with opts, returning their paths along with their kind
+func fetch(opts Options) (map[string]string, []string, []string, error) {
+	k8s, cr, drv := opts.KubernetesVersion, opts.ContainerRuntime, opts.Driver
+	files := map[string]string{}
+
+	var images []string
+	// k3s runs from its own binary and images, so the preload is of no use to it
+	preload := opts.Bootstrapper != bootstrapper.K3s && download.PreloadExists(k8s, cr, drv, true)
+	if preload {
+		klog.Infof("bundling the preload of %s with %s", k8s, cr)
+		if err := download.Preload(k8s, cr, drv); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "preload")
+		}
+		files[download.TarballPath(k8s, cr)] = KindPreload
+		if _, err := os.Stat(download.PreloadChecksumPath(k8s, cr)); err == nil {
+			files[download.PreloadChecksumPath(k8s, cr)] = KindPreload
+		}
+	} else {
+		var err error
+		if images, err = bootstrapper.GetCachedImageList("", k8s, opts.Bootstrapper); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "cached images list")
+		}
+	}
+
+	addonImages, err := imagesOfAddons(opts.Addons)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	all := append(append([]string{}, images...), addonImages...)
+	if err := image.SaveToDir(all, detect.ImageCacheDir(), false); err != nil {
+		return nil, nil, nil, err
+	}
+	for _, img := range all {
+		p := image.CachePath(detect.ImageCacheDir(), img)
+		// SaveToDir only warns about the images which don't exist
+		if _, err := os.Stat(p); err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "caching %s", img)
+		}
+		files[p] = KindImage
+	}
+
+	for _, bin := range bootstrapper.GetCachedBinaryList(opts.Bootstrapper) {
+		// the preload has the binaries
+		if preload && contains(constants.KubernetesReleaseBinaries, bin) {
+			continue
+		}
+		p, err := download.Binary(bin, k8s, "linux", detect.EffectiveArch(), opts.BinaryMirror)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "caching binary %s", bin)
+		}
+		files[p] = KindBinary
+	}
+	// for minikube kubectl
+	kubectl := "kubectl"
+	if runtime.GOOS == "windows" {
+		kubectl = "kubectl.exe"
+	}
+	p, err := download.Binary(kubectl, k8s, runtime.GOOS, detect.EffectiveArch(), opts.BinaryMirror)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "caching kubectl")
+	}
+	files[p] = KindBinary
+
+	// the VM drivers run from their own executable, which minikube start downloads
+	if drv == driver.KVM2 || drv == driver.HyperKit {
+		p, err := fetchDriver(drv)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "caching the %s driver", drv)
+		}
+		files[p] = KindDriver
+	}
+
+	switch {
+	case driver.IsKIC(drv):
+		if err := download.ImageToCache(kic.BaseImage); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "caching the base image")
+		}
+		files[download.ImagePathInCache(kic.BaseImage)] = KindBaseImage
+	case driver.IsVM(drv) && !driver.IsSSH(drv):
+		url, err := download.ISO(download.DefaultISOURLs(), false)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "caching the ISO")
+		}
+		p, err := download.ISOPath(url)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		files[p] = KindISO
+	}
+	return files, images, addonImages, nil
+}
+
+// fetchDriver downloads the executable of a VM driver for the host into the bin dir of the minikube home, returning its path
+func fetchDriver(drv string) (string, error) {
+	v, err := version.GetSemverVersion()
+	if err != nil {
+		return "", errors.Wrap(err, "parsing minikube version")
+	}
+	executable := driverPrefix + drv
+	p := localpath.MakeMiniPath("bin", executable)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", err
+	}
+	// the driver of the PATH may be of another version, so the one of this minikube version is always bundled
+	if err := download.Driver(executable, p, v); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// imagesOfAddons returns the default images of addons
+func imagesOfAddons(addons []string) ([]string, error) {
+	seen := map[string]bool{}
+	images := []string{}
+	for _, name := range addons {
+		addon, ok := assets.Addons[name]
+		if !ok {
+			return nil, errors.Errorf("unknown addon %q", name)
+		}
+		for key, img := range addon.Images {
+			if reg := addon.Registries[key]; reg != "" {
+				img = reg + "/" + img
+			}
+			if !seen[img] {
+				seen[img] = true
+				images = append(images, img)
+			}
+		}
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
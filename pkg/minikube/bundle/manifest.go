@@ -0,0 +1,415 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"archive/tar"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+
+	"k8s.io/minikube/pkg/minikube/detect"
+	"k8s.io/minikube/pkg/minikube/download"
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"k8s.io/minikube/pkg/version"
+)
+
+const (
+	// formatVersion is the version of the layout of the bundles, checked on import
+	formatVersion = 1
+	// manifestName is the first entry of a bundle
+	manifestName = "manifest.json"
+	// signatureName is the entry following the manifest in a signed bundle, a detached signature of its SHA-256 digest
+	signatureName = "manifest.json.sig"
+	// filesDir is the directory of the archive holding the files, by their path in the minikube home
+	filesDir = "files"
+	// driverPrefix is the prefix of the executables of the VM drivers, installed in the bin dir of the minikube home
+	driverPrefix = "docker-machine-driver-"
+)
+
+// Kinds of the files of a bundle
+const (
+	KindPreload   = "preload"
+	KindISO       = "iso"
+	KindBaseImage = "kic-base-image"
+	KindBinary    = "binary"
+	KindDriver    = "driver"
+	KindImage     = "image"
+)
+
+// File is a file of a bundle
+type File struct {
+	// Path is the path of the file in the minikube home, slash separated
+	Path   string
+	Kind   string
+	Size   int64
+	SHA256 string
+	// Origin is how the file was verified when it was downloaded. Only its URL is recorded in the download lock file on import:
+	// the file is verified against SHA256 if the manifest is signed by the download public key, or recorded as unverified.
+	Origin *download.LockEntry `json:",omitempty"`
+}
+
+// Manifest lists the content of a bundle, which is the first entry of its archive
+type Manifest struct {
+	Version           int
+	MinikubeVersion   string
+	Created           time.Time
+	KubernetesVersion string
+	Driver            string
+	ContainerRuntime  string
+	OS                string
+	Arch              string
+	Addons            []string `json:",omitempty"`
+	// Images are the images of Kubernetes, if there is no preload for the runtime and driver
+	Images []string `json:",omitempty"`
+	// AddonImages are the images of the addons, added to the images minikube cache loads on start
+	AddonImages []string `json:",omitempty"`
+	Files       []File
+	// Signed is true if the manifest was read along with a signature matching the download public key
+	Signed bool `json:"-"`
+}
+
+// Size returns the size of the files of the bundle
+func (m *Manifest) Size() int64 {
+	var size int64
+	for _, f := range m.Files {
+		size += f.Size
+	}
+	return size
+}
+
+// newManifest returns the manifest of files, stored in the minikube home
+func newManifest(opts Options, images, addonImages []string, files map[string]string) (*Manifest, error) {
+	m := &Manifest{
+		Version:           formatVersion,
+		MinikubeVersion:   version.GetVersion(),
+		Created:           time.Now().UTC(),
+		KubernetesVersion: opts.KubernetesVersion,
+		Driver:            opts.Driver,
+		ContainerRuntime:  opts.ContainerRuntime,
+		OS:                runtime.GOOS,
+		Arch:              detect.EffectiveArch(),
+		Addons:            opts.Addons,
+		Images:            images,
+		AddonImages:       addonImages,
+	}
+	lock, err := download.ReadLock()
+	if err != nil {
+		return nil, err
+	}
+	for p, kind := range files {
+		rel, err := filepath.Rel(localpath.MiniPath(), p)
+		if err != nil {
+			return nil, err
+		}
+		f := File{Path: filepath.ToSlash(rel), Kind: kind}
+		if !validPath(f.Path) {
+			return nil, errors.Errorf("%s is not in the cache or the drivers of %s", p, localpath.MiniPath())
+		}
+		if f.Size, f.SHA256, err = hashFile(p); err != nil {
+			return nil, err
+		}
+		if e, ok := lock[f.Path]; ok && e.SHA256 == f.SHA256 {
+			f.Origin = &e
+		}
+		m.Files = append(m.Files, f)
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	return m, nil
+}
+
+// validPath returns whether p is a path within the cache of the minikube home, or a VM driver in its bin dir
+func validPath(p string) bool {
+	if path.Clean(p) != p || strings.Contains(p, "..") || strings.Contains(p, ":") {
+		return false
+	}
+	return strings.HasPrefix(p, "cache/") || path.Dir(p) == "bin" && strings.HasPrefix(path.Base(p), driverPrefix)
+}
+
+func hashFile(p string) (int64, string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", errors.Wrapf(err, "hashing %s", p)
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// write writes the bundle of the files of m to w, with a signature of the manifest if key is not nil
+func write(m *Manifest, w io.Writer, key crypto.Signer) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(w)
+	if err := writeEntry(tw, manifestName, data, m.Created); err != nil {
+		return err
+	}
+	if key != nil {
+		digest := sha256.Sum256(data)
+		sig, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err != nil {
+			return errors.Wrap(err, "signing the manifest")
+		}
+		if err := writeEntry(tw, signatureName, sig, m.Created); err != nil {
+			return err
+		}
+	}
+	for _, f := range m.Files {
+		if err := writeFile(tw, f); err != nil {
+			return errors.Wrapf(err, "adding %s", f.Path)
+		}
+	}
+	return tw.Close()
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modTime}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// loadSigningKey reads the PEM encoded ECDSA or RSA private key signing the manifests of bundles
+func loadSigningKey(path string) (crypto.Signer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading signing key")
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.Errorf("%s does not contain a PEM encoded private key", path)
+	}
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case *rsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, errors.Errorf("unsupported private key type %T in %s, must be ECDSA or RSA", key, path)
+	}
+}
+
+func writeFile(tw *tar.Writer, f File) error {
+	src, err := os.Open(filepath.Join(localpath.MiniPath(), filepath.FromSlash(f.Path)))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	// the file must not have changed since it was hashed
+	if info.Size() != f.Size {
+		return errors.Errorf("size changed from %d to %d", f.Size, info.Size())
+	}
+	hdr := &tar.Header{Name: path.Join(filesDir, f.Path), Mode: int64(info.Mode().Perm()), Size: f.Size, ModTime: info.ModTime()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, src)
+	return err
+}
+
+// Read reads the bundle of r, verifying its files against its manifest. The files are written to the minikube home dir, unless empty.
+func Read(r io.Reader, dir string) (*Manifest, error) {
+	tr := tar.NewReader(r)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the manifest")
+	}
+	if hdr.Name != manifestName {
+		return nil, errors.Errorf("not a minikube bundle: the first entry is %s rather than %s", hdr.Name, manifestName)
+	}
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the manifest")
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, errors.Wrap(err, "parsing the manifest")
+	}
+	if m.Version != formatVersion {
+		return nil, errors.Errorf("unsupported bundle version %d, expected %d", m.Version, formatVersion)
+	}
+	if dir != "" {
+		if err := m.Check(); err != nil {
+			return nil, err
+		}
+	}
+
+	pending := map[string]File{}
+	for _, f := range m.Files {
+		if !validPath(f.Path) {
+			return nil, errors.Errorf("invalid path in the manifest: %s", f.Path)
+		}
+		pending[path.Join(filesDir, f.Path)] = f
+	}
+	for first := true; ; first = false {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Name == signatureName && first {
+			if m.Signed, err = readSignature(tr, data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		f, ok := pending[hdr.Name]
+		if !ok {
+			return nil, errors.Errorf("%s is not listed in the manifest", hdr.Name)
+		}
+		delete(pending, hdr.Name)
+		if err := readFile(tr, f, dir, m.Signed); err != nil {
+			return nil, errors.Wrapf(err, "reading %s", f.Path)
+		}
+	}
+	if len(pending) > 0 {
+		var missing []string
+		for _, f := range pending {
+			missing = append(missing, f.Path)
+		}
+		sort.Strings(missing)
+		return nil, errors.Errorf("missing from the bundle: %s", strings.Join(missing, ", "))
+	}
+	return m, nil
+}
+
+// readSignature verifies the signature of the manifest data with the download public key, returning false if none is configured
+func readSignature(r io.Reader, data []byte) (bool, error) {
+	sig, err := io.ReadAll(r)
+	if err != nil {
+		return false, errors.Wrap(err, "reading the signature of the manifest")
+	}
+	signed, err := download.CheckSignature(data, sig)
+	if err != nil {
+		return false, errors.Wrap(err, "verifying the signature of the manifest")
+	}
+	if !signed {
+		klog.Warningf("no download public key is configured, the signature of the manifest is not verified")
+	}
+	return signed, nil
+}
+
+// readFile verifies a file of the bundle, writing it to dir if not empty.
+// The file is recorded as verified in the download lock file if the manifest is signed.
+func readFile(r io.Reader, f File, dir string, signed bool) error {
+	var w io.Writer = io.Discard
+	var tmp *os.File
+	dst := ""
+	if dir != "" {
+		dst = filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		var err error
+		if tmp, err = os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".*.tmp"); err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		w = tmp
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), r)
+	if err != nil {
+		return err
+	}
+	if n != f.Size {
+		return errors.Errorf("size is %d rather than %d", n, f.Size)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != f.SHA256 {
+		return errors.Errorf("sha256 is %s rather than %s", sum, f.SHA256)
+	}
+	if tmp == nil {
+		return nil
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if f.Kind == KindBinary || f.Kind == KindDriver {
+		mode = 0755
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	klog.Infof("imported %s", dst)
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return err
+	}
+	if f.Origin == nil {
+		return nil
+	}
+	// the bundle could claim any verification status, so the file is only verified by the signature of the manifest
+	return download.RecordLock(dst, download.LockEntry{URL: f.Origin.URL, SHA256: f.SHA256, Verified: signed, Signed: signed, Imported: true, Time: time.Now()})
+}
+
+// Check returns an error if the bundle was created for another platform
+func (m *Manifest) Check() error {
+	if m.OS != runtime.GOOS || m.Arch != detect.EffectiveArch() {
+		return errors.Errorf("the bundle is for %s/%s, not %s/%s", m.OS, m.Arch, runtime.GOOS, detect.EffectiveArch())
+	}
+	return nil
+}
+
+// String returns a summary of the bundle
+func (m *Manifest) String() string {
+	return fmt.Sprintf("Kubernetes %s with %s on %s (%s/%s)", m.KubernetesVersion, m.ContainerRuntime, m.Driver, m.OS, m.Arch)
+}
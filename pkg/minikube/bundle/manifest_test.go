@@ -0,0 +1,304 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/download"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+// testBundle writes a bundle of two files of the minikube home
+func testBundle(t *testing.T) (*Manifest, []byte) {
+	t.Helper()
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+	files := map[string]string{
+		localpath.MakeMiniPath("cache", "preloaded-tarball", "preload.tar.lz4"): KindPreload,
+		localpath.MakeMiniPath("cache", "linux", "amd64", "v1.24.1", "kubelet"): KindBinary,
+	}
+	for p := range files {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(filepath.Base(p)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := newManifest(Options{KubernetesVersion: "v1.24.1", Driver: "docker", ContainerRuntime: "containerd"}, nil, nil, files)
+	if err != nil {
+		t.Fatalf("newManifest: %v", err)
+	}
+	var b bytes.Buffer
+	if err := write(m, &b, nil); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return m, b.Bytes()
+}
+
+func TestReadBundle(t *testing.T) {
+	m, data := testBundle(t)
+	if len(m.Files) != 2 || m.Files[0].Path != "cache/linux/amd64/v1.24.1/kubelet" {
+		t.Fatalf("unexpected files in the manifest: %+v", m.Files)
+	}
+
+	if _, err := Read(bytes.NewReader(data), ""); err != nil {
+		t.Errorf("verifying the bundle: %v", err)
+	}
+
+	dir := t.TempDir()
+	got, err := Read(bytes.NewReader(data), dir)
+	if err != nil {
+		t.Fatalf("reading the bundle: %v", err)
+	}
+	if got.KubernetesVersion != "v1.24.1" || len(got.Files) != 2 {
+		t.Errorf("Read() returned the manifest %+v", got)
+	}
+	kubelet := filepath.Join(dir, "cache", "linux", "amd64", "v1.24.1", "kubelet")
+	content, err := os.ReadFile(kubelet)
+	if err != nil || string(content) != "kubelet" {
+		t.Errorf("imported kubelet = %q, %v", content, err)
+	}
+	if info, err := os.Stat(kubelet); err == nil && info.Mode().Perm()&0100 == 0 {
+		t.Errorf("imported kubelet is not executable: %s", info.Mode())
+	}
+}
+
+func TestReadBundleOrigin(t *testing.T) {
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+	kubelet := localpath.MakeMiniPath("cache", "linux", "amd64", "v1.24.1", "kubelet")
+	if err := os.MkdirAll(filepath.Dir(kubelet), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(kubelet, []byte("kubelet"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, sum, err := hashFile(kubelet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin := download.LockEntry{URL: "https://example.com/kubelet", SHA256: sum, Verified: true, Signed: true}
+	if err := download.RecordLock(kubelet, origin); err != nil {
+		t.Fatal(err)
+	}
+	m, err := newManifest(Options{KubernetesVersion: "v1.24.1"}, nil, nil, map[string]string{kubelet: KindBinary})
+	if err != nil {
+		t.Fatalf("newManifest: %v", err)
+	}
+	if m.Files[0].Origin == nil || m.Files[0].Origin.URL != origin.URL {
+		t.Fatalf("manifest file %+v does not record its origin", m.Files[0])
+	}
+	var b bytes.Buffer
+	if err := write(m, &b, nil); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+	if _, err := Read(&b, localpath.MiniPath()); err != nil {
+		t.Fatalf("reading the bundle: %v", err)
+	}
+	lock, err := download.ReadLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := lock[m.Files[0].Path]
+	if got.URL != origin.URL || got.SHA256 != origin.SHA256 || !got.Imported {
+		t.Errorf("imported lock entry = %+v, want the URL and SHA-256 of %+v", got, origin)
+	}
+	// the manifest is not signed, so the file is not verified
+	if got.Verified || got.Signed {
+		t.Errorf("imported lock entry = %+v, want it unverified", got)
+	}
+}
+
+func TestReadSignedBundle(t *testing.T) {
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+	t.Cleanup(func() { viper.Set(config.DownloadPublicKey, "") })
+	kubelet := localpath.MakeMiniPath("cache", "linux", "amd64", "v1.24.1", "kubelet")
+	if err := os.MkdirAll(filepath.Dir(kubelet), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(kubelet, []byte("kubelet"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, sum, err := hashFile(kubelet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := download.RecordLock(kubelet, download.LockEntry{URL: "https://example.com/kubelet", SHA256: sum, Verified: true}); err != nil {
+		t.Fatal(err)
+	}
+	m, err := newManifest(Options{KubernetesVersion: "v1.24.1"}, nil, nil, map[string]string{kubelet: KindBinary})
+	if err != nil {
+		t.Fatalf("newManifest: %v", err)
+	}
+	key := newKey(t)
+	var b bytes.Buffer
+	if err := write(m, &b, key); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	data := b.Bytes()
+
+	tests := []struct {
+		desc     string
+		key      *ecdsa.PrivateKey
+		verified bool
+		wantErr  bool
+	}{
+		{desc: "no public key", verified: false},
+		{desc: "signing key", key: key, verified: true},
+		{desc: "other key", key: newKey(t), wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Setenv(localpath.MinikubeHome, t.TempDir())
+			viper.Set(config.DownloadPublicKey, "")
+			if tc.key != nil {
+				viper.Set(config.DownloadPublicKey, writePublicKey(t, tc.key))
+			}
+			got, err := Read(bytes.NewReader(data), localpath.MiniPath())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Read() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			lock, err := download.ReadLock()
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := lock[m.Files[0].Path]
+			if got.Signed != tc.verified || e.Verified != tc.verified || e.Signed != tc.verified || !e.Imported {
+				t.Errorf("signed manifest = %v, imported lock entry = %+v, want verified: %v", got.Signed, e, tc.verified)
+			}
+		})
+	}
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// writePublicKey writes the PEM encoded public key of key, returning its path
+func writePublicKey(t *testing.T, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := filepath.Join(t.TempDir(), "key.pub")
+	if err := os.WriteFile(pub, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return pub
+}
+
+func TestReadCorruptedBundle(t *testing.T) {
+	m, _ := testBundle(t)
+	// a file of the same size, which doesn't match its digest
+	if err := os.WriteFile(localpath.MakeMiniPath("cache", "linux", "amd64", "v1.24.1", "kubelet"), []byte("kubelex"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := write(m, &b, nil); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	corrupted := b.Bytes()
+	dir := t.TempDir()
+	if _, err := Read(bytes.NewReader(corrupted), dir); err == nil || !strings.Contains(err.Error(), "sha256") {
+		t.Errorf("Read() of a corrupted bundle returned %v, want a sha256 mismatch", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cache", "linux", "amd64", "v1.24.1", "kubelet")); !os.IsNotExist(err) {
+		t.Errorf("the corrupted file was imported: %v", err)
+	}
+}
+
+func TestReadUnlistedFile(t *testing.T) {
+	m, data := testBundle(t)
+	// drop the end of the archive, to append an entry missing from the manifest
+	var b bytes.Buffer
+	b.Write(data[:len(data)-1024])
+	tw := tar.NewWriter(&b)
+	if err := tw.WriteHeader(&tar.Header{Name: "files/cache/../../.bashrc", Mode: 0644, Size: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(&b, t.TempDir()); err == nil || !strings.Contains(err.Error(), "not listed") {
+		t.Errorf("Read() of a bundle with an unlisted file returned %v", err)
+	}
+
+	m.Files = []File{{Path: "cache/../../.bashrc", Kind: KindBinary, Size: 1}}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var invalid bytes.Buffer
+	tw = tar.NewWriter(&invalid)
+	if err := tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0644, Size: int64(len(data))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(&invalid, t.TempDir()); err == nil || !strings.Contains(err.Error(), "invalid path") {
+		t.Errorf("Read() of a manifest with an invalid path returned %v", err)
+	}
+}
+
+func TestValidPath(t *testing.T) {
+	tests := map[string]bool{
+		"cache/iso/amd64/minikube-v1.26.0-amd64.iso": true,
+		"cache/../profiles/minikube/config.json":     false,
+		"/cache/kic/amd64/kicbase.tar":               false,
+		"config/config.json":                         false,
+		"cache//kic":                                 false,
+		"bin/docker-machine-driver-kvm2":             true,
+		"bin/minikube":                               false,
+		"bin/../docker-machine-driver-kvm2":          false,
+	}
+	for p, want := range tests {
+		if got := validPath(p); got != want {
+			t.Errorf("validPath(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package certs rotates the certificates of a running cluster without deleting it
+package certs
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/docker/machine/libmachine/state"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/kapi"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/cluster"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/kubeconfig"
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/style"
+	"k8s.io/minikube/pkg/util/retry"
+)
+
+// nodeTimeout is how long a node may take to become ready once its certs were rotated
+const nodeTimeout = 6 * time.Minute
+
+// target is a node whose certs are rotated
+type target struct {
+	node   config.Node
+	runner command.Runner
+	bs     bootstrapper.Bootstrapper
+}
+
+// Rotate generates new certs for the profile of cc, and new shared CAs if ca is true, distributes them to every node,
+// and restarts the components using them. Workers join the cluster again after a CA rotation, as their kubelet client cert was signed by the previous CA.
+func Rotate(api libmachine.API, cc *config.ClusterConfig, bsName string, ca bool) error {
+	cp, err := config.PrimaryControlPlane(cc)
+	if err != nil {
+		return errors.Wrap(err, "primary control plane")
+	}
+	nodes := []config.Node{cp}
+	for _, n := range cc.Nodes {
+		if n.Name != cp.Name {
+			nodes = append(nodes, n)
+		}
+	}
+	targets := []*target{}
+	for _, n := range nodes {
+		t, err := newTarget(api, *cc, bsName, n)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, t)
+	}
+
+	backup, err := os.MkdirTemp(localpath.MiniPath(), "certs-backup-")
+	if err != nil {
+		return errors.Wrap(err, "creating certs backup")
+	}
+
+	if err := bootstrapper.RemoveCerts(*cc, ca, backup); err != nil {
+		return rollback(*cc, nil, ca, backup, errors.Wrap(err, "removing certs"))
+	}
+	for i, t := range targets {
+		if err := rotate(*cc, targets[0], t, ca); err != nil {
+			return rollback(*cc, targets[:i+1], ca, backup, err)
+		}
+	}
+	if err := os.RemoveAll(backup); err != nil {
+		klog.Warningf("removing certs backup %s: %v", backup, err)
+	}
+
+	return updateKubeconfig(cc)
+}
+
+// rotate distributes the new certs of cc to the node of t, and waits for it to be ready
+func rotate(cc config.ClusterConfig, cp *target, t *target, ca bool) error {
+	name := config.MachineName(cc, t.node)
+	out.Step(style.Restarting, "Rotating the certificates of {{.name}} ...", out.V{"name": name})
+	if ca && !t.node.ControlPlane {
+		if err := rejoin(cc, cp, t); err != nil {
+			return errors.Wrapf(err, "joining %s again", name)
+		}
+	} else {
+		if err := t.bs.SetupCerts(cc, t.node); err != nil {
+			return errors.Wrapf(err, "setting up certs of %s", name)
+		}
+		if err := t.bs.RenewCerts(cc, t.node, ca); err != nil {
+			return errors.Wrapf(err, "renewing certs of %s", name)
+		}
+	}
+	if err := t.bs.WaitForNode(cc, t.node, nodeTimeout); err != nil {
+		return errors.Wrapf(err, "waiting for %s", name)
+	}
+	return nil
+}
+
+// rollback restores the previous certs from the backup, and distributes them again to the nodes which already got new ones.
+// It returns the error which made the rotation fail. The backup is kept if the certs cannot be restored.
+func rollback(cc config.ClusterConfig, targets []*target, ca bool, backup string, rotateErr error) error {
+	out.WarningT("Rotating the certificates failed, restoring the previous ones: {{.error}}", out.V{"error": rotateErr})
+	if err := bootstrapper.RestoreCerts(cc, ca, backup); err != nil {
+		out.ErrT(style.Sad, "Restoring the previous certificates failed, they are kept in {{.path}}: {{.error}}", out.V{"path": backup, "error": err})
+		return rotateErr
+	}
+	if err := os.RemoveAll(backup); err != nil {
+		klog.Warningf("removing certs backup %s: %v", backup, err)
+	}
+	for _, t := range targets {
+		if err := rotate(cc, targets[0], t, ca); err != nil {
+			klog.Errorf("restoring the certs of %s failed: %v", t.node.Name, err)
+		}
+	}
+	return rotateErr
+}
+
+func newTarget(api libmachine.API, cc config.ClusterConfig, bsName string, n config.Node) (*target, error) {
+	name := config.MachineName(cc, n)
+	st, err := machine.Status(api, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "status of %s", name)
+	}
+	if st != state.Running.String() {
+		return nil, fmt.Errorf("node %s is not running (state=%s)", name, st)
+	}
+	h, err := machine.LoadHost(api, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading host %s", name)
+	}
+	runner, err := machine.CommandRunner(h)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := cluster.Bootstrapper(api, bsName, cc, runner)
+	if err != nil {
+		return nil, err
+	}
+	return &target{node: n, runner: runner, bs: bs}, nil
+}
+
+// rejoin resets a worker, installs the new CA, and joins it to the cluster again
+func rejoin(cc config.ClusterConfig, cp *target, t *target) error {
+	if err := t.bs.DeleteCluster(cc.KubernetesConfig); err != nil {
+		klog.Warningf("resetting %s failed, continuing anyway: %v", t.node.Name, err)
+	}
+	name := config.MachineName(cc, t.node)
+	c := exec.Command("sudo", "KUBECONFIG=/var/lib/minikube/kubeconfig", kapi.KubectlBinaryPath(cc.KubernetesConfig.KubernetesVersion), "delete", "node", name, "--ignore-not-found")
+	if _, err := cp.runner.RunCmd(c); err != nil {
+		return errors.Wrap(err, "deleting node")
+	}
+	if err := t.bs.SetupCerts(cc, t.node); err != nil {
+		return errors.Wrap(err, "setting up certs")
+	}
+
+	joinCmd, err := cp.bs.GenerateToken(cc)
+	if err != nil {
+		return errors.Wrap(err, "generating join token")
+	}
+	join := func() error {
+		return t.bs.JoinCluster(cc, t.node, joinCmd)
+	}
+	return retry.Expo(join, 10*time.Second, 3*time.Minute)
+}
+
+// updateKubeconfig writes the new client cert and CA to the kubeconfig entry of the profile, for profiles embedding them
+func updateKubeconfig(cc *config.ClusterConfig) error {
+	hostname, port, err := kubeconfig.Endpoint(cc.Name)
+	if err != nil {
+		return errors.Wrap(err, "kubeconfig endpoint")
+	}
+	kcs := &kubeconfig.Settings{
+		ClusterName:          cc.Name,
+		Namespace:            cc.KubernetesConfig.Namespace,
+		ClusterServerAddress: fmt.Sprintf("https://%s", net.JoinHostPort(hostname, strconv.Itoa(port))),
+		ClientCertificate:    localpath.ClientCert(cc.Name),
+		ClientKey:            localpath.ClientKey(cc.Name),
+		CertificateAuthority: bootstrapper.ClusterCACert(*cc),
+		ExtensionContext:     kubeconfig.NewExtension(),
+		ExtensionCluster:     kubeconfig.NewExtension(),
+		KeepContext:          true,
+		EmbedCerts:           cc.EmbedCerts,
+	}
+	kcs.SetPath(kubeconfig.PathFromEnv())
+	return kubeconfig.Update(kcs)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/pkg/errors"
 
 	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/k3s"
 	"k8s.io/minikube/pkg/minikube/bootstrapper/kubeadm"
 	"k8s.io/minikube/pkg/minikube/command"
 	"k8s.io/minikube/pkg/minikube/config"
@@ -48,6 +49,11 @@ func Bootstrapper(api libmachine.API, bootstrapperName string, cc config.Cluster
 		if err != nil {
 			return nil, errors.Wrap(err, "getting a new kubeadm bootstrapper")
 		}
+	case bootstrapper.K3s:
+		b, err = k3s.NewBootstrapper(api, cc, r)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting a new k3s bootstrapper")
+		}
 	default:
 		return nil, fmt.Errorf("unknown bootstrapper: %s", bootstrapperName)
 	}
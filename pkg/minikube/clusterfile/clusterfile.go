@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clusterfile reads, validates and writes declarative minikube cluster specs
+package clusterfile
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// APIVersion is the only supported version of the cluster file format
+	APIVersion = "minikube.sigs.k8s.io/v1alpha1"
+	// Kind is the kind of object described by a cluster file
+	Kind = "Cluster"
+
+	// RoleControlPlane marks a node as a control plane
+	RoleControlPlane = "control-plane"
+	// RoleWorker marks a node as schedulable for workloads
+	RoleWorker = "worker"
+)
+
+// Cluster is the declarative spec of a minikube profile
+type Cluster struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Metadata   Metadata `yaml:"metadata"`
+	Spec       Spec     `yaml:"spec"`
+}
+
+// Metadata identifies the profile a spec applies to
+type Metadata struct {
+	// Name is the profile name, the --profile flag is used if empty
+	Name string `yaml:"name,omitempty"`
+}
+
+// Spec maps onto config.ClusterConfig
+type Spec struct {
+	Driver             string          `yaml:"driver,omitempty"`
+	CPUs               int             `yaml:"cpus,omitempty"`
+	Memory             string          `yaml:"memory,omitempty"`
+	DiskSize           string          `yaml:"diskSize,omitempty"`
+	RegistryMirrors    []string        `yaml:"registryMirrors,omitempty"`
+	InsecureRegistries []string        `yaml:"insecureRegistries,omitempty"`
+	Kubernetes         Kubernetes      `yaml:"kubernetes,omitempty"`
+	Nodes              []Node          `yaml:"nodes,omitempty"`
+	Addons             map[string]bool `yaml:"addons,omitempty"`
+	Mounts             []Mount         `yaml:"mounts,omitempty"`
+}
+
+// Kubernetes maps onto config.KubernetesConfig
+type Kubernetes struct {
+	Version          string        `yaml:"version,omitempty"`
+	ContainerRuntime string        `yaml:"containerRuntime,omitempty"`
+	CNI              string        `yaml:"cni,omitempty"`
+	FeatureGates     string        `yaml:"featureGates,omitempty"`
+	ServiceCIDR      string        `yaml:"serviceCIDR,omitempty"`
+	DNSDomain        string        `yaml:"dnsDomain,omitempty"`
+	ImageRepository  string        `yaml:"imageRepository,omitempty"`
+	APIServerNames   []string      `yaml:"apiServerNames,omitempty"`
+	ExtraOptions     []ExtraOption `yaml:"extraOptions,omitempty"`
+}
+
+// ExtraOption maps onto config.ExtraOption
+type ExtraOption struct {
+	Component string `yaml:"component"`
+	Key       string `yaml:"key"`
+	Value     string `yaml:"value"`
+}
+
+// Node maps onto config.Node
+type Node struct {
+	// Name of the node, defaults to m02, m03, ... by position. The first node is always the primary control plane.
+	Name  string   `yaml:"name,omitempty"`
+	Roles []string `yaml:"roles,omitempty"`
+}
+
+// Mount is a host directory mounted into the cluster
+type Mount struct {
+	HostPath  string   `yaml:"hostPath"`
+	GuestPath string   `yaml:"guestPath"`
+	Type      string   `yaml:"type,omitempty"`
+	UID       string   `yaml:"uid,omitempty"`
+	GID       string   `yaml:"gid,omitempty"`
+	Options   []string `yaml:"options,omitempty"`
+}
+
+// Parse decodes a cluster spec, rejecting unknown fields
+func Parse(b []byte) (*Cluster, error) {
+	c := &Cluster{}
+	if err := yaml.UnmarshalStrict(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load reads and decodes a cluster spec from a file
+func Load(path string) (*Cluster, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading cluster file")
+	}
+	return Parse(b)
+}
+
+// Marshal encodes a cluster spec as YAML
+func Marshal(c *Cluster) ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+// HasRole returns whether the node has the given role
+func (n Node) HasRole(role string) bool {
+	for _, r := range n.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
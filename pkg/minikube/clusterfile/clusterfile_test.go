@@ -0,0 +1,259 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterfile
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/minikube/pkg/minikube/config"
+)
+
+const validFile = `apiVersion: minikube.sigs.k8s.io/v1alpha1
+kind: Cluster
+metadata:
+  name: dev
+spec:
+  driver: docker
+  cpus: 4
+  memory: 8g
+  kubernetes:
+    version: v1.23.6
+    containerRuntime: containerd
+    extraOptions:
+    - component: kubelet
+      key: max-pods
+      value: "150"
+  nodes:
+  - roles: [control-plane, worker]
+  - name: m02
+    roles: [worker]
+  addons:
+    metrics-server: true
+  mounts:
+  - hostPath: /src
+    guestPath: /src
+`
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(validFile))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if errs := Validate(c); len(errs) != 0 {
+		t.Errorf("Validate returned errors for a valid file: %v", errs)
+	}
+	if c.Spec.Kubernetes.ExtraOptions[0].Value != "150" {
+		t.Errorf("unexpected extra options: %+v", c.Spec.Kubernetes.ExtraOptions)
+	}
+
+	if _, err := Parse([]byte("apiVersion: x\nspec:\n  cpu: 2\n")); err == nil {
+		t.Errorf("expected unknown field to be rejected")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		description string
+		modify      func(c *Cluster)
+		paths       []string
+	}{
+		{"wrong kind", func(c *Cluster) { c.Kind = "Pod" }, []string{"kind"}},
+		{"bad memory", func(c *Cluster) { c.Spec.Memory = "lots" }, []string{"spec.memory"}},
+		{"bad runtime", func(c *Cluster) { c.Spec.Kubernetes.ContainerRuntime = "rkt" }, []string{"spec.kubernetes.containerRuntime"}},
+		{"bad extra option", func(c *Cluster) { c.Spec.Kubernetes.ExtraOptions[0].Component = "kubectl" }, []string{"spec.kubernetes.extraOptions[0].component"}},
+		{"unknown addon", func(c *Cluster) { c.Spec.Addons["nope"] = true }, []string{"spec.addons[nope]"}},
+		{"primary without control plane", func(c *Cluster) { c.Spec.Nodes[0].Roles = []string{RoleWorker} }, []string{"spec.nodes[0].roles"}},
+		{"primary without worker", func(c *Cluster) { c.Spec.Nodes[0].Roles = []string{RoleControlPlane} }, []string{"spec.nodes[0].roles"}},
+		{"bad role", func(c *Cluster) { c.Spec.Nodes[1].Roles = []string{"etcd"} }, []string{"spec.nodes[1].roles[0]"}},
+		{"duplicate node", func(c *Cluster) { c.Spec.Nodes = append(c.Spec.Nodes, Node{Name: "m02", Roles: []string{RoleWorker}}) }, []string{"spec.nodes[2].name"}},
+		{"duplicate default name", func(c *Cluster) {
+			c.Spec.Nodes = []Node{{Roles: []string{RoleControlPlane, RoleWorker}}, {Name: "m03", Roles: []string{RoleWorker}}, {Roles: []string{RoleWorker}}}
+		}, []string{"spec.nodes[2].name"}},
+		{"relative mount", func(c *Cluster) { c.Spec.Mounts[0].GuestPath = "src" }, []string{"spec.mounts[0].guestPath"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			c, err := Parse([]byte(validFile))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			tc.modify(c)
+			got := []string{}
+			for _, err := range Validate(c) {
+				got = append(got, err.Field)
+			}
+			if diff := cmp.Diff(tc.paths, got); diff != "" {
+				t.Errorf("unexpected error paths (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	c, err := Parse([]byte(validFile))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	cc := &config.ClusterConfig{
+		Name:             "dev",
+		Driver:           "docker",
+		CPUs:             4,
+		Memory:           8192,
+		Mount:            true,
+		MountString:      "/src:/src",
+		KubernetesConfig: config.KubernetesConfig{KubernetesVersion: "v1.23.6", ContainerRuntime: "containerd"},
+		Nodes:            []config.Node{{Name: "", ControlPlane: true, Worker: true}, {Name: "m02", ControlPlane: true, Worker: true}},
+	}
+
+	got := []string{}
+	for _, err := range ValidateUpdate(c, cc) {
+		got = append(got, err.Field)
+	}
+	if diff := cmp.Diff([]string{"spec.nodes[1].roles"}, got); diff != "" {
+		t.Errorf("unexpected error paths (-want +got):\n%s", diff)
+	}
+
+	cc.Nodes[1].ControlPlane = false
+	cc.KubernetesConfig.KubernetesVersion = "v1.22.0"
+	got = []string{}
+	for _, err := range ValidateUpdate(c, cc) {
+		got = append(got, err.Field)
+	}
+	if diff := cmp.Diff([]string{"spec.kubernetes.version"}, got); diff != "" {
+		t.Errorf("unexpected error paths (-want +got):\n%s", diff)
+	}
+
+	// settings which are only honored when a profile is created must not be silently ignored
+	cc.KubernetesConfig.KubernetesVersion = "v1.23.6"
+	cc.Driver = "kvm2"
+	c.Spec.Driver = ""
+	c.Spec.RegistryMirrors = []string{"https://mirror.example.com"}
+	c.Spec.InsecureRegistries = []string{"10.0.0.0/24"}
+	c.Spec.Kubernetes.ServiceCIDR = "10.100.0.0/16"
+	c.Spec.Kubernetes.DNSDomain = "example.local"
+	c.Spec.Kubernetes.ImageRepository = "registry.example.com"
+	c.Spec.Mounts[0].GuestPath = "/data"
+	got = []string{}
+	for _, err := range ValidateUpdate(c, cc) {
+		got = append(got, err.Field)
+	}
+	want := []string{"spec.registryMirrors", "spec.insecureRegistries", "spec.kubernetes.serviceCIDR", "spec.kubernetes.dnsDomain", "spec.kubernetes.imageRepository", "spec.mounts[0]"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected error paths (-want +got):\n%s", diff)
+	}
+}
+
+func TestNodeChanges(t *testing.T) {
+	c, err := Parse([]byte(validFile))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	c.Spec.Nodes = append(c.Spec.Nodes, Node{Roles: []string{RoleControlPlane, RoleWorker}})
+
+	cc := &config.ClusterConfig{
+		Name:             "dev",
+		KubernetesConfig: config.KubernetesConfig{KubernetesVersion: "v1.23.6", ContainerRuntime: "containerd"},
+		Nodes:            []config.Node{{ControlPlane: true, Worker: true}, {Name: "m02", Worker: true}, {Name: "m04", Worker: true}},
+	}
+
+	add, remove := c.NodeChanges(cc)
+	want := []config.Node{{Name: "m03", ControlPlane: true, Worker: true, KubernetesVersion: "v1.23.6", ContainerRuntime: "containerd"}}
+	if diff := cmp.Diff(want, add); diff != "" {
+		t.Errorf("unexpected nodes to add (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"m04"}, remove); diff != "" {
+		t.Errorf("unexpected nodes to remove (-want +got):\n%s", diff)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cc := &config.ClusterConfig{
+		Name:        "dev",
+		Driver:      "docker",
+		CPUs:        2,
+		Memory:      4000,
+		DiskSize:    20000,
+		Mount:       true,
+		MountString: `C:\src:/src`,
+		KubernetesConfig: config.KubernetesConfig{
+			KubernetesVersion: "v1.23.6",
+			ContainerRuntime:  "docker",
+			ExtraOptions:      config.ExtraOptionSlice{{Component: "kubelet", Key: "max-pods", Value: "150"}},
+		},
+		Nodes:  []config.Node{{ControlPlane: true, Worker: true}, {Name: "m02", Worker: true}},
+		Addons: map[string]bool{"dashboard": true, "ingress": false},
+	}
+
+	c := FromConfig(cc)
+	if errs := Validate(c); len(errs) != 0 {
+		t.Errorf("exported spec is not valid: %v", errs)
+	}
+	if errs := ValidateUpdate(c, cc); len(errs) != 0 {
+		t.Errorf("exported spec does not match its own profile: %v", errs)
+	}
+	if c.Spec.Mounts[0].HostPath != `C:\src` || c.Spec.Mounts[0].GuestPath != "/src" {
+		t.Errorf("unexpected mount: %+v", c.Spec.Mounts[0])
+	}
+	if add, remove := c.NodeChanges(cc); len(add)+len(remove) != 0 {
+		t.Errorf("exported spec changes nodes: add %v, remove %v", add, remove)
+	}
+	if changes := c.AddonChanges(cc); len(changes) != 0 {
+		t.Errorf("exported spec changes addons: %v", changes)
+	}
+
+	b, err := Marshal(c)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	parsed, err := Parse(b)
+	if err != nil {
+		t.Fatalf("Parse of exported spec: %v", err)
+	}
+	if diff := cmp.Diff(c, parsed); diff != "" {
+		t.Errorf("round trip changed the spec (-want +got):\n%s", diff)
+	}
+}
+
+func TestUpdateConfig(t *testing.T) {
+	c, err := Parse([]byte(validFile))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	cc := &config.ClusterConfig{KubernetesConfig: config.KubernetesConfig{
+		ExtraOptions: config.ExtraOptionSlice{{Component: "kubelet", Key: "max-pods", Value: "150"}},
+	}}
+	if c.UpdateConfig(cc) {
+		t.Errorf("UpdateConfig reported a change for identical settings")
+	}
+
+	c.Spec.Kubernetes.FeatureGates = "EphemeralContainers=true"
+	if !c.UpdateConfig(cc) {
+		t.Errorf("UpdateConfig did not report a feature gate change")
+	}
+	if cc.KubernetesConfig.FeatureGates != "EphemeralContainers=true" {
+		t.Errorf("feature gates not updated: %q", cc.KubernetesConfig.FeatureGates)
+	}
+}
+
+func TestSortedAddons(t *testing.T) {
+	got := SortedAddons(map[string]bool{"registry": true, "dashboard": false, "ingress": true})
+	if !sort.StringsAreSorted(got) || len(got) != 3 {
+		t.Errorf("SortedAddons() = %v", got)
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterfile
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/node"
+)
+
+// FromConfig returns the spec describing an existing profile
+func FromConfig(cc *config.ClusterConfig) *Cluster {
+	k8s := cc.KubernetesConfig
+	c := &Cluster{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Metadata:   Metadata{Name: cc.Name},
+		Spec: Spec{
+			Driver:             cc.Driver,
+			CPUs:               cc.CPUs,
+			RegistryMirrors:    cc.RegistryMirror,
+			InsecureRegistries: cc.InsecureRegistry,
+			Kubernetes: Kubernetes{
+				Version:          k8s.KubernetesVersion,
+				ContainerRuntime: k8s.ContainerRuntime,
+				CNI:              k8s.CNI,
+				FeatureGates:     k8s.FeatureGates,
+				ServiceCIDR:      k8s.ServiceCIDR,
+				DNSDomain:        k8s.DNSDomain,
+				ImageRepository:  k8s.ImageRepository,
+				APIServerNames:   k8s.APIServerNames,
+			},
+		},
+	}
+	if cc.Memory != 0 {
+		c.Spec.Memory = fmt.Sprintf("%dmb", cc.Memory)
+	}
+	if cc.DiskSize != 0 {
+		c.Spec.DiskSize = fmt.Sprintf("%dmb", cc.DiskSize)
+	}
+
+	for _, eo := range k8s.ExtraOptions {
+		c.Spec.Kubernetes.ExtraOptions = append(c.Spec.Kubernetes.ExtraOptions, ExtraOption{Component: eo.Component, Key: eo.Key, Value: eo.Value})
+	}
+
+	for i, n := range cc.Nodes {
+		sn := Node{Name: n.Name}
+		if i == 0 {
+			// the primary node is named after the profile
+			sn.Name = ""
+		}
+		if n.ControlPlane {
+			sn.Roles = append(sn.Roles, RoleControlPlane)
+		}
+		if n.Worker {
+			sn.Roles = append(sn.Roles, RoleWorker)
+		}
+		c.Spec.Nodes = append(c.Spec.Nodes, sn)
+	}
+
+	if len(cc.Addons) > 0 {
+		c.Spec.Addons = map[string]bool{}
+		for name, enabled := range cc.Addons {
+			c.Spec.Addons[name] = enabled
+		}
+	}
+
+	if m := profileMount(cc); m != nil {
+		c.Spec.Mounts = []Mount{*m}
+	}
+	return c
+}
+
+// profileMount returns the mount of a profile, or nil if it has none
+func profileMount(cc *config.ClusterConfig) *Mount {
+	if !cc.Mount || cc.MountString == "" {
+		return nil
+	}
+	host, guest := splitMountString(cc.MountString)
+	return &Mount{
+		HostPath:  host,
+		GuestPath: guest,
+		Type:      cc.MountType,
+		UID:       cc.MountUID,
+		GID:       cc.MountGID,
+		Options:   cc.MountOptions,
+	}
+}
+
+// NodeName returns the name of the i'th node of the spec
+func (c *Cluster) NodeName(i int) string {
+	return nodeName(c.Spec.Nodes, i)
+}
+
+// nodeName returns the name of the i'th node, defaulting to the name minikube gives to the node at its position
+func nodeName(nodes []Node, i int) string {
+	if i == 0 {
+		return ""
+	}
+	if name := nodes[i].Name; name != "" {
+		return name
+	}
+	return node.Name(i + 1)
+}
+
+// Nodes returns the nodes described by the spec, defaulting to a single control plane
+func (c *Cluster) Nodes() []config.Node {
+	if len(c.Spec.Nodes) == 0 {
+		return []config.Node{{ControlPlane: true, Worker: true}}
+	}
+	ns := []config.Node{}
+	for i, n := range c.Spec.Nodes {
+		ns = append(ns, config.Node{
+			Name:         c.NodeName(i),
+			ControlPlane: n.HasRole(RoleControlPlane),
+			Worker:       n.HasRole(RoleWorker),
+		})
+	}
+	return ns
+}
+
+// NodeChanges returns the nodes to add to and the names of the nodes to remove from a profile to match the spec
+func (c *Cluster) NodeChanges(cc *config.ClusterConfig) (add []config.Node, remove []string) {
+	want := map[string]bool{}
+	for i, n := range c.Nodes() {
+		want[n.Name] = true
+		if i == 0 {
+			continue
+		}
+		if _, _, err := node.Retrieve(*cc, n.Name); err != nil {
+			n.KubernetesVersion = cc.KubernetesConfig.KubernetesVersion
+			n.ContainerRuntime = cc.KubernetesConfig.ContainerRuntime
+			add = append(add, n)
+		}
+	}
+	for i, n := range cc.Nodes {
+		if i == 0 || want[n.Name] {
+			continue
+		}
+		remove = append(remove, n.Name)
+	}
+	return add, remove
+}
+
+// AddonChanges returns the addons whose enabled state differs between the spec and the profile, sorted by name
+func (c *Cluster) AddonChanges(cc *config.ClusterConfig) map[string]bool {
+	changes := map[string]bool{}
+	for name, enabled := range c.Spec.Addons {
+		if cc.Addons[name] != enabled {
+			changes[name] = enabled
+		}
+	}
+	return changes
+}
+
+// SortedAddons returns the addon names of a change set in a stable order
+func SortedAddons(changes map[string]bool) []string {
+	names := []string{}
+	for name := range changes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UpdateConfig applies the settings of the spec which are honored when an existing profile is restarted,
+// returning whether anything changed.
+func (c *Cluster) UpdateConfig(cc *config.ClusterConfig) bool {
+	before := *cc
+	before.KubernetesConfig.ExtraOptions = append(config.ExtraOptionSlice{}, cc.KubernetesConfig.ExtraOptions...)
+
+	k8s := c.Spec.Kubernetes
+	if k8s.FeatureGates != "" {
+		cc.KubernetesConfig.FeatureGates = k8s.FeatureGates
+	}
+	if k8s.CNI != "" {
+		cc.KubernetesConfig.CNI = k8s.CNI
+	}
+	if len(k8s.APIServerNames) > 0 {
+		cc.KubernetesConfig.APIServerNames = k8s.APIServerNames
+	}
+	if len(k8s.ExtraOptions) > 0 {
+		eos := config.ExtraOptionSlice{}
+		for _, eo := range k8s.ExtraOptions {
+			eos = append(eos, config.ExtraOption{Component: eo.Component, Key: eo.Key, Value: eo.Value})
+		}
+		cc.KubernetesConfig.ExtraOptions = eos
+	}
+
+	return !reflect.DeepEqual(before.KubernetesConfig, cc.KubernetesConfig)
+}
+
+// MountString returns the host:guest mount string of a mount
+func (m Mount) MountString() string {
+	return fmt.Sprintf("%s:%s", m.HostPath, m.GuestPath)
+}
+
+// splitMountString splits a host:guest mount string, keeping windows drive letters on the host side
+func splitMountString(s string) (string, string) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i+1:]
+}
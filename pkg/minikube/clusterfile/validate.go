@@ -0,0 +1,276 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterfile
+
+import (
+	"net"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/bsutil"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/driver"
+	"k8s.io/minikube/pkg/util"
+)
+
+// nodeNameRe matches the names minikube gives to secondary nodes
+var nodeNameRe = regexp.MustCompile(`^m[0-9]{2,}$`)
+
+// Validate checks a spec without consulting any existing profile
+func Validate(c *Cluster) field.ErrorList {
+	var errs field.ErrorList
+
+	if c.APIVersion != APIVersion {
+		errs = append(errs, field.NotSupported(field.NewPath("apiVersion"), c.APIVersion, []string{APIVersion}))
+	}
+	if c.Kind != Kind {
+		errs = append(errs, field.NotSupported(field.NewPath("kind"), c.Kind, []string{Kind}))
+	}
+	if c.Metadata.Name != "" && !config.ProfileNameValid(c.Metadata.Name) {
+		errs = append(errs, field.Invalid(field.NewPath("metadata", "name"), c.Metadata.Name, "only alphanumeric characters and dashes are allowed, starting with an alphanumeric character"))
+	}
+
+	errs = append(errs, validateSpec(&c.Spec, field.NewPath("spec"))...)
+	return errs
+}
+
+func validateSpec(s *Spec, p *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if s.Driver != "" && !driver.Supported(s.Driver) {
+		errs = append(errs, field.NotSupported(p.Child("driver"), s.Driver, driver.SupportedDrivers()))
+	}
+	if s.CPUs < 0 {
+		errs = append(errs, field.Invalid(p.Child("cpus"), s.CPUs, "must not be negative"))
+	}
+	if s.Memory != "" {
+		if _, err := util.CalculateSizeInMB(s.Memory); err != nil {
+			errs = append(errs, field.Invalid(p.Child("memory"), s.Memory, "must be a size such as 4000mb or 4g"))
+		}
+	}
+	if s.DiskSize != "" {
+		if _, err := util.CalculateSizeInMB(s.DiskSize); err != nil {
+			errs = append(errs, field.Invalid(p.Child("diskSize"), s.DiskSize, "must be a size such as 20000mb or 20g"))
+		}
+	}
+
+	errs = append(errs, validateKubernetes(&s.Kubernetes, p.Child("kubernetes"))...)
+	errs = append(errs, validateNodes(s.Nodes, p.Child("nodes"))...)
+
+	for name := range s.Addons {
+		if _, ok := assets.Addons[name]; !ok {
+			errs = append(errs, field.NotFound(p.Child("addons").Key(name), name))
+		}
+	}
+
+	if len(s.Mounts) > 1 {
+		errs = append(errs, field.TooMany(p.Child("mounts"), len(s.Mounts), 1))
+	}
+	for i, m := range s.Mounts {
+		mp := p.Child("mounts").Index(i)
+		if m.HostPath == "" {
+			errs = append(errs, field.Required(mp.Child("hostPath"), ""))
+		}
+		if m.GuestPath == "" {
+			errs = append(errs, field.Required(mp.Child("guestPath"), ""))
+		} else if !path.IsAbs(m.GuestPath) {
+			errs = append(errs, field.Invalid(mp.Child("guestPath"), m.GuestPath, "must be an absolute path"))
+		}
+	}
+	return errs
+}
+
+func validateKubernetes(k *Kubernetes, p *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if k.Version != "" {
+		if _, err := semver.Make(strings.TrimPrefix(k.Version, "v")); err != nil {
+			errs = append(errs, field.Invalid(p.Child("version"), k.Version, "must be a semantic version such as v1.23.6"))
+		}
+	}
+	if k.ContainerRuntime != "" {
+		valid := false
+		for _, r := range cruntime.ValidRuntimes() {
+			if k.ContainerRuntime == r {
+				valid = true
+			}
+		}
+		if !valid {
+			errs = append(errs, field.NotSupported(p.Child("containerRuntime"), k.ContainerRuntime, cruntime.ValidRuntimes()))
+		}
+	}
+	if k.ServiceCIDR != "" {
+		if _, _, err := net.ParseCIDR(k.ServiceCIDR); err != nil {
+			errs = append(errs, field.Invalid(p.Child("serviceCIDR"), k.ServiceCIDR, "must be a CIDR such as 10.96.0.0/12"))
+		}
+	}
+
+	for i, eo := range k.ExtraOptions {
+		ep := p.Child("extraOptions").Index(i)
+		if !config.ContainsParam(bsutil.KubeadmExtraConfigOpts, eo.Component) {
+			errs = append(errs, field.NotSupported(ep.Child("component"), eo.Component, bsutil.KubeadmExtraConfigOpts))
+		}
+		if eo.Key == "" {
+			errs = append(errs, field.Required(ep.Child("key"), ""))
+		}
+	}
+	return errs
+}
+
+func validateNodes(nodes []Node, p *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	names := map[string]bool{}
+	for i, n := range nodes {
+		np := p.Index(i)
+		if i == 0 {
+			if n.Name != "" {
+				errs = append(errs, field.Invalid(np.Child("name"), n.Name, "the primary node is named after the profile and must not have a name"))
+			}
+			// start always schedules workloads on the primary node
+			if !n.HasRole(RoleControlPlane) || !n.HasRole(RoleWorker) {
+				errs = append(errs, field.Invalid(np.Child("roles"), n.Roles, "the primary node must be both a control-plane and a worker"))
+			}
+		} else {
+			if n.Name != "" && !nodeNameRe.MatchString(n.Name) {
+				errs = append(errs, field.Invalid(np.Child("name"), n.Name, "must be of the form m02, m03, ..."))
+			}
+			// unnamed nodes are named after their position, which may clash with an explicit name
+			name := nodeName(nodes, i)
+			if names[name] {
+				errs = append(errs, field.Duplicate(np.Child("name"), name))
+			}
+			names[name] = true
+		}
+
+		if len(n.Roles) == 0 {
+			errs = append(errs, field.Required(np.Child("roles"), "at least one role is required"))
+		}
+		for j, r := range n.Roles {
+			if r != RoleControlPlane && r != RoleWorker {
+				errs = append(errs, field.NotSupported(np.Child("roles").Index(j), r, []string{RoleControlPlane, RoleWorker}))
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateUpdate checks that a spec only changes the settings of an existing profile which can be changed in place
+func ValidateUpdate(c *Cluster, cc *config.ClusterConfig) field.ErrorList {
+	var errs field.ErrorList
+
+	p := field.NewPath("spec")
+	immutable := "cannot be changed on an existing profile, delete the profile first"
+	if d := c.Spec.Driver; d != "" && d != cc.Driver {
+		errs = append(errs, field.Forbidden(p.Child("driver"), immutable))
+	}
+	if v := c.Spec.Kubernetes.Version; v != "" && v != cc.KubernetesConfig.KubernetesVersion {
+		errs = append(errs, field.Forbidden(p.Child("kubernetes", "version"), immutable))
+	}
+	if r := c.Spec.Kubernetes.ContainerRuntime; r != "" && r != cc.KubernetesConfig.ContainerRuntime {
+		errs = append(errs, field.Forbidden(p.Child("kubernetes", "containerRuntime"), immutable))
+	}
+	if c.Spec.CPUs != 0 && c.Spec.CPUs != cc.CPUs {
+		errs = append(errs, field.Forbidden(p.Child("cpus"), immutable))
+	}
+	if c.Spec.Memory != "" {
+		if mem, err := util.CalculateSizeInMB(c.Spec.Memory); err == nil && mem != cc.Memory {
+			errs = append(errs, field.Forbidden(p.Child("memory"), immutable))
+		}
+	}
+	if c.Spec.DiskSize != "" {
+		if disk, err := util.CalculateSizeInMB(c.Spec.DiskSize); err == nil && disk != cc.DiskSize {
+			errs = append(errs, field.Forbidden(p.Child("diskSize"), immutable))
+		}
+	}
+	if len(c.Spec.RegistryMirrors) > 0 && !reflect.DeepEqual(c.Spec.RegistryMirrors, cc.RegistryMirror) {
+		errs = append(errs, field.Forbidden(p.Child("registryMirrors"), immutable))
+	}
+	if len(c.Spec.InsecureRegistries) > 0 && !reflect.DeepEqual(c.Spec.InsecureRegistries, cc.InsecureRegistry) {
+		errs = append(errs, field.Forbidden(p.Child("insecureRegistries"), immutable))
+	}
+	if s := c.Spec.Kubernetes.ServiceCIDR; s != "" && s != cc.KubernetesConfig.ServiceCIDR {
+		errs = append(errs, field.Forbidden(p.Child("kubernetes", "serviceCIDR"), immutable))
+	}
+	if d := c.Spec.Kubernetes.DNSDomain; d != "" && d != cc.KubernetesConfig.DNSDomain {
+		errs = append(errs, field.Forbidden(p.Child("kubernetes", "dnsDomain"), immutable))
+	}
+	if r := c.Spec.Kubernetes.ImageRepository; r != "" && r != cc.KubernetesConfig.ImageRepository {
+		errs = append(errs, field.Forbidden(p.Child("kubernetes", "imageRepository"), immutable))
+	}
+	if len(c.Spec.Mounts) == 1 && !mountMatches(c.Spec.Mounts[0], profileMount(cc)) {
+		if driver.IsKIC(cc.Driver) {
+			errs = append(errs, field.Forbidden(p.Child("mounts").Index(0), "mounts cannot be changed after container creation"))
+		} else {
+			errs = append(errs, field.Forbidden(p.Child("mounts").Index(0), immutable))
+		}
+	}
+
+	for i, n := range c.Nodes() {
+		existing := nodeByName(cc, n.Name, i == 0)
+		if existing == nil {
+			continue
+		}
+		if existing.ControlPlane != n.ControlPlane || existing.Worker != n.Worker {
+			errs = append(errs, field.Forbidden(p.Child("nodes").Index(i).Child("roles"), "the roles of an existing node cannot be changed"))
+		}
+	}
+	return errs
+}
+
+// mountMatches returns whether the mount of a spec is the mount of a profile
+func mountMatches(m Mount, existing *Mount) bool {
+	if existing == nil {
+		return false
+	}
+	if len(m.Options) == 0 && len(existing.Options) == 0 {
+		m.Options, existing.Options = nil, nil
+	}
+	// the type, uid and gid default to the values of the profile
+	if m.Type == "" {
+		m.Type = existing.Type
+	}
+	if m.UID == "" {
+		m.UID = existing.UID
+	}
+	if m.GID == "" {
+		m.GID = existing.GID
+	}
+	return reflect.DeepEqual(m, *existing)
+}
+
+// nodeByName returns the node of the profile with the given name, or the primary node
+func nodeByName(cc *config.ClusterConfig, name string, primary bool) *config.Node {
+	if primary {
+		if len(cc.Nodes) == 0 {
+			return nil
+		}
+		return &cc.Nodes[0]
+	}
+	for i := range cc.Nodes {
+		if cc.Nodes[i].Name == name {
+			return &cc.Nodes[i]
+		}
+	}
+	return nil
+}
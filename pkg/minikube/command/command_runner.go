@@ -30,6 +30,7 @@ import (
 
 	"github.com/pkg/errors"
 	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/trace"
 )
 
 var (
@@ -99,6 +100,17 @@ func (rr RunResult) Command() string {
 	return sb.String()
 }
 
+// tracedRunCmd runs cmd using run, recording it as a child span of the current step when tracing is enabled
+func tracedRunCmd(runner string, cmd *exec.Cmd, run func(*exec.Cmd) (*RunResult, error)) (*RunResult, error) {
+	span := trace.StartChildSpan("RunCmd", map[string]string{
+		"runner":  runner,
+		"command": RunResult{Args: cmd.Args}.Command(),
+	})
+	rr, err := run(cmd)
+	span.End(err)
+	return rr, err
+}
+
 // Output returns human-readable output for an execution result
 func (rr RunResult) Output() string {
 	var sb strings.Builder
@@ -47,6 +47,10 @@ func NewExecRunner(sudo bool) Runner {
 
 // RunCmd implements the Command Runner interface to run a exec.Cmd object
 func (e *execRunner) RunCmd(cmd *exec.Cmd) (*RunResult, error) {
+	return tracedRunCmd("exec", cmd, e.runCmd)
+}
+
+func (e *execRunner) runCmd(cmd *exec.Cmd) (*RunResult, error) {
 	rr := &RunResult{Args: cmd.Args}
 	klog.Infof("Run: %v", rr.Command())
 
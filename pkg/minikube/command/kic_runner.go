@@ -52,6 +52,10 @@ func NewKICRunner(containerNameOrID string, oci string) Runner {
 }
 
 func (k *kicRunner) RunCmd(cmd *exec.Cmd) (*RunResult, error) {
+	return tracedRunCmd("kic", cmd, k.runCmd)
+}
+
+func (k *kicRunner) runCmd(cmd *exec.Cmd) (*RunResult, error) {
 	args := []string{
 		"exec",
 		// run with privileges so we can remount etc..
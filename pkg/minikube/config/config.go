@@ -54,6 +54,16 @@ const (
 	AddonListFlag = "addons"
 	// EmbedCerts represents the config for embedding certificates in kubeconfig
 	EmbedCerts = "EmbedCerts"
+	// MaxAuditSizeInMB is the key for the size at which the audit log is rotated
+	MaxAuditSizeInMB = "MaxAuditSizeInMB"
+	// RegistryCacheSize is the key for the size at which the registry cache evicts its least recently used images
+	RegistryCacheSize = "registry-cache-size"
+	// RegistryCachePort is the key for the port of the registry cache
+	RegistryCachePort = "registry-cache-port"
+	// DownloadPolicy is the key for the policy applied to artifacts that cannot be verified
+	DownloadPolicy = "download-policy"
+	// DownloadPublicKey is the key for the public key used to verify the signatures of downloads
+	DownloadPublicKey = "download-public-key"
 )
 
 var (
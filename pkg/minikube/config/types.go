@@ -48,6 +48,7 @@ type ClusterConfig struct {
 	ContainerVolumeMounts   []string // Only used by container drivers: Docker, Podman
 	InsecureRegistry        []string
 	RegistryMirror          []string
+	RegistryCache           bool   // pull the images of docker hub through the registry cache shared by the profiles
 	HostOnlyCIDR            string // Only used by the virtualbox driver
 	HypervVirtualSwitch     string
 	HypervUseExternalSwitch bool
@@ -80,6 +81,7 @@ type ClusterConfig struct {
 	VerifyComponents        map[string]bool   // map of components to verify and wait for after start.
 	StartHostTimeout        time.Duration
 	ScheduledStop           *ScheduledStopConfig
+	RecurringSchedules      []RecurringSchedule
 	ExposedPorts            []string // Only used by the docker and podman driver
 	ListenAddress           string   // Only used by the docker and podman driver
 	Network                 string   // only used by docker driver
@@ -87,6 +89,8 @@ type ClusterConfig struct {
 	MultiNodeRequested      bool
 	ExtraDisks              int // currently only implemented for hyperkit and kvm2
 	CertExpiration          time.Duration
+	CACertFile              string // external CA, or intermediate CA, signing the apiserver and client certs instead of the minikube CA
+	CAKeyFile               string // key of CACertFile
 	Mount                   bool
 	MountString             string
 	Mount9PVersion          string
@@ -97,7 +101,8 @@ type ClusterConfig struct {
 	MountPort               uint16
 	MountType               string
 	MountUID                string
-	BinaryMirror            string // Mirror location for kube binaries (kubectl, kubelet, & kubeadm)
+	SyncDirs                []string // host directories synced to the nodes, as <host dir>:<node dir>
+	BinaryMirror            string   // Mirror location for kube binaries (kubectl, kubelet, & kubeadm)
 	DisableOptimizations    bool
 	DisableMetrics          bool
 }
@@ -113,6 +118,7 @@ type KubernetesConfig struct {
 	DNSDomain           string
 	ContainerRuntime    string
 	CRISocket           string
+	Bootstrapper        string // bootstrapper the cluster was started with, kubeadm if empty
 	NetworkPlugin       string
 	FeatureGates        string // https://kubernetes.io/docs/reference/command-line-tools-reference/feature-gates/
 	ServiceCIDR         string // the subnet which Kubernetes services will be deployed to
@@ -121,6 +127,7 @@ type KubernetesConfig struct {
 	LoadBalancerEndIP   string // currently only used by MetalLB addon
 	CustomIngressCert   string // used by Ingress addon
 	RegistryAliases     string // currently only used by registry-aliases addon
+	StorageQuotas       bool   // currently only used by storage-provisioner addon
 	ExtraOptions        ExtraOptionSlice
 
 	ShouldLoadCachedImages bool
@@ -171,8 +178,14 @@ type VersionedExtraOption struct {
 }
 
 // ScheduledStopConfig contains information around scheduled stop
-// not yet used, will be used to show status of scheduled stop
 type ScheduledStopConfig struct {
 	InitiationTime int64
 	Duration       time.Duration
 }
+
+// RecurringSchedule is a cron-style schedule to repeatedly stop or start a profile
+type RecurringSchedule struct {
+	ID     int
+	Action string // "stop" or "start"
+	Cron   string // 5-field cron expression, evaluated in the local time zone of the host
+}
@@ -187,6 +187,8 @@ var (
 	// KubernetesReleaseBinaries are Kubernetes release binaries required for
 	// kubeadm (kubelet, kubeadm) and the addon manager (kubectl)
 	KubernetesReleaseBinaries = []string{"kubelet", "kubeadm", "kubectl"}
+	// K3sReleaseBinaries are the k3s release binaries, k3s also acting as kubectl
+	K3sReleaseBinaries = []string{"k3s"}
 
 	// DefaultNamespaces are Kubernetes namespaces used by minikube, including addons
 	DefaultNamespaces = []string{
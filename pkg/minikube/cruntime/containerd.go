@@ -25,11 +25,13 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
 
 	"github.com/blang/semver/v4"
+	units "github.com/docker/go-units"
 	"github.com/pkg/errors"
 	"k8s.io/klog/v2"
 	"k8s.io/minikube/pkg/minikube/assets"
@@ -38,6 +40,7 @@ import (
 	"k8s.io/minikube/pkg/minikube/command"
 	"k8s.io/minikube/pkg/minikube/config"
 	"k8s.io/minikube/pkg/minikube/download"
+	"k8s.io/minikube/pkg/minikube/image"
 	"k8s.io/minikube/pkg/minikube/style"
 	"k8s.io/minikube/pkg/minikube/sysinit"
 )
@@ -105,7 +108,7 @@ oom_score = 0
     [plugins."io.containerd.grpc.v1.cri".registry]
       [plugins."io.containerd.grpc.v1.cri".registry.mirrors]
         [plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
-          endpoint = ["https://registry-1.docker.io"]
+          endpoint = [{{ range .RegistryMirror }}"{{.}}", {{ end }}"https://registry-1.docker.io"]
         {{ range .InsecureRegistry -}}
         [plugins."io.containerd.grpc.v1.cri".registry.mirrors."{{. -}}"]
           endpoint = ["http://{{. -}}"]
@@ -129,6 +132,7 @@ type Containerd struct {
 	KubernetesVersion semver.Version
 	Init              sysinit.Manager
 	InsecureRegistry  []string
+	RegistryMirror    []string
 }
 
 // Name is a human readable name for containerd
@@ -194,7 +198,7 @@ func (r *Containerd) Available() error {
 }
 
 // generateContainerdConfig sets up /etc/containerd/config.toml
-func generateContainerdConfig(cr CommandRunner, imageRepository string, kv semver.Version, forceSystemd bool, insecureRegistry []string, inUserNamespace bool) error {
+func generateContainerdConfig(cr CommandRunner, imageRepository string, kv semver.Version, forceSystemd bool, insecureRegistry []string, registryMirror []string, inUserNamespace bool) error {
 	cPath := containerdConfigFile
 	t, err := template.New("containerd.config.toml").Parse(containerdConfigTemplate)
 	if err != nil {
@@ -206,6 +210,7 @@ func generateContainerdConfig(cr CommandRunner, imageRepository string, kv semve
 		PodInfraContainerImage string
 		SystemdCgroup          bool
 		InsecureRegistry       []string
+		RegistryMirror         []string
 		CNIConfDir             string
 		RestrictOOMScoreAdj    bool
 		Snapshotter            string
@@ -213,6 +218,7 @@ func generateContainerdConfig(cr CommandRunner, imageRepository string, kv semve
 		PodInfraContainerImage: pauseImage,
 		SystemdCgroup:          forceSystemd,
 		InsecureRegistry:       insecureRegistry,
+		RegistryMirror:         registryMirror,
 		CNIConfDir:             cni.ConfDir,
 		RestrictOOMScoreAdj:    inUserNamespace,
 		Snapshotter:            snapshotter,
@@ -248,7 +254,7 @@ func (r *Containerd) Enable(disOthers, forceSystemd, inUserNamespace bool) error
 	if err := populateCRIConfig(r.Runner, r.SocketPath()); err != nil {
 		return err
 	}
-	if err := generateContainerdConfig(r.Runner, r.ImageRepository, r.KubernetesVersion, forceSystemd, r.InsecureRegistry, inUserNamespace); err != nil {
+	if err := generateContainerdConfig(r.Runner, r.ImageRepository, r.KubernetesVersion, forceSystemd, r.InsecureRegistry, r.RegistryMirror, inUserNamespace); err != nil {
 		return err
 	}
 	if err := enableIPForwarding(r.Runner); err != nil {
@@ -312,6 +318,85 @@ func (r *Containerd) RemoveImage(name string) error {
 	return removeCRIImage(r.Runner, name)
 }
 
+// ImagesDiskUsage returns the disk space used by the images, from the size of their snapshots
+func (r *Containerd) ImagesDiskUsage() (*DiskUsage, error) {
+	infos, err := inspectCRIImages(r.Runner)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := criContainerImages(r.Runner)
+	if err != nil {
+		return nil, err
+	}
+	rr, err := r.Runner.RunCmd(exec.Command("sudo", "ctr", "-n=k8s.io", "snapshots", "usage"))
+	if err != nil {
+		return nil, errors.Wrap(err, "ctr snapshots usage")
+	}
+	sizes, err := parseSnapshotsUsage(rr.Stdout.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var images []imageLayers
+	for _, info := range infos {
+		id := strings.TrimPrefix(info.Status.ID, "sha256:")
+		size, _ := strconv.ParseInt(info.Status.Size, 10, 64)
+		img := imageLayers{ImageUsage: ImageUsage{
+			ID:         id,
+			RepoTags:   info.Status.RepoTags,
+			Created:    info.Info.Info.ImageSpec.Created,
+			Size:       size,
+			Containers: countContainers(refs, id, info.Status.RepoTags),
+		}}
+		// the committed snapshots of the layers are named after their chain ID
+		for _, chainID := range image.ChainIDs(info.Info.Info.ImageSpec.RootFS.DiffIDs) {
+			img.layers = append(img.layers, layerUsage{key: chainID, size: sizes[chainID]})
+		}
+		images = append(images, img)
+	}
+	return aggregateDiskUsage(images), nil
+}
+
+// parseSnapshotsUsage parses the output of 'ctr snapshots usage', whose sizes are human readable
+func parseSnapshotsUsage(out string) (map[string]int64, error) {
+	sizes := map[string]int64{}
+	for _, line := range strings.Split(out, "\n") {
+		// KEY SIZE UNIT INODES, the header having no unit
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			continue
+		}
+		size, err := units.RAMInBytes(fields[1] + fields[2])
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing the size of snapshot %s", fields[0])
+		}
+		sizes[fields[0]] = size
+	}
+	return sizes, nil
+}
+
+// PruneImages removes images based on ID, along with all their tags
+func (r *Containerd) PruneImages(ids []string) error {
+	var refs []string
+	for _, id := range ids {
+		refs = append(refs, "sha256:"+strings.TrimPrefix(id, "sha256:"))
+	}
+	return removeCRIImages(r.Runner, refs)
+}
+
+// KnownLayers returns the digests of the blobs in the content store
+func (r *Containerd) KnownLayers() (*KnownLayers, error) {
+	rr, err := r.Runner.RunCmd(exec.Command("sudo", "ctr", "-n=k8s.io", "content", "ls", "-q"))
+	if err != nil {
+		return nil, errors.Wrap(err, "ctr content ls")
+	}
+	known := &KnownLayers{Blobs: map[string]bool{}}
+	for _, digest := range strings.Fields(rr.Stdout.String()) {
+		known.Blobs[digest] = true
+	}
+	return known, nil
+}
+
 // TagImage tags an image in this runtime
 func (r *Containerd) TagImage(source string, target string) error {
 	klog.Infof("Tagging image %s: %s", source, target)
@@ -374,13 +459,13 @@ func downloadRemote(cr CommandRunner, src string) (string, error) {
 }
 
 // BuildImage builds an image into this runtime
-func (r *Containerd) BuildImage(src string, file string, tag string, push bool, env []string, opts []string) error {
+func (r *Containerd) BuildImage(src string, opts BuildOptions) error {
 	// download url if not already present
 	dir, err := downloadRemote(r.Runner, src)
 	if err != nil {
 		return err
 	}
-	if file != "" {
+	if file := opts.Dockerfile; file != "" {
 		if dir != src {
 			file = path.Join(dir, file)
 		}
@@ -394,14 +479,28 @@ func (r *Containerd) BuildImage(src string, file string, tag string, push bool,
 		}
 	}
 	klog.Infof("Building image: %s", dir)
+	c := exec.Command("sudo", buildctlArgs(dir, opts)...)
+	e := os.Environ()
+	e = append(e, opts.Env...)
+	c.Env = e
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if _, err := r.Runner.RunCmd(c); err != nil {
+		return errors.Wrap(err, "buildctl build")
+	}
+	return nil
+}
+
+// buildctlArgs returns the command line of buildctl to build the context in dir
+func buildctlArgs(dir string, opts BuildOptions) []string {
 	extra := ""
-	if tag != "" {
+	if tag := opts.Tag; tag != "" {
 		// add default tag if missing
 		if !strings.Contains(tag, ":") {
 			tag += ":latest"
 		}
 		extra = fmt.Sprintf(",name=%s", tag)
-		if push {
+		if opts.Push {
 			extra += ",push=true"
 		}
 	}
@@ -410,19 +509,28 @@ func (r *Containerd) BuildImage(src string, file string, tag string, push bool,
 		"--local", fmt.Sprintf("context=%s", dir),
 		"--local", fmt.Sprintf("dockerfile=%s", dir),
 		"--output", fmt.Sprintf("type=image%s", extra)}
-	for _, opt := range opts {
-		args = append(args, "--"+opt)
+	if len(opts.Platforms) > 0 {
+		args = append(args, "--opt", "platform="+strings.Join(opts.Platforms, ","))
 	}
-	c := exec.Command("sudo", args...)
-	e := os.Environ()
-	e = append(e, env...)
-	c.Env = e
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-	if _, err := r.Runner.RunCmd(c); err != nil {
-		return errors.Wrap(err, "buildctl build")
+	if opts.Target != "" {
+		args = append(args, "--opt", "target="+opts.Target)
 	}
-	return nil
+	for _, arg := range opts.BuildArgs {
+		args = append(args, "--opt", "build-arg:"+arg)
+	}
+	for _, secret := range opts.Secrets {
+		args = append(args, "--secret", secret.String())
+	}
+	if opts.CacheFrom != "" {
+		args = append(args, "--import-cache", fmt.Sprintf("type=local,src=%s", opts.CacheFrom))
+	}
+	if opts.CacheTo != "" {
+		args = append(args, "--export-cache", fmt.Sprintf("type=local,dest=%s,mode=max", opts.CacheTo))
+	}
+	for _, opt := range opts.Opts {
+		args = append(args, "--"+opt)
+	}
+	return args
 }
 
 // PushImage pushes an image
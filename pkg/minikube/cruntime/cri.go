@@ -21,9 +21,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"os/exec"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"k8s.io/klog/v2"
@@ -224,6 +226,22 @@ func removeCRIImage(cr CommandRunner, name string) error {
 	return nil
 }
 
+// removeCRIImages removes images based on ID using crictl
+func removeCRIImages(cr CommandRunner, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	klog.Infof("Removing images: %s", ids)
+
+	crictl := getCrictlPath(cr)
+	args := append([]string{crictl, "rmi"}, ids...)
+	c := exec.Command("sudo", args...)
+	if _, err := cr.RunCmd(c); err != nil {
+		return errors.Wrap(err, "crictl")
+	}
+	return nil
+}
+
 // stopCRIContainers stops containers using crictl
 func stopCRIContainers(cr CommandRunner, ids []string) error {
 	if len(ids) == 0 {
@@ -335,3 +353,150 @@ func addRepoTagToImageName(imgName string) string {
 	} // else it already has repo name dont add anything
 	return imgName
 }
+
+// criImageInfo maps to 'crictl inspecti -o json'
+type criImageInfo struct {
+	Status struct {
+		ID       string   `json:"id"`
+		RepoTags []string `json:"repoTags"`
+		Size     string   `json:"size"`
+	} `json:"status"`
+	Info struct {
+		Info struct {
+			ImageSpec struct {
+				Created time.Time `json:"created"`
+				RootFS  struct {
+					DiffIDs []string `json:"diff_ids"`
+				} `json:"rootfs"`
+			} `json:"imageSpec"`
+		} `json:"info"`
+	} `json:"info"`
+}
+
+// inspectCRIImages inspects all images using crictl
+func inspectCRIImages(cr CommandRunner) ([]criImageInfo, error) {
+	list, err := listCRIImages(cr)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	args := []string{getCrictlPath(cr), "inspecti", "-o", "json"}
+	for _, img := range list {
+		args = append(args, img.ID)
+	}
+	rr, err := cr.RunCmd(exec.Command("sudo", args...))
+	if err != nil {
+		return nil, errors.Wrap(err, "crictl inspecti")
+	}
+	return parseCRIImageInfos(rr.Stdout.Bytes())
+}
+
+// parseCRIImageInfos parses the output of crictl inspecti, which prints a JSON object per image
+func parseCRIImageInfos(out []byte) ([]criImageInfo, error) {
+	var infos []criImageInfo
+	d := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var info criImageInfo
+		if err := d.Decode(&info); err == io.EOF {
+			return infos, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "parsing crictl inspecti")
+		}
+		infos = append(infos, info)
+	}
+}
+
+// criContainerImages returns the image references of every container using crictl, whatever its state
+func criContainerImages(cr CommandRunner) ([][]string, error) {
+	rr, err := cr.RunCmd(exec.Command("sudo", getCrictlPath(cr), "ps", "-a", "-o", "json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "crictl ps")
+	}
+	var ps struct {
+		Containers []struct {
+			ImageRef string `json:"imageRef"`
+			Image    struct {
+				Image string `json:"image"`
+			} `json:"image"`
+		} `json:"containers"`
+	}
+	if err := json.Unmarshal(rr.Stdout.Bytes(), &ps); err != nil {
+		return nil, errors.Wrap(err, "parsing crictl ps")
+	}
+	var refs [][]string
+	for _, c := range ps.Containers {
+		refs = append(refs, []string{strings.TrimPrefix(c.ImageRef, "sha256:"), strings.TrimPrefix(c.Image.Image, "sha256:")})
+	}
+	return refs, nil
+}
+
+// countContainers returns how many containers reference an image, by its ID or one of its tags
+func countContainers(refs [][]string, id string, tags []string) int {
+	names := map[string]bool{id: true}
+	for _, t := range tags {
+		names[t] = true
+	}
+	count := 0
+	for _, cRefs := range refs {
+		for _, ref := range cRefs {
+			if ref != "" && names[ref] {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// layerUsage is the disk space used by a layer, identified by a key unique to its content and the
+// layers below it
+type layerUsage struct {
+	key  string
+	size int64
+}
+
+// imageLayers is an image along with its layers
+type imageLayers struct {
+	ImageUsage
+	layers []layerUsage
+}
+
+// aggregateDiskUsage computes the size of the images from their layers, a layer being shared if
+// another image uses it too. The size of images without known layers is kept.
+func aggregateDiskUsage(images []imageLayers) *DiskUsage {
+	users := map[string]int{}
+	sizes := map[string]int64{}
+	for _, img := range images {
+		seen := map[string]bool{}
+		for _, l := range img.layers {
+			if !seen[l.key] {
+				seen[l.key] = true
+				users[l.key]++
+			}
+			sizes[l.key] = l.size
+		}
+	}
+
+	du := &DiskUsage{Images: []ImageUsage{}}
+	for _, size := range sizes {
+		du.LayersSize += size
+	}
+	for _, img := range images {
+		u := img.ImageUsage
+		if len(img.layers) == 0 {
+			du.LayersSize += u.Size
+		} else {
+			u.Size = 0
+			for _, l := range img.layers {
+				u.Size += l.size
+				if users[l.key] > 1 {
+					u.SharedSize += l.size
+				}
+			}
+		}
+		du.Images = append(du.Images, u)
+	}
+	return du
+}
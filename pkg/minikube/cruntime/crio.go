@@ -17,8 +17,10 @@ limitations under the License.
 package cruntime
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path"
@@ -42,6 +44,10 @@ import (
 const (
 	// CRIOConfFile is the path to the CRI-O configuration
 	crioConfigFile = "/etc/crio/crio.conf.d/02-crio.conf"
+	// crioRegistriesConfFile is the drop-in configuring the mirrors of docker hub
+	crioRegistriesConfFile = "/etc/containers/registries.conf.d/01-minikube-mirrors.conf"
+	// crioStorageRoot is where the images, layers and containers of CRI-O are stored
+	crioStorageRoot = "/var/lib/containers/storage"
 )
 
 // CRIO contains CRIO runtime state
@@ -51,6 +57,7 @@ type CRIO struct {
 	ImageRepository   string
 	KubernetesVersion semver.Version
 	Init              sysinit.Manager
+	RegistryMirror    []string
 }
 
 // generateCRIOConfig sets up /etc/crio/crio.conf
@@ -72,6 +79,39 @@ func generateCRIOConfig(cr CommandRunner, imageRepository string, kv semver.Vers
 	return nil
 }
 
+// crioRegistriesConf returns the configuration of the mirrors of docker hub, in the format of containers-registries.conf
+func crioRegistriesConf(mirrors []string) (string, error) {
+	var b strings.Builder
+	b.WriteString("[[registry]]\nlocation = \"docker.io\"\n")
+	for _, m := range mirrors {
+		u, err := url.Parse(m)
+		if err != nil {
+			return "", errors.Wrapf(err, "parsing registry mirror %q", m)
+		}
+		fmt.Fprintf(&b, "\n[[registry.mirror]]\nlocation = %q\ninsecure = %t\n", u.Host, u.Scheme == "http")
+	}
+	return b.String(), nil
+}
+
+// generateCRIORegistriesConfig sets up the mirrors of docker hub
+func generateCRIORegistriesConfig(cr CommandRunner, mirrors []string) error {
+	if len(mirrors) == 0 {
+		if _, err := cr.RunCmd(exec.Command("sudo", "rm", "-f", crioRegistriesConfFile)); err != nil {
+			return errors.Wrap(err, "removing registry mirrors")
+		}
+		return nil
+	}
+	conf, err := crioRegistriesConf(mirrors)
+	if err != nil {
+		return err
+	}
+	c := exec.Command("/bin/bash", "-c", fmt.Sprintf("sudo mkdir -p %s && printf %%s \"%s\" | base64 -d | sudo tee %s", path.Dir(crioRegistriesConfFile), base64.StdEncoding.EncodeToString([]byte(conf)), crioRegistriesConfFile))
+	if _, err := cr.RunCmd(c); err != nil {
+		return errors.Wrap(err, "generate registry mirrors")
+	}
+	return nil
+}
+
 func (r *CRIO) forceSystemd() error {
 	c := exec.Command("/bin/bash", "-c", fmt.Sprintf("sudo sed -e 's|^.*cgroup_manager = .*$|cgroup_manager = \"systemd\"|' -i %s", crioConfigFile))
 	if _, err := r.Runner.RunCmd(c); err != nil {
@@ -197,6 +237,9 @@ func (r *CRIO) Enable(disOthers, forceSystemd, inUserNamespace bool) error {
 	if err := generateCRIOConfig(r.Runner, r.ImageRepository, r.KubernetesVersion); err != nil {
 		return err
 	}
+	if err := generateCRIORegistriesConfig(r.Runner, r.RegistryMirror); err != nil {
+		return err
+	}
 	if err := enableIPForwarding(r.Runner); err != nil {
 		return err
 	}
@@ -276,6 +319,96 @@ func (r *CRIO) RemoveImage(name string) error {
 	return removeCRIImage(r.Runner, name)
 }
 
+// crioStorageImage is an image in the overlay-images/images.json file of containers/storage
+type crioStorageImage struct {
+	ID       string    `json:"id"`
+	Names    []string  `json:"names"`
+	TopLayer string    `json:"layer"`
+	Created  time.Time `json:"created"`
+}
+
+// crioStorageLayer is a layer in the overlay-layers/layers.json file of containers/storage
+type crioStorageLayer struct {
+	ID     string `json:"id"`
+	Parent string `json:"parent"`
+	Size   int64  `json:"diff-size"`
+}
+
+// crioStorageContainer is a container in the overlay-containers/containers.json file of containers/storage
+type crioStorageContainer struct {
+	ID    string `json:"id"`
+	Image string `json:"image"`
+}
+
+// ImagesDiskUsage returns the disk space used by the images, from the metadata of the storage
+// shared by CRI-O and podman
+func (r *CRIO) ImagesDiskUsage() (*DiskUsage, error) {
+	var images []crioStorageImage
+	var layers []crioStorageLayer
+	var containers []crioStorageContainer
+	for file, v := range map[string]interface{}{
+		"overlay-images/images.json":         &images,
+		"overlay-layers/layers.json":         &layers,
+		"overlay-containers/containers.json": &containers,
+	} {
+		// the files are only created along with the first image or container
+		c := exec.Command("sudo", "sh", "-c", fmt.Sprintf("cat %s 2>/dev/null || echo '[]'", path.Join(crioStorageRoot, file)))
+		rr, err := r.Runner.RunCmd(c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", file)
+		}
+		if err := json.Unmarshal(rr.Stdout.Bytes(), v); err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", file)
+		}
+	}
+	return crioDiskUsage(images, layers, containers), nil
+}
+
+// crioDiskUsage computes the disk usage of the images of containers/storage, the pod infra
+// containers being counted along with the other ones
+func crioDiskUsage(images []crioStorageImage, layers []crioStorageLayer, containers []crioStorageContainer) *DiskUsage {
+	byID := map[string]crioStorageLayer{}
+	for _, l := range layers {
+		byID[l.ID] = l
+	}
+	used := map[string]int{}
+	for _, c := range containers {
+		used[c.Image]++
+	}
+
+	var result []imageLayers
+	for _, img := range images {
+		u := imageLayers{ImageUsage: ImageUsage{ID: img.ID, RepoTags: []string{}, Created: img.Created, Containers: used[img.ID]}}
+		for _, name := range img.Names {
+			// the names also hold the digests the image was pulled by
+			if !strings.Contains(name, "@") {
+				u.RepoTags = append(u.RepoTags, name)
+			}
+		}
+		// the layers are stacked from the top one, a layer ID depending on the layers below it
+		for id := img.TopLayer; id != ""; {
+			l, ok := byID[id]
+			if !ok {
+				break
+			}
+			u.layers = append(u.layers, layerUsage{key: l.ID, size: l.Size})
+			id = l.Parent
+		}
+		result = append(result, u)
+	}
+	return aggregateDiskUsage(result)
+}
+
+// PruneImages removes images based on ID, along with all their tags
+func (r *CRIO) PruneImages(ids []string) error {
+	return removeCRIImages(r.Runner, ids)
+}
+
+// KnownLayers is not supported, as podman only loads images with all their layers
+func (r *CRIO) KnownLayers() (*KnownLayers, error) {
+	return nil, errors.New("podman loads images with all their layers")
+}
+
 // TagImage tags an image in this runtime
 func (r *CRIO) TagImage(source string, target string) error {
 	klog.Infof("Tagging image %s: %s", source, target)
@@ -287,30 +420,25 @@ func (r *CRIO) TagImage(source string, target string) error {
 }
 
 // BuildImage builds an image into this runtime
-func (r *CRIO) BuildImage(src string, file string, tag string, push bool, env []string, opts []string) error {
+func (r *CRIO) BuildImage(src string, opts BuildOptions) error {
 	klog.Infof("Building image: %s", src)
-	args := []string{"podman", "build"}
-	if file != "" {
-		args = append(args, "-f", file)
+	if len(opts.Platforms) > 1 {
+		return errors.New("crio can't build an image for multiple platforms")
 	}
-	if tag != "" {
-		args = append(args, "-t", tag)
+	if opts.CacheFrom != "" || opts.CacheTo != "" {
+		return errors.New("crio can't import or export a local build cache")
 	}
-	args = append(args, src)
-	for _, opt := range opts {
-		args = append(args, "--"+opt)
-	}
-	c := exec.Command("sudo", args...)
+	c := exec.Command("sudo", podmanBuildArgs(src, opts)...)
 	e := os.Environ()
-	e = append(e, env...)
+	e = append(e, opts.Env...)
 	c.Env = e
 	c.Stdout = os.Stdout
 	c.Stderr = os.Stderr
 	if _, err := r.Runner.RunCmd(c); err != nil {
 		return errors.Wrap(err, "crio build image")
 	}
-	if tag != "" && push {
-		c := exec.Command("sudo", "podman", "push", tag)
+	if opts.Tag != "" && opts.Push {
+		c := exec.Command("sudo", "podman", "push", opts.Tag)
 		c.Stdout = os.Stdout
 		c.Stderr = os.Stderr
 		if _, err := r.Runner.RunCmd(c); err != nil {
@@ -320,6 +448,34 @@ func (r *CRIO) BuildImage(src string, file string, tag string, push bool, env []
 	return nil
 }
 
+// podmanBuildArgs returns the command line of podman to build an image
+func podmanBuildArgs(src string, opts BuildOptions) []string {
+	args := []string{"podman", "build"}
+	if opts.Dockerfile != "" {
+		args = append(args, "-f", opts.Dockerfile)
+	}
+	if opts.Tag != "" {
+		args = append(args, "-t", opts.Tag)
+	}
+	if len(opts.Platforms) > 0 {
+		args = append(args, "--platform", opts.Platforms[0])
+	}
+	if opts.Target != "" {
+		args = append(args, "--target", opts.Target)
+	}
+	for _, arg := range opts.BuildArgs {
+		args = append(args, "--build-arg", arg)
+	}
+	for _, secret := range opts.Secrets {
+		args = append(args, "--secret", secret.String())
+	}
+	args = append(args, src)
+	for _, opt := range opts.Opts {
+		args = append(args, "--"+opt)
+	}
+	return args
+}
+
 // PushImage pushes an image
 func (r *CRIO) PushImage(name string) error {
 	klog.Infof("Pushing image %s", name)
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/blang/semver/v4"
 	"github.com/pkg/errors"
@@ -103,7 +104,7 @@ type Manager interface {
 	// Pull an image to the runtime from the container registry
 	PullImage(string) error
 	// Build an image idempotently into the runtime on a host
-	BuildImage(string, string, string, bool, []string, []string) error
+	BuildImage(string, BuildOptions) error
 	// Save an image from the runtime on a host
 	SaveImage(string, string) error
 	// Tag an image
@@ -118,6 +119,12 @@ type Manager interface {
 
 	// RemoveImage remove image based on name
 	RemoveImage(string) error
+	// ImagesDiskUsage returns the disk space used by the images
+	ImagesDiskUsage() (*DiskUsage, error)
+	// PruneImages removes images based on ID, along with all their tags
+	PruneImages([]string) error
+	// KnownLayers returns the layers stored by the runtime, which loading an image can leave out
+	KnownLayers() (*KnownLayers, error)
 
 	// ListContainers returns a list of containers managed by this container runtime
 	ListContainers(ListContainersOptions) ([]string, error)
@@ -153,6 +160,8 @@ type Config struct {
 	KubernetesVersion semver.Version
 	// InsecureRegistry list of insecure registries
 	InsecureRegistry []string
+	// RegistryMirror list of mirrors of docker hub
+	RegistryMirror []string
 }
 
 // ListContainersOptions are the options to use for listing containers
@@ -176,6 +185,76 @@ type ListImage struct {
 	Size        string   `json:"size" yaml:"size"`
 }
 
+// BuildOptions are the options to use for building images
+type BuildOptions struct {
+	// Dockerfile is the path of the Dockerfile, defaulting to the one at the root of the context
+	Dockerfile string
+	// Tag is the name given to the new image
+	Tag string
+	// Push pushes the new image to its registry (requires Tag)
+	Push bool
+	// Env are the environment variables of the build (format: key=value)
+	Env []string
+	// Opts are arbitrary flags passed to the builder (format: key=value)
+	Opts []string
+	// Platforms are the platforms to build the image for, defaulting to the one of the node
+	Platforms []string
+	// Target is the stage of a multi-stage Dockerfile to build
+	Target string
+	// BuildArgs are the build-time variables (format: key=value)
+	BuildArgs []string
+	// Secrets are the files of the node exposed to the build as secrets
+	Secrets []BuildSecret
+	// CacheFrom is a directory of the node to import the build cache from
+	CacheFrom string
+	// CacheTo is a directory of the node to export the build cache to
+	CacheTo string
+}
+
+// BuildSecret is a file exposed to RUN --mount=type=secret instructions
+type BuildSecret struct {
+	ID  string
+	Src string
+}
+
+// String returns the secret in the format used by the builders
+func (s BuildSecret) String() string {
+	return fmt.Sprintf("id=%s,src=%s", s.ID, s.Src)
+}
+
+// ImageUsage is the disk space used by an image
+type ImageUsage struct {
+	ID       string    `json:"id" yaml:"id"`
+	RepoTags []string  `json:"repoTags" yaml:"repoTags"`
+	Created  time.Time `json:"created" yaml:"created"`
+	// Size is the size of all the layers of the image
+	Size int64 `json:"size" yaml:"size"`
+	// SharedSize is the size of the layers of the image which other images use too
+	SharedSize int64 `json:"sharedSize" yaml:"sharedSize"`
+	// Containers is the number of containers created from the image, whatever their state
+	Containers int `json:"containers" yaml:"containers"`
+}
+
+// UniqueSize returns the disk space which removing the image frees
+func (i ImageUsage) UniqueSize() int64 {
+	return i.Size - i.SharedSize
+}
+
+// DiskUsage is the disk space used by the images of a container runtime
+type DiskUsage struct {
+	Images []ImageUsage `json:"images" yaml:"images"`
+	// LayersSize is the size of the layers of all images, the shared ones being counted once
+	LayersSize int64 `json:"layersSize" yaml:"layersSize"`
+}
+
+// KnownLayers are the layers stored by a runtime, identified in the way its image loading reuses them
+type KnownLayers struct {
+	// ChainIDs are the chain IDs of the layers in the layer store of docker
+	ChainIDs map[string]bool
+	// Blobs are the digests of the blobs in the content store of containerd
+	Blobs map[string]bool
+}
+
 // ErrContainerRuntimeNotRunning is thrown when container runtime is not running
 var ErrContainerRuntimeNotRunning = errors.New("container runtime is not running")
 
@@ -232,6 +311,7 @@ func New(c Config) (Manager, error) {
 			ImageRepository:   c.ImageRepository,
 			KubernetesVersion: c.KubernetesVersion,
 			Init:              sm,
+			RegistryMirror:    c.RegistryMirror,
 		}, nil
 	case "containerd":
 		return &Containerd{
@@ -241,6 +321,7 @@ func New(c Config) (Manager, error) {
 			KubernetesVersion: c.KubernetesVersion,
 			Init:              sm,
 			InsecureRegistry:  c.InsecureRegistry,
+			RegistryMirror:    c.RegistryMirror,
 		}, nil
 	default:
 		return nil, fmt.Errorf("unknown runtime type: %q", c.Type)
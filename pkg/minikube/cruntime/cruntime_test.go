@@ -22,6 +22,7 @@ import (
 	"os/exec"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/blang/semver/v4"
 	"github.com/google/go-cmp/cmp"
@@ -30,6 +31,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/minikube/pkg/minikube/assets"
 	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/image"
 )
 
 func TestName(t *testing.T) {
@@ -797,3 +799,178 @@ func TestContainerFunctions(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDockerDiskUsage(t *testing.T) {
+	data := `{"LayersSize": 300, "Images": [
+		{"Id": "sha256:aaa", "RepoTags": ["nginx:latest"], "Created": 1650000000, "Size": 200, "SharedSize": 100, "Containers": 2},
+		{"Id": "sha256:bbb", "RepoTags": ["<none>:<none>"], "Created": 1650000000, "Size": 100, "SharedSize": -1, "Containers": -1}
+	]}`
+	got, err := parseDockerDiskUsage([]byte(data))
+	if err != nil {
+		t.Fatalf("parseDockerDiskUsage: %v", err)
+	}
+	created := time.Unix(1650000000, 0)
+	want := &DiskUsage{LayersSize: 300, Images: []ImageUsage{
+		{ID: "aaa", RepoTags: []string{"docker.io/library/nginx:latest"}, Created: created, Size: 200, SharedSize: 100, Containers: 2},
+		{ID: "bbb", RepoTags: []string{}, Created: created, Size: 100},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseDockerDiskUsage() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseSnapshotsUsage(t *testing.T) {
+	out := `KEY                                                                     SIZE      INODES
+sha256:aaa                                                              4.0 KiB   2
+sha256:bbb                                                              1.5 MiB   120
+`
+	got, err := parseSnapshotsUsage(out)
+	if err != nil {
+		t.Fatalf("parseSnapshotsUsage: %v", err)
+	}
+	want := map[string]int64{"sha256:aaa": 4096, "sha256:bbb": 1572864}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseSnapshotsUsage() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestCRIImageLayers(t *testing.T) {
+	out := `{"status": {"id": "sha256:aaa", "repoTags": ["docker.io/library/nginx:latest"], "size": "300"},
+  "info": {"info": {"imageSpec": {"created": "2022-04-15T05:20:00Z", "rootfs": {"diff_ids": ["sha256:l1", "sha256:l2"]}}}}}
+{"status": {"id": "sha256:bbb", "repoTags": [], "size": "100"}, "info": {}}
+`
+	infos, err := parseCRIImageInfos([]byte(out))
+	if err != nil {
+		t.Fatalf("parseCRIImageInfos: %v", err)
+	}
+	if len(infos) != 2 || infos[0].Status.ID != "sha256:aaa" || len(infos[1].Info.Info.ImageSpec.RootFS.DiffIDs) != 0 {
+		t.Fatalf("parseCRIImageInfos() = %+v", infos)
+	}
+
+	ids := image.ChainIDs(infos[0].Info.Info.ImageSpec.RootFS.DiffIDs)
+	// the chain ID of the second layer is the digest of "sha256:l1 sha256:l2"
+	want := []string{"sha256:l1", "sha256:c91167527fc0a2e4d29a0410abd0fa7be764b287ccb5820c0dcaf117806d6cae"}
+	if diff := cmp.Diff(want, ids); diff != "" {
+		t.Errorf("ChainIDs() returned diff (-want +got):\n%s", diff)
+	}
+
+	refs := [][]string{{"aaa", "docker.io/library/nginx:latest"}, {"", "docker.io/library/nginx:latest"}, {"ccc", ""}}
+	if got := countContainers(refs, "aaa", []string{"docker.io/library/nginx:latest"}); got != 2 {
+		t.Errorf("countContainers() = %d, want 2", got)
+	}
+}
+
+func TestCrioDiskUsage(t *testing.T) {
+	created := time.Date(2022, 4, 15, 5, 20, 0, 0, time.UTC)
+	images := []crioStorageImage{
+		{ID: "app1", Names: []string{"localhost/app:v1", "localhost/app@sha256:111"}, TopLayer: "l3", Created: created},
+		{ID: "app2", Names: []string{"localhost/app:v2"}, TopLayer: "l4", Created: created},
+		{ID: "pause", Names: []string{"registry.k8s.io/pause:3.7"}, TopLayer: "p1", Created: created},
+	}
+	layers := []crioStorageLayer{
+		{ID: "l1", Size: 100},
+		{ID: "l2", Parent: "l1", Size: 50},
+		{ID: "l3", Parent: "l2", Size: 10},
+		{ID: "l4", Parent: "l2", Size: 20},
+		{ID: "p1", Size: 1},
+	}
+	containers := []crioStorageContainer{{ID: "c1", Image: "app2"}, {ID: "infra", Image: "pause"}}
+
+	got := crioDiskUsage(images, layers, containers)
+	want := &DiskUsage{LayersSize: 181, Images: []ImageUsage{
+		{ID: "app1", RepoTags: []string{"localhost/app:v1"}, Created: created, Size: 160, SharedSize: 150},
+		{ID: "app2", RepoTags: []string{"localhost/app:v2"}, Created: created, Size: 170, SharedSize: 150, Containers: 1},
+		{ID: "pause", RepoTags: []string{"registry.k8s.io/pause:3.7"}, Created: created, Size: 1, Containers: 1},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("crioDiskUsage() returned diff (-want +got):\n%s", diff)
+	}
+	if unique := got.Images[0].UniqueSize(); unique != 10 {
+		t.Errorf("UniqueSize() = %d, want 10", unique)
+	}
+}
+
+func TestBuildArgs(t *testing.T) {
+	opts := BuildOptions{
+		Tag:       "app",
+		Platforms: []string{"linux/amd64", "linux/arm64"},
+		Target:    "prod",
+		BuildArgs: []string{"VERSION=1"},
+		Secrets:   []BuildSecret{{ID: "npm", Src: "/tmp/npmrc"}},
+		CacheFrom: "/build/cache",
+		CacheTo:   "/build/cache",
+		Opts:      []string{"no-cache"},
+	}
+
+	want := []string{"buildctl", "build", "--frontend", "dockerfile.v0", "--local", "context=/ctx", "--local", "dockerfile=/ctx",
+		"--output", "type=image,name=app:latest", "--opt", "platform=linux/amd64,linux/arm64", "--opt", "target=prod",
+		"--opt", "build-arg:VERSION=1", "--secret", "id=npm,src=/tmp/npmrc",
+		"--import-cache", "type=local,src=/build/cache", "--export-cache", "type=local,dest=/build/cache,mode=max", "--no-cache"}
+	if diff := cmp.Diff(want, buildctlArgs("/ctx", opts)); diff != "" {
+		t.Errorf("buildctlArgs() returned diff (-want +got):\n%s", diff)
+	}
+
+	want = []string{"buildx", "build", "--builder", "minikube", "--push", "-t", "app",
+		"--platform", "linux/amd64,linux/arm64", "--target", "prod", "--build-arg", "VERSION=1", "--secret", "id=npm,src=/tmp/npmrc",
+		"--cache-from", "type=local,src=/build/cache", "--cache-to", "type=local,dest=/build/cache,mode=max", "/ctx", "--no-cache"}
+	if diff := cmp.Diff(want, dockerBuildArgs("/ctx", opts, needsBuildxBuilder(opts))); diff != "" {
+		t.Errorf("dockerBuildArgs() returned diff (-want +got):\n%s", diff)
+	}
+
+	// a single platform and secrets only need the default builder
+	opts = BuildOptions{Tag: "app", Platforms: []string{"linux/arm64"}, Secrets: []BuildSecret{{ID: "npm", Src: "/tmp/npmrc"}}}
+	want = []string{"buildx", "build", "-t", "app", "--platform", "linux/arm64", "--secret", "id=npm,src=/tmp/npmrc", "/ctx"}
+	if diff := cmp.Diff(want, dockerBuildArgs("/ctx", opts, needsBuildxBuilder(opts))); diff != "" {
+		t.Errorf("dockerBuildArgs() returned diff (-want +got):\n%s", diff)
+	}
+
+	opts = BuildOptions{Dockerfile: "Dockerfile.dev", Tag: "app", BuildArgs: []string{"VERSION=1"}}
+	want = []string{"build", "-f", "Dockerfile.dev", "-t", "app", "--build-arg", "VERSION=1", "/ctx"}
+	if diff := cmp.Diff(want, dockerBuildArgs("/ctx", opts, needsBuildxBuilder(opts))); diff != "" {
+		t.Errorf("dockerBuildArgs() returned diff (-want +got):\n%s", diff)
+	}
+
+	want = []string{"podman", "build", "-f", "Dockerfile.dev", "-t", "app", "--build-arg", "VERSION=1", "/ctx"}
+	if diff := cmp.Diff(want, podmanBuildArgs("/ctx", opts)); diff != "" {
+		t.Errorf("podmanBuildArgs() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseDockerRootFS(t *testing.T) {
+	out := `["sha256:l1","sha256:l2"]
+["sha256:l1"]
+
+`
+	got := map[string]bool{}
+	if err := parseDockerRootFS([]byte(out), got); err != nil {
+		t.Fatalf("parseDockerRootFS() error = %v", err)
+	}
+	want := map[string]bool{"sha256:l1": true, "sha256:c91167527fc0a2e4d29a0410abd0fa7be764b287ccb5820c0dcaf117806d6cae": true}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseDockerRootFS() returned diff (-want +got):\n%s", diff)
+	}
+	if err := parseDockerRootFS([]byte("<no value>\n"), got); err == nil {
+		t.Errorf("parseDockerRootFS() expected an error for invalid output")
+	}
+}
+
+func TestCRIORegistriesConf(t *testing.T) {
+	got, err := crioRegistriesConf([]string{"http://host.minikube.internal:5100", "https://mirror.gcr.io"})
+	if err != nil {
+		t.Fatalf("crioRegistriesConf() error = %v", err)
+	}
+	want := `[[registry]]
+location = "docker.io"
+
+[[registry.mirror]]
+location = "host.minikube.internal:5100"
+insecure = true
+
+[[registry.mirror]]
+location = "mirror.gcr.io"
+insecure = false
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("crioRegistriesConf() returned diff (-want +got):\n%s", diff)
+	}
+}
@@ -39,6 +39,7 @@ import (
 	"k8s.io/minikube/pkg/minikube/config"
 	"k8s.io/minikube/pkg/minikube/docker"
 	"k8s.io/minikube/pkg/minikube/download"
+	"k8s.io/minikube/pkg/minikube/image"
 	"k8s.io/minikube/pkg/minikube/style"
 	"k8s.io/minikube/pkg/minikube/sysinit"
 )
@@ -299,6 +300,120 @@ func (r *Docker) RemoveImage(name string) error {
 	return nil
 }
 
+// dockerDiskUsage maps to the images of the response of the system/df endpoint of the docker API
+type dockerDiskUsage struct {
+	LayersSize int64
+	Images     []struct {
+		ID         string `json:"Id"`
+		RepoTags   []string
+		Created    int64
+		Size       int64
+		SharedSize int64
+		Containers int
+	}
+}
+
+// ImagesDiskUsage returns the disk space used by the images
+func (r *Docker) ImagesDiskUsage() (*DiskUsage, error) {
+	// unlike the API, 'docker system df -v' has no machine readable output in all docker versions
+	c := exec.Command("sudo", "curl", "-sSf", "--unix-socket", "/var/run/docker.sock", "http://localhost/system/df")
+	rr, err := r.Runner.RunCmd(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "docker disk usage")
+	}
+	return parseDockerDiskUsage(rr.Stdout.Bytes())
+}
+
+// parseDockerDiskUsage parses the response of the system/df endpoint of the docker API
+func parseDockerDiskUsage(data []byte) (*DiskUsage, error) {
+	var df dockerDiskUsage
+	if err := json.Unmarshal(data, &df); err != nil {
+		return nil, errors.Wrap(err, "parsing docker disk usage")
+	}
+	du := &DiskUsage{Images: []ImageUsage{}, LayersSize: df.LayersSize}
+	for _, img := range df.Images {
+		tags := []string{}
+		for _, t := range img.RepoTags {
+			if t != "<none>:<none>" {
+				tags = append(tags, addDockerIO(t))
+			}
+		}
+		u := ImageUsage{
+			ID:         strings.TrimPrefix(img.ID, "sha256:"),
+			RepoTags:   tags,
+			Created:    time.Unix(img.Created, 0),
+			Size:       img.Size,
+			SharedSize: img.SharedSize,
+			Containers: img.Containers,
+		}
+		// -1 is returned for the values docker did not compute
+		if u.SharedSize < 0 {
+			u.SharedSize = 0
+		}
+		if u.Containers < 0 {
+			u.Containers = 0
+		}
+		du.Images = append(du.Images, u)
+	}
+	return du, nil
+}
+
+// PruneImages removes images based on ID, along with all their tags
+func (r *Docker) PruneImages(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	klog.Infof("Removing images: %s", ids)
+	// removing an image by ID requires forcing it when it has several tags
+	c := exec.Command("docker", append([]string{"rmi", "-f"}, ids...)...)
+	if _, err := r.Runner.RunCmd(c); err != nil {
+		return errors.Wrap(err, "remove images docker")
+	}
+	return nil
+}
+
+// KnownLayers returns the chain IDs of the layers of all the images
+func (r *Docker) KnownLayers() (*KnownLayers, error) {
+	if r.UseCRI {
+		return nil, errors.New("docker loads the images of cri-dockerd with all their layers")
+	}
+	rr, err := r.Runner.RunCmd(exec.Command("docker", "images", "-aq", "--no-trunc"))
+	if err != nil {
+		return nil, errors.Wrap(err, "docker images")
+	}
+	known := &KnownLayers{ChainIDs: map[string]bool{}}
+	ids := strings.Fields(rr.Stdout.String())
+	if len(ids) == 0 {
+		return known, nil
+	}
+	args := append([]string{"image", "inspect", "--format", "{{json .RootFS.Layers}}"}, ids...)
+	rr, err = r.Runner.RunCmd(exec.Command("docker", args...))
+	if err != nil {
+		return nil, errors.Wrap(err, "docker image inspect")
+	}
+	if err := parseDockerRootFS(rr.Stdout.Bytes(), known.ChainIDs); err != nil {
+		return nil, err
+	}
+	return known, nil
+}
+
+// parseDockerRootFS adds the chain IDs of the layers of images, listed by docker image inspect one per line
+func parseDockerRootFS(out []byte, chainIDs map[string]bool) error {
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var diffIDs []string
+		if err := json.Unmarshal([]byte(line), &diffIDs); err != nil {
+			return errors.Wrapf(err, "parsing layers %q", line)
+		}
+		for _, id := range image.ChainIDs(diffIDs) {
+			chainIDs[id] = true
+		}
+	}
+	return nil
+}
+
 // TagImage tags an image in this runtime
 func (r *Docker) TagImage(source string, target string) error {
 	klog.Infof("Tagging image %s: %s", source, target)
@@ -309,31 +424,32 @@ func (r *Docker) TagImage(source string, target string) error {
 	return nil
 }
 
+// dockerBuilder is the buildx builder used for the builds the docker driver of buildx can't do
+const dockerBuilder = "minikube"
+
 // BuildImage builds an image into this runtime
-func (r *Docker) BuildImage(src string, file string, tag string, push bool, env []string, opts []string) error {
+func (r *Docker) BuildImage(src string, opts BuildOptions) error {
 	klog.Infof("Building image: %s", src)
-	args := []string{"build"}
-	if file != "" {
-		args = append(args, "-f", file)
-	}
-	if tag != "" {
-		args = append(args, "-t", tag)
-	}
-	args = append(args, src)
-	for _, opt := range opts {
-		args = append(args, "--"+opt)
+	builder := needsBuildxBuilder(opts)
+	if builder {
+		if len(opts.Platforms) > 1 && !opts.Push {
+			return errors.New("docker can't store an image for multiple platforms, it has to be pushed")
+		}
+		if err := r.ensureBuildxBuilder(); err != nil {
+			return err
+		}
 	}
-	c := exec.Command("docker", args...)
+	c := exec.Command("docker", dockerBuildArgs(src, opts, builder)...)
 	e := os.Environ()
-	e = append(e, env...)
+	e = append(e, opts.Env...)
 	c.Env = e
 	c.Stdout = os.Stdout
 	c.Stderr = os.Stderr
 	if _, err := r.Runner.RunCmd(c); err != nil {
 		return errors.Wrap(err, "buildimage docker")
 	}
-	if tag != "" && push {
-		c := exec.Command("docker", "push", tag)
+	if opts.Tag != "" && opts.Push && len(opts.Platforms) <= 1 {
+		c := exec.Command("docker", "push", opts.Tag)
 		c.Stdout = os.Stdout
 		c.Stderr = os.Stderr
 		if _, err := r.Runner.RunCmd(c); err != nil {
@@ -343,6 +459,79 @@ func (r *Docker) BuildImage(src string, file string, tag string, push bool, env
 	return nil
 }
 
+// needsBuildx returns whether the build uses features docker build doesn't have
+func needsBuildx(opts BuildOptions) bool {
+	return len(opts.Platforms) > 0 || len(opts.Secrets) > 0 || needsBuildxBuilder(opts)
+}
+
+// needsBuildxBuilder returns whether the build needs a docker-container builder,
+// as the default builder can neither build several platforms nor export its cache
+func needsBuildxBuilder(opts BuildOptions) bool {
+	return len(opts.Platforms) > 1 || opts.CacheFrom != "" || opts.CacheTo != ""
+}
+
+// ensureBuildxBuilder creates the buildx builder of minikube if it doesn't exist yet
+func (r *Docker) ensureBuildxBuilder() error {
+	if _, err := r.Runner.RunCmd(exec.Command("docker", "buildx", "version")); err != nil {
+		return errors.Wrap(err, "docker buildx is not available")
+	}
+	if _, err := r.Runner.RunCmd(exec.Command("docker", "buildx", "inspect", dockerBuilder)); err == nil {
+		return nil
+	}
+	klog.Infof("Creating buildx builder %s", dockerBuilder)
+	c := exec.Command("docker", "buildx", "create", "--name", dockerBuilder, "--driver", "docker-container")
+	if _, err := r.Runner.RunCmd(c); err != nil {
+		return errors.Wrap(err, "buildx create")
+	}
+	return nil
+}
+
+// dockerBuildArgs returns the arguments of docker to build an image
+func dockerBuildArgs(src string, opts BuildOptions, builder bool) []string {
+	args := []string{"build"}
+	if needsBuildx(opts) {
+		args = []string{"buildx", "build"}
+		if builder {
+			args = append(args, "--builder", dockerBuilder)
+			// the images of a docker-container builder stay in the builder otherwise
+			if len(opts.Platforms) > 1 {
+				args = append(args, "--push")
+			} else {
+				args = append(args, "--load")
+			}
+		}
+	}
+	if opts.Dockerfile != "" {
+		args = append(args, "-f", opts.Dockerfile)
+	}
+	if opts.Tag != "" {
+		args = append(args, "-t", opts.Tag)
+	}
+	if len(opts.Platforms) > 0 {
+		args = append(args, "--platform", strings.Join(opts.Platforms, ","))
+	}
+	if opts.Target != "" {
+		args = append(args, "--target", opts.Target)
+	}
+	for _, arg := range opts.BuildArgs {
+		args = append(args, "--build-arg", arg)
+	}
+	for _, secret := range opts.Secrets {
+		args = append(args, "--secret", secret.String())
+	}
+	if opts.CacheFrom != "" {
+		args = append(args, "--cache-from", fmt.Sprintf("type=local,src=%s", opts.CacheFrom))
+	}
+	if opts.CacheTo != "" {
+		args = append(args, "--cache-to", fmt.Sprintf("type=local,dest=%s,mode=max", opts.CacheTo))
+	}
+	args = append(args, src)
+	for _, opt := range opts.Opts {
+		args = append(args, "--"+opt)
+	}
+	return args
+}
+
 // PushImage pushes an image
 func (r *Docker) PushImage(name string) error {
 	klog.Infof("Pushing image: %s", name)
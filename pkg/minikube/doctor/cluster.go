@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doctor
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/machine/libmachine"
+	"github.com/docker/machine/libmachine/state"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/kapi"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/bsutil/kverify"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/driver"
+	"k8s.io/minikube/pkg/minikube/kubeconfig"
+	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/reason"
+)
+
+// noneDriverPorts are the host ports used by the control plane of the none driver, besides the apiserver port
+var noneDriverPorts = []int{2379, 2380, 10250, 10257, 10259}
+
+// checkClusters checks the port conflicts of stopped profiles, and the health of running profiles
+func checkClusters(profiles []*config.Profile) []Result {
+	if len(profiles) == 0 {
+		return nil
+	}
+	api, err := machine.NewAPIClient()
+	if err != nil {
+		return []Result{{Check: "cluster", Target: "any", Status: Fail, Message: fmt.Sprintf("unable to get machine client: %v", err)}}
+	}
+	defer api.Close()
+
+	rs := []Result{}
+	for _, p := range profiles {
+		rs = append(rs, checkCluster(api, p)...)
+	}
+	return rs
+}
+
+func checkCluster(api libmachine.API, p *config.Profile) []Result {
+	cc := p.Config
+	cp, err := config.PrimaryControlPlane(cc)
+	if err != nil {
+		return []Result{{Check: "cluster", Target: p.Name, Status: Fail, Message: fmt.Sprintf("unable to find control plane: %v", err), Reason: reason.GuestCpConfig.ID,
+			Advice: fmt.Sprintf("Run 'minikube delete -p %s' to delete the corrupt profile", p.Name)}}
+	}
+	hs, err := machine.Status(api, config.MachineName(*cc, cp))
+	if err != nil {
+		return []Result{{Check: "cluster", Target: p.Name, Status: Fail, Message: fmt.Sprintf("unable to get machine status: %v", err), Reason: reason.GuestStatus.ID}}
+	}
+	if hs != state.Running.String() {
+		klog.Infof("profile %s is %s, checking ports", p.Name, hs)
+		return checkPorts(p.Name, cc, cp)
+	}
+
+	host, err := machine.LoadHost(api, config.MachineName(*cc, cp))
+	if err != nil {
+		return []Result{{Check: "cluster", Target: p.Name, Status: Fail, Message: fmt.Sprintf("unable to load host: %v", err), Reason: reason.GuestLoadHost.ID}}
+	}
+	cr, err := machine.CommandRunner(host)
+	if err != nil {
+		return []Result{{Check: "cluster", Target: p.Name, Status: Fail, Message: fmt.Sprintf("unable to get command runner: %v", err), Reason: reason.InternalCommandRunner.ID}}
+	}
+	restart := fmt.Sprintf("Run 'minikube start -p %s' to restart the cluster", p.Name)
+
+	rs := []Result{}
+	if st := kverify.ServiceStatus(cr, "kubelet"); st == state.Running {
+		rs = append(rs, Result{Check: "kubelet", Target: p.Name, Status: Pass, Message: "running"})
+	} else {
+		rs = append(rs, Result{Check: "kubelet", Target: p.Name, Status: Fail, Message: st.String(), Reason: "K8S_KUBELET_NOT_RUNNING", Advice: restart})
+	}
+
+	hostname, _, port, err := driver.ControlPlaneEndpoint(cc, &cp, host.DriverName)
+	if err != nil {
+		return append(rs, Result{Check: "apiserver", Target: p.Name, Status: Fail, Message: fmt.Sprintf("unable to get forwarded endpoint: %v", err), Reason: reason.DrvCPEndpoint.ID, Advice: restart})
+	}
+	if err := kubeconfig.VerifyEndpoint(p.Name, hostname, port); err != nil {
+		rs = append(rs, Result{Check: "kubeconfig", Target: p.Name, Status: Fail, Message: fmt.Sprintf("%s is out of date: %v", kubeconfig.PathFromEnv(), err),
+			Advice: fmt.Sprintf("Run 'minikube update-context -p %s' to update the kubeconfig", p.Name)})
+	} else {
+		rs = append(rs, Result{Check: "kubeconfig", Target: p.Name, Status: Pass, Message: fmt.Sprintf("points to %s", net.JoinHostPort(hostname, strconv.Itoa(port)))})
+	}
+
+	st, err := kverify.APIServerStatus(cr, p.Name, hostname, port)
+	switch {
+	case err != nil:
+		return append(rs, Result{Check: "apiserver", Target: p.Name, Status: Fail, Message: err.Error(), Advice: restart})
+	case st == state.Paused:
+		return append(rs, Result{Check: "apiserver", Target: p.Name, Status: Warn, Message: "paused", Advice: fmt.Sprintf("Run 'minikube unpause -p %s' to resume the cluster", p.Name)})
+	case st != state.Running:
+		return append(rs, Result{Check: "apiserver", Target: p.Name, Status: Fail, Message: st.String(), Reason: "K8S_APISERVER_MISSING", Advice: restart})
+	}
+	rs = append(rs, Result{Check: "apiserver", Target: p.Name, Status: Pass, Message: "running"})
+
+	client, err := kapi.Client(p.Name)
+	if err != nil {
+		return append(rs, Result{Check: "pods", Target: p.Name, Status: Fail, Message: fmt.Sprintf("unable to get Kubernetes client: %v", err)})
+	}
+	if err := kverify.ExpectAppsRunning(client, kverify.AppsRunningList); err != nil {
+		return append(rs, Result{Check: "pods", Target: p.Name, Status: Fail, Message: err.Error(),
+			Advice: fmt.Sprintf("Run 'minikube logs --problems -p %s' to find out why", p.Name)})
+	}
+	rs = append(rs, Result{Check: "pods", Target: p.Name, Status: Pass, Message: "system pods running"})
+	if err := kverify.NodePressure(client); err != nil {
+		return append(rs, Result{Check: "node", Target: p.Name, Status: Warn, Message: err.Error(), Advice: "Free up resources in the node, or pass larger --memory and --disk-size values to 'minikube start'"})
+	}
+	return append(rs, Result{Check: "node", Target: p.Name, Status: Pass, Message: "no resource pressure"})
+}
+
+// hostPorts returns the host ports a stopped profile binds when started
+func hostPorts(cc *config.ClusterConfig, cp config.Node) ([]nat.PortBinding, error) {
+	if driver.BareMetal(cc.Driver) {
+		bs := []nat.PortBinding{{HostPort: strconv.Itoa(cp.Port)}}
+		for _, p := range noneDriverPorts {
+			bs = append(bs, nat.PortBinding{HostPort: strconv.Itoa(p)})
+		}
+		return bs, nil
+	}
+	if !driver.IsKIC(cc.Driver) || len(cc.ExposedPorts) == 0 {
+		return nil, nil
+	}
+	_, bindings, err := nat.ParsePortSpecs(cc.ExposedPorts)
+	if err != nil {
+		return nil, err
+	}
+	bs := []nat.PortBinding{}
+	for port, pbs := range bindings {
+		if port.Proto() != "tcp" {
+			continue
+		}
+		for _, b := range pbs {
+			if b.HostPort != "" {
+				bs = append(bs, b)
+			}
+		}
+	}
+	return bs, nil
+}
+
+// checkPorts checks that the host ports of a stopped profile are free
+func checkPorts(name string, cc *config.ClusterConfig, cp config.Node) []Result {
+	bs, err := hostPorts(cc, cp)
+	if err != nil {
+		return []Result{{Check: "ports", Target: name, Status: Fail, Message: fmt.Sprintf("invalid --ports: %v", err)}}
+	}
+	if len(bs) == 0 {
+		return nil
+	}
+	rs := []Result{}
+	busy := 0
+	for _, b := range bs {
+		addr := net.JoinHostPort(b.HostIP, b.HostPort)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			busy++
+			rs = append(rs, Result{Check: "ports", Target: name, Status: Fail, Message: fmt.Sprintf("port %s is in use: %v", addr, err), Reason: "GUEST_PORT_IN_USE",
+				Advice: fmt.Sprintf("Stop the process listening on port %s, it is probably another local Kubernetes installation", b.HostPort)})
+			continue
+		}
+		l.Close()
+	}
+	if busy == 0 {
+		rs = append(rs, Result{Check: "ports", Target: name, Status: Pass, Message: fmt.Sprintf("%d host ports free", len(bs))})
+	}
+	return rs
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package doctor diagnoses the prerequisites of the host and the health of existing clusters
+package doctor
+
+import (
+	"sort"
+
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/reason"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	// Pass means nothing needs to be done
+	Pass Status = "pass"
+	// Warn means minikube works, but may be slow or fail in some cases
+	Warn Status = "warn"
+	// Fail means minikube is not going to work until the problem is fixed
+	Fail Status = "fail"
+)
+
+// Result is the outcome of a check of a single target, such as a driver or a profile
+type Result struct {
+	// Check is the name of the check, such as "driver" or "disk"
+	Check string `json:"check"`
+	// Target is what was checked, such as a driver name, a path or a profile
+	Target  string `json:"target"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	// Advice is how to fix a warning or failure
+	Advice string `json:"advice,omitempty"`
+	// Doc is a reference URL for more information
+	Doc string `json:"doc,omitempty"`
+	// Reason is the ID of the reason.Kind of the failure, if there is one
+	Reason string `json:"reason,omitempty"`
+}
+
+// Run runs the host checks, and the cluster checks of the given profiles
+func Run(profiles []*config.Profile) []Result {
+	rs := []Result{}
+	rs = append(rs, checkDrivers()...)
+	rs = append(rs, checkDaemons()...)
+	rs = append(rs, checkDisk()...)
+	rs = append(rs, checkProxy(profiles)...)
+	rs = append(rs, checkClusters(profiles)...)
+	for i := range rs {
+		if rs[i].Advice != "" || rs[i].Reason == "" {
+			continue
+		}
+		if k := reason.Lookup(rs[i].Reason); k != nil {
+			rs[i].Advice = k.Advice
+		}
+	}
+	return rs
+}
+
+// Summary counts the results by status
+func Summary(rs []Result) map[Status]int {
+	sum := map[Status]int{Pass: 0, Warn: 0, Fail: 0}
+	for _, r := range rs {
+		sum[r.Status]++
+	}
+	return sum
+}
+
+// Failed returns whether any check failed
+func Failed(rs []Result) bool {
+	return Summary(rs)[Fail] > 0
+}
+
+// SortByStatus orders results with failures first, keeping the order of checks otherwise
+func SortByStatus(rs []Result) {
+	order := map[Status]int{Fail: 0, Warn: 1, Pass: 2}
+	sort.SliceStable(rs, func(i, j int) bool {
+		return order[rs[i].Status] < order[rs[j].Status]
+	})
+}
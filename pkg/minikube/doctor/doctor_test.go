@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doctor
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/docker/go-units"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/minikube/pkg/drivers/kic/oci"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/driver"
+)
+
+func TestDiskResult(t *testing.T) {
+	tests := []struct {
+		free uint64
+		want Status
+	}{
+		{1 * units.GiB, Fail},
+		{10 * units.GiB, Warn},
+		{100 * units.GiB, Pass},
+	}
+	for _, tc := range tests {
+		if got := diskResult("/home/user/.minikube", tc.free); got.Status != tc.want {
+			t.Errorf("diskResult(%d) = %+v, want %s", tc.free, got, tc.want)
+		}
+	}
+}
+
+func TestDaemonResult(t *testing.T) {
+	tests := []struct {
+		description string
+		info        oci.SysInfo
+		want        Status
+	}{
+		{"healthy", oci.SysInfo{CPUs: 4, TotalMemory: 8 * units.GiB, OSType: "linux", StorageDriver: "overlay2"}, Pass},
+		{"windows containers", oci.SysInfo{CPUs: 4, TotalMemory: 8 * units.GiB, OSType: "windows"}, Fail},
+		{"low memory", oci.SysInfo{CPUs: 4, TotalMemory: 1 * units.GiB, OSType: "linux"}, Fail},
+		{"one cpu", oci.SysInfo{CPUs: 1, TotalMemory: 8 * units.GiB, OSType: "linux"}, Fail},
+		{"errors", oci.SysInfo{CPUs: 4, TotalMemory: 8 * units.GiB, OSType: "linux", Errors: []string{"no space left"}}, Warn},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			if got := daemonResult(oci.Docker, tc.info); got.Status != tc.want {
+				t.Errorf("daemonResult() = %+v, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckProxy(t *testing.T) {
+	for _, env := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"} {
+		t.Setenv(env, "")
+	}
+	profiles := []*config.Profile{{Name: "p1", Config: &config.ClusterConfig{Nodes: []config.Node{{IP: "192.168.49.2"}}}}}
+
+	statuses := func() []Status {
+		ss := []Status{}
+		for _, r := range checkProxy(profiles) {
+			ss = append(ss, r.Status)
+		}
+		return ss
+	}
+	if diff := cmp.Diff([]Status{Pass}, statuses()); diff != "" {
+		t.Errorf("without proxy (-want +got):\n%s", diff)
+	}
+
+	t.Setenv("HTTPS_PROXY", "http://proxy.example.com:3128")
+	if diff := cmp.Diff([]Status{Pass, Warn}, statuses()); diff != "" {
+		t.Errorf("cluster IP not in NO_PROXY (-want +got):\n%s", diff)
+	}
+
+	t.Setenv("NO_PROXY", "localhost,192.168.49.0/24")
+	if diff := cmp.Diff([]Status{Pass}, statuses()); diff != "" {
+		t.Errorf("cluster IP in NO_PROXY (-want +got):\n%s", diff)
+	}
+
+	t.Setenv("HTTP_PROXY", "proxy")
+	if diff := cmp.Diff([]Status{Fail, Pass}, statuses()); diff != "" {
+		t.Errorf("invalid proxy URL (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckPorts(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	busy := l.Addr().(*net.TCPAddr).Port
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	freePort := free.Addr().(*net.TCPAddr).Port
+	free.Close()
+
+	cp := config.Node{ControlPlane: true, Port: 8443}
+	cc := &config.ClusterConfig{Driver: driver.Docker, ExposedPorts: []string{fmt.Sprintf("127.0.0.1:%d:80", freePort)}}
+	if rs := checkPorts("p1", cc, cp); len(rs) != 1 || rs[0].Status != Pass {
+		t.Errorf("checkPorts() with free port = %+v, want pass", rs)
+	}
+
+	cc.ExposedPorts = append(cc.ExposedPorts, fmt.Sprintf("127.0.0.1:%d:443", busy), "127.0.0.1:53:53/udp")
+	rs := checkPorts("p1", cc, cp)
+	if len(rs) != 1 || rs[0].Status != Fail || rs[0].Reason != "GUEST_PORT_IN_USE" {
+		t.Errorf("checkPorts() with busy port = %+v, want a single failure", rs)
+	}
+
+	cc = &config.ClusterConfig{Driver: driver.VirtualBox}
+	if rs := checkPorts("p1", cc, cp); len(rs) != 0 {
+		t.Errorf("checkPorts() of a VM = %+v, want no results", rs)
+	}
+}
+
+func TestSortByStatus(t *testing.T) {
+	rs := []Result{{Check: "a", Status: Pass}, {Check: "b", Status: Fail}, {Check: "c", Status: Warn}, {Check: "d", Status: Fail}}
+	SortByStatus(rs)
+	got := []string{}
+	for _, r := range rs {
+		got = append(got, r.Check)
+	}
+	if diff := cmp.Diff([]string{"b", "d", "c", "a"}, got); diff != "" {
+		t.Errorf("unexpected order (-want +got):\n%s", diff)
+	}
+	if !Failed(rs) || Summary(rs)[Fail] != 2 {
+		t.Errorf("Summary() = %v", Summary(rs))
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doctor
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/shirou/gopsutil/v3/disk"
+	"k8s.io/minikube/pkg/drivers/kic/oci"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/driver"
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"k8s.io/minikube/pkg/minikube/proxy"
+	"k8s.io/minikube/pkg/minikube/registry"
+)
+
+const (
+	driversDoc = "https://minikube.sigs.k8s.io/docs/drivers/"
+	proxyDoc   = "https://minikube.sigs.k8s.io/docs/handbook/vpn_and_proxy/"
+
+	// minDiskFree is the free space in the minikube home below which minikube is not going to start
+	minDiskFree = 5 * units.GiB
+	// recommendedDiskFree is the free space needed to cache images and ISOs of a few Kubernetes versions
+	recommendedDiskFree = 20 * units.GiB
+
+	// minDaemonMemory is the memory in MiB below which Kubernetes (kubeadm) will not start
+	minDaemonMemory = 1800
+	// minDaemonCPUs is the number of CPUs required by kubeadm
+	minDaemonCPUs = 2
+)
+
+// checkDrivers checks the health of the installed drivers
+func checkDrivers() []Result {
+	rs := []Result{}
+	sts := registry.Available(false)
+	for _, ds := range sts {
+		st := ds.State
+		if !st.Installed {
+			continue
+		}
+		r := Result{Check: "driver", Target: ds.Name, Advice: st.Fix, Doc: st.Doc, Reason: st.Reason}
+		switch {
+		case !st.Healthy:
+			r.Status = Fail
+			r.Message = fmt.Sprintf("installed but unhealthy: %v", st.Error)
+		case st.NeedsImprovement:
+			r.Status = Warn
+			r.Message = "healthy, but could be improved"
+			if st.Error != nil {
+				r.Message = fmt.Sprintf("healthy, but could be improved: %v", st.Error)
+			}
+		default:
+			r.Status = Pass
+			r.Message = "healthy"
+			if st.Version != "" {
+				r.Message = fmt.Sprintf("healthy (version %s)", st.Version)
+			}
+			r.Advice = ""
+			r.Doc = ""
+		}
+		rs = append(rs, r)
+	}
+	if pick, _, _ := driver.Suggest(sts); pick.Name == "" {
+		rs = append(rs, Result{Check: "driver", Target: "default", Status: Fail, Message: "no healthy driver can be selected automatically", Advice: "Install or fix one of the supported drivers, such as docker", Doc: driversDoc})
+	} else {
+		rs = append(rs, Result{Check: "driver", Target: "default", Status: Pass, Message: fmt.Sprintf("'minikube start' selects the %s driver", pick.Name)})
+	}
+	return rs
+}
+
+// checkDaemons checks the resources and errors reported by the healthy container engines
+func checkDaemons() []Result {
+	rs := []Result{}
+	for _, bin := range []string{oci.Docker, oci.Podman} {
+		if st := registry.Status(bin); !st.Installed || !st.Healthy {
+			continue
+		}
+		info, err := oci.DaemonInfo(bin)
+		if err != nil {
+			rs = append(rs, Result{Check: "daemon", Target: bin, Status: Fail, Message: fmt.Sprintf("unable to get %s info: %v", bin, err), Advice: fmt.Sprintf("Check that %s is running: %s info", bin, bin)})
+			continue
+		}
+		rs = append(rs, daemonResult(bin, info))
+	}
+	return rs
+}
+
+// daemonResult evaluates the system info of a container engine
+func daemonResult(bin string, info oci.SysInfo) Result {
+	r := Result{Check: "daemon", Target: bin}
+	memMiB := info.TotalMemory / units.MiB
+	switch {
+	case info.OSType != "" && info.OSType != "linux":
+		r.Status = Fail
+		r.Message = fmt.Sprintf("%s runs %s containers", bin, info.OSType)
+		r.Advice = "Switch the daemon to Linux containers"
+	case memMiB < minDaemonMemory:
+		r.Status = Fail
+		r.Message = fmt.Sprintf("%s has only %d MiB of memory, at least %d MiB is required", bin, memMiB, minDaemonMemory)
+		r.Advice = fmt.Sprintf("Increase the memory allocated to %s", bin)
+	case info.CPUs < minDaemonCPUs:
+		r.Status = Fail
+		r.Message = fmt.Sprintf("%s has only %d CPUs, at least %d are required", bin, info.CPUs, minDaemonCPUs)
+		r.Advice = fmt.Sprintf("Increase the CPUs allocated to %s", bin)
+	case len(info.Errors) > 0:
+		r.Status = Warn
+		r.Message = fmt.Sprintf("%s reports errors: %s", bin, strings.Join(info.Errors, "; "))
+	default:
+		r.Status = Pass
+		r.Message = fmt.Sprintf("%d CPUs, %d MiB memory, %s storage driver", info.CPUs, memMiB, info.StorageDriver)
+	}
+	return r
+}
+
+// checkDisk checks the free space in the minikube home
+func checkDisk() []Result {
+	dir := localpath.MiniPath()
+	// the minikube home does not exist before the first start
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	u, err := disk.Usage(dir)
+	if err != nil {
+		return []Result{{Check: "disk", Target: localpath.MiniPath(), Status: Warn, Message: fmt.Sprintf("unable to get free disk space: %v", err)}}
+	}
+	return []Result{diskResult(localpath.MiniPath(), u.Free)}
+}
+
+// diskResult evaluates the free space of the minikube home
+func diskResult(path string, free uint64) Result {
+	r := Result{Check: "disk", Target: path, Message: fmt.Sprintf("%s free", units.BytesSize(float64(free)))}
+	switch {
+	case free < minDiskFree:
+		r.Status = Fail
+		r.Reason = "GUEST_PROVISION_NOSPACE"
+		r.Advice = "Free up disk space, or set MINIKUBE_HOME to a disk with more space"
+	case free < recommendedDiskFree:
+		r.Status = Warn
+		r.Advice = "Ensure you have at least 20GB of free disk space, or run 'minikube delete --purge' to remove cached images and ISOs"
+	default:
+		r.Status = Pass
+	}
+	return r
+}
+
+// checkProxy checks the proxy environment variables, and that the proxy is bypassed for the clusters
+func checkProxy(profiles []*config.Profile) []Result {
+	rs := []Result{}
+	proxies := 0
+	for _, env := range proxy.EnvVars {
+		v := os.Getenv(env)
+		if v == "" || strings.EqualFold(env, "NO_PROXY") {
+			continue
+		}
+		proxies++
+		u, err := url.Parse(v)
+		if err != nil || u.Host == "" {
+			rs = append(rs, Result{Check: "proxy", Target: env, Status: Fail, Message: fmt.Sprintf("invalid proxy URL %q", v), Advice: fmt.Sprintf("Set %s to a URL such as http://proxy.example.com:3128", env), Doc: proxyDoc})
+			continue
+		}
+		rs = append(rs, Result{Check: "proxy", Target: env, Status: Pass, Message: fmt.Sprintf("using %s", u.Redacted())})
+	}
+	if proxies == 0 {
+		return []Result{{Check: "proxy", Target: "environment", Status: Pass, Message: "no proxy configured"}}
+	}
+
+	for _, p := range profiles {
+		if p.Config == nil {
+			continue
+		}
+		for _, n := range p.Config.Nodes {
+			if n.IP == "" || proxy.IsIPExcluded(n.IP) {
+				continue
+			}
+			rs = append(rs, Result{Check: "proxy", Target: p.Name, Status: Warn, Message: fmt.Sprintf("NO_PROXY does not include %s", n.IP),
+				Advice: fmt.Sprintf("Add the IP of the cluster to NO_PROXY: export NO_PROXY=$NO_PROXY,%s", n.IP), Doc: proxyDoc})
+			break
+		}
+	}
+	return rs
+}
@@ -35,8 +35,39 @@ func DefaultKubeBinariesURL() string {
 	return fmt.Sprintf("https://%s/kubernetes-release/release", downloadHost)
 }
 
+// DefaultK3sBinariesURL returns a URL to k3s binaries
+func DefaultK3sBinariesURL() string {
+	return "https://github.com/k3s-io/k3s/releases/download"
+}
+
+// K3sVersion returns the first k3s release of a Kubernetes version
+func K3sVersion(version string) string {
+	return version + "+k3s1"
+}
+
+// k3sBinaryWithChecksumURL gets the location of the k3s binary, which is named after the architecture, except on amd64
+func k3sBinaryWithChecksumURL(version, archName, binaryURL string) string {
+	if binaryURL == "" {
+		binaryURL = DefaultK3sBinariesURL()
+	}
+
+	base := fmt.Sprintf("%s/%s", binaryURL, K3sVersion(version))
+	binaryName := "k3s"
+	switch archName {
+	case "amd64":
+	case "arm":
+		binaryName = "k3s-armhf"
+	default:
+		binaryName = "k3s-" + archName
+	}
+	return fmt.Sprintf("%s/%s?checksum=file:%s/sha256sum-%s.txt", base, binaryName, base, archName)
+}
+
 // binaryWithChecksumURL gets the location of a Kubernetes binary
 func binaryWithChecksumURL(binaryName, version, osName, archName, binaryURL string) (string, error) {
+	if binaryName == "k3s" {
+		return k3sBinaryWithChecksumURL(version, archName, binaryURL), nil
+	}
 	if binaryURL == "" {
 		binaryURL = DefaultKubeBinariesURL()
 	}
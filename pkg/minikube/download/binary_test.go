@@ -0,0 +1,44 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package download
+
+import "testing"
+
+func TestBinaryWithChecksumURL(t *testing.T) {
+	tests := []struct {
+		binary    string
+		version   string
+		arch      string
+		binaryURL string
+		want      string
+	}{
+		{"kubelet", "v1.23.6", "amd64", "", "https://storage.googleapis.com/kubernetes-release/release/v1.23.6/bin/linux/amd64/kubelet?checksum=file:https://storage.googleapis.com/kubernetes-release/release/v1.23.6/bin/linux/amd64/kubelet.sha256"},
+		{"kubelet", "v1.16.0", "amd64", "https://mirror.example.com", "https://mirror.example.com/v1.16.0/bin/linux/amd64/kubelet?checksum=file:https://mirror.example.com/v1.16.0/bin/linux/amd64/kubelet.sha1"},
+		{"k3s", "v1.23.6", "amd64", "", "https://github.com/k3s-io/k3s/releases/download/v1.23.6+k3s1/k3s?checksum=file:https://github.com/k3s-io/k3s/releases/download/v1.23.6+k3s1/sha256sum-amd64.txt"},
+		{"k3s", "v1.23.6", "arm64", "", "https://github.com/k3s-io/k3s/releases/download/v1.23.6+k3s1/k3s-arm64?checksum=file:https://github.com/k3s-io/k3s/releases/download/v1.23.6+k3s1/sha256sum-arm64.txt"},
+		{"k3s", "v1.23.6", "arm", "https://mirror.example.com/k3s", "https://mirror.example.com/k3s/v1.23.6+k3s1/k3s-armhf?checksum=file:https://mirror.example.com/k3s/v1.23.6+k3s1/sha256sum-arm.txt"},
+	}
+	for _, tc := range tests {
+		got, err := binaryWithChecksumURL(tc.binary, tc.version, "linux", tc.arch, tc.binaryURL)
+		if err != nil {
+			t.Fatalf("binaryWithChecksumURL(%s, %s, %s): %v", tc.binary, tc.version, tc.arch, err)
+		}
+		if got != tc.want {
+			t.Errorf("binaryWithChecksumURL(%s, %s, %s) = %s, want %s", tc.binary, tc.version, tc.arch, got, tc.want)
+		}
+	}
+}
@@ -37,10 +37,16 @@ import (
 var (
 	// DownloadMock is called instead of the download implementation if not nil.
 	DownloadMock func(src, dst string) error
-	checkCache   = os.Stat
+	checkCache   = verifiedCache
 
 	aliyunMirror = "kubernetes.oss-cn-hangzhou.aliyuncs.com"
 	downloadHost = "storage.googleapis.com"
+
+	getters = map[string]getter.Getter{
+		"file":  &getter.FileGetter{Copy: false},
+		"http":  &getter.HttpGetter{Netrc: false},
+		"https": &getter.HttpGetter{Netrc: false},
+	}
 )
 
 // SetAliyunMirror set the download host for Aliyun mirror
@@ -77,11 +83,7 @@ func download(src string, dst string) error {
 		Dir:     false,
 		Mode:    getter.ClientModeFile,
 		Options: clientOptions,
-		Getters: map[string]getter.Getter{
-			"file":  &getter.FileGetter{Copy: false},
-			"http":  &getter.HttpGetter{Netrc: false},
-			"https": &getter.HttpGetter{Netrc: false},
-		},
+		Getters: getters,
 	}
 
 	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
@@ -102,7 +104,18 @@ func download(src string, dst string) error {
 	if err := client.Get(); err != nil {
 		return errors.Wrapf(err, "getter: %+v", client)
 	}
-	return os.Rename(tmpDst, dst)
+
+	entry, err := verify(src, tmpDst)
+	if err != nil {
+		if err := os.Remove(tmpDst); err != nil {
+			klog.Warningf("Failed to remove %s: %v", tmpDst, err)
+		}
+		return errors.Wrap(err, "verify")
+	}
+	if err := os.Rename(tmpDst, dst); err != nil {
+		return err
+	}
+	return RecordLock(dst, entry)
 }
 
 // withinUnitTset detects if we are in running within a unit-test
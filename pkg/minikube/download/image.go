@@ -44,8 +44,8 @@ var (
 	}
 )
 
-// imagePathInCache returns path in local cache directory
-func imagePathInCache(img string) string {
+// ImagePathInCache returns the path of a kic image in the local cache directory
+func ImagePathInCache(img string) string {
 	f := filepath.Join(detect.KICCacheDir(), path.Base(img)+".tar")
 	f = localpath.SanitizeCacheDir(f)
 	return f
@@ -53,7 +53,7 @@ func imagePathInCache(img string) string {
 
 // ImageExistsInCache if img exist in local cache directory
 func ImageExistsInCache(img string) bool {
-	f := imagePathInCache(img)
+	f := ImagePathInCache(img)
 
 	// Check if image exists locally
 	klog.Infof("Checking for %s in local cache directory", img)
@@ -88,7 +88,7 @@ var checkImageExistsInDaemon = ImageExistsInDaemon
 
 // ImageToCache downloads img (if not present in cache) and writes it to the local cache directory
 func ImageToCache(img string) error {
-	f := imagePathInCache(img)
+	f := ImagePathInCache(img)
 	fileLock := f + ".lock"
 
 	releaser, err := lockDownload(fileLock)
@@ -121,6 +121,9 @@ func ImageToCache(img string) error {
 	if err != nil {
 		return errors.Wrap(err, "parsing reference")
 	}
+	if err := verifyImageRef(ref); err != nil {
+		return err
+	}
 	tag, err := name.NewTag(strings.Split(img, "@")[0])
 	if err != nil {
 		return errors.Wrap(err, "parsing tag")
@@ -197,7 +200,7 @@ func parseImage(img string) (*name.Tag, name.Reference, error) {
 
 // CacheToDaemon loads image from tarball in the local cache directory to the local docker daemon
 func CacheToDaemon(img string) error {
-	p := imagePathInCache(img)
+	p := ImagePathInCache(img)
 
 	tag, ref, err := parseImage(img)
 	if err != nil {
@@ -245,6 +248,9 @@ func ImageToDaemon(img string) error {
 	if err != nil {
 		return errors.Wrap(err, "parsing reference")
 	}
+	if err := verifyImageRef(ref); err != nil {
+		return err
+	}
 	tag, err := name.NewTag(strings.Split(img, "@")[0])
 	if err != nil {
 		return errors.Wrap(err, "parsing tag")
@@ -83,6 +83,15 @@ func localISOPath(u *url.URL) string {
 	return filepath.Join(detect.ISOCacheDir(), path.Base(u.Path))
 }
 
+// ISOPath returns where the ISO at isoURL is stored locally
+func ISOPath(isoURL string) (string, error) {
+	u, err := url.Parse(isoURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "url.parse %q", isoURL)
+	}
+	return localISOPath(u), nil
+}
+
 // ISO downloads and returns the path to the downloaded ISO
 func ISO(urls []string, skipChecksum bool) (string, error) {
 	errs := map[string]string{}
@@ -132,7 +141,7 @@ func downloadISO(isoURL string, skipChecksum bool) error {
 	}
 	defer releaser.Release()
 
-	if _, err := os.Stat(dst); err == nil {
+	if _, err := checkCache(dst); err == nil {
 		return nil
 	}
 
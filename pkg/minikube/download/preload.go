@@ -44,7 +44,7 @@ const (
 	// PreloadVersion is the current version of the preloaded tarball
 	//
 	// NOTE: You may need to bump this version up when upgrading auxiliary docker images
-	PreloadVersion = "v18"
+	PreloadVersion = "v19"
 	// PreloadBucket is the name of the GCS bucket where preloaded volume tarballs exist
 	PreloadBucket = "minikube-preloaded-volume-tarballs"
 )
@@ -213,6 +213,9 @@ func Preload(k8sVersion, containerRuntime, driverName string) error {
 		if err != nil {
 			return errors.Wrap(err, "rename")
 		}
+		if err := renameLock(targetPath, realPath); err != nil {
+			return errors.Wrap(err, "download lock")
+		}
 	}
 
 	// If the download was successful, mark off that the preload exists in the cache.
@@ -0,0 +1,56 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package download
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/blang/semver/v4"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+// tunnelRelayWithChecksumURL gets the location of the tunnel-relay agent released along with minikube
+func tunnelRelayWithChecksumURL(v semver.Version, archName string) string {
+	base := fmt.Sprintf("https://github.com/kubernetes/minikube/releases/download/v%s/tunnel-relay-linux-%s", v, archName)
+	return fmt.Sprintf("%s?checksum=file:%s.sha256", base, base)
+}
+
+// TunnelRelay downloads the tunnel-relay agent of a minikube version, for nodes whose base image predates it
+func TunnelRelay(v semver.Version, archName string) (string, error) {
+	targetFilepath := path.Join(localpath.MakeMiniPath("cache", "linux", archName, "v"+v.String()), "tunnel-relay")
+	url := tunnelRelayWithChecksumURL(v, archName)
+
+	releaser, err := lockDownload(targetFilepath + ".lock")
+	if releaser != nil {
+		defer releaser.Release()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := checkCache(targetFilepath); err == nil {
+		klog.Infof("Not caching tunnel-relay, using %s", url)
+		return targetFilepath, nil
+	}
+	if err := download(url, targetFilepath); err != nil {
+		return "", errors.Wrapf(err, "download failed: %s", url)
+	}
+	return targetFilepath, nil
+}
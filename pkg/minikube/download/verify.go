@@ -0,0 +1,399 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package download
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/hashicorp/go-getter"
+	"github.com/juju/mutex"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"k8s.io/minikube/pkg/util/lock"
+)
+
+const (
+	// PolicyDefault verifies every artifact for which a SHA-256 checksum is published, and warns about the rest
+	PolicyDefault = "default"
+	// PolicyStrict refuses any artifact that cannot be verified against a SHA-256 checksum
+	PolicyStrict = "strict"
+)
+
+// Policies are the supported values of the download-policy setting
+var Policies = []string{PolicyDefault, PolicyStrict}
+
+// LockEntry records how a downloaded artifact was verified
+type LockEntry struct {
+	URL string `json:"url"`
+	// SHA256 is the digest of the artifact on disk
+	SHA256 string `json:"sha256"`
+	// Verified is true if SHA256 matched a checksum published alongside the artifact
+	Verified bool `json:"verified"`
+	// Signed is true if the artifact's detached signature matched the configured public key
+	Signed bool `json:"signed"`
+	// Imported is true if the artifact was imported from a bundle rather than downloaded. It is only verified, and signed,
+	// if the manifest of the bundle was signed by the configured public key.
+	Imported bool      `json:"imported,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// verifiedFiles remembers cached files that were already checked against the lock file by this process
+var verifiedFiles sync.Map
+
+// ValidatePolicy returns an error if policy is not a supported download policy
+func ValidatePolicy(policy string) error {
+	for _, p := range Policies {
+		if policy == p {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid download policy %q, must be one of: %s", policy, strings.Join(Policies, ", "))
+}
+
+// StrictPolicy returns whether unverified downloads must be refused
+func StrictPolicy() bool {
+	return viper.GetString(config.DownloadPolicy) == PolicyStrict
+}
+
+// LockFile returns the path of the file recording the digests of verified downloads
+func LockFile() string {
+	return localpath.MakeMiniPath("cache", "download-lock.json")
+}
+
+// ReadLock returns the entries of the download lock file, keyed by path relative to the minikube home
+func ReadLock() (map[string]LockEntry, error) {
+	entries := map[string]LockEntry{}
+	b, err := os.ReadFile(LockFile())
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", LockFile())
+	}
+	return entries, nil
+}
+
+// updateLock applies fn to the entries of the lock file while holding its lock
+func updateLock(fn func(map[string]LockEntry)) error {
+	// lock.WriteFile holds the mutex of the file itself, so serialize the read-modify-write with a separate one
+	spec := lock.PathMutexSpec(LockFile() + ".lock")
+	releaser, err := mutex.Acquire(spec)
+	if err != nil {
+		return errors.Wrapf(err, "acquiring lock for %s", LockFile())
+	}
+	defer releaser.Release()
+
+	entries, err := ReadLock()
+	if err != nil {
+		return err
+	}
+	fn(entries)
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(LockFile()), 0755); err != nil {
+		return err
+	}
+	return lock.WriteFile(LockFile(), b, 0644)
+}
+
+// lockKey returns the key of file in the lock file
+func lockKey(file string) string {
+	if rel, err := filepath.Rel(localpath.MiniPath(), file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+// RecordLock records how file was verified in the download lock file
+func RecordLock(file string, e LockEntry) error {
+	verifiedFiles.Delete(file)
+	return updateLock(func(entries map[string]LockEntry) {
+		entries[lockKey(file)] = e
+	})
+}
+
+func renameLock(oldFile, newFile string) error {
+	return updateLock(func(entries map[string]LockEntry) {
+		if e, ok := entries[lockKey(oldFile)]; ok {
+			delete(entries, lockKey(oldFile))
+			entries[lockKey(newFile)] = e
+		}
+	})
+}
+
+// checkLock verifies that file matches the verified digest recorded in the lock file, and was signed if a public key is configured
+func checkLock(file string) error {
+	st, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	stamp := fmt.Sprintf("%d-%d", st.Size(), st.ModTime().UnixNano())
+	if v, ok := verifiedFiles.Load(file); ok && v.(string) == stamp {
+		return nil
+	}
+
+	entries, err := ReadLock()
+	if err != nil {
+		return err
+	}
+	e, ok := entries[lockKey(file)]
+	if !ok {
+		return fmt.Errorf("%s is not recorded in %s", file, LockFile())
+	}
+	if !e.Verified && e.Imported {
+		return fmt.Errorf("%s was imported from a bundle whose manifest is not signed by the download public key", file)
+	}
+	if !e.Verified {
+		return fmt.Errorf("%s was downloaded without a SHA-256 checksum", file)
+	}
+	key, err := publicKey()
+	if err != nil {
+		return err
+	}
+	if key != nil && !e.Signed {
+		return fmt.Errorf("%s was downloaded without a signature matching %s", file, viper.GetString(config.DownloadPublicKey))
+	}
+	sum, err := fileSHA256(file)
+	if err != nil {
+		return err
+	}
+	if sum != e.SHA256 {
+		return fmt.Errorf("%s has SHA-256 %s, but %s was recorded", file, sum, e.SHA256)
+	}
+	verifiedFiles.Store(file, stamp)
+	return nil
+}
+
+// verifiedCache is the default implementation of checkCache: under the strict policy, a cached file only counts if it still matches its lock entry
+func verifiedCache(file string) (os.FileInfo, error) {
+	st, err := os.Stat(file)
+	if err != nil || !StrictPolicy() {
+		return st, err
+	}
+	if err := checkLock(file); err != nil {
+		klog.Warningf("Not using cached %s: %v", file, err)
+		return nil, err
+	}
+	return st, nil
+}
+
+// splitChecksum splits a go-getter source into the artifact URL and its checksum parameter
+func splitChecksum(src string) (string, string) {
+	i := strings.Index(src, "?checksum=")
+	if i < 0 {
+		return src, ""
+	}
+	return src[:i], src[i+len("?checksum="):]
+}
+
+func fileSHA256(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "reading %s", file)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fetchSidecar downloads a small file published next to an artifact, such as a checksum or a signature
+var fetchSidecar = func(src string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "minikube-sidecar")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	dst := filepath.Join(dir, "sidecar")
+	client := &getter.Client{
+		Src:     src,
+		Dst:     dst,
+		Mode:    getter.ClientModeFile,
+		Getters: getters,
+	}
+	if err := client.Get(); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(dst)
+}
+
+// parseSHA256 parses the checksum files published alongside artifacts: a hex digest, optionally followed by a file name
+func parseSHA256(b []byte) (string, error) {
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty checksum file")
+	}
+	sum := strings.ToLower(fields[0])
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid SHA-256 checksum %q", fields[0])
+	}
+	return sum, nil
+}
+
+// publicKey returns the public key configured to verify download signatures, or nil if none is configured
+func publicKey() (crypto.PublicKey, error) {
+	path := viper.GetString(config.DownloadPublicKey)
+	if path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading download public key")
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain a PEM encoded public key", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T in %s, must be ECDSA or RSA", key, path)
+	}
+}
+
+// CheckSignature verifies a detached signature of the SHA-256 digest of data with the configured public key.
+// It returns false, without error, if no public key is configured.
+func CheckSignature(data, sig []byte) (bool, error) {
+	key, err := publicKey()
+	if err != nil || key == nil {
+		return false, err
+	}
+	digest := sha256.Sum256(data)
+	if err := verifySignature(key, hex.EncodeToString(digest[:]), sig); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// verifySignature checks a detached signature, either raw or base64 encoded, of the SHA-256 digest sum
+func verifySignature(key crypto.PublicKey, sum string, sig []byte) error {
+	digest, err := hex.DecodeString(sum)
+	if err != nil {
+		return err
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig))); err == nil {
+		sig = decoded
+	}
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, digest, sig) {
+			return fmt.Errorf("invalid ECDSA signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, sig)
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// verify checks a file downloaded from src against the download policy, and returns the entry to record in the lock file
+func verify(src, file string) (LockEntry, error) {
+	u, checksum := splitChecksum(src)
+	e := LockEntry{URL: u, Time: time.Now()}
+
+	sum, err := fileSHA256(file)
+	if err != nil {
+		return e, err
+	}
+	e.SHA256 = sum
+
+	// go-getter has already checked these against the published checksum, or checksum list such as sha256sum-amd64.txt
+	if strings.HasPrefix(checksum, "sha256:") || strings.HasPrefix(checksum, "file:") && strings.Contains(filepath.Base(checksum), "sha256") {
+		e.Verified = true
+	} else if b, err := fetchSidecar(u + ".sha256"); err == nil {
+		want, err := parseSHA256(b)
+		if err != nil {
+			return e, errors.Wrapf(err, "%s.sha256", u)
+		}
+		if want != sum {
+			return e, fmt.Errorf("SHA-256 mismatch for %s: expected %s, got %s", u, want, sum)
+		}
+		e.Verified = true
+	} else {
+		klog.Infof("no SHA-256 checksum published for %s: %v", u, err)
+	}
+
+	if !e.Verified {
+		if StrictPolicy() {
+			return e, fmt.Errorf("refusing %s: no SHA-256 checksum is published and the download policy is %q", u, PolicyStrict)
+		}
+		klog.Warningf("%s could not be verified against a SHA-256 checksum", u)
+	}
+
+	key, err := publicKey()
+	if err != nil {
+		return e, err
+	}
+	if key == nil {
+		return e, nil
+	}
+	sig, err := fetchSidecar(u + ".sig")
+	if err != nil {
+		if StrictPolicy() {
+			return e, errors.Wrapf(err, "refusing %s: fetching signature", u)
+		}
+		klog.Warningf("%s is not signed: %v", u, err)
+		return e, nil
+	}
+	if err := verifySignature(key, sum, sig); err != nil {
+		return e, errors.Wrapf(err, "verifying signature of %s", u)
+	}
+	e.Signed = true
+	return e, nil
+}
+
+// verifyImageRef refuses, under the strict policy, images that are not pinned by digest: the registry client verifies every blob against the digest it was requested by
+func verifyImageRef(ref name.Reference) error {
+	if _, ok := ref.(name.Digest); ok || !StrictPolicy() {
+		return nil
+	}
+	return fmt.Errorf("refusing %s: the image is not pinned by digest and the download policy is %q", ref, PolicyStrict)
+}
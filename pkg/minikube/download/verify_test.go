@@ -0,0 +1,301 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package download
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+// setupVerify writes "hello" into the minikube home and serves the given sidecar files
+func setupVerify(t *testing.T, policy string, sidecars map[string]string) string {
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+	viper.Set(config.DownloadPolicy, policy)
+	viper.Set(config.DownloadPublicKey, "")
+	t.Cleanup(func() {
+		viper.Set(config.DownloadPolicy, PolicyDefault)
+		viper.Set(config.DownloadPublicKey, "")
+	})
+
+	orig := fetchSidecar
+	fetchSidecar = func(src string) ([]byte, error) {
+		if s, ok := sidecars[src]; ok {
+			return []byte(s), nil
+		}
+		return nil, fmt.Errorf("404 %s", src)
+	}
+	t.Cleanup(func() { fetchSidecar = orig })
+
+	f := localpath.MakeMiniPath("cache", "hello")
+	if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(f, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	const u = "https://example.com/hello"
+	tests := []struct {
+		desc     string
+		src      string
+		policy   string
+		sidecars map[string]string
+		verified bool
+		wantErr  bool
+	}{
+		{desc: "checked by getter", src: u + "?checksum=file:" + u + ".sha256", policy: PolicyStrict, verified: true},
+		{desc: "checked by getter against a list", src: u + "?checksum=file:https://example.com/sha256sum-amd64.txt", policy: PolicyStrict, verified: true},
+		{desc: "published checksum", src: u, policy: PolicyStrict, sidecars: map[string]string{u + ".sha256": helloSHA256 + "  hello\n"}, verified: true},
+		{desc: "checksum mismatch", src: u, policy: PolicyDefault, sidecars: map[string]string{u + ".sha256": helloSHA256[1:] + "0"}, wantErr: true},
+		{desc: "md5 only", src: u + "?checksum=md5:5d41402abc4b2a76b9719d911017c592", policy: PolicyDefault},
+		{desc: "md5 only strict", src: u + "?checksum=md5:5d41402abc4b2a76b9719d911017c592", policy: PolicyStrict, wantErr: true},
+		{desc: "no checksum strict", src: u, policy: PolicyStrict, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := setupVerify(t, tc.policy, tc.sidecars)
+			e, err := verify(tc.src, f)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("verify(%q) error = %v, wantErr %v", tc.src, err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if e.URL != u || e.SHA256 != helloSHA256 || e.Verified != tc.verified || e.Signed {
+				t.Errorf("verify(%q) = %+v", tc.src, e)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	const u = "https://example.com/hello"
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, _ := hex.DecodeString(helloSHA256)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badSig, err := ecdsa.SignASN1(rand.Reader, other, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc    string
+		policy  string
+		sig     string
+		signed  bool
+		wantErr bool
+	}{
+		{desc: "raw", policy: PolicyStrict, sig: string(sig), signed: true},
+		{desc: "base64", policy: PolicyStrict, sig: base64.StdEncoding.EncodeToString(sig) + "\n", signed: true},
+		{desc: "wrong key", policy: PolicyDefault, sig: string(badSig), wantErr: true},
+		{desc: "unsigned", policy: PolicyDefault},
+		{desc: "unsigned strict", policy: PolicyStrict, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			sidecars := map[string]string{u + ".sha256": helloSHA256}
+			if tc.sig != "" {
+				sidecars[u+".sig"] = tc.sig
+			}
+			f := setupVerify(t, tc.policy, sidecars)
+
+			der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub := filepath.Join(t.TempDir(), "key.pub")
+			if err := os.WriteFile(pub, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+				t.Fatal(err)
+			}
+			viper.Set(config.DownloadPublicKey, pub)
+
+			e, err := verify(u, f)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("verify() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err == nil && e.Signed != tc.signed {
+				t.Errorf("verify() signed = %v, want %v", e.Signed, tc.signed)
+			}
+		})
+	}
+}
+
+func TestVerifiedCache(t *testing.T) {
+	f := setupVerify(t, PolicyDefault, nil)
+
+	if _, err := verifiedCache(f); err != nil {
+		t.Errorf("default policy should accept unrecorded files: %v", err)
+	}
+	viper.Set(config.DownloadPolicy, PolicyStrict)
+	if _, err := verifiedCache(f); err == nil {
+		t.Errorf("strict policy accepted an unrecorded file")
+	}
+
+	if err := RecordLock(f, LockEntry{URL: "https://example.com/hello", SHA256: helloSHA256}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifiedCache(f); err == nil {
+		t.Errorf("strict policy accepted a file downloaded without a SHA-256 checksum")
+	}
+
+	if err := RecordLock(f, LockEntry{URL: "https://example.com/hello", SHA256: helloSHA256, Verified: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifiedCache(f); err != nil {
+		t.Errorf("strict policy refused a verified file: %v", err)
+	}
+	entries, err := ReadLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := entries["cache/hello"]; !ok {
+		t.Errorf("lock entries = %v, want cache/hello", entries)
+	}
+
+	if err := os.WriteFile(f, []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifiedCache(f); err == nil {
+		t.Errorf("strict policy accepted a file that no longer matches its lock entry")
+	}
+}
+
+func TestVerifiedCacheSigned(t *testing.T) {
+	const u = "https://example.com/hello"
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, _ := hex.DecodeString(helloSHA256)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := setupVerify(t, PolicyStrict, map[string]string{u + ".sha256": helloSHA256, u + ".sig": string(sig)})
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := filepath.Join(t.TempDir(), "key.pub")
+	if err := os.WriteFile(pub, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set(config.DownloadPublicKey, pub)
+
+	if err := RecordLock(f, LockEntry{URL: u, SHA256: helloSHA256, Verified: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifiedCache(f); err == nil {
+		t.Errorf("strict policy accepted an unsigned file while a public key is configured")
+	}
+	if err := RecordLock(f, LockEntry{URL: u, SHA256: helloSHA256, Verified: true, Signed: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifiedCache(f); err != nil {
+		t.Errorf("strict policy refused a signed file: %v", err)
+	}
+}
+
+func TestVerifiedCacheImported(t *testing.T) {
+	const u = "https://example.com/hello"
+	// the published checksum must not be fetched: imported files are verified offline
+	f := setupVerify(t, PolicyStrict, map[string]string{u + ".sha256": helloSHA256})
+
+	if err := RecordLock(f, LockEntry{URL: u, SHA256: helloSHA256, Imported: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifiedCache(f); err == nil {
+		t.Errorf("strict policy accepted an import from a bundle with an unsigned manifest")
+	}
+
+	if err := RecordLock(f, LockEntry{URL: u, SHA256: helloSHA256, Verified: true, Signed: true, Imported: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifiedCache(f); err != nil {
+		t.Errorf("strict policy refused an import from a bundle with a signed manifest: %v", err)
+	}
+
+	if err := os.WriteFile(f, []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifiedCache(f); err == nil {
+		t.Errorf("strict policy accepted an import that no longer matches the SHA-256 of its manifest")
+	}
+}
+
+func TestCheckSignature(t *testing.T) {
+	setupVerify(t, PolicyStrict, nil)
+	data := []byte("manifest")
+	if ok, err := CheckSignature(data, []byte("sig")); ok || err != nil {
+		t.Errorf("CheckSignature() without a public key = %v, %v, want false, nil", ok, err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := filepath.Join(t.TempDir(), "key.pub")
+	if err := os.WriteFile(pub, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set(config.DownloadPublicKey, pub)
+
+	if ok, err := CheckSignature(data, sig); !ok || err != nil {
+		t.Errorf("CheckSignature() = %v, %v, want true, nil", ok, err)
+	}
+	if ok, err := CheckSignature([]byte("tampered"), sig); ok || err == nil {
+		t.Errorf("CheckSignature() of tampered data = %v, %v, want an error", ok, err)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/bsutil"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+// Save takes a snapshot of the etcd of a running cluster into its profile. The oldest backups are then removed,
+// so that at most retain are kept, and their names returned.
+func Save(api libmachine.API, cc *config.ClusterConfig, name string, retain int) (*Backup, []string, error) {
+	if Exists(cc.Name, name) {
+		return nil, nil, fmt.Errorf("etcd backup %q already exists", name)
+	}
+	if err := os.MkdirAll(localpath.EtcdBackups(cc.Name), 0755); err != nil {
+		return nil, nil, errors.Wrap(err, "mkdir")
+	}
+	if err := SaveFile(api, cc, backupPath(cc.Name, name)); err != nil {
+		return nil, nil, err
+	}
+
+	b, err := Find(cc.Name, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	removed, err := prune(cc.Name, retain)
+	if err != nil {
+		return b, removed, errors.Wrap(err, "removing old backups")
+	}
+	return b, removed, nil
+}
+
+// SaveFile takes a snapshot of the etcd of a running cluster into the dst file of the host
+func SaveFile(api libmachine.API, cc *config.ClusterConfig, dst string) error {
+	_, runner, cr, err := controlPlane(api, cc)
+	if err != nil {
+		return err
+	}
+
+	// etcdctl runs within the static pod, which can only write to the etcd data dir
+	snap := path.Join(bsutil.EtcdDataDir(), filepath.Base(dst))
+	src := path.Join(guestDir, filepath.Base(dst))
+	defer func() {
+		if _, err := runner.RunCmd(exec.Command("sudo", "rm", "-rf", snap, guestDir)); err != nil {
+			klog.Warningf("failed to remove %s: %v", guestDir, err)
+		}
+	}()
+
+	if _, err := etcdctl(runner, cr, "snapshot", "save", snap); err != nil {
+		return errors.Wrap(err, "etcdctl snapshot save")
+	}
+	if _, err := runner.RunCmd(exec.Command("sudo", "mkdir", "-p", guestDir)); err != nil {
+		return errors.Wrap(err, "creating staging dir")
+	}
+	if _, err := runner.RunCmd(exec.Command("sudo", "mv", snap, src)); err != nil {
+		return errors.Wrap(err, "staging snapshot")
+	}
+	if _, err := runner.RunCmd(exec.Command("sudo", "chmod", "0644", src)); err != nil {
+		return errors.Wrap(err, "chmod snapshot")
+	}
+
+	if err := copyFrom(runner, src, dst); err != nil {
+		if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+			klog.Warningf("failed to remove partial snapshot %s: %v", dst, err)
+		}
+		return errors.Wrap(err, "transferring snapshot")
+	}
+	return nil
+}
+
+// copyFrom copies a file from the guest to the host
+func copyFrom(runner command.Runner, src string, dst string) error {
+	tf, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err := tf.Close(); err != nil {
+		return err
+	}
+
+	f, err := assets.NewFileAsset(dst, path.Dir(src), path.Base(src), "0644")
+	if err != nil {
+		return errors.Wrapf(err, "creating copyable file asset: %s", dst)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			klog.Warningf("error closing the file %s: %v", f.GetSourcePath(), err)
+		}
+	}()
+	return runner.CopyFrom(f)
+}
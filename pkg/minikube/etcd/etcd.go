@@ -0,0 +1,258 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package etcd backs up, restores and defragments the etcd of a cluster, with the etcdctl of its static pod
+package etcd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/docker/machine/libmachine"
+	"github.com/docker/machine/libmachine/state"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/vmpath"
+	"k8s.io/minikube/pkg/util"
+)
+
+// DefaultRetain is the number of backups of a profile kept by default
+const DefaultRetain = 5
+
+const (
+	backupExt = ".db"
+	endpoint  = "https://127.0.0.1:2379"
+)
+
+var (
+	// certsDir is where kubeadm generates the etcd certs, mounted at the same path in the static pod
+	certsDir = path.Join(vmpath.GuestKubernetesCertsDir, "etcd")
+	// guestDir is where backups are staged within the guest
+	guestDir = path.Join(vmpath.GuestPersistentDir, "etcd-backup")
+	// minVersion is the first Kubernetes version whose etcd image has an etcdctl using the v3 API by default
+	minVersion = semver.MustParse("1.17.0")
+)
+
+// Backup is an etcd snapshot of a profile stored on the host
+type Backup struct {
+	Name    string
+	Path    string
+	Size    int64
+	Created time.Time
+}
+
+// ErrNotExist is returned when the requested backup does not exist
+type ErrNotExist struct {
+	Name string
+}
+
+func (e *ErrNotExist) Error() string {
+	if e.Name == "" {
+		return "no etcd backup exists"
+	}
+	return fmt.Sprintf("etcd backup %q does not exist", e.Name)
+}
+
+// IsNotExist returns whether the error is an ErrNotExist
+func IsNotExist(err error) bool {
+	_, ok := errors.Cause(err).(*ErrNotExist)
+	return ok
+}
+
+// NameValid returns whether the name can be used as a backup name
+func NameValid(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	return !strings.ContainsAny(name, `/\: `)
+}
+
+// Supported returns an error if the etcd of the cluster cannot be managed by minikube
+func Supported(cc *config.ClusterConfig) error {
+	if cc.KubernetesConfig.Bootstrapper == bootstrapper.K3s {
+		return fmt.Errorf("the %s bootstrapper stores the cluster state in sqlite rather than etcd", bootstrapper.K3s)
+	}
+	v, err := util.ParseKubernetesVersion(cc.KubernetesConfig.KubernetesVersion)
+	if err != nil {
+		return errors.Wrap(err, "parsing Kubernetes version")
+	}
+	if v.LT(minVersion) {
+		return fmt.Errorf("Kubernetes %s is not supported, the etcd commands require v%s or later", cc.KubernetesConfig.KubernetesVersion, minVersion)
+	}
+	return nil
+}
+
+// List returns the backups of a profile, oldest first
+func List(profile string) ([]Backup, error) {
+	dir := localpath.EtcdBackups(profile)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Backup{}, nil
+		}
+		return nil, err
+	}
+
+	bs := []Backup{}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != backupExt {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, errors.Wrapf(err, "stat %s", e.Name())
+		}
+		bs = append(bs, Backup{
+			Name:    strings.TrimSuffix(e.Name(), backupExt),
+			Path:    filepath.Join(dir, e.Name()),
+			Size:    fi.Size(),
+			Created: fi.ModTime(),
+		})
+	}
+	sort.Slice(bs, func(i, j int) bool { return bs[i].Created.Before(bs[j].Created) })
+	return bs, nil
+}
+
+// Find returns the backup of a profile with the given name, or the latest one if name is empty
+func Find(profile string, name string) (*Backup, error) {
+	bs, err := List(profile)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		if len(bs) == 0 {
+			return nil, &ErrNotExist{}
+		}
+		return &bs[len(bs)-1], nil
+	}
+	for i := range bs {
+		if bs[i].Name == name {
+			return &bs[i], nil
+		}
+	}
+	return nil, &ErrNotExist{Name: name}
+}
+
+// Exists returns whether a backup with the given name exists for the profile
+func Exists(profile string, name string) bool {
+	_, err := os.Stat(backupPath(profile, name))
+	return err == nil
+}
+
+// prune removes the oldest backups of a profile, so that at most retain are kept. 0 keeps every backup.
+func prune(profile string, retain int) ([]string, error) {
+	if retain <= 0 {
+		return nil, nil
+	}
+	bs, err := List(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for i := 0; i < len(bs)-retain; i++ {
+		klog.Infof("removing etcd backup %s", bs[i].Path)
+		if err := os.Remove(bs[i].Path); err != nil {
+			return removed, errors.Wrapf(err, "removing %s", bs[i].Path)
+		}
+		removed = append(removed, bs[i].Name)
+	}
+	return removed, nil
+}
+
+// backupPath returns the path to a named backup of a profile
+func backupPath(profile string, name string) string {
+	return filepath.Join(localpath.EtcdBackups(profile), name+backupExt)
+}
+
+// etcdctlArgs returns the arguments running etcdctl in the etcd container, authenticated with the certs of its static pod
+func etcdctlArgs(id string, args ...string) []string {
+	return append([]string{
+		"crictl", "exec", id, "etcdctl",
+		"--endpoints=" + endpoint,
+		"--cacert=" + path.Join(certsDir, "ca.crt"),
+		"--cert=" + path.Join(certsDir, "healthcheck-client.crt"),
+		"--key=" + path.Join(certsDir, "healthcheck-client.key"),
+	}, args...)
+}
+
+// etcdctl runs etcdctl within the running etcd container of the node
+func etcdctl(runner command.Runner, cr cruntime.Manager, args ...string) (*command.RunResult, error) {
+	id, err := container(cr)
+	if err != nil {
+		return nil, err
+	}
+	return runner.RunCmd(exec.Command("sudo", etcdctlArgs(id, args...)...))
+}
+
+// container returns the ID of the running etcd container of the node
+func container(cr cruntime.Manager) (string, error) {
+	ids, err := cr.ListContainers(cruntime.ListContainersOptions{State: cruntime.Running, Name: "etcd", Namespaces: []string{"kube-system"}})
+	if err != nil {
+		return "", errors.Wrap(err, "listing etcd containers")
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("etcd is not running")
+	}
+	return ids[0], nil
+}
+
+// controlPlane returns the primary control plane of a running cluster, with a runner and the container runtime of its node
+func controlPlane(api libmachine.API, cc *config.ClusterConfig) (config.Node, command.Runner, cruntime.Manager, error) {
+	if err := Supported(cc); err != nil {
+		return config.Node{}, nil, nil, err
+	}
+	cp, err := config.PrimaryControlPlane(cc)
+	if err != nil {
+		return config.Node{}, nil, nil, errors.Wrap(err, "getting primary control plane")
+	}
+
+	machineName := config.MachineName(*cc, cp)
+	st, err := machine.Status(api, machineName)
+	if err != nil {
+		return cp, nil, nil, errors.Wrapf(err, "status of %s", machineName)
+	}
+	if st != state.Running.String() {
+		return cp, nil, nil, fmt.Errorf("node %s is not running (state=%s)", machineName, st)
+	}
+	h, err := machine.LoadHost(api, machineName)
+	if err != nil {
+		return cp, nil, nil, errors.Wrapf(err, "loading host %s", machineName)
+	}
+	runner, err := machine.CommandRunner(h)
+	if err != nil {
+		return cp, nil, nil, errors.Wrapf(err, "command runner of %s", machineName)
+	}
+
+	cr, err := cruntime.New(cruntime.Config{Type: cc.KubernetesConfig.ContainerRuntime, Runner: runner})
+	if err != nil {
+		return cp, nil, nil, errors.Wrap(err, "runtime")
+	}
+	return cp, runner, cr, nil
+}
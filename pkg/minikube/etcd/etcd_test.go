@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+func TestListAndPrune(t *testing.T) {
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+
+	bs, err := List("p1")
+	if err != nil {
+		t.Fatalf("List() on missing dir: %v", err)
+	}
+	if len(bs) != 0 {
+		t.Fatalf("expected no backups, got %d", len(bs))
+	}
+	if _, err := Find("p1", ""); !IsNotExist(err) {
+		t.Errorf("Find(latest) = %v, want ErrNotExist", err)
+	}
+
+	if err := os.MkdirAll(localpath.EtcdBackups("p1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i, name := range []string{"d", "c", "b", "a"} {
+		p := backupPath("p1", name)
+		if err := os.WriteFile(p, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(-time.Duration(i) * time.Hour)
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// files which are not backups must be left alone
+	if err := os.WriteFile(backupPath("p1", "partial")+".part", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := Find("p1", "")
+	if err != nil || latest.Name != "d" {
+		t.Errorf("Find(latest) = %+v, %v, want d", latest, err)
+	}
+	if _, err := Find("p1", "missing"); !IsNotExist(err) {
+		t.Errorf("Find(missing) = %v, want ErrNotExist", err)
+	}
+
+	if removed, err := prune("p1", 0); err != nil || len(removed) != 0 {
+		t.Errorf("prune(0) = %v, %v, want nothing removed", removed, err)
+	}
+	removed, err := prune("p1", 2)
+	if err != nil {
+		t.Fatalf("prune(2): %v", err)
+	}
+	if diff := cmp.Diff([]string{"a", "b"}, removed); diff != "" {
+		t.Errorf("removed mismatch (-want +got):\n%s", diff)
+	}
+
+	bs, err = List("p1")
+	if err != nil {
+		t.Fatalf("List(): %v", err)
+	}
+	names := []string{}
+	for _, b := range bs {
+		names = append(names, b.Name)
+	}
+	if diff := cmp.Diff([]string{"c", "d"}, names); diff != "" {
+		t.Errorf("backups mismatch (-want +got):\n%s", diff)
+	}
+	if !Exists("p1", "c") || Exists("p1", "a") || Exists("p2", "c") {
+		t.Errorf("unexpected backups after prune: %+v", bs)
+	}
+}
+
+func TestNameValid(t *testing.T) {
+	tests := map[string]bool{
+		"":                     false,
+		"..":                   false,
+		"a/b":                  false,
+		"c:":                   false,
+		"before upgrade":       false,
+		"before-upgrade":       true,
+		"etcd-20220601-120000": true,
+	}
+	for name, want := range tests {
+		if got := NameValid(name); got != want {
+			t.Errorf("NameValid(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestSupported(t *testing.T) {
+	tests := []struct {
+		bootstrapper string
+		version      string
+		supported    bool
+	}{
+		{"", "v1.23.6", true},
+		{"kubeadm", "v1.17.0", true},
+		{"kubeadm", "v1.16.15", false},
+		{"k3s", "v1.23.6", false},
+	}
+	for _, tc := range tests {
+		cc := &config.ClusterConfig{KubernetesConfig: config.KubernetesConfig{Bootstrapper: tc.bootstrapper, KubernetesVersion: tc.version}}
+		if err := Supported(cc); (err == nil) != tc.supported {
+			t.Errorf("Supported(%s, %s) = %v, want supported: %t", tc.bootstrapper, tc.version, err, tc.supported)
+		}
+	}
+}
+
+func TestEtcdctlArgs(t *testing.T) {
+	got := strings.Join(etcdctlArgs("abc123", "endpoint", "health"), " ")
+	want := "crictl exec abc123 etcdctl --endpoints=https://127.0.0.1:2379 --cacert=/var/lib/minikube/certs/etcd/ca.crt" +
+		" --cert=/var/lib/minikube/certs/etcd/healthcheck-client.crt --key=/var/lib/minikube/certs/etcd/healthcheck-client.key endpoint health"
+	if got != want {
+		t.Errorf("etcdctlArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	out := `[{"Endpoint":"https://192.168.49.2:2379","Status":{"header":{"cluster_id":9188633046227391000,"member_id":12593026477526642892,"revision":1529,"raft_term":2},"version":"3.5.1","dbSize":2654208,"leader":12593026477526642892,"raftIndex":1710,"raftTerm":2,"raftAppliedIndex":1710,"dbSizeInUse":1421312}},` +
+		`{"Endpoint":"https://192.168.49.3:2379","Status":{"header":{"member_id":16},"version":"3.5.1","dbSize":2654208,"leader":12593026477526642892,"raftIndex":1710,"raftTerm":2}}]`
+
+	got, err := parseStatus([]byte(out))
+	if err != nil {
+		t.Fatalf("parseStatus: %v", err)
+	}
+	want := []MemberStatus{
+		{Endpoint: "https://192.168.49.2:2379", ID: "aec36adc501070cc", Version: "3.5.1", Leader: true, DBSize: 2654208, DBSizeInUse: 1421312, RaftTerm: 2, RaftIndex: 1710},
+		{Endpoint: "https://192.168.49.3:2379", ID: "10", Version: "3.5.1", DBSize: 2654208, RaftTerm: 2, RaftIndex: 1710},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseStatus mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := parseStatus([]byte("Error: context deadline exceeded")); err == nil {
+		t.Errorf("expected an error parsing invalid output")
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/kapi"
+	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/bsutil"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/sysinit"
+	"k8s.io/minikube/pkg/minikube/vmpath"
+	"k8s.io/minikube/pkg/util/retry"
+)
+
+// Restore replaces the etcd data of a running cluster with a backup of its profile, the latest one if name is empty.
+// The etcd static pod, and the rest of the control plane, are then re-initialized from the restored data.
+func Restore(api libmachine.API, cc *config.ClusterConfig, name string, timeout time.Duration) (*Backup, error) {
+	b, err := Find(cc.Name, name)
+	if err != nil {
+		return nil, err
+	}
+	return b, RestoreFile(api, cc, b.Path, timeout)
+}
+
+// RestoreFile replaces the etcd data of a running cluster with the snapshot in the src file of the host.
+// The snapshot may come from another cluster, the restored member takes the name and IP of the control plane of cc.
+func RestoreFile(api libmachine.API, cc *config.ClusterConfig, src string, timeout time.Duration) error {
+	cps := 0
+	for _, n := range cc.Nodes {
+		if n.ControlPlane {
+			cps++
+		}
+	}
+	if cps > 1 {
+		return fmt.Errorf("restoring etcd is only supported with a single control plane, the cluster has %d", cps)
+	}
+	cp, runner, cr, err := controlPlane(api, cc)
+	if err != nil {
+		return err
+	}
+
+	dataDir := bsutil.EtcdDataDir()
+	snap := path.Join(dataDir, "restore"+backupExt)
+	restored := path.Join(dataDir, "restored")
+	defer func() {
+		if _, err := runner.RunCmd(exec.Command("sudo", "rm", "-rf", snap, restored, guestDir)); err != nil {
+			klog.Warningf("failed to remove %s: %v", guestDir, err)
+		}
+	}()
+
+	if _, err := runner.RunCmd(exec.Command("sudo", "mkdir", "-p", guestDir)); err != nil {
+		return errors.Wrap(err, "creating staging dir")
+	}
+	staged, err := copyTo(runner, src)
+	if err != nil {
+		return errors.Wrap(err, "transferring snapshot")
+	}
+	// etcdctl runs within the static pod, which can only read the etcd data dir
+	if _, err := runner.RunCmd(exec.Command("sudo", "cp", staged, snap)); err != nil {
+		return errors.Wrap(err, "staging snapshot")
+	}
+
+	member := bsutil.KubeNodeName(*cc, cp)
+	peerURL := fmt.Sprintf("https://%s:2380", cp.IP)
+	if _, err := etcdctl(runner, cr, "snapshot", "restore", snap,
+		"--data-dir="+restored,
+		"--name="+member,
+		fmt.Sprintf("--initial-cluster=%s=%s", member, peerURL),
+		"--initial-advertise-peer-urls="+peerURL); err != nil {
+		return errors.Wrap(err, "etcdctl snapshot restore")
+	}
+
+	if err := replaceMember(runner, cr, dataDir, restored); err != nil {
+		return err
+	}
+	if err := waitForControlPlane(runner, cr, cc, timeout); err != nil {
+		return errors.Wrap(err, "waiting for the control plane")
+	}
+	return nil
+}
+
+// replaceMember replaces the member dir of etcd with the restored one while the kubelet and control plane are stopped.
+// The kubelet is started again afterwards, which re-initializes the etcd static pod.
+func replaceMember(runner command.Runner, cr cruntime.Manager, dataDir string, restored string) error {
+	sm := sysinit.New(runner)
+	if err := sm.Stop("kubelet"); err != nil {
+		return errors.Wrap(err, "stopping kubelet")
+	}
+	// always bring the kubelet back, so the static pods are recreated even if the restore failed
+	defer func() {
+		if err := sm.Start("kubelet"); err != nil {
+			klog.Errorf("failed to start kubelet: %v", err)
+		}
+	}()
+
+	ids, err := cr.ListContainers(cruntime.ListContainersOptions{State: cruntime.Running, Namespaces: []string{"kube-system"}})
+	if err != nil {
+		return errors.Wrap(err, "list running")
+	}
+	if err := cr.StopContainers(ids); err != nil {
+		return errors.Wrap(err, "stopping control plane")
+	}
+
+	member := path.Join(dataDir, "member")
+	old := path.Join(dataDir, "member.old")
+	if _, err := runner.RunCmd(exec.Command("sudo", "mv", member, old)); err != nil {
+		return errors.Wrap(err, "moving etcd data")
+	}
+	if _, err := runner.RunCmd(exec.Command("sudo", "mv", path.Join(restored, "member"), member)); err != nil {
+		if _, rerr := runner.RunCmd(exec.Command("sudo", "mv", old, member)); rerr != nil {
+			klog.Errorf("failed to move %s back: %v", old, rerr)
+		}
+		return errors.Wrap(err, "replacing etcd data")
+	}
+	if _, err := runner.RunCmd(exec.Command("sudo", "rm", "-rf", old)); err != nil {
+		klog.Warningf("failed to remove %s: %v", old, err)
+	}
+	return nil
+}
+
+// waitForControlPlane waits for the new etcd to be healthy, then for the apiserver to be ready
+func waitForControlPlane(runner command.Runner, cr cruntime.Manager, cc *config.ClusterConfig, timeout time.Duration) error {
+	start := time.Now()
+	health := func() error {
+		_, err := etcdctl(runner, cr, "endpoint", "health")
+		return err
+	}
+	if err := retry.Local(health, timeout); err != nil {
+		return errors.Wrap(err, "etcd health")
+	}
+
+	ready := func() error {
+		c := exec.Command("sudo", kapi.KubectlBinaryPath(cc.KubernetesConfig.KubernetesVersion), "get", "--raw=/readyz",
+			fmt.Sprintf("--kubeconfig=%s", path.Join(vmpath.GuestPersistentDir, "kubeconfig")))
+		_, err := runner.RunCmd(c)
+		return err
+	}
+	return retry.Local(ready, timeout-time.Since(start))
+}
+
+// copyTo copies a backup into the staging dir of the guest, returning the guest path
+func copyTo(runner command.Runner, src string) (string, error) {
+	f, err := assets.NewFileAsset(src, guestDir, filepath.Base(src), "0644")
+	if err != nil {
+		return "", errors.Wrapf(err, "creating copyable file asset: %s", src)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			klog.Warningf("error closing the file %s: %v", f.GetSourcePath(), err)
+		}
+	}()
+
+	if err := runner.Copy(f); err != nil {
+		return "", err
+	}
+	return path.Join(guestDir, filepath.Base(src)), nil
+}
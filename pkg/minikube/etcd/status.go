@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/pkg/errors"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+)
+
+// MemberStatus is the status of an etcd member, as reported by etcdctl endpoint status
+type MemberStatus struct {
+	Endpoint string
+	ID       string
+	Version  string
+	Leader   bool
+	// DBSize is the size of the database file, DBSizeInUse the part of it which is not free space reclaimable by a defrag
+	DBSize      int64
+	DBSizeInUse int64
+	RaftTerm    uint64
+	RaftIndex   uint64
+}
+
+// endpointStatus is an element of the output of etcdctl endpoint status -w json
+type endpointStatus struct {
+	Endpoint string
+	Status   struct {
+		Header struct {
+			MemberID uint64 `json:"member_id"`
+		} `json:"header"`
+		Version     string `json:"version"`
+		DBSize      int64  `json:"dbSize"`
+		DBSizeInUse int64  `json:"dbSizeInUse"`
+		Leader      uint64 `json:"leader"`
+		RaftIndex   uint64 `json:"raftIndex"`
+		RaftTerm    uint64 `json:"raftTerm"`
+	}
+}
+
+// Status returns the status of every etcd member of a running cluster
+func Status(api libmachine.API, cc *config.ClusterConfig) ([]MemberStatus, error) {
+	_, runner, cr, err := controlPlane(api, cc)
+	if err != nil {
+		return nil, err
+	}
+	return status(runner, cr)
+}
+
+// Defrag defragments the etcd of every control plane of a running cluster, releasing the free space of its database.
+// The status of the members is returned from before and after the defrag.
+func Defrag(api libmachine.API, cc *config.ClusterConfig) ([]MemberStatus, []MemberStatus, error) {
+	_, runner, cr, err := controlPlane(api, cc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before, err := status(runner, cr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := etcdctl(runner, cr, "defrag", "--cluster"); err != nil {
+		return before, nil, errors.Wrap(err, "etcdctl defrag")
+	}
+	after, err := status(runner, cr)
+	if err != nil {
+		return before, nil, err
+	}
+	return before, after, nil
+}
+
+// status returns the status of every etcd member, queried from the etcd of the node
+func status(runner command.Runner, cr cruntime.Manager) ([]MemberStatus, error) {
+	rr, err := etcdctl(runner, cr, "endpoint", "status", "--cluster", "-w", "json")
+	if err != nil {
+		return nil, errors.Wrap(err, "etcdctl endpoint status")
+	}
+	return parseStatus(rr.Stdout.Bytes())
+}
+
+// parseStatus parses the output of etcdctl endpoint status -w json
+func parseStatus(b []byte) ([]MemberStatus, error) {
+	var eps []endpointStatus
+	if err := json.Unmarshal(b, &eps); err != nil {
+		return nil, errors.Wrap(err, "decoding endpoint status")
+	}
+
+	ms := []MemberStatus{}
+	for _, ep := range eps {
+		ms = append(ms, MemberStatus{
+			Endpoint:    ep.Endpoint,
+			ID:          fmt.Sprintf("%x", ep.Status.Header.MemberID),
+			Version:     ep.Status.Version,
+			Leader:      ep.Status.Leader == ep.Status.Header.MemberID,
+			DBSize:      ep.Status.DBSize,
+			DBSizeInUse: ep.Status.DBSizeInUse,
+			RaftTerm:    ep.Status.RaftTerm,
+			RaftIndex:   ep.Status.RaftIndex,
+		})
+	}
+	return ms, nil
+}
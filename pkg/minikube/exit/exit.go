@@ -23,9 +23,11 @@ import (
 	"runtime"
 
 	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/audit"
 	"k8s.io/minikube/pkg/minikube/out"
 	"k8s.io/minikube/pkg/minikube/reason"
 	"k8s.io/minikube/pkg/minikube/style"
+	"k8s.io/minikube/pkg/trace"
 )
 
 var (
@@ -64,6 +66,7 @@ func Message(r reason.Kind, format string, args ...out.V) {
 		out.Error(r, "Exiting due to {{.fatal_code}}: {{.fatal_msg}}", args...)
 	}
 
+	audit.SetReason(r.ID)
 	Code(r.ExitCode)
 }
 
@@ -72,6 +75,9 @@ func Code(code int) {
 	if shell {
 		out.Output(os.Stdout, fmt.Sprintf("false exit code %d\n", code))
 	}
+	// deferred calls do not run on os.Exit, so flush the trace and log the command here
+	trace.Cleanup()
+	audit.Log(code)
 	os.Exit(code)
 }
 
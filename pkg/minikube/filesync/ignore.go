@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IgnoreFiles are read from the root of a synced host directory, listing the paths not to sync in the
+// gitignore format. Patterns can not use "**" other than as a leading "**/".
+var IgnoreFiles = []string{".gitignore", ".minikubeignore"}
+
+const (
+	// conflictSuffix is appended to the version of a file which lost a conflict
+	conflictSuffix = ".sync-conflict"
+	// tempSuffix is appended to files while they are copied to the host
+	tempSuffix = ".minikube-sync"
+)
+
+// defaultIgnores are never synced
+var defaultIgnores = []string{".git/", "*" + conflictSuffix, "*" + tempSuffix}
+
+type pattern struct {
+	glob     string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// Ignore matches the paths excluded from a sync.
+type Ignore struct {
+	patterns []pattern
+}
+
+// NewIgnore parses patterns in the gitignore format, on top of the default ones.
+func NewIgnore(lines []string) *Ignore {
+	ig := &Ignore{}
+	for _, l := range append(append([]string{}, defaultIgnores...), lines...) {
+		l = strings.TrimRight(l, " \t\r")
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		var p pattern
+		if strings.HasPrefix(l, "!") {
+			p.negate = true
+			l = l[1:]
+		}
+		if strings.HasSuffix(l, "/") {
+			p.dirOnly = true
+			l = strings.TrimSuffix(l, "/")
+		}
+		if strings.HasPrefix(l, "**/") {
+			l = strings.TrimPrefix(l, "**/")
+		} else if strings.Contains(l, "/") {
+			p.anchored = true
+			l = strings.TrimPrefix(l, "/")
+		}
+		if l == "" {
+			continue
+		}
+		p.glob = l
+		ig.patterns = append(ig.patterns, p)
+	}
+	return ig
+}
+
+// LoadIgnore reads the ignore files at the root of dir.
+func LoadIgnore(dir string) (*Ignore, error) {
+	var lines []string
+	for _, name := range IgnoreFiles {
+		f, err := os.Open(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			lines = append(lines, s.Text())
+		}
+		f.Close()
+		if err := s.Err(); err != nil {
+			return nil, errors.Wrapf(err, "reading %s", name)
+		}
+	}
+	return NewIgnore(lines), nil
+}
+
+// Match returns whether rel, a slash separated path relative to the synced directory, is ignored,
+// either itself or because one of its parent directories is.
+func (ig *Ignore) Match(rel string, dir bool) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if ig.match(parts[:i], true) {
+			return true
+		}
+	}
+	return ig.match(parts, dir)
+}
+
+// match applies the patterns to a path, the last matching one deciding whether it is ignored.
+func (ig *Ignore) match(parts []string, dir bool) bool {
+	ignored := false
+	for _, p := range ig.patterns {
+		if p.dirOnly && !dir {
+			continue
+		}
+		if p.matches(parts) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+func (p pattern) matches(parts []string) bool {
+	if p.anchored {
+		ok, _ := path.Match(p.glob, strings.Join(parts, "/"))
+		return ok
+	}
+	// unanchored patterns match the trailing elements of the path, at any depth
+	depth := strings.Count(p.glob, "/") + 1
+	if depth > len(parts) {
+		return false
+	}
+	ok, _ := path.Match(p.glob, strings.Join(parts[len(parts)-depth:], "/"))
+	return ok
+}
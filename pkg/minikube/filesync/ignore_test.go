@@ -0,0 +1,61 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import "testing"
+
+func TestIgnoreMatch(t *testing.T) {
+	ig := NewIgnore([]string{
+		"# build output",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/vendor",
+		"docs/*.tmp",
+		"**/cache/data",
+		"",
+	})
+
+	tests := []struct {
+		path    string
+		dir     bool
+		ignored bool
+	}{
+		{"main.go", false, false},
+		{"debug.log", false, true},
+		{"logs/debug.log", false, true},
+		{"logs/keep.log", false, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"build/out.bin", false, true},
+		{"src/build/out.bin", false, true},
+		{"vendor/lib.go", false, true},
+		{"src/vendor/lib.go", false, false},
+		{"docs/a.tmp", false, true},
+		{"docs/sub/a.tmp", false, false},
+		{"x/cache/data", false, true},
+		{"cache/data/file", false, true},
+		{".git/config", false, true},
+		{"main.go.sync-conflict", false, true},
+		{".main.go.minikube-sync", false, true},
+	}
+	for _, tc := range tests {
+		if got := ig.Match(tc.path, tc.dir); got != tc.ignored {
+			t.Errorf("Match(%q, dir=%v) = %v, want %v", tc.path, tc.dir, got, tc.ignored)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"syscall"
+	"time"
+
+	ps "github.com/mitchellh/go-ps"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"k8s.io/minikube/pkg/util/lock"
+)
+
+// Status is the state of a running sync, stored in its profile for other minikube processes to list and stop it
+type Status struct {
+	PID       int
+	HostDir   string
+	NodeDir   string
+	Started   time.Time
+	LastSync  time.Time `json:",omitempty"`
+	Pushed    int
+	Pulled    int
+	Deleted   int
+	Conflicts int
+	LastError string `json:",omitempty"`
+}
+
+// Count adds the actions of a sync to the counters of the status.
+func (st *Status) Count(actions []Action, err error) {
+	st.LastSync = time.Now()
+	st.LastError = ""
+	if err != nil {
+		st.LastError = err.Error()
+	}
+	for _, a := range actions {
+		switch a.Op {
+		case Push:
+			st.Pushed++
+		case Pull:
+			st.Pulled++
+		case DeleteNode, DeleteHost:
+			st.Deleted++
+		}
+		if a.Conflict {
+			st.Conflicts++
+		}
+	}
+}
+
+func statusDir(profile string) string {
+	return filepath.Join(localpath.Profile(profile), "syncs")
+}
+
+func statusFile(profile, nodeDir string) string {
+	return filepath.Join(statusDir(profile), fmt.Sprintf("%x", sha256.Sum256([]byte(nodeDir)))[:16]+".json")
+}
+
+// SaveStatus stores the status of a sync of profile.
+func SaveStatus(profile string, st *Status) error {
+	data, err := json.MarshalIndent(st, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(statusDir(profile), 0755); err != nil {
+		return err
+	}
+	return lock.WriteFile(statusFile(profile, st.NodeDir), data, 0644)
+}
+
+// RemoveStatus removes the status of a sync of profile, once it stopped.
+func RemoveStatus(profile, nodeDir string) error {
+	if err := os.Remove(statusFile(profile, nodeDir)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ListStatus returns the syncs running for profile, cleaning up the ones whose process is gone.
+func ListStatus(profile string) ([]*Status, error) {
+	files, err := filepath.Glob(filepath.Join(statusDir(profile), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var statuses []*Status
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		st := &Status{}
+		if err := json.Unmarshal(data, st); err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", f)
+		}
+		if p, err := ps.FindProcess(st.PID); err != nil || p == nil {
+			klog.Infof("removing the status of the stale sync of %s, pid %d", st.NodeDir, st.PID)
+			if err := os.Remove(f); err != nil {
+				klog.Warningf("unable to remove %s: %v", f, err)
+			}
+			continue
+		}
+		statuses = append(statuses, st)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].NodeDir < statuses[j].NodeDir })
+	return statuses, nil
+}
+
+// Stop stops the syncs of profile to nodeDir, or all of them if it is empty, returning the stopped ones.
+func Stop(profile, nodeDir string) ([]*Status, error) {
+	statuses, err := ListStatus(profile)
+	if err != nil {
+		return nil, err
+	}
+	var stopped []*Status
+	for _, st := range statuses {
+		if nodeDir != "" && st.NodeDir != nodeDir {
+			continue
+		}
+		p, err := os.FindProcess(st.PID)
+		if err != nil {
+			return stopped, errors.Wrapf(err, "finding process %d", st.PID)
+		}
+		klog.Infof("stopping the sync of %s, pid %d", st.NodeDir, st.PID)
+		if runtime.GOOS == "windows" {
+			// the process can't clean up after itself, as Windows has no termination signal
+			err = p.Kill()
+			if rerr := RemoveStatus(profile, st.NodeDir); rerr != nil {
+				klog.Warningf("unable to remove the status of %s: %v", st.NodeDir, rerr)
+			}
+		} else {
+			err = p.Signal(syscall.SIGTERM)
+		}
+		if err != nil {
+			return stopped, errors.Wrapf(err, "stopping process %d", st.PID)
+		}
+		stopped = append(stopped, st)
+	}
+	return stopped, nil
+}
+
+// Manifest maps the syncs of a profile to the node to the paths they copied, for the next sync to
+// delete the ones which were removed from the host meanwhile
+type Manifest map[string][]string
+
+// ManifestKey returns the key of the sync of cfg to a node in a manifest.
+func ManifestKey(node string, cfg Config) string {
+	return fmt.Sprintf("%s:%s:%s", node, cfg.HostDir, cfg.NodeDir)
+}
+
+func manifestFile(profile string) string {
+	return filepath.Join(localpath.Profile(profile), "files-sync.json")
+}
+
+// LoadManifest returns the manifest of profile, which is empty before its first sync.
+func LoadManifest(profile string) (Manifest, error) {
+	m := Manifest{}
+	data, err := os.ReadFile(manifestFile(profile))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", manifestFile(profile))
+	}
+	return m, nil
+}
+
+// SaveManifest stores the manifest of profile.
+func SaveManifest(profile string, m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(localpath.Profile(profile), 0755); err != nil {
+		return err
+	}
+	return lock.WriteFile(manifestFile(profile), data, 0644)
+}
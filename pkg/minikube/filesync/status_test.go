@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+func TestStatus(t *testing.T) {
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+
+	running := &Status{PID: os.Getpid(), HostDir: "/src", NodeDir: "/app"}
+	running.Count([]Action{{Op: Push}, {Op: Pull, Conflict: true}, {Op: DeleteHost}}, errors.New("copy failed"))
+	if running.Pushed != 1 || running.Pulled != 1 || running.Deleted != 1 || running.Conflicts != 1 || running.LastError != "copy failed" {
+		t.Errorf("unexpected counters: %+v", running)
+	}
+	// no process has a negative pid
+	stale := &Status{PID: -1, HostDir: "/src", NodeDir: "/stale"}
+	for _, st := range []*Status{running, stale} {
+		if err := SaveStatus("p1", st); err != nil {
+			t.Fatalf("SaveStatus: %v", err)
+		}
+	}
+
+	statuses, err := ListStatus("p1")
+	if err != nil {
+		t.Fatalf("ListStatus: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].NodeDir != "/app" || statuses[0].Pushed != 1 {
+		t.Errorf("ListStatus() = %+v, want only the running sync", statuses)
+	}
+	if _, err := os.Stat(statusFile("p1", "/stale")); !os.IsNotExist(err) {
+		t.Errorf("the status of the stale sync was not removed")
+	}
+
+	if err := RemoveStatus("p1", "/app"); err != nil {
+		t.Fatalf("RemoveStatus: %v", err)
+	}
+	if statuses, _ := ListStatus("p1"); len(statuses) != 0 {
+		t.Errorf("ListStatus() = %+v after RemoveStatus, want none", statuses)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+
+	m, err := LoadManifest("p1")
+	if err != nil || len(m) != 0 {
+		t.Fatalf("LoadManifest() = %v, %v, want an empty manifest", m, err)
+	}
+	key := ManifestKey("m02", Config{HostDir: "/files", NodeDir: "/"})
+	m[key] = []string{"etc/hosts"}
+	if err := SaveManifest("p1", m); err != nil {
+		t.Fatalf("SaveManifest: %v", err)
+	}
+	m, err = LoadManifest("p1")
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	if got := m[key]; len(got) != 1 || got[0] != "etc/hosts" {
+		t.Errorf("paths of %s = %v, want [etc/hosts]", key, got)
+	}
+}
@@ -0,0 +1,512 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package filesync keeps a host directory and a directory of a node in sync, by copying the files
+// which changed through the command runner of the node. Unlike a 9p mount, the files are regular
+// files of the node, so that file watchers of the containers get notified of their changes.
+package filesync
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+
+	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/command"
+)
+
+// Direction is which sides of a sync changes are copied from
+type Direction int
+
+const (
+	// Bidirectional copies the changes of both sides, the newest version winning conflicts
+	Bidirectional Direction = iota
+	// ToNode copies the files of the host, overwriting changes made on the node. Only the files of the
+	// host are listed on the node, and directories are never deleted from it, so that the node
+	// directory can hold other files, and even be the root directory.
+	ToNode
+)
+
+// Op is an operation applied to a path to sync it
+type Op string
+
+const (
+	// Push copies a file or creates a directory on the node
+	Push Op = "push"
+	// Pull copies a file or creates a directory on the host
+	Pull Op = "pull"
+	// DeleteNode deletes a path from the node
+	DeleteNode Op = "delete on node"
+	// DeleteHost deletes a path from the host
+	DeleteHost Op = "delete on host"
+)
+
+// Action is an operation to sync a path
+type Action struct {
+	Op   Op
+	Path string
+	Dir  bool
+	// Conflict is set when both sides changed, the other version being kept next to the file with the conflict suffix
+	Conflict bool
+}
+
+// Config is the configuration of a sync
+type Config struct {
+	// HostDir is the directory of the host
+	HostDir string
+	// NodeDir is the absolute path of the directory in the node
+	NodeDir string
+	// Owner is the owner given to the files copied to the node, as accepted by chown
+	Owner string
+	// Direction is which sides changes are copied from
+	Direction Direction
+	// Known are the paths copied to the node by a previous ToNode sync, which are deleted from the
+	// node if they no longer exist on the host
+	Known []string
+}
+
+// pair is the state of a path on both sides, when they were last in sync
+type pair struct {
+	host entry
+	node entry
+}
+
+// Syncer syncs a host directory with a node directory.
+type Syncer struct {
+	cfg    Config
+	runner command.Runner
+	ignore *Ignore
+	known  map[string]bool
+	// last is the state of the paths which were in sync after the previous sync
+	last map[string]pair
+}
+
+// New returns a syncer, which first reconciles both directories by comparing their content.
+func New(r command.Runner, cfg Config) (*Syncer, error) {
+	if !path.IsAbs(cfg.NodeDir) {
+		return nil, errors.Errorf("node directory %s must be an absolute path", cfg.NodeDir)
+	}
+	ig, err := LoadIgnore(cfg.HostDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading ignore files")
+	}
+	if cfg.Direction == ToNode {
+		// the ignore files only configure the sync, the node directory not being a copy of the host one
+		for _, name := range IgnoreFiles {
+			ig.patterns = append(ig.patterns, NewIgnore([]string{"/" + name}).patterns...)
+		}
+	}
+	known := map[string]bool{}
+	for _, p := range cfg.Known {
+		known[p] = true
+	}
+	return &Syncer{cfg: cfg, runner: r, ignore: ig, known: known}, nil
+}
+
+// Config returns the configuration of the syncer.
+func (s *Syncer) Config() Config {
+	return s.cfg
+}
+
+// Synced returns the paths which were in sync after the previous sync.
+func (s *Syncer) Synced() []string {
+	var paths []string
+	for p := range s.last {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Sync copies the changes since the previous sync, returning the actions applied.
+func (s *Syncer) Sync() ([]Action, error) {
+	return s.sync(false)
+}
+
+// Plan returns the actions the next sync would apply.
+func (s *Syncer) Plan() ([]Action, error) {
+	return s.sync(true)
+}
+
+func (s *Syncer) sync(dryRun bool) ([]Action, error) {
+	initial := s.last == nil
+	if initial && !dryRun {
+		if err := s.nodeCommand("mkdir", "-p", s.cfg.NodeDir); err != nil {
+			return nil, err
+		}
+	}
+
+	host, err := s.listHost(initial)
+	if err != nil {
+		return nil, err
+	}
+	node, err := s.listNode(host, initial)
+	if err != nil && !(initial && dryRun) {
+		return nil, err
+	}
+
+	actions := s.plan(host, node)
+	if dryRun {
+		return actions, nil
+	}
+
+	failed, err := s.apply(actions)
+	if len(actions) > len(failed) {
+		// the synced paths are recorded in their state after the copies, the other ones as listed
+		// before, not to miss the changes made meanwhile
+		synced, lerr := s.listHost(false)
+		if lerr != nil {
+			return actions, lerr
+		}
+		syncedNode, lerr := s.listNode(synced, false)
+		if lerr != nil {
+			return actions, lerr
+		}
+		for _, a := range actions {
+			update(host, synced, a.Path)
+			update(node, syncedNode, a.Path)
+		}
+	}
+	s.record(host, node, failed)
+	return actions, err
+}
+
+// listHost lists the host directory, only keeping its files when syncing to the node.
+func (s *Syncer) listHost(sums bool) (tree, error) {
+	t, err := listHost(s.cfg.HostDir, s.ignore, sums)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing %s", s.cfg.HostDir)
+	}
+	if s.cfg.Direction == ToNode {
+		for p, e := range t {
+			if e.Dir {
+				delete(t, p)
+			}
+		}
+	}
+	return t, nil
+}
+
+// listNode lists the node directory, or when syncing to the node only the paths of the host and
+// the ones synced before.
+func (s *Syncer) listNode(host tree, sums bool) (tree, error) {
+	if s.cfg.Direction != ToNode {
+		return listNode(s.runner, s.cfg.NodeDir, s.ignore, sums)
+	}
+	paths := map[string]bool{}
+	for p := range host {
+		paths[p] = true
+	}
+	for p := range s.last {
+		paths[p] = true
+	}
+	for p := range s.known {
+		paths[p] = true
+	}
+	var rels []string
+	for p := range paths {
+		rels = append(rels, p)
+	}
+	sort.Strings(rels)
+	return listNodePaths(s.runner, s.cfg.NodeDir, rels, s.ignore, sums)
+}
+
+// update sets the state of path in t to the one in from.
+func update(t, from tree, path string) {
+	if e, ok := from[path]; ok {
+		t[path] = e
+	} else {
+		delete(t, path)
+	}
+}
+
+// plan compares both sides to their state after the previous sync.
+func (s *Syncer) plan(host, node tree) []Action {
+	paths := map[string]bool{}
+	for p := range host {
+		paths[p] = true
+	}
+	for p := range node {
+		paths[p] = true
+	}
+	for p := range s.last {
+		paths[p] = true
+	}
+
+	var actions []Action
+	for p := range paths {
+		h, hok := host[p]
+		n, nok := node[p]
+		l, lok := s.last[p]
+		if a, ok := s.decide(p, h, hok, n, nok, l, lok); ok {
+			actions = append(actions, a)
+		}
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Path < actions[j].Path })
+	return actions
+}
+
+func (s *Syncer) decide(p string, h entry, hok bool, n entry, nok bool, l pair, lok bool) (Action, bool) {
+	push := Action{Op: Push, Path: p, Dir: h.Dir}
+	pull := Action{Op: Pull, Path: p, Dir: n.Dir}
+	toNode := s.cfg.Direction == ToNode
+
+	if !lok {
+		// no previous state: the host wins, unless the path only exists on the node
+		switch {
+		case hok && nok && identical(h, n):
+			return Action{}, false
+		case hok:
+			return push, true
+		case nok && !toNode:
+			return pull, true
+		case nok && !n.Dir && s.known[p]:
+			return Action{Op: DeleteNode, Path: p}, true
+		}
+		return Action{}, false
+	}
+
+	hostChanged := !same(l.host, true, h, hok)
+	nodeChanged := !same(l.node, true, n, nok)
+	switch {
+	case !hostChanged && !nodeChanged:
+		return Action{}, false
+	case !hok && !nok:
+		return Action{}, false
+	case hostChanged && !nodeChanged, toNode:
+		if !hok {
+			return Action{Op: DeleteNode, Path: p, Dir: n.Dir}, true
+		}
+		return push, true
+	case nodeChanged && !hostChanged:
+		if !nok {
+			return Action{Op: DeleteHost, Path: p, Dir: h.Dir}, true
+		}
+		return pull, true
+	}
+
+	// both sides changed: a change wins over a deletion, and the newest change over the other one
+	switch {
+	case !hok:
+		return pull, true
+	case !nok:
+		return push, true
+	case h.Dir && n.Dir:
+		return Action{}, false
+	case h.Dir || n.Dir:
+		// a file replaced by a directory, or the reverse, is not merged
+		return push, true
+	case n.MTime > h.MTime:
+		pull.Conflict = true
+		return pull, true
+	}
+	push.Conflict = true
+	return push, true
+}
+
+// apply applies actions, returning the paths which failed to sync and the first error.
+func (s *Syncer) apply(actions []Action) (map[string]bool, error) {
+	failed := map[string]bool{}
+	var firstErr error
+	fail := func(p string, err error) {
+		klog.Warningf("syncing %s failed: %v", p, err)
+		failed[p] = true
+		if firstErr == nil {
+			firstErr = errors.Wrapf(err, "syncing %s", p)
+		}
+	}
+
+	var nodeDeletes, nodeDirs, pushed []string
+	modes := map[os.FileMode][]string{}
+	for _, a := range actions {
+		switch {
+		case a.Op == DeleteNode:
+			nodeDeletes = append(nodeDeletes, nodePath(s.cfg.NodeDir, a.Path))
+		case a.Op == Push && a.Dir:
+			nodeDirs = append(nodeDirs, nodePath(s.cfg.NodeDir, a.Path))
+		case a.Op == Push:
+			nodeDirs = append(nodeDirs, nodePath(s.cfg.NodeDir, path.Dir(a.Path)))
+		}
+	}
+	if err := s.nodeXargs(nodeDeletes, "rm", "-rf", "--"); err != nil {
+		for _, a := range actions {
+			if a.Op == DeleteNode {
+				fail(a.Path, err)
+			}
+		}
+	}
+	if err := s.nodeXargs(unique(nodeDirs), "mkdir", "-p", "--"); err != nil {
+		return allPaths(actions), errors.Wrap(err, "creating directories on the node")
+	}
+
+	// deepest paths first, so that deleting a directory does not fail on its content
+	for i := len(actions) - 1; i >= 0; i-- {
+		if a := actions[i]; a.Op == DeleteHost {
+			if err := os.RemoveAll(s.hostPath(a.Path)); err != nil {
+				fail(a.Path, err)
+			}
+		}
+	}
+	for _, a := range actions {
+		var err error
+		switch a.Op {
+		case Push:
+			if a.Conflict {
+				err = s.pullFile(a.Path, s.hostPath(a.Path)+conflictSuffix)
+			}
+			if err == nil && !a.Dir {
+				var mode os.FileMode
+				if mode, err = s.pushFile(a.Path); err == nil {
+					modes[mode] = append(modes[mode], nodePath(s.cfg.NodeDir, a.Path))
+				}
+			}
+			if err == nil {
+				pushed = append(pushed, nodePath(s.cfg.NodeDir, a.Path))
+			}
+		case Pull:
+			if a.Conflict {
+				err = os.Rename(s.hostPath(a.Path), s.hostPath(a.Path)+conflictSuffix)
+			}
+			if err == nil && a.Dir {
+				err = os.MkdirAll(s.hostPath(a.Path), 0755)
+			} else if err == nil {
+				err = s.pullFile(a.Path, s.hostPath(a.Path))
+			}
+		}
+		if err != nil {
+			fail(a.Path, err)
+		}
+	}
+
+	// the copies only set the permissions of the files they create
+	for mode, paths := range modes {
+		if err := s.nodeXargs(paths, "chmod", fmt.Sprintf("%04o", mode), "--"); err != nil {
+			klog.Warningf("unable to change the permissions of the synced files: %v", err)
+		}
+	}
+	if s.cfg.Owner != "" {
+		if err := s.nodeXargs(pushed, "chown", s.cfg.Owner, "--"); err != nil {
+			klog.Warningf("unable to change the owner of the synced files: %v", err)
+		}
+	}
+	return failed, firstErr
+}
+
+// pushFile copies a host file to the node, returning its permissions.
+func (s *Syncer) pushFile(rel string) (os.FileMode, error) {
+	src := s.hostPath(rel)
+	info, err := os.Stat(src)
+	if err != nil {
+		return 0, err
+	}
+	mode := info.Mode().Perm()
+	dst := nodePath(s.cfg.NodeDir, rel)
+	f, err := assets.NewFileAsset(src, path.Dir(dst), path.Base(dst), fmt.Sprintf("%04o", mode))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return mode, s.runner.Copy(f)
+}
+
+// pullFile copies a node file to dst on the host, replacing it at once so that its readers never
+// see a partial copy.
+func (s *Syncer) pullFile(rel, dst string) error {
+	src := nodePath(s.cfg.NodeDir, rel)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+tempSuffix)
+	if err := os.WriteFile(tmp, nil, 0644); err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	f, err := assets.NewFileAsset(tmp, path.Dir(src), path.Base(src), "0644")
+	if err != nil {
+		return err
+	}
+	err = s.runner.CopyFrom(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// record stores the state of the paths in sync, forgetting the ones which failed so that they
+// are retried.
+func (s *Syncer) record(host, node tree, failed map[string]bool) {
+	last := map[string]pair{}
+	for p, h := range host {
+		n, ok := node[p]
+		if failed[p] || (!ok && s.cfg.Direction == Bidirectional) {
+			continue
+		}
+		last[p] = pair{host: h, node: n}
+	}
+	s.last = last
+}
+
+func (s *Syncer) hostPath(rel string) string {
+	return filepath.Join(s.cfg.HostDir, filepath.FromSlash(rel))
+}
+
+// nodeCommand runs a command as root on the node.
+func (s *Syncer) nodeCommand(args ...string) error {
+	_, err := s.runner.RunCmd(exec.Command("sudo", args...))
+	return err
+}
+
+// nodeXargs runs a command as root on the node with paths appended, which are passed on stdin
+// through xargs not to exceed the maximum length of a command line.
+func (s *Syncer) nodeXargs(paths []string, args ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	c := exec.Command("sudo", append([]string{"xargs", "-0", "-r"}, args...)...)
+	c.Stdin = bytes.NewBufferString(strings.Join(paths, "\x00"))
+	_, err := s.runner.RunCmd(c)
+	return err
+}
+
+func unique(paths []string) []string {
+	seen := map[string]bool{}
+	var u []string
+	for _, p := range paths {
+		if !seen[p] {
+			seen[p] = true
+			u = append(u, p)
+		}
+	}
+	return u
+}
+
+func allPaths(actions []Action) map[string]bool {
+	paths := map[string]bool{}
+	for _, a := range actions {
+		paths[a.Path] = true
+	}
+	return paths
+}
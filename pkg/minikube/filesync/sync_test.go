@@ -0,0 +1,293 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/command"
+)
+
+// localRunner runs the commands of the node on the local machine, without sudo.
+type localRunner struct {
+	command.Runner
+}
+
+func (r localRunner) RunCmd(c *exec.Cmd) (*command.RunResult, error) {
+	if c.Args[0] == "sudo" {
+		stdin := c.Stdin
+		c = exec.Command(c.Args[1], c.Args[2:]...)
+		c.Stdin = stdin
+	}
+	return r.Runner.RunCmd(c)
+}
+
+func (r localRunner) CopyFrom(f assets.CopyableFile) error {
+	data, err := os.ReadFile(f.GetTargetPath())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.GetSourcePath(), data, 0644)
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// touch sets the modification time of a file, relative to now.
+func touch(t *testing.T, p string, d time.Duration) {
+	t.Helper()
+	when := time.Now().Add(d)
+	if err := os.Chtimes(p, when, when); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func newTestSyncer(t *testing.T, direction Direction) (*Syncer, string, string) {
+	t.Helper()
+	host, node := t.TempDir(), t.TempDir()
+	return &Syncer{cfg: Config{HostDir: host, NodeDir: node, Direction: direction}, runner: localRunner{command.NewExecRunner(false)}, ignore: NewIgnore(nil)}, host, node
+}
+
+func mustSync(t *testing.T, s *Syncer) []Action {
+	t.Helper()
+	actions, err := s.Sync()
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	return actions
+}
+
+func assertFiles(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	if got := readFiles(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("files of %s = %v, want %v", dir, got, want)
+	}
+}
+
+func TestSyncInitial(t *testing.T) {
+	s, host, node := newTestSyncer(t, Bidirectional)
+	writeFiles(t, host, map[string]string{"a/b.txt": "b", "same.txt": "same", "diff.txt": "host"})
+	writeFiles(t, node, map[string]string{"node.txt": "node", "same.txt": "same", "diff.txt": "node"})
+
+	got := mustSync(t, s)
+	want := []Action{
+		{Op: Push, Path: "a", Dir: true},
+		{Op: Push, Path: "a/b.txt"},
+		{Op: Push, Path: "diff.txt"},
+		{Op: Pull, Path: "node.txt"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %+v, want %+v", got, want)
+	}
+	all := map[string]string{"a/b.txt": "b", "same.txt": "same", "diff.txt": "host", "node.txt": "node"}
+	assertFiles(t, host, all)
+	assertFiles(t, node, all)
+
+	if got := mustSync(t, s); len(got) != 0 {
+		t.Errorf("expected nothing to sync once in sync, got %+v", got)
+	}
+}
+
+func TestSyncChanges(t *testing.T) {
+	s, host, node := newTestSyncer(t, Bidirectional)
+	writeFiles(t, host, map[string]string{"edit-host": "1", "edit-node": "1", "rm-host": "1", "rm-node": "1"})
+	mustSync(t, s)
+
+	writeFiles(t, host, map[string]string{"edit-host": "22", "new-host": "1"})
+	writeFiles(t, node, map[string]string{"edit-node": "22", "dir/new-node": "1"})
+	if err := os.Remove(filepath.Join(host, "rm-host")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(node, "rm-node")); err != nil {
+		t.Fatal(err)
+	}
+
+	got := mustSync(t, s)
+	want := []Action{
+		{Op: Pull, Path: "dir", Dir: true},
+		{Op: Pull, Path: "dir/new-node"},
+		{Op: Push, Path: "edit-host"},
+		{Op: Pull, Path: "edit-node"},
+		{Op: Push, Path: "new-host"},
+		{Op: DeleteNode, Path: "rm-host"},
+		{Op: DeleteHost, Path: "rm-node"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %+v, want %+v", got, want)
+	}
+	all := map[string]string{"edit-host": "22", "edit-node": "22", "new-host": "1", "dir/new-node": "1"}
+	assertFiles(t, host, all)
+	assertFiles(t, node, all)
+}
+
+func TestSyncConflict(t *testing.T) {
+	s, host, node := newTestSyncer(t, Bidirectional)
+	writeFiles(t, host, map[string]string{"file": "base"})
+	mustSync(t, s)
+
+	writeFiles(t, host, map[string]string{"file": "host change"})
+	writeFiles(t, node, map[string]string{"file": "node change"})
+	touch(t, filepath.Join(host, "file"), -time.Minute)
+
+	got := mustSync(t, s)
+	want := []Action{{Op: Pull, Path: "file", Conflict: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %+v, want %+v", got, want)
+	}
+	assertFiles(t, host, map[string]string{"file": "node change", "file" + conflictSuffix: "host change"})
+	assertFiles(t, node, map[string]string{"file": "node change"})
+}
+
+func TestSyncIgnore(t *testing.T) {
+	s, host, node := newTestSyncer(t, Bidirectional)
+	writeFiles(t, host, map[string]string{"main.go": "package main", "debug.log": "log", "build/out": "bin", ".minikubeignore": "*.log\nbuild/\n"})
+	ig, err := LoadIgnore(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.ignore = ig
+	writeFiles(t, node, map[string]string{"node.log": "log"})
+
+	mustSync(t, s)
+	assertFiles(t, node, map[string]string{"main.go": "package main", ".minikubeignore": "*.log\nbuild/\n", "node.log": "log"})
+	if _, err := os.Stat(filepath.Join(host, "node.log")); !os.IsNotExist(err) {
+		t.Errorf("ignored node file was pulled")
+	}
+}
+
+func TestSyncToNode(t *testing.T) {
+	s, host, node := newTestSyncer(t, ToNode)
+	writeFiles(t, host, map[string]string{"conf": "host"})
+	writeFiles(t, node, map[string]string{"other": "node"})
+	mustSync(t, s)
+
+	writeFiles(t, node, map[string]string{"conf": "changed on the node"})
+	got := mustSync(t, s)
+	want := []Action{{Op: Push, Path: "conf"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %+v, want %+v", got, want)
+	}
+	assertFiles(t, node, map[string]string{"conf": "host", "other": "node"})
+	assertFiles(t, host, map[string]string{"conf": "host"})
+}
+
+func TestSyncToNodeKnown(t *testing.T) {
+	s, host, node := newTestSyncer(t, ToNode)
+	writeFiles(t, host, map[string]string{"etc/kept": "host", "etc/mode": "host"})
+	writeFiles(t, node, map[string]string{"etc/removed": "synced before", "etc/other": "node"})
+	s.known = map[string]bool{"etc/removed": true, "etc/kept": true}
+
+	got := mustSync(t, s)
+	want := []Action{
+		{Op: Push, Path: "etc/kept"},
+		{Op: Push, Path: "etc/mode"},
+		{Op: DeleteNode, Path: "etc/removed"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %+v, want %+v", got, want)
+	}
+	assertFiles(t, node, map[string]string{"etc/kept": "host", "etc/mode": "host", "etc/other": "node"})
+	if got, want := s.Synced(), []string{"etc/kept", "etc/mode"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Synced() = %v, want %v", got, want)
+	}
+
+	// removing the last file of a directory does not remove the directory from the node
+	if err := os.Chmod(filepath.Join(host, "etc/mode"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(host, "etc/kept")); err != nil {
+		t.Fatal(err)
+	}
+	got = mustSync(t, s)
+	want = []Action{{Op: DeleteNode, Path: "etc/kept"}, {Op: Push, Path: "etc/mode"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %+v, want %+v", got, want)
+	}
+	info, err := os.Stat(filepath.Join(node, "etc/mode"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode of the synced file = %o, want 600", info.Mode().Perm())
+	}
+	assertFiles(t, node, map[string]string{"etc/mode": "host", "etc/other": "node"})
+}
+
+func TestPlan(t *testing.T) {
+	s, host, node := newTestSyncer(t, Bidirectional)
+	writeFiles(t, host, map[string]string{"file": "host"})
+
+	got, err := s.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if want := []Action{{Op: Push, Path: "file"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %+v, want %+v", got, want)
+	}
+	assertFiles(t, node, map[string]string{})
+}
+
+func TestParseFindTime(t *testing.T) {
+	tests := map[string]int64{
+		"1665000000.1234567890": 1665000000123456789,
+		"1665000000.5":          1665000000500000000,
+		"1665000000":            1665000000000000000,
+	}
+	for in, want := range tests {
+		got, err := parseFindTime(in)
+		if err != nil || got != want {
+			t.Errorf("parseFindTime(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+}
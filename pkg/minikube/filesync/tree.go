@@ -0,0 +1,275 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+
+	"k8s.io/minikube/pkg/minikube/command"
+)
+
+// entry is the state of a path on one side of a sync. Directories only record their mode, as their
+// size and modification time change with their content.
+type entry struct {
+	Dir   bool
+	Size  int64
+	Mode  os.FileMode
+	MTime int64
+	// Sum is the sha256 of a file, only computed when reconciling trees without a previous state
+	Sum string
+}
+
+// tree maps the slash separated paths relative to a synced directory to their state
+type tree map[string]entry
+
+// same returns whether a path is in the same state as before, its presence included.
+func same(before entry, hadBefore bool, after entry, hasAfter bool) bool {
+	if hadBefore != hasAfter {
+		return false
+	}
+	return !hadBefore || (before.Dir == after.Dir && before.Size == after.Size && before.Mode == after.Mode && before.MTime == after.MTime)
+}
+
+// identical returns whether two sides have the same content, which requires checksums for files.
+func identical(h, n entry) bool {
+	if h.Dir || n.Dir {
+		return h.Dir && n.Dir
+	}
+	return h.Sum != "" && h.Sum == n.Sum
+}
+
+// listHost lists the host directory, skipping ignored paths, symlinks and other special files.
+func listHost(root string, ig *Ignore, sums bool) (tree, error) {
+	t := tree{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if ig.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			klog.V(2).Infof("not syncing %s: not a regular file", p)
+			return nil
+		}
+		info, err := d.Info()
+		if os.IsNotExist(err) {
+			// removed while listing, it will be noticed by the next sync
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e := entry{Dir: d.IsDir(), Mode: info.Mode().Perm()}
+		if !e.Dir {
+			e.Size = info.Size()
+			e.MTime = info.ModTime().UnixNano()
+			if sums {
+				if e.Sum, err = fileSum(p); err != nil {
+					return err
+				}
+			}
+		}
+		t[rel] = e
+		return nil
+	})
+	return t, err
+}
+
+func fileSum(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// listNode lists the node directory, skipping ignored paths, symlinks and other special files.
+func listNode(r command.Runner, root string, ig *Ignore, sums bool) (tree, error) {
+	rr, err := r.RunCmd(exec.Command("sudo", "find", root, "-mindepth", "1", "-printf", `%y %s %T@ %m %P\0`))
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing %s", root)
+	}
+	t, err := parseFind(rr.Stdout.Bytes(), ig, "")
+	if err != nil || !sums {
+		return t, err
+	}
+
+	rr, err = r.RunCmd(exec.Command("sudo", "find", root, "-type", "f", "-exec", "sha256sum", "{}", "+"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "computing the checksums of %s", root)
+	}
+	addSums(t, rr.Stdout.String(), root)
+	return t, nil
+}
+
+// listNodePaths lists the files among the paths of the node directory, the missing ones being skipped.
+func listNodePaths(r command.Runner, root string, rels []string, ig *Ignore, sums bool) (tree, error) {
+	t := tree{}
+	if len(rels) == 0 {
+		return t, nil
+	}
+	var paths []string
+	for _, rel := range rels {
+		paths = append(paths, nodePath(root, rel))
+	}
+	// the paths are appended by xargs, while find expects its expression after them
+	out, err := nodeXargsOutput(r, paths, `find "$@" -maxdepth 0 -printf '%y %s %T@ %m %p\0' 2>/dev/null; exit 0`)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the synced files of %s", root)
+	}
+	found, err := parseFind(out, ig, rootPrefix(root))
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for rel, e := range found {
+		if !e.Dir {
+			t[rel] = e
+			files = append(files, nodePath(root, rel))
+		}
+	}
+	if !sums || len(files) == 0 {
+		return t, nil
+	}
+
+	out, err = nodeXargsOutput(r, files, `sha256sum "$@" 2>/dev/null; exit 0`)
+	if err != nil {
+		return nil, errors.Wrapf(err, "computing the checksums of the synced files of %s", root)
+	}
+	addSums(t, string(out), root)
+	return t, nil
+}
+
+// nodeXargsOutput runs a shell script as root on the node with paths as its arguments, which are
+// passed on stdin through xargs not to exceed the maximum length of a command line.
+func nodeXargsOutput(r command.Runner, paths []string, script string) ([]byte, error) {
+	c := exec.Command("sudo", "xargs", "-0", "-r", "sh", "-c", script, "sh")
+	c.Stdin = bytes.NewBufferString(strings.Join(paths, "\x00"))
+	rr, err := r.RunCmd(c)
+	if err != nil {
+		return nil, err
+	}
+	return rr.Stdout.Bytes(), nil
+}
+
+// addSums sets the checksums of the files of t from the output of sha256sum.
+func addSums(t tree, out string, root string) {
+	for _, line := range strings.Split(out, "\n") {
+		sum, p, ok := strings.Cut(line, "  ")
+		if !ok {
+			continue
+		}
+		rel := strings.TrimPrefix(p, rootPrefix(root))
+		if e, ok := t[rel]; ok {
+			e.Sum = sum
+			t[rel] = e
+		}
+	}
+}
+
+// rootPrefix returns the prefix of the paths of the node directory.
+func rootPrefix(root string) string {
+	return strings.TrimSuffix(root, "/") + "/"
+}
+
+// parseFind parses the output of find -printf '%y %s %T@ %m %P\0', prefix being trimmed from the
+// printed paths.
+func parseFind(out []byte, ig *Ignore, prefix string) (tree, error) {
+	t := tree{}
+	for _, rec := range bytes.Split(out, []byte{0}) {
+		if len(rec) == 0 {
+			continue
+		}
+		fields := strings.SplitN(string(rec), " ", 5)
+		if len(fields) != 5 {
+			return nil, errors.Errorf("unexpected find output: %q", rec)
+		}
+		kind, rel := fields[0], strings.TrimPrefix(fields[4], prefix)
+		if kind != "f" && kind != "d" {
+			continue
+		}
+		e := entry{Dir: kind == "d"}
+		if ig.Match(rel, e.Dir) {
+			continue
+		}
+		mode, err := strconv.ParseUint(fields[3], 8, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing the mode of %s", rel)
+		}
+		e.Mode = os.FileMode(mode).Perm()
+		if !e.Dir {
+			if e.Size, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+				return nil, errors.Wrapf(err, "parsing the size of %s", rel)
+			}
+			if e.MTime, err = parseFindTime(fields[2]); err != nil {
+				return nil, errors.Wrapf(err, "parsing the modification time of %s", rel)
+			}
+		}
+		t[rel] = e
+	}
+	return t, nil
+}
+
+// parseFindTime parses seconds with a fractional part, as printed by %T@, into nanoseconds.
+func parseFindTime(s string) (int64, error) {
+	secs, frac, _ := strings.Cut(s, ".")
+	sec, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	frac = (frac + "000000000")[:9]
+	nsec, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return sec*1e9 + nsec, nil
+}
+
+// nodePath returns the path of rel in the node directory.
+func nodePath(root, rel string) string {
+	return path.Join(root, rel)
+}
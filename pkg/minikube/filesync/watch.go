@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultDebounce is how long the changes of the host are collected before syncing them
+	DefaultDebounce = 300 * time.Millisecond
+	// DefaultPollInterval is how often the node is checked for changes, which can't be watched from the host
+	DefaultPollInterval = 2 * time.Second
+)
+
+// Watch syncs the changes until ctx is done: once the changes of the host settled for debounce, and
+// every poll interval for the changes of the node. report is called with the result of every sync.
+func (s *Syncer) Watch(ctx context.Context, debounce, poll time.Duration, report func([]Action, error)) error {
+	return Watch(ctx, []*Syncer{s}, debounce, poll, func(_ *Syncer, actions []Action, err error) {
+		report(actions, err)
+	})
+}
+
+// Watch runs syncers until ctx is done, as Syncer.Watch does, the syncers of a host directory
+// sharing its watch. The syncers run concurrently, report being called with the result of every
+// one of them in order.
+func Watch(ctx context.Context, syncers []*Syncer, debounce, poll time.Duration, report func(*Syncer, []Action, error)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "watching the host directories")
+	}
+	defer w.Close()
+	// the first syncer of every host directory decides which of its paths are ignored
+	watched := map[string]*Syncer{}
+	for _, s := range syncers {
+		if _, ok := watched[s.cfg.HostDir]; ok {
+			continue
+		}
+		watched[s.cfg.HostDir] = s
+		if err := s.watchDirs(w, s.cfg.HostDir); err != nil {
+			return err
+		}
+	}
+
+	settled := time.NewTimer(debounce)
+	settled.Stop()
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			s := watcherOf(watched, ev.Name)
+			if s == nil || s.ignored(ev.Name) {
+				continue
+			}
+			if ev.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					if err := s.watchDirs(w, ev.Name); err != nil {
+						klog.Warningf("unable to watch %s: %v", ev.Name, err)
+					}
+				}
+			}
+			settled.Reset(debounce)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			klog.Warningf("error watching the host directories: %v", err)
+		case <-settled.C:
+			SyncAll(syncers, report)
+		case <-ticker.C:
+			SyncAll(syncers, report)
+		}
+	}
+}
+
+// watcherOf returns the syncer watching the host directory which contains p.
+func watcherOf(watched map[string]*Syncer, p string) *Syncer {
+	var found *Syncer
+	for dir, s := range watched {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		// nested host directories are watched by the syncer of the innermost one
+		if found == nil || len(dir) > len(found.cfg.HostDir) {
+			found = s
+		}
+	}
+	return found
+}
+
+// SyncAll runs the syncers concurrently, then reports their results in order.
+func SyncAll(syncers []*Syncer, report func(*Syncer, []Action, error)) {
+	type result struct {
+		actions []Action
+		err     error
+	}
+	results := make([]result, len(syncers))
+	var wg sync.WaitGroup
+	for i, s := range syncers {
+		wg.Add(1)
+		go func(i int, s *Syncer) {
+			defer wg.Done()
+			actions, err := s.Sync()
+			results[i] = result{actions, err}
+		}(i, s)
+	}
+	wg.Wait()
+	for i, s := range syncers {
+		report(s, results[i].actions, results[i].err)
+	}
+}
+
+// watchDirs watches dir and the directories below it which are not ignored.
+func (s *Syncer) watchDirs(w *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != s.cfg.HostDir && s.ignored(p) {
+			return filepath.SkipDir
+		}
+		return w.Add(p)
+	})
+}
+
+// ignored returns whether a host path is ignored, or outside of the synced directory.
+func (s *Syncer) ignored(p string) bool {
+	rel, err := filepath.Rel(s.cfg.HostDir, p)
+	if err != nil || rel == "." {
+		return err != nil
+	}
+	info, err := os.Stat(p)
+	return s.ignore.Match(filepath.ToSlash(rel), err == nil && info.IsDir())
+}
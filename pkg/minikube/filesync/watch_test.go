@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchFanOut(t *testing.T) {
+	s1, host, node1 := newTestSyncer(t, ToNode)
+	s2, _, node2 := newTestSyncer(t, ToNode)
+	s2.cfg.HostDir = host
+	for _, s := range []*Syncer{s1, s2} {
+		mustSync(t, s)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	synced := map[*Syncer]bool{}
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, []*Syncer{s1, s2}, 10*time.Millisecond, time.Second, func(s *Syncer, actions []Action, err error) {
+			if err != nil {
+				t.Errorf("sync failed: %v", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(actions) > 0 {
+				synced[s] = true
+			}
+			if synced[s1] && synced[s2] {
+				cancel()
+			}
+		})
+	}()
+
+	// the watch is set up asynchronously, a missed event being caught by the poll
+	time.Sleep(100 * time.Millisecond)
+	writeFiles(t, host, map[string]string{"etc/conf": "new"})
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Watch: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the change to be synced")
+	}
+	assertFiles(t, node1, map[string]string{"etc/conf": "new"})
+	assertFiles(t, node2, map[string]string{"etc/conf": "new"})
+}
@@ -58,6 +58,11 @@ func DeleteFromCacheDir(images []string) error {
 	return cleanImageCacheDir()
 }
 
+// CachePath returns the path at which SaveToDir stores an image in cacheDir
+func CachePath(cacheDir, image string) string {
+	return localpath.SanitizeCacheDir(filepath.Join(cacheDir, image))
+}
+
 // SaveToDir will cache images on the host
 //
 // The cache directory currently caches images using the imagename_tag
@@ -68,8 +73,7 @@ func SaveToDir(images []string, cacheDir string, overwrite bool) error {
 	for _, image := range images {
 		image := image
 		g.Go(func() error {
-			dst := filepath.Join(cacheDir, image)
-			dst = localpath.SanitizeCacheDir(dst)
+			dst := CachePath(cacheDir, image)
 			if err := saveToTarFile(image, dst, overwrite); err != nil {
 				if err == errCacheImageDoesntExist {
 					out.WarningT("The image '{{.imageName}}' was not found; unable to add it to cache.", out.V{"imageName": image})
@@ -0,0 +1,424 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pkg/errors"
+)
+
+// maxMetadataSize is the size up to which the files of an image archive are kept in memory while
+// scanning it, to read its manifest and image configs
+const maxMetadataSize = 4 << 20
+
+// containerdImageName is the annotation of an OCI index naming the image imported by containerd
+const containerdImageName = "io.containerd.image.name"
+
+// ChainIDs returns the chain IDs of the layers of an image from their diff IDs, as defined by the
+// OCI image spec, which identify a layer along with the layers below it
+func ChainIDs(diffIDs []string) []string {
+	var ids []string
+	for i, d := range diffIDs {
+		if i == 0 {
+			ids = append(ids, d)
+			continue
+		}
+		ids = append(ids, fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(ids[i-1]+" "+d))))
+	}
+	return ids
+}
+
+// archiveFile is a regular file of an image archive
+type archiveFile struct {
+	digest string
+	size   int64
+	gzip   bool
+	// data are the contents of the small files, such as the manifest and the image configs
+	data []byte
+}
+
+// imageArchive is an image archive written by docker save, or by go-containerregistry
+type imageArchive struct {
+	manifest tarball.Manifest
+	files    map[string]*archiveFile
+	links    map[string]string
+}
+
+// scanArchive reads the manifest of an image archive along with the digests of its files
+func scanArchive(src string) (*imageArchive, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	a := &imageArchive{files: map[string]*archiveFile{}, links: map[string]string{}}
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", src)
+		}
+		name := path.Clean(hdr.Name)
+		switch hdr.Typeflag {
+		case tar.TypeSymlink, tar.TypeLink:
+			target := hdr.Linkname
+			if hdr.Typeflag == tar.TypeSymlink {
+				target = path.Join(path.Dir(name), target)
+			}
+			a.links[name] = path.Clean(target)
+		case tar.TypeReg:
+			af, err := scanFile(tr, hdr.Size)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading %s in %s", name, src)
+			}
+			a.files[name] = af
+		}
+	}
+
+	mf, err := a.file("manifest.json")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(mf.data, &a.manifest); err != nil {
+		return nil, errors.Wrap(err, "parsing manifest.json")
+	}
+	return a, nil
+}
+
+// scanFile computes the digest of a file of an archive, keeping its contents if small
+func scanFile(r io.Reader, size int64) (*archiveFile, error) {
+	h := sha256.New()
+	var data bytes.Buffer
+	w := io.Writer(h)
+	if size <= maxMetadataSize {
+		w = io.MultiWriter(h, &data)
+	}
+	head := make([]byte, 2)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	if _, err := w.Write(head[:n]); err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return nil, err
+	}
+	af := &archiveFile{
+		digest: fmt.Sprintf("sha256:%x", h.Sum(nil)),
+		size:   size,
+		gzip:   n == 2 && head[0] == 0x1f && head[1] == 0x8b,
+	}
+	if size <= maxMetadataSize {
+		af.data = data.Bytes()
+	}
+	return af, nil
+}
+
+// resolve returns the name of the regular file a file of the archive links to
+func (a *imageArchive) resolve(name string) string {
+	name = path.Clean(name)
+	for i := 0; i < 10; i++ {
+		target, ok := a.links[name]
+		if !ok {
+			break
+		}
+		name = target
+	}
+	return name
+}
+
+// file returns a regular file of the archive, following the links
+func (a *imageArchive) file(name string) (*archiveFile, error) {
+	f, ok := a.files[a.resolve(name)]
+	if !ok {
+		return nil, errors.Errorf("%s not found in the image archive", name)
+	}
+	return f, nil
+}
+
+// config returns the image config of an image of the archive
+func (a *imageArchive) config(d tarball.Descriptor) (*archiveFile, *v1.ConfigFile, error) {
+	f, err := a.file(d.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+	if f.data == nil {
+		return nil, nil, errors.Errorf("image config %s is too large", d.Config)
+	}
+	cfg, err := v1.ParseConfigFile(bytes.NewReader(f.data))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "parsing image config %s", d.Config)
+	}
+	if len(cfg.RootFS.DiffIDs) != len(d.Layers) {
+		return nil, nil, errors.Errorf("image config %s has %d layers, the manifest %d", d.Config, len(cfg.RootFS.DiffIDs), len(d.Layers))
+	}
+	return f, cfg, nil
+}
+
+// copyArchive copies the files of an image archive to w, skipping the regular files for which skip returns true
+func copyArchive(src string, tw *tar.Writer, skip func(name string) bool) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "reading %s", src)
+		}
+		if hdr.Typeflag == tar.TypeReg && skip(path.Clean(hdr.Name)) {
+			continue
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// WriteDockerArchiveDiff writes the image archive src to w without the layers of which the chain ID is known,
+// and returns the size of the layers left out. Docker loads such an archive as long as it still has these layers.
+func WriteDockerArchiveDiff(src string, w io.Writer, known map[string]bool) (int64, error) {
+	a, err := scanArchive(src)
+	if err != nil {
+		return 0, err
+	}
+
+	// a layer file can be shared by several images, and is only left out if all of them have it already
+	needed := map[string]bool{}
+	layers := map[string]bool{}
+	for _, d := range a.manifest {
+		_, cfg, err := a.config(d)
+		if err != nil {
+			return 0, err
+		}
+		diffIDs := []string{}
+		for _, id := range cfg.RootFS.DiffIDs {
+			diffIDs = append(diffIDs, id.String())
+		}
+		for i, id := range ChainIDs(diffIDs) {
+			name := a.resolve(d.Layers[i])
+			layers[name] = true
+			if !known[id] {
+				needed[name] = true
+			}
+		}
+	}
+
+	var skipped int64
+	tw := tar.NewWriter(w)
+	err = copyArchive(src, tw, func(name string) bool {
+		if layers[name] && !needed[name] {
+			skipped += a.files[name].size
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return 0, err
+	}
+	return skipped, tw.Close()
+}
+
+// WriteOCIArchiveDiff writes the image archive src to w as an OCI image layout without the layers of which the
+// digest is known, and returns the size of the layers left out. Containerd imports such an archive as long as
+// its content store still has these layers.
+func WriteOCIArchiveDiff(src string, w io.Writer, known map[string]bool) (int64, error) {
+	a, err := scanArchive(src)
+	if err != nil {
+		return 0, err
+	}
+
+	index := v1.IndexManifest{SchemaVersion: 2, MediaType: types.OCIImageIndex}
+	// the manifests and configs to write, by digest
+	blobs := map[string][]byte{}
+	// the layer files to write, by name
+	needed := map[string]string{}
+	var skipped int64
+	for _, d := range a.manifest {
+		if len(d.RepoTags) == 0 {
+			return 0, errors.Errorf("image %s has no tag", d.Config)
+		}
+		cf, _, err := a.config(d)
+		if err != nil {
+			return 0, err
+		}
+		m := v1.Manifest{
+			SchemaVersion: 2,
+			MediaType:     types.OCIManifestSchema1,
+			Config:        v1.Descriptor{MediaType: types.OCIConfigJSON, Size: cf.size},
+		}
+		if m.Config.Digest, err = v1.NewHash(cf.digest); err != nil {
+			return 0, err
+		}
+		blobs[cf.digest] = cf.data
+		for _, l := range d.Layers {
+			lf, err := a.file(l)
+			if err != nil {
+				return 0, err
+			}
+			desc := v1.Descriptor{MediaType: types.OCIUncompressedLayer, Size: lf.size}
+			if lf.gzip {
+				desc.MediaType = types.OCILayer
+			}
+			if desc.Digest, err = v1.NewHash(lf.digest); err != nil {
+				return 0, err
+			}
+			m.Layers = append(m.Layers, desc)
+			name := a.resolve(l)
+			if _, ok := needed[name]; ok {
+				continue
+			}
+			if known[lf.digest] {
+				skipped += lf.size
+				needed[name] = ""
+				continue
+			}
+			needed[name] = lf.digest
+		}
+
+		raw, err := json.Marshal(m)
+		if err != nil {
+			return 0, err
+		}
+		digest, size, err := v1.SHA256(bytes.NewReader(raw))
+		if err != nil {
+			return 0, err
+		}
+		blobs[digest.String()] = raw
+		for _, tag := range d.RepoTags {
+			index.Manifests = append(index.Manifests, v1.Descriptor{
+				MediaType:   types.OCIManifestSchema1,
+				Size:        size,
+				Digest:      digest,
+				Annotations: map[string]string{containerdImageName: fullImageName(tag)},
+			})
+		}
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeTarFile(tw, "oci-layout", []byte(`{"imageLayoutVersion":"1.0.0"}`)); err != nil {
+		return 0, err
+	}
+	raw, err := json.Marshal(index)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeTarFile(tw, "index.json", raw); err != nil {
+		return 0, err
+	}
+	digests := []string{}
+	for digest := range blobs {
+		digests = append(digests, digest)
+	}
+	sort.Strings(digests)
+	for _, digest := range digests {
+		if err := writeTarFile(tw, blobPath(digest), blobs[digest]); err != nil {
+			return 0, err
+		}
+	}
+	if err := copyLayers(src, tw, needed); err != nil {
+		return 0, err
+	}
+	return skipped, tw.Close()
+}
+
+// copyLayers copies the layer files of an image archive to the blobs of an OCI image layout
+func copyLayers(src string, tw *tar.Writer, layers map[string]string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "reading %s", src)
+		}
+		digest := layers[path.Clean(hdr.Name)]
+		if hdr.Typeflag != tar.TypeReg || digest == "" {
+			continue
+		}
+		// a layer file is written once, even if several names link to it
+		layers[path.Clean(hdr.Name)] = ""
+		if err := tw.WriteHeader(&tar.Header{Name: blobPath(digest), Mode: 0644, Size: hdr.Size, Typeflag: tar.TypeReg}); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// writeTarFile writes a regular file to a tarball
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// blobPath returns the path of a blob in an OCI image layout
+func blobPath(digest string) string {
+	return path.Join("blobs", strings.Replace(digest, ":", "/", 1))
+}
+
+// fullImageName returns the fully qualified name of an image, as containerd names it
+func fullImageName(tag string) string {
+	t, err := name.NewTag(tag)
+	if err != nil {
+		return tag
+	}
+	full := t.Name()
+	if strings.HasPrefix(full, legacyDefaultDomain+"/") {
+		full = defaultDomain + strings.TrimPrefix(full, legacyDefaultDomain)
+	}
+	return full
+}
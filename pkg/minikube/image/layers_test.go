@@ -0,0 +1,205 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+// writeTestArchive writes a random image of 3 layers to an archive, and returns it with the archive path
+func writeTestArchive(t *testing.T) (v1.Image, string) {
+	img, err := random.Image(1024, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := name.NewTag("example/app:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(t.TempDir(), "app.tar")
+	if err := tarball.WriteToFile(src, tag, img); err != nil {
+		t.Fatal(err)
+	}
+	return img, src
+}
+
+// archiveFiles returns the names of the regular files of a tarball, and the contents of one of them
+func archiveFiles(t *testing.T, data []byte, read string) ([]string, []byte) {
+	var names []string
+	var contents []byte
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		if hdr.Name == read {
+			if contents, err = io.ReadAll(tr); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, contents
+}
+
+func TestWriteDockerArchiveDiff(t *testing.T) {
+	img, src := writeTestArchive(t)
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffIDs := []string{}
+	for _, id := range cfg.RootFS.DiffIDs {
+		diffIDs = append(diffIDs, id.String())
+	}
+	chainIDs := ChainIDs(diffIDs)
+
+	// the node has the first two layers
+	known := map[string]bool{chainIDs[0]: true, chainIDs[1]: true}
+	var out bytes.Buffer
+	skipped, err := WriteDockerArchiveDiff(src, &out, known)
+	if err != nil {
+		t.Fatalf("WriteDockerArchiveDiff() error = %v", err)
+	}
+
+	var want int64
+	for _, l := range layers[:2] {
+		size, err := l.Size()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want += size
+	}
+	if skipped != want {
+		t.Errorf("WriteDockerArchiveDiff() skipped %d bytes, want %d", skipped, want)
+	}
+
+	cfgName, err := img.ConfigName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := layers[2].Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, _ := archiveFiles(t, out.Bytes(), "")
+	wantNames := []string{cfgName.String(), digest.Hex + ".tar.gz", "manifest.json"}
+	sort.Strings(wantNames)
+	if diff := cmp.Diff(wantNames, names); diff != "" {
+		t.Errorf("WriteDockerArchiveDiff() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteOCIArchiveDiff(t *testing.T) {
+	img, src := writeTestArchive(t)
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digests := []v1.Hash{}
+	for _, l := range layers {
+		d, err := l.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		digests = append(digests, d)
+	}
+
+	known := map[string]bool{digests[0].String(): true}
+	var out bytes.Buffer
+	skipped, err := WriteOCIArchiveDiff(src, &out, known)
+	if err != nil {
+		t.Fatalf("WriteOCIArchiveDiff() error = %v", err)
+	}
+	size, err := layers[0].Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != size {
+		t.Errorf("WriteOCIArchiveDiff() skipped %d bytes, want %d", skipped, size)
+	}
+
+	names, raw := archiveFiles(t, out.Bytes(), "index.json")
+	var index v1.IndexManifest
+	if err := json.Unmarshal(raw, &index); err != nil {
+		t.Fatalf("parsing index.json: %v", err)
+	}
+	if len(index.Manifests) != 1 {
+		t.Fatalf("index.json has %d manifests, want 1", len(index.Manifests))
+	}
+	m := index.Manifests[0]
+	if got := m.Annotations[containerdImageName]; got != "docker.io/example/app:v1" {
+		t.Errorf("image name = %q, want docker.io/example/app:v1", got)
+	}
+
+	cfgName, err := img.ConfigName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantNames := []string{"oci-layout", "index.json", blobPath(m.Digest.String()), blobPath(cfgName.String()),
+		blobPath(digests[1].String()), blobPath(digests[2].String())}
+	sort.Strings(wantNames)
+	if diff := cmp.Diff(wantNames, names); diff != "" {
+		t.Errorf("WriteOCIArchiveDiff() returned diff (-want +got):\n%s", diff)
+	}
+
+	_, raw = archiveFiles(t, out.Bytes(), blobPath(m.Digest.String()))
+	manifest, err := v1.ParseManifest(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, l := range manifest.Layers {
+		if l.Digest != digests[i] {
+			t.Errorf("layer %d digest = %s, want %s", i, l.Digest, digests[i])
+		}
+	}
+}
+
+func TestFullImageName(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                 "docker.io/library/nginx:latest",
+		"example/app:v1":        "docker.io/example/app:v1",
+		"gcr.io/k8s/pause:3.7":  "gcr.io/k8s/pause:3.7",
+		"localhost:5000/app:v2": "localhost:5000/app:v2",
+	}
+	for tag, want := range tests {
+		if got := fullImageName(tag); got != want {
+			t.Errorf("fullImageName(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
@@ -68,17 +68,42 @@ func EventLog(name string) string {
 	return filepath.Join(Profile(name), "events.json")
 }
 
+// EtcdBackups returns the path to the directory holding the etcd backups of a profile.
+func EtcdBackups(name string) string {
+	return filepath.Join(Profile(name), "etcd")
+}
+
 // AuditLog returns the path to the audit log.
 // This log contains a history of commands run, by who, when, and what arguments.
 func AuditLog() string {
 	return filepath.Join(MiniPath(), "logs", "audit.json")
 }
 
+// Snapshots returns the path to the directory holding profile snapshots.
+func Snapshots() string {
+	return filepath.Join(MiniPath(), "snapshots")
+}
+
+// Snapshot returns the path to the archive of a named profile snapshot.
+func Snapshot(name string) string {
+	return filepath.Join(Snapshots(), name+".tar.gz")
+}
+
+// RegistryCache returns the path to the directory of the registry cache shared by the profiles.
+func RegistryCache() string {
+	return filepath.Join(MiniPath(), "cache", "registry")
+}
+
 // LastStartLog returns the path to the last start log.
 func LastStartLog() string {
 	return filepath.Join(MiniPath(), "logs", "lastStart.txt")
 }
 
+// ProblemDetectors returns the path to the file of user defined log problem detectors
+func ProblemDetectors() string {
+	return filepath.Join(MiniPath(), "detectors.yaml")
+}
+
 // ClientCert returns client certificate path, used by kubeconfig
 func ClientCert(name string) string {
 	new := filepath.Join(Profile(name), "client.crt")
@@ -104,6 +129,16 @@ func PID(profile string) string {
 	return path.Join(Profile(profile), "pid")
 }
 
+// SchedulePID returns the path to the pid file of the recurring schedule daemon of the profile
+func SchedulePID(profile string) string {
+	return path.Join(Profile(profile), "schedule.pid")
+}
+
+// ScheduleLog returns the path to the log file of the recurring schedule daemon of the profile
+func ScheduleLog(profile string) string {
+	return path.Join(Profile(profile), "schedule.log")
+}
+
 // ClientKey returns client certificate path, used by kubeconfig
 func ClientKey(name string) string {
 	new := filepath.Join(Profile(name), "client.key")
@@ -129,6 +164,16 @@ func CACert() string {
 	return filepath.Join(MiniPath(), "ca.crt")
 }
 
+// ProfileCACert returns the path of the external CA certificate of a profile, which signs its certificates instead of the minikube CA
+func ProfileCACert(name string) string {
+	return filepath.Join(Profile(name), "ca.crt")
+}
+
+// ProfileCAKey returns the path of the key of the external CA of a profile
+func ProfileCAKey(name string) string {
+	return filepath.Join(Profile(name), "ca.key")
+}
+
 // MachinePath returns the minikube machine path of a machine
 func MachinePath(machine string, miniHome ...string) string {
 	miniPath := MiniPath()
@@ -0,0 +1,333 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"k8s.io/minikube/pkg/minikube/reason"
+)
+
+// Severity is how serious a detected problem is
+type Severity string
+
+const (
+	// SeverityInfo is a problem which is worth knowing about, but is usually harmless
+	SeverityInfo Severity = "info"
+	// SeverityWarning is a problem which may degrade the cluster
+	SeverityWarning Severity = "warning"
+	// SeverityError is a problem which breaks part of the cluster
+	SeverityError Severity = "error"
+	// SeverityFatal is a problem which prevents the cluster from running
+	SeverityFatal Severity = "fatal"
+)
+
+// severityRanks orders severities from least to most serious
+var severityRanks = map[Severity]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+	SeverityFatal:   4,
+}
+
+// Components inspected by detectors, besides the important pods without their "kube-" prefix, such as "etcd" or "scheduler"
+const (
+	// ComponentAny inspects all logs
+	ComponentAny = ""
+	// ComponentKubelet inspects the kubelet journal
+	ComponentKubelet = "kubelet"
+	// ComponentAPIServer inspects the kube-apiserver container logs
+	ComponentAPIServer = "apiserver"
+	// ComponentRuntime inspects the container runtime journal
+	ComponentRuntime = "runtime"
+	// ComponentDmesg inspects the kernel ring buffer
+	ComponentDmesg = "dmesg"
+)
+
+// Detector detects a known problem in the logs of a component
+type Detector struct {
+	// ID is a unique and stable name for the problem
+	ID string `yaml:"id" json:"id"`
+	// Component is which logs the detector inspects, all logs if empty
+	Component string `yaml:"component,omitempty" json:"component,omitempty"`
+	// Severity is how serious the problem is
+	Severity Severity `yaml:"severity" json:"severity"`
+	// Match is a regular expression matching log lines which point to the problem
+	Match string `yaml:"match" json:"match"`
+	// Ignore is a regular expression matching log lines which should not be reported, even if Match matches
+	Ignore string `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+	// Advice is actionable text that the user should follow, defaults to the advice of Reason
+	Advice string `yaml:"advice,omitempty" json:"advice,omitempty"`
+	// Reason is the ID of the known issue (reason.Kind) describing the problem
+	Reason string `yaml:"reason,omitempty" json:"reason,omitempty"`
+
+	matchRe  *regexp.Regexp
+	ignoreRe *regexp.Regexp
+}
+
+// detectorFile is the format of the user defined detectors file
+type detectorFile struct {
+	Detectors []*Detector `yaml:"detectors"`
+}
+
+// builtinDetectors are the detectors shipped with minikube, most serious first
+var builtinDetectors = mustCompile([]*Detector{
+	{ID: "unknown-flag", Severity: SeverityFatal, Match: `unknown flag: --`,
+		Advice: "Remove the flag from --extra-config, it is not supported by this version of Kubernetes"},
+	{ID: "container-manager", Component: ComponentKubelet, Severity: SeverityFatal, Match: `Failed to start ContainerManager`, Reason: "HOST_PIDS_CGROUP"},
+	{ID: "runtime-daemon", Component: ComponentRuntime, Severity: SeverityFatal, Match: `failed to start daemon`, Reason: "PR_DOCKER_CGROUP_MOUNT"},
+	{ID: "port-in-use", Severity: SeverityFatal, Match: `failed to create listener|address already in use`, Reason: "GUEST_PORT_IN_USE"},
+	{ID: "apiserver-insecure-port", Component: ComponentAPIServer, Severity: SeverityError, Match: `STDIN.*127.0.0.1:8080`},
+	{ID: "kubelet-no-apiserver", Component: ComponentKubelet, Severity: SeverityError, Match: `kubelet.*no API client|kubelet.*No api server`, Reason: "K8S_KUBELET_NOT_RUNNING"},
+	{ID: "bad-certificate", Severity: SeverityError, Match: `tls: bad certificate`, Reason: "GUEST_UNSIGNED_CERT"},
+	{ID: "rbac-forbidden", Severity: SeverityError,
+		Match: `forbidden.*no providers available|Unable to register node.*forbidden|Failed to initialize CSINodeInfo.*forbidden|kubelet.*forbidden.*cannot \w+ resource|leases.*forbidden.*cannot \w+ resource`},
+	{ID: "container-start", Component: ComponentKubelet, Severity: SeverityError, Match: `failed to "StartContainer"`},
+	{ID: "oom-kill", Component: ComponentDmesg, Severity: SeverityError, Match: `Out of memory: Kill(ed)? process|oom-kill`,
+		Advice: "Pass in a larger --memory value to 'minikube start', or reduce the resource requests of your workloads"},
+	{ID: "no-space", Severity: SeverityError, Match: `no space left on device`, Reason: "GUEST_PROVISION_NOSPACE"},
+	{ID: "eviction", Component: ComponentKubelet, Severity: SeverityWarning, Match: `eviction manager:.*evicted|unable to evict any pods|eviction manager: unexpected error|Failed to admit pod`,
+		Advice: "The node is running out of resources, free up disk space or pass in larger --memory and --disk-size values to 'minikube start'"},
+	{ID: "error", Severity: SeverityWarning, Match: `^error: `, Ignore: `error: no objects passed to apply`},
+	{ID: "anonymous-auth", Component: ComponentAPIServer, Severity: SeverityInfo, Match: `Resetting AnonymousAuth to false`},
+})
+
+// components returns the valid detector components
+func components() []string {
+	cs := []string{ComponentAny, ComponentKubelet, ComponentAPIServer, ComponentRuntime, ComponentDmesg}
+	for _, p := range importantPods {
+		if c := strings.TrimPrefix(p, "kube-"); c != ComponentAPIServer {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// componentOf returns the component of a log source, as named by logCommands
+func componentOf(source string, runtime string) string {
+	if source == runtime {
+		return ComponentRuntime
+	}
+	if i := strings.Index(source, " ["); i > 0 {
+		source = source[:i]
+	}
+	return strings.TrimPrefix(source, "kube-")
+}
+
+// compile validates the detector and compiles its regular expressions
+func (d *Detector) compile() error {
+	if d.ID == "" {
+		return fmt.Errorf("detector has no id")
+	}
+	valid := false
+	for _, c := range components() {
+		if d.Component == c {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("detector %s: invalid component %q, valid components are: %v", d.ID, d.Component, components()[1:])
+	}
+	if _, ok := severityRanks[d.Severity]; !ok {
+		return fmt.Errorf("detector %s: invalid severity %q, valid severities are: [%s %s %s %s]", d.ID, d.Severity, SeverityInfo, SeverityWarning, SeverityError, SeverityFatal)
+	}
+	if d.Reason != "" && reason.Lookup(d.Reason) == nil {
+		return fmt.Errorf("detector %s: unknown reason %q", d.ID, d.Reason)
+	}
+	var err error
+	if d.matchRe, err = regexp.Compile(d.Match); err != nil || d.Match == "" {
+		return fmt.Errorf("detector %s: invalid match %q: %v", d.ID, d.Match, err)
+	}
+	if d.Ignore != "" {
+		if d.ignoreRe, err = regexp.Compile(d.Ignore); err != nil {
+			return fmt.Errorf("detector %s: invalid ignore %q: %v", d.ID, d.Ignore, err)
+		}
+	}
+	return nil
+}
+
+func mustCompile(ds []*Detector) []*Detector {
+	for _, d := range ds {
+		if err := d.compile(); err != nil {
+			panic(err)
+		}
+	}
+	return ds
+}
+
+// Matches returns whether the log line of the component points to the problem
+func (d *Detector) Matches(component string, line string) bool {
+	if d.Component != ComponentAny && d.Component != component {
+		return false
+	}
+	if !d.matchRe.MatchString(line) {
+		return false
+	}
+	return d.ignoreRe == nil || !d.ignoreRe.MatchString(line)
+}
+
+// kind returns the known issue of the detector, merged with its own advice
+func (d *Detector) kind() reason.Kind {
+	k := reason.Kind{ID: d.Reason}
+	if d.Reason != "" {
+		if rk := reason.Lookup(d.Reason); rk != nil {
+			k = *rk
+		}
+	}
+	if d.Advice != "" {
+		k.Advice = d.Advice
+	}
+	return k
+}
+
+// LoadDetectors loads user defined detectors from a YAML file, returning none if the file does not exist
+func LoadDetectors(path string) ([]*Detector, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", path)
+	}
+	var f detectorFile
+	if err := yaml.UnmarshalStrict(b, &f); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+	seen := map[string]bool{}
+	for _, d := range f.Detectors {
+		if d.Severity == "" {
+			d.Severity = SeverityError
+		}
+		if err := d.compile(); err != nil {
+			return nil, err
+		}
+		if seen[d.ID] {
+			return nil, fmt.Errorf("duplicate detector %s", d.ID)
+		}
+		seen[d.ID] = true
+	}
+	return f.Detectors, nil
+}
+
+// Detectors returns the user defined detectors from the minikube home followed by the built-in detectors.
+// A user defined detector replaces the built-in detector with the same ID.
+func Detectors() []*Detector {
+	ds, err := LoadDetectors(localpath.ProblemDetectors())
+	if err != nil {
+		klog.Warningf("ignoring user defined problem detectors: %v", err)
+	}
+	return mergeDetectors(ds, builtinDetectors)
+}
+
+func mergeDetectors(user []*Detector, builtin []*Detector) []*Detector {
+	ids := map[string]bool{}
+	ds := []*Detector{}
+	for _, d := range user {
+		ids[d.ID] = true
+		ds = append(ds, d)
+	}
+	for _, d := range builtin {
+		if !ids[d.ID] {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+// detect returns the first detector matching the log line of the component, or nil
+func detect(ds []*Detector, component string, line string) *Detector {
+	for _, d := range ds {
+		if d.Matches(component, line) {
+			return d
+		}
+	}
+	return nil
+}
+
+// finding is a log line matched by a detector
+type finding struct {
+	source   string
+	line     string
+	detector *Detector
+}
+
+// Problem is a problem found by a detector, de-duplicated across log sources
+type Problem struct {
+	ID        string   `json:"id"`
+	Component string   `json:"component,omitempty"`
+	Severity  Severity `json:"severity"`
+	// Count is how many log lines matched
+	Count int `json:"count"`
+	// Sources are the logs the problem was found in
+	Sources []string `json:"sources"`
+	// Lines are the distinct matching log lines, in the order they were found
+	Lines  []string `json:"lines"`
+	Reason string   `json:"reason,omitempty"`
+	Advice string   `json:"advice,omitempty"`
+	URL    string   `json:"url,omitempty"`
+	Issues []string `json:"issues,omitempty"`
+}
+
+// rank groups findings by detector and orders them by severity, then by number of occurrences
+func rank(fs []finding) []*Problem {
+	byID := map[string]*Problem{}
+	ps := []*Problem{}
+	for _, f := range fs {
+		d := f.detector
+		p, ok := byID[d.ID]
+		if !ok {
+			k := d.kind()
+			p = &Problem{ID: d.ID, Component: d.Component, Severity: d.Severity, Sources: []string{}, Lines: []string{}, Reason: d.Reason, Advice: k.Advice, URL: k.URL, Issues: k.IssueURLs()}
+			byID[d.ID] = p
+			ps = append(ps, p)
+		}
+		p.Count++
+		if !contains(p.Sources, f.source) {
+			p.Sources = append(p.Sources, f.source)
+		}
+		if !contains(p.Lines, f.line) {
+			p.Lines = append(p.Lines, f.line)
+		}
+	}
+	sort.SliceStable(ps, func(i, j int) bool {
+		if ri, rj := severityRanks[ps[i].Severity], severityRanks[ps[j].Severity]; ri != rj {
+			return ri > rj
+		}
+		if ps[i].Count != ps[j].Count {
+			return ps[i].Count > ps[j].Count
+		}
+		return ps[i].ID < ps[j].ID
+	})
+	return ps
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
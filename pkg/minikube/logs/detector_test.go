@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadDetectors(t *testing.T) {
+	tests := []struct {
+		description string
+		content     string
+		ids         []string
+		wantErr     bool
+	}{
+		{"valid", `detectors:
+- id: proxy
+  component: runtime
+  severity: warning
+  match: 'proxyconnect tcp'
+  advice: Check your HTTP_PROXY settings
+- id: port-in-use
+  match: 'bind: address already in use'
+  reason: GUEST_PORT_IN_USE
+`, []string{"proxy", "port-in-use"}, false},
+		{"no id", "detectors:\n- match: foo\n", nil, true},
+		{"bad component", "detectors:\n- id: x\n  component: kubectl\n  match: foo\n", nil, true},
+		{"bad severity", "detectors:\n- id: x\n  severity: urgent\n  match: foo\n", nil, true},
+		{"bad regexp", "detectors:\n- id: x\n  match: '(foo'\n", nil, true},
+		{"empty match", "detectors:\n- id: x\n", nil, true},
+		{"unknown reason", "detectors:\n- id: x\n  match: foo\n  reason: NOT_A_REASON\n", nil, true},
+		{"unknown field", "detectors:\n- id: x\n  match: foo\n  regex: bar\n", nil, true},
+		{"duplicate", "detectors:\n- id: x\n  match: foo\n- id: x\n  match: bar\n", nil, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			ds, err := loadDetectorsFromString(t, tc.content)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("LoadDetectors() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			ids := []string{}
+			for _, d := range ds {
+				ids = append(ids, d.ID)
+			}
+			if tc.ids == nil {
+				tc.ids = []string{}
+			}
+			if diff := cmp.Diff(tc.ids, ids); diff != "" {
+				t.Errorf("unexpected detectors (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	ds, err := LoadDetectors(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil || len(ds) != 0 {
+		t.Errorf("LoadDetectors() of a missing file = %v, %v, want no detectors", ds, err)
+	}
+}
+
+func TestComponentOf(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"kubelet", ComponentKubelet},
+		{"dmesg", ComponentDmesg},
+		{"kube-apiserver [3c8f2d1e]", ComponentAPIServer},
+		{"etcd [a1b2]", "etcd"},
+		{"containerd", ComponentRuntime},
+		{"container status", "container status"},
+	}
+	for _, tc := range tests {
+		if got := componentOf(tc.source, "containerd"); got != tc.want {
+			t.Errorf("componentOf(%q) = %q, want %q", tc.source, got, tc.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	user, err := loadDetectorsFromString(t, "detectors:\n- id: container-start\n  component: kubelet\n  severity: fatal\n  match: 'failed to \"StartContainer\"'\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := mergeDetectors(user, builtinDetectors)
+
+	line := `failed to "StartContainer" for "kube-apiserver" with CrashLoopBackOff`
+	if d := detect(ds, ComponentKubelet, line); d == nil || d.Severity != SeverityFatal {
+		t.Errorf("detect() = %+v, want the user defined detector", d)
+	}
+	if d := detect(ds, ComponentAPIServer, line); d != nil {
+		t.Errorf("detect() matched %s outside of its component", d.ID)
+	}
+	if d := detect(ds, ComponentDmesg, "Out of memory: Killed process 4242 (etcd)"); d == nil || d.ID != "oom-kill" {
+		t.Errorf("detect() = %+v, want oom-kill", d)
+	}
+}
+
+func TestRank(t *testing.T) {
+	byID := map[string]*Detector{}
+	for _, d := range builtinDetectors {
+		byID[d.ID] = d
+	}
+	fs := []finding{
+		{source: "kubelet", line: "eviction manager: pods a evicted", detector: byID["eviction"]},
+		{source: "kubelet", line: "eviction manager: pods a evicted", detector: byID["eviction"]},
+		{source: "kube-apiserver [1]", line: "error: x", detector: byID["error"]},
+		{source: "kubelet", line: "address already in use", detector: byID["port-in-use"]},
+		{source: "etcd [2]", line: "listen tcp: address already in use", detector: byID["port-in-use"]},
+		{source: "kubelet", line: "eviction manager: pods b evicted", detector: byID["eviction"]},
+	}
+	ps := rank(fs)
+	ids := []string{}
+	for _, p := range ps {
+		ids = append(ids, p.ID)
+	}
+	if diff := cmp.Diff([]string{"port-in-use", "eviction", "error"}, ids); diff != "" {
+		t.Fatalf("unexpected ranking (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"kubelet", "etcd [2]"}, ps[0].Sources); diff != "" {
+		t.Errorf("unexpected sources (-want +got):\n%s", diff)
+	}
+	if ps[0].Advice == "" || len(ps[0].Issues) == 0 {
+		t.Errorf("advice of reason %s not linked: %+v", ps[0].Reason, ps[0])
+	}
+	if ps[1].Count != 3 || len(ps[1].Lines) != 2 {
+		t.Errorf("eviction not de-duplicated: count %d, lines %v", ps[1].Count, ps[1].Lines)
+	}
+}
+
+// loadDetectorsFromString writes the detectors to a temporary file and loads them
+func loadDetectorsFromString(t *testing.T, content string) ([]*Detector, error) {
+	path := filepath.Join(t.TempDir(), "detectors.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadDetectors(path)
+}
@@ -24,7 +24,6 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"regexp"
 	"sort"
 	"strings"
 
@@ -40,38 +39,6 @@ import (
 	"k8s.io/minikube/pkg/minikube/style"
 )
 
-// rootCauses are regular expressions that match known failures
-var rootCauses = []string{
-	`^error: `,
-	`eviction manager: pods.* evicted`,
-	`unknown flag: --`,
-	`forbidden.*no providers available`,
-	`eviction manager:.*evicted`,
-	`tls: bad certificate`,
-	`kubelet.*no API client`,
-	`kubelet.*No api server`,
-	`STDIN.*127.0.0.1:8080`,
-	`failed to create listener`,
-	`address already in use`,
-	`unable to evict any pods`,
-	`eviction manager: unexpected error`,
-	`Resetting AnonymousAuth to false`,
-	`Unable to register node.*forbidden`,
-	`Failed to initialize CSINodeInfo.*forbidden`,
-	`Failed to admit pod`,
-	`failed to "StartContainer"`,
-	`Failed to start ContainerManager`,
-	`kubelet.*forbidden.*cannot \w+ resource`,
-	`leases.*forbidden.*cannot \w+ resource`,
-	`failed to start daemon`,
-}
-
-// rootCauseRe combines rootCauses into a single regex
-var rootCauseRe = regexp.MustCompile(strings.Join(rootCauses, "|"))
-
-// ignoreCauseRe is a regular expression that matches spurious errors to not surface
-var ignoreCauseRe = regexp.MustCompile("error: no objects passed to apply")
-
 // importantPods are a list of pods to retrieve logs for, in addition to the bootstrapper logs.
 var importantPods = []string{
 	"kube-apiserver",
@@ -110,16 +77,40 @@ func Follow(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.Cluster
 	return nil
 }
 
-// IsProblem returns whether this line matches a known problem
+// IsProblem returns whether this line matches a known problem of any component
 func IsProblem(line string) bool {
-	return rootCauseRe.MatchString(line) && !ignoreCauseRe.MatchString(line)
+	for _, d := range builtinDetectors {
+		if d.Matches(d.Component, line) {
+			return true
+		}
+	}
+	return false
 }
 
 // FindProblems finds possible root causes among the logs
 func FindProblems(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, cr logRunner) map[string][]string {
 	pMap := map[string][]string{}
+	for _, f := range scanProblems(r, bs, cfg, cr, Detectors()) {
+		pMap[f.source] = append(pMap[f.source], f.line)
+	}
+	return pMap
+}
+
+// DetectProblems finds possible root causes among the logs, ranked by severity and de-duplicated by detector
+func DetectProblems(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, cr logRunner) []*Problem {
+	return rank(scanProblems(r, bs, cfg, cr, Detectors()))
+}
+
+// scanProblems runs the detectors against the recent logs of every component
+func scanProblems(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, cr logRunner, ds []*Detector) []finding {
+	fs := []finding{}
 	cmds := logCommands(r, bs, cfg, lookBackwardsCount, false)
+	names := []string{}
 	for name := range cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
 		klog.Infof("Gathering logs for %s ...", name)
 		var b bytes.Buffer
 		c := exec.Command("/bin/bash", "-c", cmds[name])
@@ -130,20 +121,17 @@ func FindProblems(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.C
 			klog.Warningf("failed %s: command: %s %v output: %s", name, rr.Command(), err, rr.Output())
 			continue
 		}
+		component := componentOf(name, r.Name())
 		scanner := bufio.NewScanner(&b)
-		problems := []string{}
 		for scanner.Scan() {
 			l := scanner.Text()
-			if IsProblem(l) {
-				klog.Warningf("Found %s problem: %s", name, l)
-				problems = append(problems, l)
+			if d := detect(ds, component, l); d != nil {
+				klog.Warningf("Found %s problem %s: %s", name, d.ID, l)
+				fs = append(fs, finding{source: name, line: l, detector: d})
 			}
 		}
-		if len(problems) > 0 {
-			pMap[name] = problems
-		}
 	}
-	return pMap
+	return fs
 }
 
 // OutputProblems outputs discovered problems.
@@ -162,6 +150,46 @@ func OutputProblems(problems map[string][]string, maxLines int, logOutput *os.Fi
 	}
 }
 
+// OutputReport outputs a ranked problem report, most serious problems first.
+func OutputReport(problems []*Problem, maxLines int, logOutput *os.File) {
+	out.SetErrFile(logOutput)
+	defer out.SetErrFile(os.Stderr)
+
+	if len(problems) == 0 {
+		out.ErrT(style.Celebrate, "No problems detected")
+		return
+	}
+	for i, p := range problems {
+		if i > 0 {
+			out.ErrT(style.Empty, "")
+		}
+		st := style.Failure
+		switch p.Severity {
+		case SeverityInfo:
+			st = style.Notice
+		case SeverityWarning:
+			st = style.Warning
+		}
+		out.ErrT(st, "[{{.severity}}] {{.id}}: {{.count}} occurrence{{if gt .count 1}}s{{end}} in {{.sources}}", out.V{"severity": p.Severity, "id": p.ID, "count": p.Count, "sources": strings.Join(p.Sources, ", ")})
+		lines := p.Lines
+		if len(lines) > maxLines {
+			lines = lines[len(lines)-maxLines:]
+		}
+		for _, l := range lines {
+			out.ErrT(style.LogEntry, l)
+		}
+		if p.Advice != "" {
+			out.ErrT(style.Tip, "Suggestion: {{.advice}}", out.V{"advice": p.Advice})
+		}
+		if p.URL != "" {
+			out.ErrT(style.Documentation, "Documentation: {{.url}}", out.V{"url": p.URL})
+		}
+		for _, u := range p.Issues {
+			out.ErrT(style.Issues, "Related issue: {{.url}}", out.V{"url": u})
+		}
+	}
+}
+
 // Output displays logs from multiple sources in tail(1) format
 func Output(r cruntime.Manager, bs bootstrapper.Bootstrapper, cfg config.ClusterConfig, runner command.Runner, lines int, logOutput *os.File) error {
 	cmds := logCommands(r, bs, cfg, lines, false)
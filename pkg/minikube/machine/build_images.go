@@ -17,6 +17,7 @@ limitations under the License.
 package machine
 
 import (
+	"io"
 	"net/url"
 	"os"
 	"os/exec"
@@ -25,6 +26,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/machine/libmachine/state"
 	"github.com/pkg/errors"
 	"k8s.io/klog/v2"
@@ -39,8 +41,9 @@ import (
 // buildRoot is where images should be built from within the guest VM
 var buildRoot = path.Join(vmpath.GuestPersistentDir, "build")
 
-// BuildImage builds image to all profiles
-func BuildImage(path string, file string, tag string, push bool, env []string, opt []string, profiles []*config.Profile, allNodes bool, nodeName string) error {
+// BuildImage builds image to all profiles. The secrets and the cache directories of opts are paths of the host,
+// transferred to the nodes for the build. With buildOnce, the image is built on a single node and loaded into the others.
+func BuildImage(path string, opts cruntime.BuildOptions, profiles []*config.Profile, allNodes bool, nodeName string, buildOnce bool) error {
 	api, err := NewAPIClient()
 	if err != nil {
 		return errors.Wrap(err, "api")
@@ -75,14 +78,16 @@ func BuildImage(path string, file string, tag string, push bool, env []string, o
 			return err
 		}
 
-		for _, n := range c.Nodes {
+		// the image built once, to load into the other nodes
+		built := ""
+		for _, n := range buildOrder(c.Nodes, cp, nodeName) {
 			m := config.MachineName(*c, n)
 
 			if !allNodes {
 				// build images on the primary control plane node by default
 				if nodeName == "" && n != cp {
 					continue
-				} else if nodeName != n.Name && nodeName != m {
+				} else if nodeName != "" && nodeName != n.Name && nodeName != m {
 					continue
 				}
 			}
@@ -105,16 +110,30 @@ func BuildImage(path string, file string, tag string, push bool, env []string, o
 				if err != nil {
 					return err
 				}
-				if remote {
-					err = buildImage(cr, c.KubernetesConfig, path, file, tag, push, env, opt)
-				} else {
-					err = transferAndBuildImage(cr, c.KubernetesConfig, path, file, tag, push, env, opt)
+				switch {
+				case built != "":
+					err = transferAndLoadImage(cr, c.KubernetesConfig, built, opts.Tag)
+				case remote:
+					err = buildImage(cr, c.KubernetesConfig, path, opts)
+				default:
+					err = transferAndBuildImage(cr, c.KubernetesConfig, path, opts)
+				}
+				if err != nil && buildOnce && built == "" {
+					// building on the other nodes would fail the same way, or defeat building once
+					return errors.Wrapf(err, "building %s on %s", opts.Tag, m)
 				}
 				if err != nil {
 					failed = append(failed, m)
 					klog.Warningf("Failed to build image for profile %s. make sure the profile is running. %v", pName, err)
 					continue
 				}
+				if buildOnce && built == "" {
+					built, err = saveBuiltImage(cr, c.KubernetesConfig, opts.Tag)
+					if err != nil {
+						return errors.Wrapf(err, "saving %s", opts.Tag)
+					}
+					defer os.Remove(built)
+				}
 				succeeded = append(succeeded, m)
 			}
 		}
@@ -125,25 +144,60 @@ func BuildImage(path string, file string, tag string, push bool, env []string, o
 	return nil
 }
 
+// buildOrder returns the nodes with the one to build on first: the given node, or else the primary control plane
+func buildOrder(nodes []config.Node, cp config.Node, nodeName string) []config.Node {
+	first := func(n config.Node) bool {
+		if nodeName != "" {
+			return n.Name == nodeName
+		}
+		return n == cp
+	}
+	ordered := []config.Node{}
+	for _, n := range nodes {
+		if first(n) {
+			ordered = append([]config.Node{n}, ordered...)
+		} else {
+			ordered = append(ordered, n)
+		}
+	}
+	return ordered
+}
+
+// saveBuiltImage saves an image built on a node to a temporary file of the host
+func saveBuiltImage(cr command.Runner, k8s config.KubernetesConfig, tag string) (string, error) {
+	tmp, err := os.CreateTemp("", "build.*.tar")
+	if err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := transferAndSaveImage(cr, k8s, tmp.Name(), tag); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
 // buildImage builds a single image
-func buildImage(cr command.Runner, k8s config.KubernetesConfig, src string, file string, tag string, push bool, env []string, opt []string) error {
+func buildImage(cr command.Runner, k8s config.KubernetesConfig, src string, opts cruntime.BuildOptions) error {
 	r, err := cruntime.New(cruntime.Config{Type: k8s.ContainerRuntime, Runner: cr})
 	if err != nil {
 		return errors.Wrap(err, "runtime")
 	}
 	klog.Infof("Building image from url: %s", src)
 
-	err = r.BuildImage(src, file, tag, push, env, opt)
+	err = runBuild(cr, r, src, opts)
 	if err != nil {
 		return errors.Wrapf(err, "%s build %s", r.Name(), src)
 	}
 
-	klog.Infof("Built %s from %s", tag, src)
+	klog.Infof("Built %s from %s", opts.Tag, src)
 	return nil
 }
 
 // transferAndBuildImage transfers and builds a single image
-func transferAndBuildImage(cr command.Runner, k8s config.KubernetesConfig, src string, file string, tag string, push bool, env []string, opt []string) error {
+func transferAndBuildImage(cr command.Runner, k8s config.KubernetesConfig, src string, opts cruntime.BuildOptions) error {
 	r, err := cruntime.New(cruntime.Config{Type: k8s.ContainerRuntime, Runner: cr})
 	if err != nil {
 		return errors.Wrap(err, "runtime")
@@ -187,10 +241,10 @@ func transferAndBuildImage(cr command.Runner, k8s config.KubernetesConfig, src s
 		return err
 	}
 
-	if file != "" && !path.IsAbs(file) {
-		file = path.Join(context, file)
+	if opts.Dockerfile != "" && !path.IsAbs(opts.Dockerfile) {
+		opts.Dockerfile = path.Join(context, opts.Dockerfile)
 	}
-	err = r.BuildImage(context, file, tag, push, env, opt)
+	err = runBuild(cr, r, context, opts)
 	if err != nil {
 		return errors.Wrapf(err, "%s build %s", r.Name(), dst)
 	}
@@ -204,6 +258,185 @@ func transferAndBuildImage(cr command.Runner, k8s config.KubernetesConfig, src s
 		return err
 	}
 
-	klog.Infof("Built %s from %s", tag, src)
+	klog.Infof("Built %s from %s", opts.Tag, src)
+	return nil
+}
+
+// runBuild transfers the secrets and the build cache of the host to the node,
+// builds the image and brings the exported build cache back to the host
+func runBuild(cr command.Runner, r cruntime.Manager, src string, opts cruntime.BuildOptions) error {
+	nodeOpts := opts
+	if len(opts.Secrets) > 0 {
+		rr, err := cr.RunCmd(exec.Command("sudo", "mktemp", "-d"))
+		if err != nil {
+			return err
+		}
+		dir := strings.TrimSpace(rr.Stdout.String())
+		defer func() {
+			if _, err := cr.RunCmd(exec.Command("sudo", "rm", "-rf", dir)); err != nil {
+				klog.Warningf("Failed to remove the build secrets: %v", err)
+			}
+		}()
+		nodeOpts.Secrets = nil
+		for _, s := range opts.Secrets {
+			if err := copyFile(cr, s.Src, dir, s.ID, "0600"); err != nil {
+				return errors.Wrapf(err, "transferring secret %s", s.ID)
+			}
+			nodeOpts.Secrets = append(nodeOpts.Secrets, cruntime.BuildSecret{ID: s.ID, Src: path.Join(dir, s.ID)})
+		}
+	}
+
+	if opts.CacheFrom != "" || opts.CacheTo != "" {
+		if _, err := cr.RunCmd(exec.Command("sudo", "mkdir", "-p", buildRoot)); err != nil {
+			return err
+		}
+	}
+	if opts.CacheFrom != "" {
+		nodeOpts.CacheFrom = ""
+		// there is no cache to import before the first export
+		if _, err := os.Stat(filepath.Join(opts.CacheFrom, "index.json")); err == nil {
+			nodeOpts.CacheFrom = path.Join(buildRoot, "cache-from")
+			if err := transferDir(cr, opts.CacheFrom, nodeOpts.CacheFrom); err != nil {
+				return errors.Wrap(err, "transferring build cache")
+			}
+			defer removeNodeDir(cr, nodeOpts.CacheFrom)
+		} else {
+			klog.Infof("No build cache to import in %s", opts.CacheFrom)
+		}
+	}
+	if opts.CacheTo != "" {
+		nodeOpts.CacheTo = path.Join(buildRoot, "cache-to")
+		removeNodeDir(cr, nodeOpts.CacheTo)
+		defer removeNodeDir(cr, nodeOpts.CacheTo)
+	}
+
+	if err := r.BuildImage(src, nodeOpts); err != nil {
+		return err
+	}
+
+	if opts.CacheTo != "" {
+		if err := fetchDir(cr, nodeOpts.CacheTo, opts.CacheTo); err != nil {
+			return errors.Wrap(err, "transferring build cache")
+		}
+	}
 	return nil
 }
+
+// copyFile copies a file of the host to the node
+func copyFile(cr command.Runner, src string, dir string, name string, perms string) error {
+	f, err := assets.NewFileAsset(src, dir, name, perms)
+	if err != nil {
+		return errors.Wrapf(err, "creating copyable file asset: %s", name)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			klog.Warningf("error closing the file %s: %v", f.GetSourcePath(), err)
+		}
+	}()
+	return cr.Copy(f)
+}
+
+// transferDir copies a directory of the host to the node
+func transferDir(cr command.Runner, src string, dst string) error {
+	tar, err := archive.Tar(src, archive.Uncompressed)
+	if err != nil {
+		return err
+	}
+	defer tar.Close()
+	tmp, err := os.CreateTemp("", "build-cache.*.tar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, tar); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	name := path.Base(dst) + ".tar"
+	if err := copyFile(cr, tmp.Name(), path.Dir(dst), name, "0644"); err != nil {
+		return err
+	}
+	archivePath := path.Join(path.Dir(dst), name)
+	defer func() {
+		if _, err := cr.RunCmd(exec.Command("sudo", "rm", "-f", archivePath)); err != nil {
+			klog.Warningf("Failed to remove %s: %v", archivePath, err)
+		}
+	}()
+	if _, err := cr.RunCmd(exec.Command("sudo", "mkdir", "-p", dst)); err != nil {
+		return err
+	}
+	_, err = cr.RunCmd(exec.Command("sudo", "tar", "-C", dst, "-xf", archivePath))
+	return err
+}
+
+// fetchDir copies a directory of the node to the host, replacing the previous contents of dst
+func fetchDir(cr command.Runner, src string, dst string) error {
+	name := path.Base(src) + ".tar"
+	archivePath := path.Join(path.Dir(src), name)
+	if _, err := cr.RunCmd(exec.Command("sudo", "tar", "-C", src, "-cf", archivePath, ".")); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := cr.RunCmd(exec.Command("sudo", "rm", "-f", archivePath)); err != nil {
+			klog.Warningf("Failed to remove %s: %v", archivePath, err)
+		}
+	}()
+
+	tmp, err := os.CreateTemp("", "build-cache.*.tar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	f, err := assets.NewFileAsset(tmp.Name(), path.Dir(src), name, "0644")
+	if err != nil {
+		return errors.Wrapf(err, "creating copyable file asset: %s", name)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			klog.Warningf("error closing the file %s: %v", f.GetSourcePath(), err)
+		}
+	}()
+	if err := cr.CopyFrom(f); err != nil {
+		return err
+	}
+
+	return extractDir(tmp.Name(), dst)
+}
+
+// extractDir extracts a tarball of the host into dst, replacing its previous contents
+func extractDir(tarball string, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dst), filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	f, err := os.Open(tarball)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := archive.Untar(f, tmp, &archive.TarOptions{NoLchown: true}); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// removeNodeDir removes a directory of the node, if any
+func removeNodeDir(cr command.Runner, dir string) {
+	if _, err := cr.RunCmd(exec.Command("sudo", "rm", "-rf", dir)); err != nil {
+		klog.Warningf("Failed to remove %s: %v", dir, err)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/minikube/pkg/minikube/config"
+)
+
+func TestBuildOrder(t *testing.T) {
+	cp := config.Node{Name: "", ControlPlane: true, Worker: true}
+	m02 := config.Node{Name: "m02", Worker: true}
+	m03 := config.Node{Name: "m03", Worker: true}
+	nodes := []config.Node{cp, m02, m03}
+
+	if diff := cmp.Diff([]config.Node{cp, m02, m03}, buildOrder(nodes, cp, "")); diff != "" {
+		t.Errorf("buildOrder() returned diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]config.Node{m03, cp, m02}, buildOrder(nodes, cp, "m03")); diff != "" {
+		t.Errorf("buildOrder() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestExtractDir(t *testing.T) {
+	tmp := t.TempDir()
+	tarball := filepath.Join(tmp, "cache.tar")
+	f, err := os.Create(tarball)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(f)
+	files := map[string]string{"index.json": "{}", "blobs/sha256/abc": "layer"}
+	for _, name := range []string{"index.json", "blobs/sha256/abc"} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name]))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the previous contents of the cache are replaced
+	dst := filepath.Join(tmp, "cache")
+	if err := os.MkdirAll(filepath.Join(dst, "blobs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "stale"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := extractDir(tarball, dst); err != nil {
+		t.Fatalf("extractDir() error = %v", err)
+	}
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "stale")); !os.IsNotExist(err) {
+		t.Errorf("stale file was not removed: %v", err)
+	}
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("extractDir() left temporary files: %v", entries)
+	}
+}
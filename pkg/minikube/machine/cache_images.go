@@ -47,6 +47,7 @@ import (
 	"k8s.io/minikube/pkg/minikube/localpath"
 	"k8s.io/minikube/pkg/minikube/out"
 	"k8s.io/minikube/pkg/minikube/vmpath"
+	"k8s.io/minikube/pkg/trace"
 )
 
 // loadRoot is where images should be loaded from within the guest VM
@@ -273,12 +274,22 @@ func transferAndLoadCachedImage(cr command.Runner, k8s config.KubernetesConfig,
 }
 
 // transferAndLoadImage transfers and loads a single image
-func transferAndLoadImage(cr command.Runner, k8s config.KubernetesConfig, src string, imgName string) error {
+func transferAndLoadImage(cr command.Runner, k8s config.KubernetesConfig, src string, imgName string) (err error) {
+	span := trace.StartChildSpan("LoadImage", map[string]string{"image": imgName, "source": src})
+	defer func() { span.End(err) }()
+
 	r, err := cruntime.New(cruntime.Config{Type: k8s.ContainerRuntime, Runner: cr})
 	if err != nil {
 		return errors.Wrap(err, "runtime")
 	}
 
+	// loading the image over the previous one keeps the layers they share on the node
+	err = transferAndLoadLayers(cr, r, src)
+	if err == nil {
+		return nil
+	}
+	klog.Infof("Loading the whole image %s: %v", src, err)
+
 	if err := removeExistingImage(r, src, imgName); err != nil {
 		return err
 	}
@@ -22,11 +22,16 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"k8s.io/klog/v2"
 	"k8s.io/minikube/pkg/minikube/assets"
 	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/filesync"
 	"k8s.io/minikube/pkg/minikube/localpath"
 	"k8s.io/minikube/pkg/minikube/vmpath"
 )
@@ -40,9 +45,42 @@ var guaranteed = map[string]bool{
 	"/tmp": true,
 }
 
-// syncLocalAssets syncs files from MINIKUBE_HOME into the cluster
-func syncLocalAssets(cr command.Runner) error {
-	fs, err := localAssets()
+// SyncDir is a host directory whose files are copied to a directory of the nodes
+type SyncDir struct {
+	HostDir string
+	NodeDir string
+}
+
+// ParseSyncDir parses a sync directory in the <host dir>:<node dir> format
+func ParseSyncDir(s string) (SyncDir, error) {
+	// the host directory may contain a colon on Windows, unlike the node one
+	i := strings.LastIndex(s, ":")
+	if i <= 0 {
+		return SyncDir{}, errors.Errorf("invalid sync directory %q: expected <host dir>:<node dir>", s)
+	}
+	d := SyncDir{HostDir: s[:i], NodeDir: s[i+1:]}
+	if !path.IsAbs(d.NodeDir) {
+		return SyncDir{}, errors.Errorf("invalid sync directory %q: the node directory must be an absolute path", s)
+	}
+	return d, nil
+}
+
+// SyncDirs returns the directories synced to the nodes of cc: the files directory of MINIKUBE_HOME, then the extra ones of cc
+func SyncDirs(cc config.ClusterConfig) ([]SyncDir, error) {
+	dirs := []SyncDir{{HostDir: localpath.MakeMiniPath("files"), NodeDir: "/"}}
+	for _, s := range cc.SyncDirs {
+		d, err := ParseSyncDir(s)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+// syncLocalAssets syncs files from MINIKUBE_HOME and the extra sync directories into the machine name of the cluster
+func syncLocalAssets(cr command.Runner, cc config.ClusterConfig, name string) error {
+	fs, err := localAssets(cc)
 	defer func() {
 		for _, f := range fs {
 			if err := f.Close(); err != nil {
@@ -85,11 +123,57 @@ func syncLocalAssets(cr command.Runner) error {
 			return err
 		}
 	}
+	if err := recordSyncedAssets(cc, name, fs); err != nil {
+		klog.Warningf("unable to record the synced files of %s: %v", name, err)
+	}
 	return nil
 }
 
-// localAssets returns local files and addons from the minikube home directory
-func localAssets() ([]assets.CopyableFile, error) {
+// manifestMu serializes the updates of the files sync manifest by the nodes starting in parallel
+var manifestMu sync.Mutex
+
+// recordSyncedAssets adds the files copied from the sync directories to the files sync manifest of the profile,
+// so that `minikube files sync` deletes them from the machine once they are removed from the host
+func recordSyncedAssets(cc config.ClusterConfig, name string, fs []assets.CopyableFile) error {
+	dirs, err := SyncDirs(cc)
+	if err != nil {
+		return err
+	}
+
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+	m, err := filesync.LoadManifest(cc.Name)
+	if err != nil {
+		return err
+	}
+	for _, d := range dirs {
+		key := filesync.ManifestKey(name, filesync.Config{HostDir: d.HostDir, NodeDir: d.NodeDir})
+		known := map[string]bool{}
+		for _, p := range m[key] {
+			known[p] = true
+		}
+		for _, f := range fs {
+			rel, err := filepath.Rel(d.HostDir, f.GetSourcePath())
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			known[filepath.ToSlash(rel)] = true
+		}
+		if len(known) == 0 {
+			continue
+		}
+		paths := []string{}
+		for p := range known {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		m[key] = paths
+	}
+	return filesync.SaveManifest(cc.Name, m)
+}
+
+// localAssets returns local files and addons from the minikube home directory, and the files of the extra sync directories
+func localAssets(cc config.ClusterConfig) ([]assets.CopyableFile, error) {
 	fs, err := assetsFromDir(localpath.MakeMiniPath("addons"), vmpath.GuestAddonsDir, true)
 	if err != nil {
 		return fs, errors.Wrap(err, "addons dir")
@@ -101,6 +185,22 @@ func localAssets() ([]assets.CopyableFile, error) {
 	}
 
 	fs = append(fs, localFiles...)
+
+	dirs, err := SyncDirs(cc)
+	if err != nil {
+		return fs, err
+	}
+	for _, d := range dirs[1:] {
+		if _, err := os.Stat(d.HostDir); err != nil {
+			klog.Warningf("skipping sync directory %s: %v", d.HostDir, err)
+			continue
+		}
+		dirFiles, err := assetsFromDir(d.HostDir, d.NodeDir, false)
+		if err != nil {
+			return fs, errors.Wrapf(err, "sync dir %s", d.HostDir)
+		}
+		fs = append(fs, dirFiles...)
+	}
 	return fs, nil
 }
 
@@ -22,6 +22,8 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/filesync"
 	"k8s.io/minikube/pkg/minikube/localpath"
 	testutil "k8s.io/minikube/pkg/minikube/tests"
 	"k8s.io/minikube/pkg/minikube/vmpath"
@@ -174,3 +176,68 @@ func TestSyncDest(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSyncDir(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    SyncDir
+		wantErr bool
+	}{
+		{in: "/home/user/conf:/etc/conf", want: SyncDir{HostDir: "/home/user/conf", NodeDir: "/etc/conf"}},
+		{in: `C:\Users\user\conf:/etc/conf`, want: SyncDir{HostDir: `C:\Users\user\conf`, NodeDir: "/etc/conf"}},
+		{in: "/home/user/conf", wantErr: true},
+		{in: ":/etc/conf", wantErr: true},
+		{in: "/home/user/conf:etc/conf", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := ParseSyncDir(test.in)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseSyncDir(%q) error = %v, want error: %v", test.in, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseSyncDir(%q) = %+v, want %+v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestRecordSyncedAssets(t *testing.T) {
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+	extra := t.TempDir()
+	if err := os.MkdirAll(localpath.MakeMiniPath("addons"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{localpath.MakeMiniPath("files", "etc", "test", "a.conf"), filepath.Join(extra, "b.conf")} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cc := config.ClusterConfig{Name: "sync", SyncDirs: []string{extra + ":/etc/extra"}}
+	key := filesync.ManifestKey("sync", filesync.Config{HostDir: extra, NodeDir: "/etc/extra"})
+	// files of a previous start which were removed from the host since
+	if err := filesync.SaveManifest("sync", filesync.Manifest{key: {"removed.conf"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := localAssets(cc)
+	if err != nil {
+		t.Fatalf("localAssets: %v", err)
+	}
+	if err := recordSyncedAssets(cc, "sync", fs); err != nil {
+		t.Fatalf("recordSyncedAssets: %v", err)
+	}
+	got, err := filesync.LoadManifest("sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filesync.Manifest{
+		filesync.ManifestKey("sync", filesync.Config{HostDir: localpath.MakeMiniPath("files"), NodeDir: "/"}): {"etc/test/a.conf"},
+		key: {"b.conf", "removed.conf"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected manifest (-want +got):\n%s", diff)
+	}
+}
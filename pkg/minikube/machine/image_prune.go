@@ -0,0 +1,248 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/machine/libmachine/state"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/images"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/out"
+)
+
+// NodeDiskUsage is the disk space used by the images of a node
+type NodeDiskUsage struct {
+	Node string `json:"node" yaml:"node"`
+	cruntime.DiskUsage
+}
+
+// PruneOptions selects the images to prune, the images used by containers never being pruned
+type PruneOptions struct {
+	// Unused selects all the unused images, instead of only the dangling ones
+	Unused bool
+	// OlderThan only selects the images created longer ago, if set
+	OlderThan time.Duration
+	// Regex only selects the images with a tag or an ID matching it, if set
+	Regex *regexp.Regexp
+	// DryRun only returns the selected images, without removing them
+	DryRun bool
+}
+
+// PruneResult is the images pruned from a node
+type PruneResult struct {
+	Node   string
+	Images []cruntime.ImageUsage
+	// Reclaimed is the disk space freed, or the one which would at least be freed on a dry run
+	Reclaimed int64
+}
+
+// nodeRuntime is the container runtime of a running node
+type nodeRuntime struct {
+	node string
+	cr   cruntime.Manager
+}
+
+// runningRuntimes returns the container runtimes of the running nodes of profile
+func runningRuntimes(profile *config.Profile) ([]nodeRuntime, *config.ClusterConfig, func(), error) {
+	api, err := NewAPIClient()
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "error creating api client")
+	}
+
+	c, err := config.Load(profile.Name)
+	if err != nil {
+		api.Close()
+		klog.Errorf("Failed to load profile %q: %v", profile.Name, err)
+		return nil, nil, nil, errors.Wrapf(err, "error loading config for profile :%v", profile.Name)
+	}
+
+	var runtimes []nodeRuntime
+	for _, n := range c.Nodes {
+		m := config.MachineName(*c, n)
+
+		status, err := Status(api, m)
+		if err != nil {
+			klog.Warningf("error getting status for %s: %v", m, err)
+			continue
+		}
+		if status != state.Running.String() {
+			continue
+		}
+		h, err := api.Load(m)
+		if err != nil {
+			klog.Warningf("Failed to load machine %q: %v", m, err)
+			continue
+		}
+		runner, err := CommandRunner(h)
+		if err != nil {
+			api.Close()
+			return nil, nil, nil, err
+		}
+		cr, err := cruntime.New(cruntime.Config{Type: c.KubernetesConfig.ContainerRuntime, Runner: runner})
+		if err != nil {
+			api.Close()
+			return nil, nil, nil, errors.Wrap(err, "error creating container runtime")
+		}
+		runtimes = append(runtimes, nodeRuntime{node: m, cr: cr})
+	}
+	return runtimes, c, func() { api.Close() }, nil
+}
+
+// ImagesDiskUsage returns the disk space used by the images of all running nodes in profile
+func ImagesDiskUsage(profile *config.Profile) ([]NodeDiskUsage, error) {
+	runtimes, _, done, err := runningRuntimes(profile)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	var usage []NodeDiskUsage
+	for _, nr := range runtimes {
+		du, err := nr.cr.ImagesDiskUsage()
+		if err != nil {
+			return usage, errors.Wrapf(err, "disk usage of %s", nr.node)
+		}
+		usage = append(usage, NodeDiskUsage{Node: nr.node, DiskUsage: *du})
+	}
+	return usage, nil
+}
+
+// PruneImages removes the images selected by opts from all running nodes in profile. The images
+// required by the Kubernetes version of the cluster are kept, some of them only being used by pods
+// and not by containers.
+func PruneImages(profile *config.Profile, opts PruneOptions) ([]PruneResult, error) {
+	runtimes, c, done, err := runningRuntimes(profile)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	protected := map[string]bool{}
+	if imgs, err := images.Kubeadm(c.KubernetesConfig.ImageRepository, c.KubernetesConfig.KubernetesVersion); err == nil {
+		for _, img := range imgs {
+			protected[normalizeTag(img)] = true
+		}
+	} else {
+		klog.Warningf("unable to list the kubernetes images: %v", err)
+	}
+
+	var results []PruneResult
+	var firstErr error
+	for _, nr := range runtimes {
+		before, err := nr.cr.ImagesDiskUsage()
+		if err != nil {
+			return results, errors.Wrapf(err, "disk usage of %s", nr.node)
+		}
+		candidates := pruneCandidates(before.Images, opts, protected, time.Now())
+		res := PruneResult{Node: nr.node, Images: candidates}
+		if opts.DryRun || len(candidates) == 0 {
+			for _, img := range candidates {
+				res.Reclaimed += img.UniqueSize()
+			}
+			results = append(results, res)
+			continue
+		}
+
+		var ids []string
+		for _, img := range candidates {
+			ids = append(ids, img.ID)
+		}
+		if err := nr.cr.PruneImages(ids); err != nil {
+			klog.Warningf("Failed to prune images for profile %s %v", profile.Name, err.Error())
+			out.WarningT("Failed to prune images for profile {{.pName}} {{.error}}", out.V{"pName": profile.Name, "error": err.Error()})
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "pruning images of %s", nr.node)
+			}
+		}
+
+		// some images may fail to be removed, so the result is what is gone
+		after, err := nr.cr.ImagesDiskUsage()
+		if err != nil {
+			return results, errors.Wrapf(err, "disk usage of %s", nr.node)
+		}
+		remaining := map[string]bool{}
+		for _, img := range after.Images {
+			remaining[img.ID] = true
+		}
+		res.Images = nil
+		for _, img := range candidates {
+			if !remaining[img.ID] {
+				res.Images = append(res.Images, img)
+			}
+		}
+		res.Reclaimed = before.LayersSize - after.LayersSize
+		results = append(results, res)
+	}
+	return results, firstErr
+}
+
+// pruneCandidates returns the images selected by opts, among the ones which no container uses and
+// which are not protected
+func pruneCandidates(imgs []cruntime.ImageUsage, opts PruneOptions, protected map[string]bool, now time.Time) []cruntime.ImageUsage {
+	var candidates []cruntime.ImageUsage
+	for _, img := range imgs {
+		if img.Containers > 0 || (!opts.Unused && len(img.RepoTags) > 0) {
+			continue
+		}
+		if opts.OlderThan > 0 && (img.Created.IsZero() || now.Sub(img.Created) < opts.OlderThan) {
+			continue
+		}
+		if opts.Regex != nil && !matchesImage(opts.Regex, img) {
+			continue
+		}
+		if isProtected(protected, img) {
+			continue
+		}
+		candidates = append(candidates, img)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].UniqueSize() > candidates[j].UniqueSize() })
+	return candidates
+}
+
+func matchesImage(re *regexp.Regexp, img cruntime.ImageUsage) bool {
+	if re.MatchString(img.ID) {
+		return true
+	}
+	for _, t := range img.RepoTags {
+		if re.MatchString(t) || re.MatchString(normalizeTag(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isProtected(protected map[string]bool, img cruntime.ImageUsage) bool {
+	for _, t := range img.RepoTags {
+		if protected[normalizeTag(t)] {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTag strips the implicit registry and repository of docker hub from an image tag
+func normalizeTag(tag string) string {
+	tag = strings.TrimPrefix(tag, "docker.io/")
+	return strings.TrimPrefix(tag, "library/")
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	imgs := []cruntime.ImageUsage{
+		{ID: "dangling", Created: now.Add(-48 * time.Hour), Size: 100},
+		{ID: "dangling-used", Created: now.Add(-48 * time.Hour), Containers: 1},
+		{ID: "app-old", RepoTags: []string{"docker.io/library/app:v1"}, Created: now.Add(-30 * 24 * time.Hour), Size: 300, SharedSize: 100},
+		{ID: "app-new", RepoTags: []string{"docker.io/library/app:v2"}, Created: now.Add(-time.Hour), Size: 300, SharedSize: 100},
+		{ID: "pause", RepoTags: []string{"registry.k8s.io/pause:3.7"}, Created: now.Add(-90 * 24 * time.Hour)},
+		{ID: "used", RepoTags: []string{"docker.io/library/nginx:latest"}, Containers: 2},
+	}
+	protected := map[string]bool{"registry.k8s.io/pause:3.7": true}
+	ids := func(imgs []cruntime.ImageUsage) []string {
+		var ids []string
+		for _, img := range imgs {
+			ids = append(ids, img.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		description string
+		opts        PruneOptions
+		want        []string
+	}{
+		{"dangling", PruneOptions{}, []string{"dangling"}},
+		{"unused", PruneOptions{Unused: true}, []string{"app-old", "app-new", "dangling"}},
+		{"older than", PruneOptions{Unused: true, OlderThan: 24 * time.Hour}, []string{"app-old", "dangling"}},
+		{"regex", PruneOptions{Unused: true, Regex: regexp.MustCompile(`^app:`)}, []string{"app-old", "app-new"}},
+		{"regex on dangling", PruneOptions{Regex: regexp.MustCompile(`^app`)}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			got := ids(pruneCandidates(imgs, test.opts, protected, now))
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("pruneCandidates() returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
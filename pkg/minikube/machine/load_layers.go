@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+
+	units "github.com/docker/go-units"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/image"
+)
+
+// transferAndLoadLayers loads an image archive of the host into the runtime, transferring only the
+// layers the node doesn't have yet. It fails when the runtime can't load an image without all its
+// layers, or when the node has none of them.
+func transferAndLoadLayers(cr command.Runner, r cruntime.Manager, src string) error {
+	known, err := r.KnownLayers()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "layers.*.tar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	var skipped int64
+	if known.ChainIDs != nil {
+		skipped, err = image.WriteDockerArchiveDiff(src, tmp, known.ChainIDs)
+	} else {
+		skipped, err = image.WriteOCIArchiveDiff(src, tmp, known.Blobs)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "writing the missing layers")
+	}
+	if skipped == 0 {
+		return errors.New("the node has none of the layers")
+	}
+
+	filename := filepath.Base(tmp.Name())
+	if err := copyFile(cr, tmp.Name(), loadRoot, filename, "0644"); err != nil {
+		return errors.Wrap(err, "transferring the missing layers")
+	}
+	dst := path.Join(loadRoot, filename)
+	defer func() {
+		if _, err := cr.RunCmd(exec.Command("sudo", "rm", "-f", dst)); err != nil {
+			klog.Warningf("Failed to remove %s: %v", dst, err)
+		}
+	}()
+
+	loadImageLock.Lock()
+	defer loadImageLock.Unlock()
+
+	if err := r.LoadImage(dst); err != nil {
+		return errors.Wrapf(err, "%s load %s", r.Name(), dst)
+	}
+	klog.Infof("Loaded %s without the %s of layers already on the node", src, units.HumanSize(float64(skipped)))
+	return nil
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/docker/machine/libmachine/host"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/drivers/kic/oci"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/driver"
+)
+
+// Resources are the CPU count, memory size (MB) and disk size (MB) of a machine.
+// A zero value leaves the allocation unchanged.
+type Resources struct {
+	CPUs     int
+	Memory   int
+	DiskSize int
+}
+
+// Resources reported by Resize
+const (
+	ResourceCPUs   = "cpus"
+	ResourceMemory = "memory"
+	ResourceDisk   = "disk"
+)
+
+// ResizeMode describes how a driver applies a change of resources
+type ResizeMode string
+
+const (
+	// ResizeLive changes are applied immediately, even to a running machine
+	ResizeLive ResizeMode = "live"
+	// ResizeRestart changes take effect the next time the machine starts
+	ResizeRestart ResizeMode = "restart"
+	// ResizeUnsupported changes cannot be applied by the driver
+	ResizeUnsupported ResizeMode = "unsupported"
+)
+
+// ResizeResult reports the change of one resource on one machine
+type ResizeResult struct {
+	Machine  string     `json:"machine"`
+	Resource string     `json:"resource"`
+	From     int        `json:"from"`
+	To       int        `json:"to"`
+	Mode     ResizeMode `json:"mode"`
+}
+
+// ResizeModeFor returns how the driver applies a change to the given resource.
+func ResizeModeFor(drvName string, resource string) ResizeMode {
+	switch {
+	case driver.IsKIC(drvName):
+		// containers use the storage of the host, there is no disk to grow
+		if resource == ResourceDisk {
+			return ResizeUnsupported
+		}
+		return ResizeLive
+	case driver.IsKVM(drvName), driver.IsQEMU(drvName):
+		return ResizeRestart
+	case drvName == driver.HyperKit:
+		if resource == ResourceDisk {
+			return ResizeUnsupported
+		}
+		return ResizeRestart
+	default:
+		return ResizeUnsupported
+	}
+}
+
+// Resize applies new resource allocations to every machine of the cluster and reports
+// how each change was applied. Allocations matching the cluster config and those the
+// driver does not support are skipped. If a machine fails to resize, the machines already
+// resized get their previous allocations back, so that they keep matching the cluster config.
+// Saving the cluster config is left to the caller.
+func Resize(api libmachine.API, cc config.ClusterConfig, r Resources) ([]ResizeResult, error) {
+	changes := []ResizeResult{}
+	prev := Resources{}
+	add := func(resource string, from int, to *int, old *int) {
+		if *to == 0 || *to == from || ResizeModeFor(cc.Driver, resource) == ResizeUnsupported {
+			*to = 0
+			return
+		}
+		*old = from
+		changes = append(changes, ResizeResult{Resource: resource, From: from, To: *to, Mode: ResizeModeFor(cc.Driver, resource)})
+	}
+	add(ResourceCPUs, cc.CPUs, &r.CPUs, &prev.CPUs)
+	add(ResourceMemory, cc.Memory, &r.Memory, &prev.Memory)
+	add(ResourceDisk, cc.DiskSize, &r.DiskSize, &prev.DiskSize)
+
+	results := []ResizeResult{}
+	if len(changes) == 0 {
+		return results, nil
+	}
+	for i, n := range cc.Nodes {
+		name := config.MachineName(cc, n)
+		klog.Infof("resizing %s: %+v", name, r)
+		if err := resizeMachine(api, cc.Driver, name, r); err != nil {
+			// the failed machine may be partially resized as well
+			for _, rn := range cc.Nodes[:i+1] {
+				rname := config.MachineName(cc, rn)
+				klog.Infof("restoring the resources of %s: %+v", rname, prev)
+				if rerr := resizeMachine(api, cc.Driver, rname, prev); rerr != nil {
+					klog.Errorf("restoring the resources of %s failed: %v", rname, rerr)
+				}
+			}
+			return nil, err
+		}
+		for _, c := range changes {
+			c.Machine = name
+			results = append(results, c)
+		}
+	}
+	return results, nil
+}
+
+// resizeMachine applies the allocations to a machine, updating running containers live
+func resizeMachine(api libmachine.API, drvName string, name string, r Resources) error {
+	if driver.IsKIC(drvName) {
+		if err := oci.UpdateContainer(drvName, name, r.CPUs, r.Memory); err != nil {
+			return errors.Wrapf(err, "update %s", name)
+		}
+	}
+	if err := saveDriverResources(api, name, r); err != nil {
+		return errors.Wrapf(err, "save driver config of %s", name)
+	}
+	return nil
+}
+
+// saveDriverResources updates the allocations stored in the driver config of a machine,
+// which drivers that run as plugins only receive when the machine is loaded.
+func saveDriverResources(api libmachine.API, name string, r Resources) error {
+	lc, ok := api.(*LocalClient)
+	if !ok {
+		return fmt.Errorf("unexpected API client %T", api)
+	}
+	h, err := lc.Filestore.Load(name)
+	if err != nil {
+		return errors.Wrapf(err, "filestore %q", name)
+	}
+
+	raw := map[string]interface{}{}
+	d := json.NewDecoder(bytes.NewReader(h.RawDriver))
+	d.UseNumber()
+	if err := d.Decode(&raw); err != nil {
+		return errors.Wrap(err, "decode driver config")
+	}
+	setDriverResources(raw, r)
+	// the kic driver keeps its allocations in the config it was created with
+	if nc, ok := raw["NodeConfig"].(map[string]interface{}); ok {
+		setDriverResources(nc, r)
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return errors.Wrap(err, "encode driver config")
+	}
+	h.RawDriver = b
+	h.Driver = &host.RawDataDriver{Data: b}
+	return lc.Filestore.Save(h)
+}
+
+// setDriverResources sets the allocations the driver config already has fields for.
+func setDriverResources(raw map[string]interface{}, r Resources) {
+	for key, v := range map[string]int{"CPU": r.CPUs, "Memory": r.Memory, "DiskSize": r.DiskSize} {
+		if _, ok := raw[key]; ok && v != 0 {
+			raw[key] = v
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/driver"
+)
+
+func TestResizeModeFor(t *testing.T) {
+	tests := []struct {
+		driver   string
+		resource string
+		want     ResizeMode
+	}{
+		{driver.Docker, ResourceMemory, ResizeLive},
+		{driver.Podman, ResourceDisk, ResizeUnsupported},
+		{driver.KVM2, ResourceCPUs, ResizeRestart},
+		{driver.QEMU2, ResourceDisk, ResizeRestart},
+		{driver.HyperKit, ResourceDisk, ResizeUnsupported},
+		{driver.VirtualBox, ResourceMemory, ResizeUnsupported},
+		{driver.None, ResourceCPUs, ResizeUnsupported},
+	}
+	for _, tc := range tests {
+		if got := ResizeModeFor(tc.driver, tc.resource); got != tc.want {
+			t.Errorf("ResizeModeFor(%s, %s) = %s, want %s", tc.driver, tc.resource, got, tc.want)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	home := t.TempDir()
+	api, err := NewAPIClient(home)
+	if err != nil {
+		t.Fatalf("NewAPIClient: %v", err)
+	}
+	machineDir := writeResizeHost(t, home, "resize")
+
+	cc := config.ClusterConfig{Name: "resize", Driver: driver.QEMU2, CPUs: 2, Memory: 2200, DiskSize: 20000, Nodes: []config.Node{{ControlPlane: true}}}
+	got, err := Resize(api, cc, Resources{CPUs: 2, Memory: 4096, DiskSize: 40000})
+	if err != nil {
+		t.Fatalf("Resize: %v", err)
+	}
+	want := []ResizeResult{
+		{Machine: "resize", Resource: ResourceMemory, From: 2200, To: 4096, Mode: ResizeRestart},
+		{Machine: "resize", Resource: ResourceDisk, From: 20000, To: 40000, Mode: ResizeRestart},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results (-want +got):\n%s", diff)
+	}
+
+	wantDriver := map[string]interface{}{"MachineName": "resize", "CPU": 2.0, "Memory": 4096.0, "DiskSize": 40000.0, "CPUType": "host"}
+	if diff := cmp.Diff(wantDriver, savedDriver(t, machineDir)); diff != "" {
+		t.Errorf("unexpected driver config (-want +got):\n%s", diff)
+	}
+}
+
+func TestResizeRollback(t *testing.T) {
+	home := t.TempDir()
+	api, err := NewAPIClient(home)
+	if err != nil {
+		t.Fatalf("NewAPIClient: %v", err)
+	}
+	machineDir := writeResizeHost(t, home, "resize")
+
+	// the machine of the second node does not exist, so resizing it fails
+	cc := config.ClusterConfig{Name: "resize", Driver: driver.QEMU2, CPUs: 2, Memory: 2200, DiskSize: 20000, Nodes: []config.Node{{ControlPlane: true}, {Name: "m02"}}}
+	if _, err := Resize(api, cc, Resources{Memory: 4096}); err == nil {
+		t.Fatalf("Resize succeeded, want an error")
+	}
+
+	wantDriver := map[string]interface{}{"MachineName": "resize", "CPU": 2.0, "Memory": 2200.0, "DiskSize": 20000.0, "CPUType": "host"}
+	if diff := cmp.Diff(wantDriver, savedDriver(t, machineDir)); diff != "" {
+		t.Errorf("driver config not restored (-want +got):\n%s", diff)
+	}
+}
+
+// writeResizeHost writes the config of a qemu2 machine with 2 CPUs, 2200MB of memory and 20000MB of disk, and returns its directory
+func writeResizeHost(t *testing.T, home string, name string) string {
+	t.Helper()
+	machineDir := filepath.Join(home, "machines", name)
+	if err := os.MkdirAll(machineDir, 0700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	hostConfig := `{
+	"ConfigVersion": 3,
+	"Driver": {"MachineName": "` + name + `", "CPU": 2, "Memory": 2200, "DiskSize": 20000, "CPUType": "host"},
+	"DriverName": "qemu2",
+	"HostOptions": {"AuthOptions": {"StorePath": "` + machineDir + `"}},
+	"Name": "` + name + `"
+}`
+	if err := os.WriteFile(filepath.Join(machineDir, "config.json"), []byte(hostConfig), 0600); err != nil {
+		t.Fatalf("writefile: %v", err)
+	}
+	return machineDir
+}
+
+// savedDriver returns the driver config saved in the machine directory
+func savedDriver(t *testing.T, machineDir string) map[string]interface{} {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(machineDir, "config.json"))
+	if err != nil {
+		t.Fatalf("readfile: %v", err)
+	}
+	var saved struct {
+		Driver map[string]interface{}
+	}
+	if err := json.Unmarshal(b, &saved); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return saved.Driver
+}
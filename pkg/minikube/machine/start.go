@@ -332,7 +332,7 @@ func postStartSetup(h *host.Host, mc config.ClusterConfig) error {
 	if driver.IsVM(mc.Driver) || driver.IsKIC(mc.Driver) || driver.IsSSH(mc.Driver) {
 		logRemoteOsRelease(r)
 	}
-	return syncLocalAssets(r)
+	return syncLocalAssets(r, mc, h.Name)
 }
 
 // acquireMachinesLock protects against code that is not parallel-safe (libmachine, cert setup)
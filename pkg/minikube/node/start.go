@@ -41,7 +41,6 @@ import (
 	"k8s.io/minikube/pkg/drivers/kic/oci"
 	"k8s.io/minikube/pkg/kapi"
 	"k8s.io/minikube/pkg/minikube/bootstrapper"
-	"k8s.io/minikube/pkg/minikube/bootstrapper/bsutil"
 	"k8s.io/minikube/pkg/minikube/bootstrapper/images"
 	"k8s.io/minikube/pkg/minikube/bootstrapper/kubeadm"
 	"k8s.io/minikube/pkg/minikube/cluster"
@@ -304,10 +303,10 @@ func joinCluster(starter Starter, cpBs bootstrapper.Bootstrapper, bs bootstrappe
 		if err := bs.JoinCluster(*starter.Cfg, *starter.Node, joinCmd); err != nil {
 			klog.Errorf("worker node failed to join cluster, will retry: %v", err)
 
-			// reset worker node to revert any changes made by previous kubeadm init/join
+			// reset worker node to revert any changes made by previous init/join
 			klog.Infof("resetting worker node %q before attempting to rejoin cluster...", starter.Node.Name)
-			if _, err := starter.Runner.RunCmd(exec.Command("/bin/bash", "-c", fmt.Sprintf("%s reset --force", bsutil.InvokeKubeadm(starter.Cfg.KubernetesConfig.KubernetesVersion)))); err != nil {
-				klog.Infof("kubeadm reset failed, continuing anyway: %v", err)
+			if err := bs.DeleteCluster(starter.Cfg.KubernetesConfig); err != nil {
+				klog.Infof("reset failed, continuing anyway: %v", err)
 			} else {
 				klog.Infof("successfully reset worker node %q", starter.Node.Name)
 			}
@@ -369,6 +368,7 @@ func configureRuntimes(runner cruntime.CommandRunner, cc config.ClusterConfig, k
 		ImageRepository:   cc.KubernetesConfig.ImageRepository,
 		KubernetesVersion: kv,
 		InsecureRegistry:  cc.InsecureRegistry,
+		RegistryMirror:    cc.RegistryMirror,
 	}
 	cr, err := cruntime.New(co)
 	if err != nil {
@@ -521,7 +521,7 @@ func setupKubeconfig(h *host.Host, cc *config.ClusterConfig, n *config.Node, clu
 		ClusterServerAddress: addr,
 		ClientCertificate:    localpath.ClientCert(cc.Name),
 		ClientKey:            localpath.ClientKey(cc.Name),
-		CertificateAuthority: localpath.CACert(),
+		CertificateAuthority: bootstrapper.ClusterCACert(*cc),
 		KeepContext:          cc.KeepContext,
 		EmbedCerts:           cc.EmbedCerts,
 	}
@@ -781,7 +781,7 @@ func addCoreDNSEntry(runner command.Runner, name, ip string, cc config.ClusterCo
 	cm := strings.TrimSpace(out.Stdout.String())
 
 	// check if this specific host entry already exists in coredns configmap, so not to duplicate/override it
-	host := regexp.MustCompile(fmt.Sprintf(`(?smU)^ *hosts {.*%s.*}`, name))
+	host := regexp.MustCompile(fmt.Sprintf(`(?smU)^ *hosts [^{]*{.*%s.*}`, name))
 	if host.MatchString(cm) {
 		klog.Infof("CoreDNS already contains %q host record, skipping...", name)
 		return nil
@@ -789,12 +789,12 @@ func addCoreDNSEntry(runner command.Runner, name, ip string, cc config.ClusterCo
 
 	// inject hosts block with host record into coredns configmap
 	sed := fmt.Sprintf("sed '/^        forward . \\/etc\\/resolv.conf.*/i \\        hosts {\\n           %s %s\\n           fallthrough\\n        }'", ip, name)
-	// check if hosts block already exists in coredns configmap
-	hosts := regexp.MustCompile(`(?smU)^ *hosts {.*}`)
+	// check if hosts block already exists in coredns configmap, such as the "hosts /etc/coredns/NodeHosts {" block of k3s
+	hosts := regexp.MustCompile(`(?smU)^ *hosts [^{]*{.*}`)
 	if hosts.MatchString(cm) {
 		// inject host record into existing coredns configmap hosts block instead
 		klog.Info("CoreDNS already contains hosts block, will inject host record there...")
-		sed = fmt.Sprintf("sed '/^        hosts {.*/a \\           %s %s'", ip, name)
+		sed = fmt.Sprintf("sed '/^        hosts .*{/a \\           %s %s'", ip, name)
 	}
 
 	// replace coredns configmap via kubectl
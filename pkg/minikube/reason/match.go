@@ -85,3 +85,14 @@ func MatchKnownIssue(r Kind, err error, goos string) *Kind {
 
 	return genericMatch
 }
+
+// Lookup returns the known issue with the given ID, or nil if there is none
+func Lookup(id string) *Kind {
+	for _, ki := range knownIssues() {
+		if ki.ID == id {
+			k := ki.Kind
+			return &k
+		}
+	}
+	return nil
+}
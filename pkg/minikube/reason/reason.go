@@ -110,6 +110,8 @@ var (
 	InternalGenerateDocs = Kind{ID: "MK_GENERATE_DOCS", ExitCode: ExProgramError}
 	// minikube failed to marshal a JSON object
 	InternalJSONMarshal = Kind{ID: "MK_JSON_MARSHAL", ExitCode: ExProgramError}
+	// minikube failed to read or encode the audit log
+	InternalAuditQuery = Kind{ID: "MK_AUDIT_QUERY", ExitCode: ExProgramError}
 	// minikube failed to create a Kubernetes client set which is necessary for querying the Kubernetes API
 	InternalKubernetesClient = Kind{ID: "MK_K8S_CLIENT", ExitCode: ExControlPlaneUnavailable}
 	// minikube failed to list some configuration data
@@ -238,6 +240,8 @@ var (
 	HostHomeChown = Kind{ID: "HOST_HOME_CHOWN", ExitCode: ExHostPermission}
 	// minikube failed to open the host browser, such as when running minikube dashboard
 	HostBrowser = Kind{ID: "HOST_BROWSER", ExitCode: ExHostError}
+	// minikube failed to create, verify or import an offline bundle
+	HostBundle = Kind{ID: "HOST_BUNDLE", ExitCode: ExHostError}
 	// minikube failed to load cluster config from the host for the profile in use
 	HostConfigLoad = Kind{ID: "HOST_CONFIG_LOAD", ExitCode: ExHostConfig}
 	// the current user has insufficient permissions to create the minikube profile directory
@@ -268,8 +272,22 @@ var (
 	HostPathStat = Kind{ID: "HOST_PATH_STAT", ExitCode: ExHostError}
 	// minikube failed to purge minikube config directories
 	HostPurge = Kind{ID: "HOST_PURGE", ExitCode: ExHostError}
+	// minikube failed to serve or read the statistics of the registry cache
+	HostRegistryCache = Kind{ID: "HOST_REGISTRY_CACHE", ExitCode: ExHostError}
 	// minikube failed to persist profile config
 	HostSaveProfile = Kind{ID: "HOST_SAVE_PROFILE", ExitCode: ExHostConfig}
+	// minikube failed to list or delete profile snapshots on the host
+	HostSnapshot = Kind{ID: "HOST_SNAPSHOT", ExitCode: ExHostError}
+	// the requested profile snapshot does not exist
+	HostSnapshotNotFound = Kind{ID: "HOST_SNAPSHOT_NOT_FOUND", ExitCode: ExHostNotFound}
+	// minikube failed to list or remove the etcd backups of a profile
+	HostEtcdBackup = Kind{ID: "HOST_ETCD_BACKUP", ExitCode: ExHostError}
+	// the requested etcd backup does not exist
+	HostEtcdBackupNotFound = Kind{ID: "HOST_ETCD_BACKUP_NOT_FOUND", ExitCode: ExHostNotFound}
+	// minikube failed to read the certificates of a profile
+	HostCerts = Kind{ID: "HOST_CERTS", ExitCode: ExHostError}
+	// the requested recurring schedule does not exist
+	HostScheduleNotFound = Kind{ID: "HOST_SCHEDULE_NOT_FOUND", ExitCode: ExHostNotFound}
 
 	// minikube could not find a provider for the selected driver
 	ProviderNotFound = Kind{ID: "PROVIDER_NOT_FOUND", ExitCode: ExProviderNotFound}
@@ -319,6 +337,8 @@ var (
 	GuestImageLoad = Kind{ID: "GUEST_IMAGE_LOAD", ExitCode: ExGuestError}
 	// minikube failed to remove an image
 	GuestImageRemove = Kind{ID: "GUEST_IMAGE_REMOVE", ExitCode: ExGuestError}
+	// minikube failed to prune images
+	GuestImagePrune = Kind{ID: "GUEST_IMAGE_PRUNE", ExitCode: ExGuestError}
 	// minikube failed to pull an image
 	GuestImagePull = Kind{ID: "GUEST_IMAGE_PULL", ExitCode: ExGuestError}
 	// minikube failed to build an image
@@ -329,6 +349,8 @@ var (
 	GuestImagePush = Kind{ID: "GUEST_IMAGE_PUSH", ExitCode: ExGuestError}
 	// minikube failed to tag an image
 	GuestImageTag = Kind{ID: "GUEST_IMAGE_TAG", ExitCode: ExGuestError}
+	// minikube failed to sync the local files to the nodes
+	GuestFileSync = Kind{ID: "GUEST_FILE_SYNC", ExitCode: ExGuestError}
 	// minikube failed to load host
 	GuestLoadHost = Kind{ID: "GUEST_LOAD_HOST", ExitCode: ExGuestError}
 	// minkube failed to create a mount
@@ -370,6 +392,26 @@ var (
 	GuestStopTimeout = Kind{ID: "GUEST_STOP_TIMEOUT", ExitCode: ExGuestTimeout}
 	// minikube failed to unpause the cluster process
 	GuestUnpause = Kind{ID: "GUEST_UNPAUSE", ExitCode: ExGuestError}
+	// minikube failed to capture a snapshot of a profile
+	GuestSnapshotSave = Kind{ID: "GUEST_SNAPSHOT_SAVE", ExitCode: ExGuestError}
+	// minikube failed to restore a snapshot into a profile
+	GuestSnapshotRestore = Kind{ID: "GUEST_SNAPSHOT_RESTORE", ExitCode: ExGuestError}
+	// minikube failed to back up the etcd of a cluster
+	GuestEtcdBackup = Kind{ID: "GUEST_ETCD_BACKUP", ExitCode: ExGuestError}
+	// minikube failed to restore the etcd of a cluster from a backup
+	GuestEtcdRestore = Kind{ID: "GUEST_ETCD_RESTORE", ExitCode: ExGuestError}
+	// minikube failed to defragment the etcd of a cluster
+	GuestEtcdDefrag = Kind{ID: "GUEST_ETCD_DEFRAG", ExitCode: ExGuestError}
+	// minikube failed to get the status of the etcd of a cluster
+	GuestEtcdStatus = Kind{ID: "GUEST_ETCD_STATUS", ExitCode: ExGuestError}
+	// the etcd of the cluster cannot be managed by minikube, e.g. with the k3s bootstrapper
+	GuestEtcdUnsupported = Kind{ID: "GUEST_ETCD_UNSUPPORTED", ExitCode: ExGuestUnsupported}
+	// the snapshot was taken from a cluster which does not match the target profile
+	GuestSnapshotIncompatible = Kind{ID: "GUEST_SNAPSHOT_INCOMPATIBLE", ExitCode: ExGuestConflict, Style: style.Conflict}
+	// minikube failed to change the CPUs, memory or disk size of a profile
+	GuestResize = Kind{ID: "GUEST_RESIZE", ExitCode: ExGuestError}
+	// the driver of the profile cannot change the requested resource
+	GuestResizeUnsupported = Kind{ID: "GUEST_RESIZE_UNSUPPORTED", ExitCode: ExGuestUnsupported}
 	// minikube failed to check if Kubernetes containers are paused
 	GuestCheckPaused = Kind{ID: "GUEST_CHECK_PAUSED", ExitCode: ExGuestError}
 	// minikube cluster was created used a driver that is incompatible with the driver being requested
@@ -441,6 +483,18 @@ var (
 	KubernetesTooOld = Kind{ID: "K8S_OLD_UNSUPPORTED", ExitCode: ExControlPlaneUnsupported}
 	// a too new Kubernetes version was specified for minikube to use
 	KubernetesTooNew = Kind{ID: "K8S_NEW_UNSUPPORTED", ExitCode: ExControlPlaneUnsupported}
+	// minikube failed to upgrade the Kubernetes version of a cluster
+	KubernetesUpgrade = Kind{ID: "K8S_UPGRADE_FAILED", ExitCode: ExControlPlaneError}
+	// the cluster can not be upgraded to the requested Kubernetes version
+	KubernetesUpgradeUnsupported = Kind{ID: "K8S_UPGRADE_UNSUPPORTED", ExitCode: ExControlPlaneUnsupported}
+	// minikube failed to upgrade the Kubernetes version of a cluster, and to roll it back
+	KubernetesUpgradeRollback = Kind{
+		ID:       "K8S_UPGRADE_ROLLBACK_FAILED",
+		ExitCode: ExControlPlaneError,
+		Advice:   translate.T("The pre-upgrade backup of each node is kept in {{.backup}}. If the cluster does not recover, recreate it with 'minikube delete{{.profile}}'."),
+	}
+	// minikube failed to rotate the certificates of a cluster
+	KubernetesCertsRotate = Kind{ID: "K8S_CERTS_ROTATE", ExitCode: ExControlPlaneError}
 	// minikube was unable to safely downgrade installed Kubernetes version
 	KubernetesDowngrade = Kind{
 		ID:       "K8S_DOWNGRADE_UNSUPPORTED",
@@ -0,0 +1,421 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrycache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	ps "github.com/mitchellh/go-ps"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+
+	"k8s.io/minikube/pkg/minikube/constants"
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"k8s.io/minikube/pkg/util/lock"
+	"k8s.io/minikube/pkg/util/retry"
+)
+
+const (
+	// DefaultPort is the port the server listens on, unless set with minikube config set registry-cache-port
+	DefaultPort = 5100
+	// DefaultMaxSize is the size of the cache, unless set with minikube config set registry-cache-size
+	DefaultMaxSize = "20g"
+
+	statsInterval = 10 * time.Second
+)
+
+// ServerState is the state of the running server, stored in the cache directory for the other minikube processes
+type ServerState struct {
+	PID  int
+	Port int
+	// Addresses are the IPs the server listens on
+	Addresses []string
+	MaxSize   int64
+	Started   time.Time
+}
+
+// Dir returns the directory of the registry cache
+func Dir() string {
+	return localpath.RegistryCache()
+}
+
+func stateFile() string {
+	return filepath.Join(Dir(), "server.json")
+}
+
+func profilesFile() string {
+	return filepath.Join(Dir(), "profiles.json")
+}
+
+// LogFile returns the path of the log of the server
+func LogFile() string {
+	return filepath.Join(Dir(), "server.log")
+}
+
+// MirrorURL returns the URL of the registry cache for the container runtimes of the nodes
+func MirrorURL(port int) string {
+	return fmt.Sprintf("http://%s:%d", constants.HostAlias, port)
+}
+
+// Running returns the state of the server, or nil if it isn't running
+func Running() (*ServerState, error) {
+	data, err := os.ReadFile(stateFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	st := &ServerState{}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", stateFile())
+	}
+	if p, err := ps.FindProcess(st.PID); err != nil || p == nil {
+		klog.Infof("removing the state of the stale registry cache server, pid %d", st.PID)
+		if err := os.Remove(stateFile()); err != nil {
+			klog.Warningf("unable to remove %s: %v", stateFile(), err)
+		}
+		return nil, nil
+	}
+	return st, nil
+}
+
+// Profiles returns the profiles using the registry cache
+func Profiles() ([]string, error) {
+	var profiles []string
+	data, err := os.ReadFile(profilesFile())
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", profilesFile())
+	}
+	return profiles, nil
+}
+
+func saveProfiles(profiles []string) error {
+	sort.Strings(profiles)
+	data, err := json.MarshalIndent(profiles, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return err
+	}
+	return lock.WriteFile(profilesFile(), data, 0644)
+}
+
+// ListenAddresses returns the IPs the server listens on for a profile whose nodes reach the host at hostIP.
+// The server pulls with the credentials of the user, so it only listens on the loopback interface and, if
+// it is an address of the host rather than one forwarded to its loopback interface, on hostIP.
+func ListenAddresses(hostIP net.IP) []string {
+	addrs := []string{"127.0.0.1"}
+	if hostIP == nil || hostIP.IsLoopback() || hostIP.IsUnspecified() {
+		return addrs
+	}
+	ifaddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		klog.Warningf("unable to list the addresses of the host: %v", err)
+		return addrs
+	}
+	for _, a := range ifaddrs {
+		if n, ok := a.(*net.IPNet); ok && n.IP.Equal(hostIP) {
+			return append(addrs, hostIP.String())
+		}
+	}
+	klog.Infof("%s is not an address of the host, the registry cache is reached through its loopback interface", hostIP)
+	return addrs
+}
+
+// Start registers profile as a user of the registry cache, and starts its server on port if it isn't running.
+// The server is restarted if it doesn't listen on every address of addrs yet. The port the server listens on is
+// returned, which differs from port if the server was already started by another profile.
+func Start(profile string, addrs []string, port int, maxSize int64) (int, error) {
+	profiles, err := Profiles()
+	if err != nil {
+		return 0, err
+	}
+	found := false
+	for _, p := range profiles {
+		found = found || p == profile
+	}
+	if !found {
+		if err := saveProfiles(append(profiles, profile)); err != nil {
+			return 0, errors.Wrap(err, "registering the profile")
+		}
+	}
+
+	st, err := Running()
+	if err != nil {
+		return 0, err
+	}
+	if st != nil {
+		missing := missingAddresses(st.Addresses, addrs)
+		if len(missing) == 0 {
+			return st.Port, nil
+		}
+		// the other profiles still need the addresses the server already listens on
+		klog.Infof("restarting the registry cache to listen on %s", strings.Join(missing, ", "))
+		addrs = append(st.Addresses, missing...)
+		port = st.Port
+		if err := stopAndWait(); err != nil {
+			return 0, err
+		}
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, errors.Wrap(err, "finding minikube executable")
+	}
+	logFile, err := os.OpenFile(LogFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, errors.Wrap(err, "opening the registry cache log")
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, "cache", "serve", "--alsologtostderr", "--port", strconv.Itoa(port), "--max-size", strconv.FormatInt(maxSize, 10),
+		"--listen-address", strings.Join(addrs, ","))
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return 0, errors.Wrap(err, "starting the registry cache")
+	}
+	klog.Infof("started the registry cache on %s port %d with PID %d", strings.Join(addrs, ", "), port, cmd.Process.Pid)
+	if err := cmd.Process.Release(); err != nil {
+		return 0, err
+	}
+
+	ready := func() error {
+		resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/v2/", port))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+	if err := retry.Expo(ready, 100*time.Millisecond, 10*time.Second); err != nil {
+		return 0, err
+	}
+	return port, nil
+}
+
+// missingAddresses returns the addresses of want which are not in have
+func missingAddresses(have, want []string) []string {
+	missing := []string{}
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			found = found || h == w
+		}
+		if !found {
+			missing = append(missing, w)
+		}
+	}
+	return missing
+}
+
+// stopAndWait stops the server, and waits for it to release its port
+func stopAndWait() error {
+	if err := Stop(); err != nil {
+		return err
+	}
+	stopped := func() error {
+		st, err := Running()
+		if err != nil {
+			return err
+		}
+		if st != nil {
+			return fmt.Errorf("the registry cache is still running with PID %d", st.PID)
+		}
+		return nil
+	}
+	return retry.Expo(stopped, 100*time.Millisecond, 10*time.Second)
+}
+
+// Release unregisters profile, and stops the server once no profile uses the registry cache
+func Release(profile string) error {
+	profiles, err := Profiles()
+	if err != nil {
+		return err
+	}
+	left := []string{}
+	for _, p := range profiles {
+		if p != profile {
+			left = append(left, p)
+		}
+	}
+	if len(left) != len(profiles) {
+		if err := saveProfiles(left); err != nil {
+			return errors.Wrap(err, "unregistering the profile")
+		}
+	}
+	if len(left) > 0 {
+		return nil
+	}
+	return Stop()
+}
+
+// Stop stops the server, if running
+func Stop() error {
+	st, err := Running()
+	if err != nil || st == nil {
+		return err
+	}
+	p, err := os.FindProcess(st.PID)
+	if err != nil {
+		return errors.Wrapf(err, "finding process %d", st.PID)
+	}
+	klog.Infof("stopping the registry cache, pid %d", st.PID)
+	if runtime.GOOS == "windows" {
+		// the process can't clean up after itself, as Windows has no termination signal
+		err = p.Kill()
+		if rerr := os.Remove(stateFile()); rerr != nil {
+			klog.Warningf("unable to remove %s: %v", stateFile(), rerr)
+		}
+	} else {
+		err = p.Signal(syscall.SIGTERM)
+	}
+	return errors.Wrapf(err, "stopping process %d", st.PID)
+}
+
+// listen returns a listener on port for each of addrs. Listening on every interface is refused.
+func listen(addrs []string, port int) ([]net.Listener, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no address to listen on")
+	}
+	ls := []net.Listener{}
+	for _, a := range addrs {
+		ip := net.ParseIP(a)
+		if ip == nil || ip.IsUnspecified() {
+			for _, l := range ls {
+				l.Close()
+			}
+			return nil, fmt.Errorf("invalid listen address %q: must be the IP of a single interface", a)
+		}
+		l, err := net.Listen("tcp", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+		if err != nil {
+			for _, l := range ls {
+				l.Close()
+			}
+			return nil, err
+		}
+		ls = append(ls, l)
+		// with port 0, every address must share the port picked for the first one
+		port = l.Addr().(*net.TCPAddr).Port
+	}
+	return ls, nil
+}
+
+// Serve runs the server of the registry cache on addrs until it is terminated
+func Serve(addrs []string, port int, maxSize int64) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return err
+	}
+	stats, err := LoadStats(Dir())
+	if err != nil {
+		return err
+	}
+	stats.Update(func(st *Stats) {
+		if st.Since.IsZero() {
+			st.Since = time.Now()
+		}
+	})
+	saveStats := func() {
+		if err := stats.Save(); err != nil {
+			klog.Warningf("unable to save the registry cache stats: %v", err)
+		}
+	}
+	saveStats()
+	defer saveStats()
+	store := NewStore(Dir(), maxSize)
+	store.Stats = stats
+	// the size limit may have been lowered since the last start
+	if err := store.Evict(); err != nil {
+		return errors.Wrap(err, "evicting")
+	}
+
+	ls, err := listen(addrs, port)
+	if err != nil {
+		return err
+	}
+	st := ServerState{PID: os.Getpid(), Port: port, Addresses: addrs, MaxSize: maxSize, Started: time.Now()}
+	data, err := json.MarshalIndent(st, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := lock.WriteFile(stateFile(), data, 0644); err != nil {
+		return err
+	}
+	defer os.Remove(stateFile())
+
+	srv := &http.Server{Handler: &Server{
+		Store:    store,
+		Stats:    stats,
+		Upstream: DefaultUpstream,
+		Options:  []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)},
+	}}
+	go func() {
+		// minikube cache stats reads the counters from the disk
+		for range time.Tick(statsInterval) {
+			saveStats()
+		}
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		klog.Infof("stopping the registry cache")
+		if err := srv.Shutdown(context.Background()); err != nil {
+			klog.Warningf("shutting down: %v", err)
+		}
+	}()
+
+	klog.Infof("serving the registry cache of %s on %s port %d", DefaultUpstream, strings.Join(addrs, ", "), port)
+	errs := make(chan error, len(ls))
+	for _, l := range ls {
+		go func(l net.Listener) {
+			errs <- srv.Serve(l)
+		}(l)
+	}
+	for range ls {
+		if err := <-errs; err != http.ErrServerClosed {
+			return err
+		}
+	}
+	return nil
+}
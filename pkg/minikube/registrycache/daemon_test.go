@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrycache
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestListen(t *testing.T) {
+	ls, err := listen([]string{"127.0.0.1"}, 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	for _, l := range ls {
+		defer l.Close()
+	}
+	if len(ls) != 1 {
+		t.Fatalf("got %d listeners, want 1", len(ls))
+	}
+	if ip := ls[0].Addr().(*net.TCPAddr).IP; !ip.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("listening on %s, want 127.0.0.1", ls[0].Addr())
+	}
+
+	// the server pulls with the credentials of the user, so it must never listen on every interface
+	for _, addrs := range [][]string{{"0.0.0.0"}, {"::"}, {""}, {"127.0.0.1", "0.0.0.0"}, {}} {
+		if ls, err := listen(addrs, 0); err == nil {
+			for _, l := range ls {
+				l.Close()
+			}
+			t.Errorf("listen(%q) = %v, want an error", addrs, ls)
+		}
+	}
+}
+
+func TestListenAddresses(t *testing.T) {
+	loopback := []string{"127.0.0.1"}
+	tests := []struct {
+		desc   string
+		hostIP net.IP
+		want   []string
+	}{
+		{"unknown host IP", nil, loopback},
+		{"none driver", net.ParseIP("127.0.0.1"), loopback},
+		{"unspecified", net.ParseIP("0.0.0.0"), loopback},
+		// e.g. host.docker.internal of Docker Desktop, which is forwarded to the loopback interface of the host
+		{"not an address of the host", net.ParseIP("192.0.2.1"), loopback},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, ListenAddresses(tc.hostIP)); diff != "" {
+				t.Errorf("ListenAddresses(%s) mismatch (-want +got):\n%s", tc.hostIP, diff)
+			}
+		})
+	}
+
+	// an address of an interface of the host, as the gateway of the network of the nodes is
+	ifaddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range ifaddrs {
+		n, ok := a.(*net.IPNet)
+		if !ok || n.IP.IsLoopback() || n.IP.To4() == nil {
+			continue
+		}
+		want := []string{"127.0.0.1", n.IP.String()}
+		if diff := cmp.Diff(want, ListenAddresses(n.IP)); diff != "" {
+			t.Errorf("ListenAddresses(%s) mismatch (-want +got):\n%s", n.IP, diff)
+		}
+		break
+	}
+}
+
+func TestMissingAddresses(t *testing.T) {
+	got := missingAddresses([]string{"127.0.0.1", "192.168.49.1"}, []string{"127.0.0.1", "192.168.58.1"})
+	if diff := cmp.Diff([]string{"192.168.58.1"}, got); diff != "" {
+		t.Errorf("missingAddresses mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrycache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+)
+
+// DefaultUpstream is the registry mirrored by the cache: the registry mirrors of the container runtimes only apply to docker hub
+const DefaultUpstream = "index.docker.io"
+
+// Server serves the registry API to the container runtimes of the nodes, pulling the blobs and manifests
+// missing from its store through from the upstream registry. It is read-only.
+type Server struct {
+	Store    *Store
+	Stats    *Stats
+	Upstream string
+	Options  []remote.Option
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		registryError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "the registry cache is read-only")
+		return
+	}
+	if r.URL.Path == "/v2/" || r.URL.Path == "/v2" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	repo, kind, ref, ok := parsePath(r.URL.Path)
+	if !ok {
+		registryError(w, http.StatusNotFound, "NAME_INVALID", fmt.Sprintf("invalid path %s", r.URL.Path))
+		return
+	}
+	if kind == "blobs" {
+		s.serveBlob(w, r, repo, ref)
+		return
+	}
+	s.serveManifest(w, r, repo, ref)
+}
+
+// parsePath returns the repository, the kind of content (manifests or blobs) and the reference of a request
+func parsePath(p string) (string, string, string, bool) {
+	p = strings.TrimPrefix(p, "/v2/")
+	for _, kind := range []string{"manifests", "blobs"} {
+		sep := "/" + kind + "/"
+		i := strings.LastIndex(p, sep)
+		if i <= 0 {
+			continue
+		}
+		repo, ref := p[:i], p[i+len(sep):]
+		if kind == "blobs" && !ValidDigest(ref) {
+			return "", "", "", false
+		}
+		if !ValidDigest(ref) && !ValidTag(repo, ref) {
+			return "", "", "", false
+		}
+		if !ValidTag(repo, "latest") {
+			return "", "", "", false
+		}
+		return repo, kind, ref, true
+	}
+	return "", "", "", false
+}
+
+func (s *Server) options(r *http.Request) []remote.Option {
+	return append([]remote.Option{remote.WithContext(r.Context())}, s.Options...)
+}
+
+func (s *Server) serveManifest(w http.ResponseWriter, r *http.Request, repo, ref string) {
+	tag := ""
+	digest := ref
+	if !ValidDigest(ref) {
+		tag = ref
+		// the tag may have moved since it was cached
+		upstream, err := name.NewTag(fmt.Sprintf("%s/%s:%s", s.Upstream, repo, tag))
+		if err != nil {
+			registryError(w, http.StatusNotFound, "NAME_INVALID", err.Error())
+			return
+		}
+		desc, err := remote.Head(upstream, s.options(r)...)
+		if err == nil {
+			digest = desc.Digest.String()
+		} else {
+			// serving the cached tag keeps the cache usable offline
+			cached, cerr := s.Store.Tag(repo, tag)
+			if cerr != nil {
+				upstreamError(w, err)
+				return
+			}
+			klog.Infof("serving the cached %s:%s, as %s is unreachable: %v", repo, tag, s.Upstream, err)
+			digest = cached
+		}
+	}
+
+	data, mediaType, err := s.Store.Manifest(digest)
+	switch {
+	case err == nil:
+		s.Stats.Update(func(st *Stats) { st.Hits++ })
+		if tag != "" {
+			if err := s.Store.PutTag(repo, tag, digest); err != nil {
+				klog.Warningf("unable to store the tag %s:%s: %v", repo, tag, err)
+			}
+		}
+	case err == errNotCached:
+		upstream, err := name.ParseReference(fmt.Sprintf("%s/%s%s", s.Upstream, repo, refSuffix(ref)))
+		if err != nil {
+			registryError(w, http.StatusNotFound, "NAME_INVALID", err.Error())
+			return
+		}
+		desc, err := remote.Get(upstream, s.options(r)...)
+		if err != nil {
+			upstreamError(w, err)
+			return
+		}
+		data, mediaType = desc.Manifest, string(desc.MediaType)
+		s.Stats.Update(func(st *Stats) { st.Misses++ })
+		if digest, err = s.Store.PutManifest(repo, tag, data, mediaType); err != nil {
+			klog.Warningf("unable to cache the manifest %s/%s: %v", repo, ref, err)
+		}
+	default:
+		registryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		if _, err := w.Write(data); err != nil {
+			klog.Warningf("serving the manifest %s/%s: %v", repo, ref, err)
+		}
+	}
+}
+
+// refSuffix returns the reference as it follows the repository in an image name
+func refSuffix(ref string) string {
+	if ValidDigest(ref) {
+		return "@" + ref
+	}
+	return ":" + ref
+}
+
+func (s *Server) serveBlob(w http.ResponseWriter, r *http.Request, repo, digest string) {
+	f, err := s.Store.OpenBlob(digest)
+	if err == nil {
+		defer f.Close()
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if r.Method == http.MethodGet {
+			s.Stats.Update(func(st *Stats) { st.Hits++ })
+			if info, err := f.Stat(); err == nil {
+				s.Stats.Update(func(st *Stats) { st.ServedBytes += info.Size() })
+			}
+		}
+		http.ServeContent(w, r, "", time.Time{}, f)
+		return
+	}
+	if err != errNotCached {
+		registryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+
+	upstream, err := name.NewDigest(fmt.Sprintf("%s/%s@%s", s.Upstream, repo, digest))
+	if err != nil {
+		registryError(w, http.StatusNotFound, "NAME_INVALID", err.Error())
+		return
+	}
+	l, err := remote.Layer(upstream, s.options(r)...)
+	if err != nil {
+		upstreamError(w, err)
+		return
+	}
+	size, err := l.Size()
+	if err != nil {
+		upstreamError(w, err)
+		return
+	}
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	rc, err := l.Compressed()
+	if err != nil {
+		upstreamError(w, err)
+		return
+	}
+	defer rc.Close()
+	bw, err := s.Store.NewBlobWriter(digest)
+	if err != nil {
+		registryError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	n, err := io.Copy(io.MultiWriter(w, bw), rc)
+	s.Stats.Update(func(st *Stats) {
+		st.Misses++
+		st.FetchedBytes += n
+		st.ServedBytes += n
+	})
+	if err != nil {
+		klog.Warningf("pulling the blob %s of %s: %v", digest, repo, err)
+		bw.Cancel()
+		return
+	}
+	if err := bw.Commit(); err != nil {
+		klog.Warningf("unable to cache the blob %s: %v", digest, err)
+	}
+}
+
+// registryError writes an error in the format of the registry API
+func registryError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	body := map[string][]map[string]string{"errors": {{"code": code, "message": message}}}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		klog.Warningf("writing the error %s: %v", code, err)
+	}
+}
+
+// upstreamError relays an error of the upstream registry
+func upstreamError(w http.ResponseWriter, err error) {
+	var terr *transport.Error
+	if errors.As(err, &terr) && terr.StatusCode != 0 {
+		code := "UNKNOWN"
+		if len(terr.Errors) > 0 {
+			code = string(terr.Errors[0].Code)
+		}
+		registryError(w, terr.StatusCode, code, err.Error())
+		return
+	}
+	registryError(w, http.StatusBadGateway, "UNAVAILABLE", err.Error())
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrycache
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestServerPullThrough(t *testing.T) {
+	upstream := httptest.NewServer(registry.New())
+	defer upstream.Close()
+	upstreamHost := strings.TrimPrefix(upstream.URL, "http://")
+
+	img, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatalf("random.Image: %v", err)
+	}
+	tag, err := name.NewTag(upstreamHost + "/library/test:v1")
+	if err != nil {
+		t.Fatalf("NewTag: %v", err)
+	}
+	if err := remote.Write(tag, img); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	dir := t.TempDir()
+	stats, err := LoadStats(dir)
+	if err != nil {
+		t.Fatalf("LoadStats: %v", err)
+	}
+	store := NewStore(dir, 0)
+	cache := httptest.NewServer(&Server{Store: store, Stats: stats, Upstream: upstreamHost})
+	defer cache.Close()
+	cacheHost := strings.TrimPrefix(cache.URL, "http://")
+
+	pull := func() {
+		t.Helper()
+		ref, err := name.NewTag(cacheHost + "/library/test:v1")
+		if err != nil {
+			t.Fatalf("NewTag: %v", err)
+		}
+		got, err := remote.Image(ref)
+		if err != nil {
+			t.Fatalf("Image: %v", err)
+		}
+		if _, err := got.RawConfigFile(); err != nil {
+			t.Fatalf("RawConfigFile: %v", err)
+		}
+		layers, err := got.Layers()
+		if err != nil {
+			t.Fatalf("Layers: %v", err)
+		}
+		for _, l := range layers {
+			rc, err := l.Compressed()
+			if err != nil {
+				t.Fatalf("Compressed: %v", err)
+			}
+			rc.Close()
+		}
+		want, _ := img.Digest()
+		if d, _ := got.Digest(); d != want {
+			t.Errorf("pulled %s, want %s", d, want)
+		}
+	}
+
+	pull()
+	u, err := store.Usage()
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	// the config is a blob, along with the two layers
+	if u.Blobs != 3 || u.Manifests != 1 {
+		t.Errorf("Usage() = %+v, want 3 blobs and 1 manifest", u)
+	}
+	misses := stats.Misses
+	if stats.Hits != 0 || misses == 0 {
+		t.Errorf("first pull: %d hits, %d misses", stats.Hits, misses)
+	}
+
+	pull()
+	if stats.Misses != misses || stats.Hits == 0 {
+		t.Errorf("second pull: %d hits, %d misses, want no more misses", stats.Hits, stats.Misses)
+	}
+
+	// the cached tag is served while the upstream registry is down
+	upstream.Close()
+	pull()
+
+	ref := fmt.Sprintf("%s/library/missing:v1", cacheHost)
+	missing, err := name.NewTag(ref)
+	if err != nil {
+		t.Fatalf("NewTag: %v", err)
+	}
+	if _, err := remote.Image(missing); err == nil {
+		t.Errorf("pulling %s succeeded without the upstream registry", ref)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrycache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/minikube/pkg/util/lock"
+)
+
+// Stats are the counters of the registry cache server, stored in its directory for minikube cache stats
+type Stats struct {
+	// Since is when the server first started
+	Since time.Time
+	// Hits and Misses count the blobs and manifests served from the cache, and pulled from the registry
+	Hits   int64
+	Misses int64
+	// ServedBytes is the size of the blobs served to the nodes, FetchedBytes the size of the ones pulled from the registry
+	ServedBytes  int64
+	FetchedBytes int64
+	Evicted      int64
+
+	mu    sync.Mutex
+	path  string
+	dirty bool
+}
+
+func statsFile(dir string) string {
+	return filepath.Join(dir, "stats.json")
+}
+
+// LoadStats returns the counters stored in dir, which are empty before the first start of the server
+func LoadStats(dir string) (*Stats, error) {
+	st := &Stats{path: statsFile(dir)}
+	data, err := os.ReadFile(st.path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", st.path)
+	}
+	return st, nil
+}
+
+// Update changes the counters, which are stored by Save
+func (st *Stats) Update(f func(*Stats)) {
+	if st == nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	f(st)
+	st.dirty = true
+}
+
+// Save stores the counters, if changed since they were last stored
+func (st *Stats) Save() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if !st.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(st, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := lock.WriteFile(st.path, data, 0644); err != nil {
+		return err
+	}
+	st.dirty = false
+	return nil
+}
+
+// HitRatio returns the share of the requests served from the cache
+func (st *Stats) HitRatio() float64 {
+	if st.Hits+st.Misses == 0 {
+		return 0
+	}
+	return float64(st.Hits) / float64(st.Hits+st.Misses)
+}
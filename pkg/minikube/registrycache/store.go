@@ -0,0 +1,308 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrycache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+)
+
+var (
+	digestRe = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	// repoRe and tagRe are the grammar of the distribution spec, which also keeps the paths of the store in its directory
+	repoRe = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*)*$`)
+	tagRe  = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+)
+
+// errNotCached is returned for the content missing from the store
+var errNotCached = errors.New("not cached")
+
+// Store keeps the blobs and manifests pulled through the cache by digest, along with the digests of the tags.
+// The blobs and manifests least recently used are evicted when their size goes beyond MaxSize.
+type Store struct {
+	Dir     string
+	MaxSize int64
+	// Stats counts the evictions, if set
+	Stats *Stats
+
+	mu sync.Mutex
+}
+
+// NewStore returns the store in dir
+func NewStore(dir string, maxSize int64) *Store {
+	return &Store{Dir: dir, MaxSize: maxSize}
+}
+
+func (s *Store) blobPath(digest string) string {
+	return filepath.Join(s.Dir, "blobs", strings.Replace(digest, ":", string(filepath.Separator), 1))
+}
+
+func (s *Store) manifestPath(digest string) string {
+	return filepath.Join(s.Dir, "manifests", strings.Replace(digest, ":", string(filepath.Separator), 1))
+}
+
+func (s *Store) tagPath(repo, tag string) string {
+	return filepath.Join(s.Dir, "tags", filepath.FromSlash(repo), tag)
+}
+
+// ValidDigest returns whether digest is a sha256 digest
+func ValidDigest(digest string) bool {
+	return digestRe.MatchString(digest)
+}
+
+// ValidTag returns whether repo and tag are a valid repository name and tag
+func ValidTag(repo, tag string) bool {
+	return repoRe.MatchString(repo) && tagRe.MatchString(tag)
+}
+
+// touch marks a file as used now, for the eviction
+func touch(path string) {
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		klog.Warningf("unable to update the times of %s: %v", path, err)
+	}
+}
+
+// OpenBlob opens a cached blob
+func (s *Store) OpenBlob(digest string) (*os.File, error) {
+	p := s.blobPath(digest)
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, errNotCached
+	}
+	if err != nil {
+		return nil, err
+	}
+	touch(p)
+	return f, nil
+}
+
+// Manifest returns a cached manifest along with its media type
+func (s *Store) Manifest(digest string) ([]byte, string, error) {
+	p := s.manifestPath(digest)
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, "", errNotCached
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	mediaType, err := os.ReadFile(p + ".type")
+	if err != nil {
+		return nil, "", err
+	}
+	touch(p)
+	return data, string(mediaType), nil
+}
+
+// Tag returns the digest a tag pointed to when it was last pulled
+func (s *Store) Tag(repo, tag string) (string, error) {
+	data, err := os.ReadFile(s.tagPath(repo, tag))
+	if os.IsNotExist(err) {
+		return "", errNotCached
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// PutManifest stores a manifest, and the digest of its tag if pulled by tag
+func (s *Store) PutManifest(repo, tag string, data []byte, mediaType string) (string, error) {
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	p := s.manifestPath(digest)
+	if err := writeFile(p+".type", []byte(mediaType)); err != nil {
+		return "", err
+	}
+	if err := writeFile(p, data); err != nil {
+		return "", err
+	}
+	if tag != "" {
+		if err := s.PutTag(repo, tag, digest); err != nil {
+			return "", err
+		}
+	}
+	return digest, s.Evict()
+}
+
+// PutTag stores the digest a tag points to
+func (s *Store) PutTag(repo, tag, digest string) error {
+	return writeFile(s.tagPath(repo, tag), []byte(digest))
+}
+
+// writeFile writes a file atomically, creating its directory
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// BlobWriter writes a blob to the store, which is only added once complete and verified
+type BlobWriter struct {
+	store  *Store
+	digest string
+	f      *os.File
+	h      io.Writer
+	sum    func() []byte
+}
+
+// NewBlobWriter returns a writer adding a blob to the store
+func (s *Store) NewBlobWriter(digest string) (*BlobWriter, error) {
+	p := s.blobPath(digest)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	return &BlobWriter{store: s, digest: digest, f: f, h: io.MultiWriter(f, h), sum: func() []byte { return h.Sum(nil) }}, nil
+}
+
+// Write implements io.Writer
+func (w *BlobWriter) Write(p []byte) (int, error) {
+	return w.h.Write(p)
+}
+
+// Commit adds the blob to the store, if its content matches its digest
+func (w *BlobWriter) Commit() error {
+	if err := w.f.Close(); err != nil {
+		os.Remove(w.f.Name())
+		return err
+	}
+	if got := fmt.Sprintf("sha256:%x", w.sum()); got != w.digest {
+		os.Remove(w.f.Name())
+		return errors.Errorf("blob %s has digest %s", w.digest, got)
+	}
+	if err := os.Rename(w.f.Name(), w.store.blobPath(w.digest)); err != nil {
+		os.Remove(w.f.Name())
+		return err
+	}
+	return w.store.Evict()
+}
+
+// Cancel discards the blob
+func (w *BlobWriter) Cancel() {
+	w.f.Close()
+	os.Remove(w.f.Name())
+}
+
+// entry is a blob or manifest of the store
+type entry struct {
+	path string
+	size int64
+	used time.Time
+}
+
+// entries lists the blobs and manifests of the store
+func (s *Store) entries() ([]entry, error) {
+	var entries []entry
+	for _, dir := range []string{"blobs", "manifests"} {
+		err := filepath.Walk(filepath.Join(s.Dir, dir), func(p string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || strings.HasSuffix(p, ".tmp") || strings.HasSuffix(p, ".type") {
+				return nil
+			}
+			entries = append(entries, entry{path: p, size: info.Size(), used: info.ModTime()})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Evict removes the blobs and manifests least recently used until the store fits in its maximum size
+func (s *Store) Evict() error {
+	if s.MaxSize <= 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].used.Before(entries[j].used) })
+	for _, e := range entries {
+		if total <= s.MaxSize {
+			break
+		}
+		klog.Infof("evicting %s (%d bytes), last used %s", e.path, e.size, e.used)
+		if err := os.Remove(e.path); err != nil {
+			return err
+		}
+		os.Remove(e.path + ".type")
+		total -= e.size
+		s.Stats.Update(func(st *Stats) { st.Evicted++ })
+	}
+	return nil
+}
+
+// Usage is the content of the store
+type Usage struct {
+	Blobs     int
+	Manifests int
+	Size      int64
+}
+
+// Usage returns the content of the store
+func (s *Store) Usage() (Usage, error) {
+	u := Usage{}
+	entries, err := s.entries()
+	if err != nil {
+		return u, err
+	}
+	manifests := filepath.Join(s.Dir, "manifests") + string(filepath.Separator)
+	for _, e := range entries {
+		if strings.HasPrefix(e.path, manifests) {
+			u.Manifests++
+		} else {
+			u.Blobs++
+		}
+		u.Size += e.size
+	}
+	return u, nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registrycache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func putBlob(t *testing.T, s *Store, data []byte) string {
+	t.Helper()
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	w, err := s.NewBlobWriter(digest)
+	if err != nil {
+		t.Fatalf("NewBlobWriter: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	return digest
+}
+
+func TestStoreEvict(t *testing.T) {
+	s := NewStore(t.TempDir(), 250)
+	var digests []string
+	for i := 0; i < 3; i++ {
+		digests = append(digests, putBlob(t, s, []byte(fmt.Sprintf("%0100d", i))))
+		// the eviction is by modification time
+		old := time.Now().Add(time.Duration(i-10) * time.Minute)
+		if err := os.Chtimes(s.blobPath(digests[i]), old, old); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+	}
+	// the first blob is the least recently used
+	if _, err := os.Stat(s.blobPath(digests[0])); !os.IsNotExist(err) {
+		t.Errorf("blob %s was not evicted: %v", digests[0], err)
+	}
+
+	// reading the second one makes the third the least recently used
+	f, err := s.OpenBlob(digests[1])
+	if err != nil {
+		t.Fatalf("OpenBlob: %v", err)
+	}
+	f.Close()
+	putBlob(t, s, []byte(fmt.Sprintf("%0100d", 3)))
+	if _, err := os.Stat(s.blobPath(digests[2])); !os.IsNotExist(err) {
+		t.Errorf("blob %s was not evicted: %v", digests[2], err)
+	}
+	if _, err := s.OpenBlob(digests[1]); err != nil {
+		t.Errorf("blob %s was evicted: %v", digests[1], err)
+	}
+
+	u, err := s.Usage()
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if u.Blobs != 2 || u.Size != 200 {
+		t.Errorf("Usage() = %+v, want 2 blobs of 200 bytes", u)
+	}
+}
+
+func TestBlobWriterDigest(t *testing.T) {
+	s := NewStore(t.TempDir(), 0)
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte("expected")))
+	w, err := s.NewBlobWriter(digest)
+	if err != nil {
+		t.Fatalf("NewBlobWriter: %v", err)
+	}
+	if _, err := w.Write([]byte("corrupted")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Commit(); err == nil {
+		t.Errorf("Commit of a blob not matching its digest succeeded")
+	}
+	if _, err := s.OpenBlob(digest); err != errNotCached {
+		t.Errorf("OpenBlob() = %v, want %v", err, errNotCached)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(nil))
+	tests := []struct {
+		path string
+		repo string
+		kind string
+		ref  string
+		ok   bool
+	}{
+		{"/v2/library/busybox/manifests/latest", "library/busybox", "manifests", "latest", true},
+		{"/v2/library/busybox/manifests/" + digest, "library/busybox", "manifests", digest, true},
+		{"/v2/library/busybox/blobs/" + digest, "library/busybox", "blobs", digest, true},
+		{"/v2/library/busybox/blobs/latest", "", "", "", false},
+		{"/v2/../../etc/manifests/latest", "", "", "", false},
+		{"/v2/library/busybox/tags/list", "", "", "", false},
+	}
+	for _, tc := range tests {
+		repo, kind, ref, ok := parsePath(tc.path)
+		if repo != tc.repo || kind != tc.kind || ref != tc.ref || ok != tc.ok {
+			t.Errorf("parsePath(%q) = %q, %q, %q, %v, want %q, %q, %q, %v", tc.path, repo, kind, ref, ok, tc.repo, tc.kind, tc.ref, tc.ok)
+		}
+	}
+}
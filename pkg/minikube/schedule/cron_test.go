@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+func TestParseCron(t *testing.T) {
+	valid := []string{"0 19 * * mon-fri", "*/15 8-18 * * 1-5", "0 0 1,15 * *", "30 6 * jan-mar 7", "@daily", "@Weekly"}
+	for _, expr := range valid {
+		if _, err := ParseCron(expr); err != nil {
+			t.Errorf("ParseCron(%q) returned error: %v", expr, err)
+		}
+	}
+	invalid := []string{"", "0 19 * *", "60 * * * *", "0 24 * * *", "0 0 0 * *", "0 0 * 13 *", "0 0 * * 8", "0 0 * * foo", "*/0 * * * *", "5-1 * * * *", "@yearly"}
+	for _, expr := range invalid {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) did not return an error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2022-06-03 is a friday
+	from := time.Date(2022, 6, 3, 19, 30, 0, 0, time.Local)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 19 * * mon-fri", time.Date(2022, 6, 6, 19, 0, 0, 0, time.Local)},
+		{"0 8 * * mon-fri", time.Date(2022, 6, 6, 8, 0, 0, 0, time.Local)},
+		{"*/15 * * * *", time.Date(2022, 6, 3, 19, 45, 0, 0, time.Local)},
+		{"30 19 * * *", time.Date(2022, 6, 4, 19, 30, 0, 0, time.Local)},
+		{"0 0 * * sun", time.Date(2022, 6, 5, 0, 0, 0, 0, time.Local)},
+		{"0 0 * * 7", time.Date(2022, 6, 5, 0, 0, 0, 0, time.Local)},
+		// day of month and day of week are ORed when both are restricted
+		{"0 12 10 * sat", time.Date(2022, 6, 4, 12, 0, 0, 0, time.Local)},
+		{"0 0 1 jan *", time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local)},
+		{"0 0 31 feb *", time.Time{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			c, err := ParseCron(tc.expr)
+			if err != nil {
+				t.Fatalf("ParseCron: %v", err)
+			}
+			if got := c.Next(from); !got.Equal(tc.want) {
+				t.Errorf("Next(%s) = %s, want %s", from, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNextStop(t *testing.T) {
+	now := time.Date(2022, 6, 3, 17, 0, 0, 0, time.Local)
+	cc := &config.ClusterConfig{}
+	if _, ok := nextStop(cc, now, true); ok {
+		t.Errorf("NextStop returned a stop for a profile without schedules")
+	}
+
+	cc.RecurringSchedules = []config.RecurringSchedule{
+		{ID: 1, Action: ActionStart, Cron: "0 18 * * *"},
+		{ID: 2, Action: ActionStop, Cron: "0 19 * * mon-fri"},
+	}
+	want := time.Date(2022, 6, 3, 19, 0, 0, 0, time.Local)
+	if got, ok := nextStop(cc, now, true); !ok || !got.Equal(want) {
+		t.Errorf("nextStop() = %s, %v, want %s", got, ok, want)
+	}
+
+	// an earlier one-shot scheduled stop wins, an expired one is ignored
+	cc.ScheduledStop = &config.ScheduledStopConfig{InitiationTime: now.Unix(), Duration: 30 * time.Minute}
+	want = now.Add(30 * time.Minute)
+	if got, ok := nextStop(cc, now, true); !ok || !got.Equal(want) {
+		t.Errorf("nextStop() = %s, %v, want %s", got, ok, want)
+	}
+	cc.ScheduledStop.InitiationTime = now.Add(-time.Hour).Unix()
+	want = time.Date(2022, 6, 3, 19, 0, 0, 0, time.Local)
+	if got, ok := nextStop(cc, now, true); !ok || !got.Equal(want) {
+		t.Errorf("nextStop() = %s, %v, want %s", got, ok, want)
+	}
+
+	// recurring stops do not happen while the schedule daemon is not running
+	if got, ok := nextStop(cc, now, false); ok {
+		t.Errorf("nextStop() = %s without the schedule daemon, want no stop", got)
+	}
+}
+
+func TestDaemonRunning(t *testing.T) {
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+	if DaemonRunning("p1") {
+		t.Errorf("DaemonRunning() = true without a PID file")
+	}
+
+	pidFile := localpath.SchedulePID("p1")
+	if err := os.MkdirAll(filepath.Dir(pidFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if !DaemonRunning("p1") {
+		t.Errorf("DaemonRunning() = false for a running process")
+	}
+
+	// a daemon which did not survive a reboot of the host leaves a stale PID file behind
+	if err := os.WriteFile(pidFile, []byte("-1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if DaemonRunning("p1") {
+		t.Errorf("DaemonRunning() = true for a stale PID file")
+	}
+}
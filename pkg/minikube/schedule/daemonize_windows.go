@@ -26,8 +26,10 @@ import (
 	"github.com/pkg/errors"
 	"k8s.io/klog/v2"
 	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/config"
 	"k8s.io/minikube/pkg/minikube/constants"
 	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/mustload"
 	"k8s.io/minikube/pkg/minikube/sysinit"
 )
 
@@ -37,6 +39,11 @@ func KillExisting(profiles []string) {
 		if err := killExisting(profile); err != nil {
 			klog.Errorf("error terminating scheduled stop for profile %s: %v", profile, err)
 		}
+		_, cc := mustload.Partial(profile)
+		cc.ScheduledStop = nil
+		if err := config.SaveProfile(profile, cc); err != nil {
+			klog.Errorf("error saving profile for profile %s: %v", profile, err)
+		}
 	}
 }
 
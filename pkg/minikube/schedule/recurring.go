@@ -0,0 +1,296 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+const (
+	// ActionStop stops a profile
+	ActionStop = "stop"
+	// ActionStart starts a profile
+	ActionStart = "start"
+
+	// pollInterval is how often the daemon reloads the profile config, so that cancelled schedules,
+	// deleted profiles, clock changes and resuming from sleep are picked up without signalling the daemon
+	pollInterval = time.Minute
+)
+
+// ErrScheduleNotFound is returned when cancelling a recurring schedule which does not exist
+type ErrScheduleNotFound struct {
+	ID int
+}
+
+func (e *ErrScheduleNotFound) Error() string {
+	return fmt.Sprintf("recurring schedule %d does not exist", e.ID)
+}
+
+// AddRecurring adds a recurring schedule to the profile and restarts its schedule daemon
+func AddRecurring(profile string, cc *config.ClusterConfig, action string, expr string) (*config.RecurringSchedule, error) {
+	if action != ActionStop && action != ActionStart {
+		return nil, fmt.Errorf("invalid action %q, valid actions are: [%s %s]", action, ActionStop, ActionStart)
+	}
+	if _, err := ParseCron(expr); err != nil {
+		return nil, err
+	}
+
+	id := 1
+	for _, s := range cc.RecurringSchedules {
+		if s.ID >= id {
+			id = s.ID + 1
+		}
+	}
+	s := config.RecurringSchedule{ID: id, Action: action, Cron: expr}
+	cc.RecurringSchedules = append(cc.RecurringSchedules, s)
+	if err := config.SaveProfile(profile, cc); err != nil {
+		return nil, errors.Wrap(err, "saving profile")
+	}
+	if err := RestartDaemon(profile); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CancelRecurring removes recurring schedules from the profile, all of them if no IDs are given.
+// The schedule daemon exits once no schedules are left.
+func CancelRecurring(profile string, cc *config.ClusterConfig, ids ...int) error {
+	keep := []config.RecurringSchedule{}
+	if len(ids) > 0 {
+		for _, id := range ids {
+			found := false
+			for _, s := range cc.RecurringSchedules {
+				if s.ID == id {
+					found = true
+				}
+			}
+			if !found {
+				return &ErrScheduleNotFound{ID: id}
+			}
+		}
+		for _, s := range cc.RecurringSchedules {
+			if !containsID(ids, s.ID) {
+				keep = append(keep, s)
+			}
+		}
+	}
+	cc.RecurringSchedules = keep
+	if err := config.SaveProfile(profile, cc); err != nil {
+		return errors.Wrap(err, "saving profile")
+	}
+	return RestartDaemon(profile)
+}
+
+func containsID(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// NextRun returns the next run of a recurring schedule after t, or the zero time if the schedule is invalid or never runs
+func NextRun(s config.RecurringSchedule, t time.Time) time.Time {
+	c, err := ParseCron(s.Cron)
+	if err != nil {
+		klog.Warningf("invalid recurring schedule %d %q: %v", s.ID, s.Cron, err)
+		return time.Time{}
+	}
+	return c.Next(t)
+}
+
+// nextRecurring returns the recurring schedule of the given action which runs next after t, any action if action is empty
+func nextRecurring(schedules []config.RecurringSchedule, action string, t time.Time) (time.Time, *config.RecurringSchedule) {
+	var next time.Time
+	var found *config.RecurringSchedule
+	for i, s := range schedules {
+		if action != "" && s.Action != action {
+			continue
+		}
+		n := NextRun(s, t)
+		if n.IsZero() {
+			continue
+		}
+		if found == nil || n.Before(next) {
+			next = n
+			found = &schedules[i]
+		}
+	}
+	return next, found
+}
+
+// NextStop returns when the profile is going to be stopped next, by either a scheduled or a recurring stop.
+// Recurring stops are only run by the schedule daemon, so they are ignored while it is not running.
+func NextStop(cc *config.ClusterConfig, now time.Time) (time.Time, bool) {
+	return nextStop(cc, now, DaemonRunning(cc.Name))
+}
+
+func nextStop(cc *config.ClusterConfig, now time.Time, daemon bool) (time.Time, bool) {
+	var next time.Time
+	ok := false
+	if daemon {
+		var s *config.RecurringSchedule
+		next, s = nextRecurring(cc.RecurringSchedules, ActionStop, now)
+		ok = s != nil
+	}
+	if cc.ScheduledStop != nil {
+		at := time.Unix(cc.ScheduledStop.InitiationTime, 0).Add(cc.ScheduledStop.Duration)
+		if at.After(now) && (!ok || at.Before(next)) {
+			next = at
+			ok = true
+		}
+	}
+	return next, ok
+}
+
+// EnsureDaemon starts the schedule daemon of the profile if it has recurring schedules and the daemon is not running,
+// such as after a reboot of the host
+func EnsureDaemon(profile string) error {
+	if DaemonRunning(profile) {
+		return nil
+	}
+	return RestartDaemon(profile)
+}
+
+// DaemonRunning returns whether the schedule daemon of the profile is running
+func DaemonRunning(profile string) bool {
+	b, err := os.ReadFile(localpath.SchedulePID(profile))
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(string(b))
+	if err != nil {
+		klog.Warningf("invalid schedule daemon PID %q: %v", b, err)
+		return false
+	}
+	return processAlive(pid)
+}
+
+// RestartDaemon stops the schedule daemon of the profile, and starts a new one if it has recurring schedules
+func RestartDaemon(profile string) error {
+	if err := killDaemon(profile); err != nil {
+		klog.Warningf("error killing schedule daemon for profile %s: %v", profile, err)
+	}
+	cc, err := config.Load(profile)
+	if err != nil {
+		return errors.Wrap(err, "loading profile")
+	}
+	if len(cc.RecurringSchedules) == 0 {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "finding minikube executable")
+	}
+	logFile, err := os.OpenFile(localpath.ScheduleLog(profile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "opening schedule log")
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, "schedule", "daemon", "--profile", profile)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "starting schedule daemon")
+	}
+	klog.Infof("started schedule daemon for profile %s with PID %d", profile, cmd.Process.Pid)
+	if err := os.WriteFile(localpath.SchedulePID(profile), []byte(strconv.Itoa(cmd.Process.Pid)), 0600); err != nil {
+		return errors.Wrap(err, "writing schedule daemon PID file")
+	}
+	return cmd.Process.Release()
+}
+
+// killDaemon kills the schedule daemon of the profile using its PID file
+func killDaemon(profile string) error {
+	file := localpath.SchedulePID(profile)
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", file)
+	}
+	defer func() {
+		if err := os.Remove(file); err != nil {
+			klog.Errorf("error deleting %s: %v, you may have to delete in manually", file, err)
+		}
+	}()
+	pid, err := strconv.Atoi(string(b))
+	if err != nil {
+		return errors.Wrapf(err, "converting %s to int", b)
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return errors.Wrap(err, "finding process")
+	}
+	klog.Infof("killing schedule daemon %d of profile %s", pid, profile)
+	if err := p.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return errors.Wrapf(err, "killing %d", pid)
+	}
+	return nil
+}
+
+// RunDaemon runs the recurring schedules of the profile until none are left or the profile is deleted
+func RunDaemon(profile string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "finding minikube executable")
+	}
+	for {
+		cc, err := config.Load(profile)
+		if err != nil {
+			if config.IsNotExist(err) {
+				klog.Infof("profile %s was deleted, exiting", profile)
+				return nil
+			}
+			return errors.Wrap(err, "loading profile")
+		}
+		next, s := nextRecurring(cc.RecurringSchedules, "", time.Now())
+		if s == nil {
+			klog.Infof("no recurring schedules left for profile %s, exiting", profile)
+			return nil
+		}
+
+		wait := time.Until(next)
+		if wait > pollInterval {
+			time.Sleep(pollInterval)
+			continue
+		}
+		time.Sleep(wait)
+
+		klog.Infof("running recurring schedule %d of profile %s: %s %q", s.ID, profile, s.Action, s.Cron)
+		cmd := exec.Command(exe, s.Action, "--profile", profile)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			klog.Errorf("%s of profile %s failed: %v", s.Action, profile, err)
+		}
+	}
+}
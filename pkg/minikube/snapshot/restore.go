@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/etcd"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+// Restore loads the images of a snapshot into every node of a running cluster, then replaces its etcd data with the one of the snapshot.
+// The etcd member is restored with the name and IP of the control plane of cc, so the cluster may differ from the snapshotted one.
+func Restore(api libmachine.API, cc *config.ClusterConfig, name string, timeout time.Duration) error {
+	m, err := Load(name)
+	if err != nil {
+		return err
+	}
+	if err := Compatible(m, cc); err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp(localpath.Snapshots(), "."+name+"-")
+	if err != nil {
+		return errors.Wrap(err, "creating staging dir")
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			klog.Warningf("failed to remove %s: %v", dir, err)
+		}
+	}()
+	if err := extract(name, dir); err != nil {
+		return errors.Wrap(err, "extracting snapshot")
+	}
+
+	for i, n := range cc.Nodes {
+		runner, err := nodeRunner(api, *cc, n)
+		if err != nil {
+			return err
+		}
+		if err := restoreNode(runner, cc, m.Nodes[i], nodeDir(dir, i)); err != nil {
+			return errors.Wrapf(err, "node %s", config.MachineName(*cc, n))
+		}
+	}
+	if err := etcd.RestoreFile(api, cc, filepath.Join(dir, etcdFile), timeout); err != nil {
+		return errors.Wrap(err, "etcd")
+	}
+
+	// keep the addon state consistent with the restored etcd data
+	sc, err := LoadConfig(name)
+	if err != nil {
+		return err
+	}
+	if cc.Addons == nil {
+		cc.Addons = map[string]bool{}
+	}
+	for addon, enabled := range sc.Addons {
+		cc.Addons[addon] = enabled
+	}
+	return config.SaveProfile(cc.Name, cc)
+}
+
+// restoreNode loads the images captured from a node
+func restoreNode(runner command.Runner, cc *config.ClusterConfig, nm NodeManifest, dir string) error {
+	cr, err := cruntime.New(cruntime.Config{Type: cc.KubernetesConfig.ContainerRuntime, Runner: runner})
+	if err != nil {
+		return errors.Wrap(err, "runtime")
+	}
+
+	if _, err := runner.RunCmd(exec.Command("sudo", "mkdir", "-p", guestDir)); err != nil {
+		return errors.Wrap(err, "creating staging dir")
+	}
+	defer func() {
+		if _, err := runner.RunCmd(exec.Command("sudo", "rm", "-rf", guestDir)); err != nil {
+			klog.Warningf("failed to remove %s: %v", guestDir, err)
+		}
+	}()
+
+	for _, img := range nm.Images {
+		dst, err := copyTo(runner, filepath.Join(dir, imagesDir, imageFile(img)))
+		if err != nil {
+			return errors.Wrapf(err, "transferring %s", img)
+		}
+		if err := cr.LoadImage(dst); err != nil {
+			return errors.Wrapf(err, "%s load %s", cr.Name(), img)
+		}
+	}
+	return nil
+}
+
+// copyTo copies a snapshot file into the staging dir of the guest, returning the guest path
+func copyTo(runner command.Runner, src string) (string, error) {
+	f, err := assets.NewFileAsset(src, guestDir, filepath.Base(src), "0644")
+	if err != nil {
+		return "", errors.Wrapf(err, "creating copyable file asset: %s", src)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			klog.Warningf("error closing the file %s: %v", f.GetSourcePath(), err)
+		}
+	}()
+
+	if err := runner.Copy(f); err != nil {
+		return "", err
+	}
+	return path.Join(guestDir, filepath.Base(src)), nil
+}
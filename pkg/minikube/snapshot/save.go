@@ -0,0 +1,226 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/docker/machine/libmachine/state"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/images"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/etcd"
+	"k8s.io/minikube/pkg/minikube/localpath"
+	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/vmpath"
+	"k8s.io/minikube/pkg/version"
+)
+
+// guestDir is where snapshot data is staged within the guest
+var guestDir = path.Join(vmpath.GuestPersistentDir, "snapshot")
+
+// Save captures the config, etcd data and images of every node of a running cluster into the archive of a snapshot
+func Save(api libmachine.API, cc *config.ClusterConfig, name string) (*Manifest, error) {
+	if Exists(name) {
+		return nil, fmt.Errorf("snapshot %q already exists", name)
+	}
+	if err := etcd.Supported(cc); err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Version:           ManifestVersion,
+		Name:              name,
+		Profile:           cc.Name,
+		Created:           time.Now(),
+		MinikubeVersion:   version.GetVersion(),
+		Driver:            cc.Driver,
+		KubernetesVersion: cc.KubernetesConfig.KubernetesVersion,
+		ContainerRuntime:  cc.KubernetesConfig.ContainerRuntime,
+	}
+
+	if err := os.MkdirAll(localpath.Snapshots(), 0755); err != nil {
+		return nil, errors.Wrap(err, "mkdir")
+	}
+	// the data is staged next to the archive, which is only written once everything was captured
+	dir, err := os.MkdirTemp(localpath.Snapshots(), "."+name+"-")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating staging dir")
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			klog.Warningf("failed to remove %s: %v", dir, err)
+		}
+	}()
+
+	for i, n := range cc.Nodes {
+		runner, err := nodeRunner(api, *cc, n)
+		if err != nil {
+			return nil, err
+		}
+		nm, err := saveNode(runner, cc, n, nodeDir(dir, i))
+		if err != nil {
+			return nil, errors.Wrapf(err, "node %s", config.MachineName(*cc, n))
+		}
+		m.Nodes = append(m.Nodes, *nm)
+	}
+	if err := etcd.SaveFile(api, cc, filepath.Join(dir, etcdFile)); err != nil {
+		return nil, errors.Wrap(err, "etcd")
+	}
+
+	if err := writeJSON(filepath.Join(dir, configFile), cc); err != nil {
+		return nil, errors.Wrap(err, "writing config")
+	}
+	if err := writeJSON(filepath.Join(dir, manifestFile), m); err != nil {
+		return nil, errors.Wrap(err, "writing manifest")
+	}
+	if err := writeArchive(dir, localpath.Snapshot(name)); err != nil {
+		return nil, errors.Wrap(err, "writing archive")
+	}
+	return m, nil
+}
+
+// nodeRunner returns a command runner for a node which must be running
+func nodeRunner(api libmachine.API, cc config.ClusterConfig, n config.Node) (command.Runner, error) {
+	machineName := config.MachineName(cc, n)
+	st, err := machine.Status(api, machineName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "status of %s", machineName)
+	}
+	if st != state.Running.String() {
+		return nil, fmt.Errorf("node %s is not running (state=%s)", machineName, st)
+	}
+
+	h, err := machine.LoadHost(api, machineName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading host %s", machineName)
+	}
+	return machine.CommandRunner(h)
+}
+
+// saveNode captures the user images of a node into dir
+func saveNode(runner command.Runner, cc *config.ClusterConfig, n config.Node, dir string) (*NodeManifest, error) {
+	nm := &NodeManifest{Name: n.Name, ControlPlane: n.ControlPlane, Worker: n.Worker}
+
+	cr, err := cruntime.New(cruntime.Config{Type: cc.KubernetesConfig.ContainerRuntime, Runner: runner})
+	if err != nil {
+		return nil, errors.Wrap(err, "runtime")
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, imagesDir), 0755); err != nil {
+		return nil, errors.Wrap(err, "mkdir")
+	}
+	if _, err := runner.RunCmd(exec.Command("sudo", "mkdir", "-p", guestDir)); err != nil {
+		return nil, errors.Wrap(err, "creating staging dir")
+	}
+	defer func() {
+		if _, err := runner.RunCmd(exec.Command("sudo", "rm", "-rf", guestDir)); err != nil {
+			klog.Warningf("failed to remove %s: %v", guestDir, err)
+		}
+	}()
+
+	imgs, err := userImages(cr, cc.KubernetesConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing images")
+	}
+	for _, img := range imgs {
+		src := path.Join(guestDir, imageFile(img))
+		if err := cr.SaveImage(img, src); err != nil {
+			return nil, errors.Wrapf(err, "%s save %s", cr.Name(), img)
+		}
+		if err := copyFrom(runner, src, filepath.Join(dir, imagesDir, imageFile(img))); err != nil {
+			return nil, errors.Wrapf(err, "transferring %s", img)
+		}
+		if _, err := runner.RunCmd(exec.Command("sudo", "rm", "-f", src)); err != nil {
+			klog.Warningf("failed to remove %s: %v", src, err)
+		}
+		nm.Images = append(nm.Images, img)
+	}
+
+	return nm, nil
+}
+
+// userImages returns the tagged images of a node which are not part of the Kubernetes bootstrap set
+func userImages(cr cruntime.Manager, k8s config.KubernetesConfig) ([]string, error) {
+	list, err := cr.ListImages(cruntime.ListImagesOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	skip := map[string]bool{}
+	if bs, err := images.Kubeadm(k8s.ImageRepository, k8s.KubernetesVersion); err == nil {
+		for _, img := range bs {
+			skip[img] = true
+		}
+	} else {
+		klog.Warningf("unable to list bootstrap images, capturing everything: %v", err)
+	}
+
+	seen := map[string]bool{}
+	imgs := []string{}
+	for _, li := range list {
+		for _, tag := range li.RepoTags {
+			if tag == "" || tag == "<none>:<none>" || skip[tag] || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			imgs = append(imgs, tag)
+		}
+	}
+	return imgs, nil
+}
+
+// copyFrom copies a file from the guest to the host
+func copyFrom(runner command.Runner, src string, dst string) error {
+	tf, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err := tf.Close(); err != nil {
+		return err
+	}
+
+	f, err := assets.NewFileAsset(dst, path.Dir(src), path.Base(src), "0644")
+	if err != nil {
+		return errors.Wrapf(err, "creating copyable file asset: %s", dst)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			klog.Warningf("error closing the file %s: %v", f.GetSourcePath(), err)
+		}
+	}()
+	return runner.CopyFrom(f)
+}
+
+func writeJSON(file string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0644)
+}
@@ -0,0 +1,373 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package snapshot captures the state of a minikube profile and restores it into another profile
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+// ManifestVersion is the version of the snapshot layout written by this version of minikube
+const ManifestVersion = 1
+
+const (
+	// archiveExt is the extension of the archive of a snapshot, see localpath.Snapshot
+	archiveExt = ".tar.gz"
+	// manifestFile is the first entry of the archive, so that it can be listed without reading the images
+	manifestFile = "manifest.json"
+	configFile   = "config.json"
+	etcdFile     = "etcd.db"
+	imagesDir    = "images"
+	nodesDir     = "nodes"
+)
+
+// NodeManifest describes what was captured from a single node
+type NodeManifest struct {
+	Name         string
+	ControlPlane bool
+	Worker       bool
+	// Images are the image references captured from the node's container runtime
+	Images []string
+}
+
+// Manifest describes the contents of a snapshot
+type Manifest struct {
+	Version           int
+	Name              string
+	Profile           string
+	Created           time.Time
+	MinikubeVersion   string
+	Driver            string
+	KubernetesVersion string
+	ContainerRuntime  string
+	Nodes             []NodeManifest
+}
+
+// IncompatibleError is returned when a snapshot cannot be restored into a cluster
+type IncompatibleError struct {
+	Snapshot string
+	Reasons  []string
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("snapshot %q is incompatible: %s", e.Snapshot, strings.Join(e.Reasons, "; "))
+}
+
+// IsIncompatible returns whether the error is an IncompatibleError
+func IsIncompatible(err error) bool {
+	_, ok := errors.Cause(err).(*IncompatibleError)
+	return ok
+}
+
+// ErrNotExist is returned when the requested snapshot does not exist
+type ErrNotExist struct {
+	Name string
+}
+
+func (e *ErrNotExist) Error() string {
+	return fmt.Sprintf("snapshot %q does not exist", e.Name)
+}
+
+// IsNotExist returns whether the error is an ErrNotExist
+func IsNotExist(err error) bool {
+	_, ok := errors.Cause(err).(*ErrNotExist)
+	return ok
+}
+
+// NameValid returns whether the name can be used as a snapshot name
+func NameValid(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	return !strings.ContainsAny(name, `/\:`)
+}
+
+// Exists returns whether a snapshot with the given name exists
+func Exists(name string) bool {
+	_, err := os.Stat(localpath.Snapshot(name))
+	return err == nil
+}
+
+// Load reads the manifest of a snapshot
+func Load(name string) (*Manifest, error) {
+	m := &Manifest{}
+	if err := readJSON(name, manifestFile, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LoadConfig reads the cluster config captured in a snapshot
+func LoadConfig(name string) (*config.ClusterConfig, error) {
+	cc := &config.ClusterConfig{}
+	if err := readJSON(name, configFile, cc); err != nil {
+		return nil, err
+	}
+	return cc, nil
+}
+
+// List returns the manifests of all snapshots, oldest first
+func List() ([]*Manifest, error) {
+	entries, err := os.ReadDir(localpath.Snapshots())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Manifest{}, nil
+		}
+		return nil, err
+	}
+
+	ms := []*Manifest{}
+	for _, e := range entries {
+		// snapshots being saved are staged in hidden directories
+		if e.IsDir() || !strings.HasSuffix(e.Name(), archiveExt) {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), archiveExt)
+		m, err := Load(name)
+		if err != nil {
+			klog.Warningf("skipping invalid snapshot %q: %v", name, err)
+			continue
+		}
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Created.Before(ms[j].Created) })
+	return ms, nil
+}
+
+// Delete removes a snapshot from disk
+func Delete(name string) error {
+	if !Exists(name) {
+		return &ErrNotExist{Name: name}
+	}
+	return os.Remove(localpath.Snapshot(name))
+}
+
+// Compatible returns an IncompatibleError if the snapshot cannot be restored into the cluster
+func Compatible(m *Manifest, cc *config.ClusterConfig) error {
+	var reasons []string
+
+	if m.Version > ManifestVersion {
+		reasons = append(reasons, fmt.Sprintf("snapshot format v%d is newer than the supported v%d", m.Version, ManifestVersion))
+	}
+	if m.KubernetesVersion != cc.KubernetesConfig.KubernetesVersion {
+		reasons = append(reasons, fmt.Sprintf("Kubernetes version %s does not match %s", m.KubernetesVersion, cc.KubernetesConfig.KubernetesVersion))
+	}
+	if m.ContainerRuntime != cc.KubernetesConfig.ContainerRuntime {
+		reasons = append(reasons, fmt.Sprintf("container runtime %s does not match %s", m.ContainerRuntime, cc.KubernetesConfig.ContainerRuntime))
+	}
+	cps := 0
+	for _, n := range m.Nodes {
+		if n.ControlPlane {
+			cps++
+		}
+	}
+	if cps > 1 {
+		reasons = append(reasons, fmt.Sprintf("etcd can only be restored with a single control plane, the snapshot has %d", cps))
+	}
+	if len(m.Nodes) != len(cc.Nodes) {
+		reasons = append(reasons, fmt.Sprintf("snapshot has %d node(s), cluster has %d", len(m.Nodes), len(cc.Nodes)))
+	} else {
+		for i, n := range m.Nodes {
+			if n.ControlPlane != cc.Nodes[i].ControlPlane {
+				reasons = append(reasons, fmt.Sprintf("node %d control-plane role differs (snapshot: %t, cluster: %t)", i, n.ControlPlane, cc.Nodes[i].ControlPlane))
+			}
+		}
+	}
+
+	if len(reasons) > 0 {
+		return &IncompatibleError{Snapshot: m.Name, Reasons: reasons}
+	}
+	return nil
+}
+
+// nodeDir returns the directory holding the data of the i'th node, within the directory a snapshot is staged in
+func nodeDir(dir string, i int) string {
+	return filepath.Join(dir, nodesDir, fmt.Sprintf("%d", i))
+}
+
+// imageFile returns a flat file name for an image reference
+func imageFile(img string) string {
+	r := strings.NewReplacer("/", "_", ":", "_", "@", "_")
+	return r.Replace(img) + ".tar"
+}
+
+// readJSON decodes the entry of the archive of a snapshot into v
+func readJSON(name string, entry string, v interface{}) error {
+	f, err := os.Open(localpath.Snapshot(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ErrNotExist{Name: name}
+		}
+		return errors.Wrap(err, "opening snapshot")
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.Wrapf(err, "reading snapshot %q", name)
+	}
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return errors.Errorf("%s is missing from snapshot %q", entry, name)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "reading snapshot %q", name)
+		}
+		if hdr.Name != entry {
+			continue
+		}
+		if err := json.NewDecoder(tr).Decode(v); err != nil {
+			return errors.Wrapf(err, "decoding %s of %q", entry, name)
+		}
+		return nil
+	}
+}
+
+// writeArchive writes the files staged in dir into the archive dst, the manifest first
+func writeArchive(dir string, dst string) error {
+	files := []string{manifestFile, configFile, etcdFile}
+	err := filepath.WalkDir(filepath.Join(dir, nodesDir), func(p string, d fs.DirEntry, err error) error {
+		// a cluster without user images has no files to add
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "listing images")
+	}
+
+	// the archive is written next to dst, so that a partial archive is never listed
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	gw := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(gw)
+	for _, rel := range files {
+		if err := addFile(tw, dir, rel); err != nil {
+			return errors.Wrapf(err, "adding %s", rel)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func addFile(tw *tar.Writer, dir string, rel string) error {
+	f, err := os.Open(filepath.Join(dir, rel))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: filepath.ToSlash(rel), Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// extract extracts the archive of a snapshot into dir
+func extract(name string, dir string) error {
+	f, err := os.Open(localpath.Snapshot(name))
+	if err != nil {
+		return errors.Wrap(err, "opening snapshot")
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !validEntry(hdr.Name) {
+			return errors.Errorf("invalid path in the archive: %s", hdr.Name)
+		}
+		if err := extractFile(tr, filepath.Join(dir, filepath.FromSlash(hdr.Name))); err != nil {
+			return errors.Wrapf(err, "extracting %s", hdr.Name)
+		}
+	}
+}
+
+func extractFile(r io.Reader, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// validEntry returns whether p is a relative path which stays within the directory the archive is extracted in
+func validEntry(p string) bool {
+	return p != "" && path.Clean(p) == p && !path.IsAbs(p) && p != ".." && !strings.HasPrefix(p, "../") && !strings.Contains(p, ":")
+}
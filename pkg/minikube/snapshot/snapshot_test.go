@@ -0,0 +1,218 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+func TestCompatible(t *testing.T) {
+	m := &Manifest{
+		Version:           ManifestVersion,
+		Name:              "snap",
+		KubernetesVersion: "v1.23.6",
+		ContainerRuntime:  "docker",
+		Nodes:             []NodeManifest{{Name: "", ControlPlane: true, Worker: true}, {Name: "m02", Worker: true}},
+	}
+
+	tests := []struct {
+		description  string
+		modify       func(cc *config.ClusterConfig)
+		incompatible bool
+	}{
+		{"same cluster", func(cc *config.ClusterConfig) {}, false},
+		{"different version", func(cc *config.ClusterConfig) { cc.KubernetesConfig.KubernetesVersion = "v1.24.1" }, true},
+		{"different runtime", func(cc *config.ClusterConfig) { cc.KubernetesConfig.ContainerRuntime = "containerd" }, true},
+		{"fewer nodes", func(cc *config.ClusterConfig) { cc.Nodes = cc.Nodes[:1] }, true},
+		{"different roles", func(cc *config.ClusterConfig) { cc.Nodes[1].ControlPlane = true }, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			cc := &config.ClusterConfig{
+				KubernetesConfig: config.KubernetesConfig{KubernetesVersion: "v1.23.6", ContainerRuntime: "docker"},
+				Nodes:            []config.Node{{Name: "", ControlPlane: true, Worker: true}, {Name: "m02", Worker: true}},
+			}
+			tc.modify(cc)
+			err := Compatible(m, cc)
+			if IsIncompatible(err) != tc.incompatible {
+				t.Errorf("Compatible() = %v, want incompatible: %t", err, tc.incompatible)
+			}
+		})
+	}
+
+	newer := *m
+	newer.Version = ManifestVersion + 1
+	cc := &config.ClusterConfig{
+		KubernetesConfig: config.KubernetesConfig{KubernetesVersion: "v1.23.6", ContainerRuntime: "docker"},
+		Nodes:            []config.Node{{ControlPlane: true}, {}},
+	}
+	if err := Compatible(&newer, cc); !IsIncompatible(err) {
+		t.Errorf("expected newer manifest version to be refused, got %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+
+	ms, err := List()
+	if err != nil {
+		t.Fatalf("List() on missing dir: %v", err)
+	}
+	if len(ms) != 0 {
+		t.Fatalf("expected no snapshots, got %d", len(ms))
+	}
+
+	now := time.Now()
+	for i, name := range []string{"newer", "older"} {
+		writeSnapshot(t, &Manifest{Version: ManifestVersion, Name: name, Created: now.Add(-time.Duration(i) * time.Hour)}, map[string]string{})
+	}
+	// a snapshot being saved is staged in a hidden directory, and must not be listed
+	if err := os.MkdirAll(filepath.Join(localpath.Snapshots(), ".partial-123"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ms, err = List()
+	if err != nil {
+		t.Fatalf("List(): %v", err)
+	}
+	if len(ms) != 2 || ms[0].Name != "older" || ms[1].Name != "newer" {
+		t.Errorf("unexpected snapshots: %+v", ms)
+	}
+
+	if _, err := Load("partial"); !IsNotExist(err) {
+		t.Errorf("Load(partial) = %v, want ErrNotExist", err)
+	}
+	if err := Delete("older"); err != nil {
+		t.Errorf("Delete(older): %v", err)
+	}
+	if Exists("older") {
+		t.Errorf("snapshot still exists after Delete")
+	}
+}
+
+func TestArchive(t *testing.T) {
+	t.Setenv(localpath.MinikubeHome, t.TempDir())
+
+	img := filepath.Join(nodesDir, "1", imagesDir, imageFile("busybox:latest"))
+	m := &Manifest{Version: ManifestVersion, Name: "snap", Nodes: []NodeManifest{{ControlPlane: true}, {Name: "m02", Images: []string{"busybox:latest"}}}}
+	writeSnapshot(t, m, map[string]string{img: "image"})
+
+	got, err := Load("snap")
+	if err != nil {
+		t.Fatalf("Load(): %v", err)
+	}
+	if diff := cmp.Diff(m, got); diff != "" {
+		t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+	}
+	cc, err := LoadConfig("snap")
+	if err != nil {
+		t.Fatalf("LoadConfig(): %v", err)
+	}
+	if cc.Name != "source" {
+		t.Errorf("LoadConfig().Name = %q, want source", cc.Name)
+	}
+
+	dir := t.TempDir()
+	if err := extract("snap", dir); err != nil {
+		t.Fatalf("extract(): %v", err)
+	}
+	for file, want := range map[string]string{etcdFile: "etcd", img: "image"} {
+		b, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("reading %s: %v", file, err)
+		}
+		if string(b) != want {
+			t.Errorf("%s = %q, want %q", file, b, want)
+		}
+	}
+}
+
+func TestValidEntry(t *testing.T) {
+	tests := map[string]bool{
+		"":                        false,
+		"..":                      false,
+		"../config.json":          false,
+		"/etc/passwd":             false,
+		"nodes/../../etc":         false,
+		"c:/windows":              false,
+		"manifest.json":           true,
+		"nodes/0/images/busy.tar": true,
+	}
+	for p, want := range tests {
+		if got := validEntry(p); got != want {
+			t.Errorf("validEntry(%q) = %t, want %t", p, got, want)
+		}
+	}
+}
+
+// writeSnapshot writes the archive of a snapshot with an etcd file, and the extra files
+func writeSnapshot(t *testing.T, m *Manifest, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := writeJSON(filepath.Join(dir, manifestFile), m); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeJSON(filepath.Join(dir, configFile), &config.ClusterConfig{Name: "source"}); err != nil {
+		t.Fatal(err)
+	}
+	files[etcdFile] = "etcd"
+	for file, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(localpath.Snapshots(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeArchive(dir, localpath.Snapshot(m.Name)); err != nil {
+		t.Fatalf("writeArchive(): %v", err)
+	}
+}
+
+func TestNameValid(t *testing.T) {
+	tests := map[string]bool{
+		"":             false,
+		"..":           false,
+		"a/b":          false,
+		"c:":           false,
+		"seeded-db":    true,
+		"minikube-123": true,
+	}
+	for name, want := range tests {
+		if got := NameValid(name); got != want {
+			t.Errorf("NameValid(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestImageFile(t *testing.T) {
+	got := imageFile("gcr.io/k8s-minikube/busybox:latest")
+	want := "gcr.io_k8s-minikube_busybox_latest.tar"
+	if got != want {
+		t.Errorf("imageFile() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package top
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/minikube/pkg/kapi"
+)
+
+// podMetricsPath is the metrics.k8s.io API served by the metrics-server addon
+const podMetricsPath = "/apis/metrics.k8s.io/v1beta1/pods"
+
+// NamespaceUsage is the resource usage of the pods of a Kubernetes namespace
+type NamespaceUsage struct {
+	Profile   string `json:"profile"`
+	Namespace string `json:"namespace"`
+	Pods      int    `json:"pods"`
+	// CPUMillis is the CPU usage in thousandths of a CPU
+	CPUMillis   int64 `json:"cpuMillis"`
+	MemoryBytes int64 `json:"memoryBytes"`
+}
+
+// podMetricsList is the subset of metrics.k8s.io/v1beta1 PodMetricsList used by top
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Containers []struct {
+			Usage map[string]string `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// Namespaces returns the resource usage of each namespace of a profile, as reported by metrics-server
+func Namespaces(profile string) ([]*NamespaceUsage, error) {
+	client, err := kapi.Client(profile)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting Kubernetes client")
+	}
+	b, err := client.CoreV1().RESTClient().Get().AbsPath(podMetricsPath).DoRaw(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "getting pod metrics")
+	}
+	return parsePodMetrics(profile, b)
+}
+
+func parsePodMetrics(profile string, b []byte) ([]*NamespaceUsage, error) {
+	var l podMetricsList
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, errors.Wrap(err, "parsing pod metrics")
+	}
+	byNamespace := map[string]*NamespaceUsage{}
+	for _, pod := range l.Items {
+		ns := pod.Metadata.Namespace
+		u, ok := byNamespace[ns]
+		if !ok {
+			u = &NamespaceUsage{Profile: profile, Namespace: ns}
+			byNamespace[ns] = u
+		}
+		u.Pods++
+		for _, c := range pod.Containers {
+			if v, ok := c.Usage["cpu"]; ok {
+				q, err := resource.ParseQuantity(v)
+				if err != nil {
+					return nil, errors.Wrapf(err, "parsing cpu %q", v)
+				}
+				u.CPUMillis += q.MilliValue()
+			}
+			if v, ok := c.Usage["memory"]; ok {
+				q, err := resource.ParseQuantity(v)
+				if err != nil {
+					return nil, errors.Wrapf(err, "parsing memory %q", v)
+				}
+				u.MemoryBytes += q.Value()
+			}
+		}
+	}
+	us := []*NamespaceUsage{}
+	for _, u := range byNamespace {
+		us = append(us, u)
+	}
+	// busiest namespaces first
+	sort.Slice(us, func(i, j int) bool {
+		if us[i].CPUMillis != us[j].CPUMillis {
+			return us[i].CPUMillis > us[j].CPUMillis
+		}
+		return us[i].Namespace < us[j].Namespace
+	})
+	return us, nil
+}
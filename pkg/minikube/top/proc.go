@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package top
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"k8s.io/minikube/pkg/minikube/command"
+)
+
+// procScript prints the CPU times, the number of CPUs, the memory, the network counters and the usage of /var
+const procScript = `head -n 1 /proc/stat; nproc; grep -E '^(MemTotal|MemAvailable):' /proc/meminfo; tail -n +3 /proc/net/dev; df -B1 --output=size,used /var | tail -n 1`
+
+// procSnapshot is a single sample of the /proc counters of a node
+type procSnapshot struct {
+	// cpuTotal and cpuIdle are in clock ticks since boot
+	cpuTotal, cpuIdle   uint64
+	cpus                int
+	memTotal            int64
+	memAvailable        int64
+	netRx, netTx        int64
+	diskTotal, diskUsed int64
+}
+
+func readProc(cr command.Runner) (*procSnapshot, error) {
+	rr, err := cr.RunCmd(exec.Command("/bin/bash", "-c", procScript))
+	if err != nil {
+		return nil, err
+	}
+	return parseProc(rr.Stdout.String())
+}
+
+func parseProc(s string) (*procSnapshot, error) {
+	ps := &procSnapshot{}
+	sc := bufio.NewScanner(strings.NewReader(s))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "cpu":
+			// cpu user nice system idle iowait irq softirq steal ...
+			for i, f := range fields[1:] {
+				v, err := strconv.ParseUint(f, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("parsing %q: %v", line, err)
+				}
+				// guest times are already included in user and nice
+				if i < 8 {
+					ps.cpuTotal += v
+				}
+				if i == 3 || i == 4 {
+					ps.cpuIdle += v
+				}
+			}
+		case fields[0] == "MemTotal:" || fields[0] == "MemAvailable:":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("parsing %q", line)
+			}
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parsing %q: %v", line, err)
+			}
+			if fields[0] == "MemTotal:" {
+				ps.memTotal = kb * 1024
+			} else {
+				ps.memAvailable = kb * 1024
+			}
+		case strings.Contains(fields[0], ":"):
+			// iface: rx_bytes rx_packets ... (8 fields) tx_bytes ...
+			iface := strings.TrimSuffix(fields[0], ":")
+			stats := fields[1:]
+			if i := strings.Index(line, ":"); i > 0 && !strings.HasSuffix(fields[0], ":") {
+				// the counters may be glued to the interface name: "eth0:123 4 ..."
+				iface = line[:i]
+				stats = strings.Fields(line[i+1:])
+			}
+			if iface == "lo" || len(stats) < 9 {
+				continue
+			}
+			rx, err1 := strconv.ParseInt(stats[0], 10, 64)
+			tx, err2 := strconv.ParseInt(stats[8], 10, 64)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("parsing %q", line)
+			}
+			ps.netRx += rx
+			ps.netTx += tx
+		case len(fields) == 1:
+			n, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("parsing CPUs %q: %v", line, err)
+			}
+			ps.cpus = n
+		case len(fields) == 2:
+			total, err1 := strconv.ParseInt(fields[0], 10, 64)
+			used, err2 := strconv.ParseInt(fields[1], 10, 64)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("parsing disk usage %q", line)
+			}
+			ps.diskTotal, ps.diskUsed = total, used
+		}
+	}
+	if ps.cpuTotal == 0 || ps.memTotal == 0 {
+		return nil, fmt.Errorf("unexpected /proc output: %q", s)
+	}
+	return ps, sc.Err()
+}
+
+// cpuPercent returns the CPU usage between two samples as a percentage of a single CPU
+func cpuPercent(a, b *procSnapshot) float64 {
+	if b.cpuTotal <= a.cpuTotal {
+		return 0
+	}
+	total := float64(b.cpuTotal - a.cpuTotal)
+	busy := total - float64(b.cpuIdle-a.cpuIdle)
+	return busy / total * 100 * float64(b.cpus)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package top reports the resource usage of the nodes of minikube profiles
+package top
+
+import (
+	"sort"
+	"time"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/docker/machine/libmachine/state"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/drivers/kic/oci"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/driver"
+	"k8s.io/minikube/pkg/minikube/machine"
+)
+
+// sampleInterval is the time between the two /proc samples used to compute the CPU usage of VMs
+var sampleInterval = time.Second
+
+// NodeUsage is the resource usage of a node
+type NodeUsage struct {
+	Profile string `json:"profile"`
+	Node    string `json:"node"`
+	// CPUPercent is the CPU usage as a percentage of a single CPU, so it can exceed 100 on multiple CPUs
+	CPUPercent  float64 `json:"cpuPercent"`
+	CPUs        int     `json:"cpus"`
+	MemoryUsed  int64   `json:"memoryUsed"`
+	MemoryTotal int64   `json:"memoryTotal"`
+	// DiskUsed and DiskTotal are the usage of /var, where images and volumes are stored
+	DiskUsed  int64 `json:"diskUsed"`
+	DiskTotal int64 `json:"diskTotal"`
+	// NetRx and NetTx are the bytes received and sent since the node started
+	NetRx int64 `json:"netRx"`
+	NetTx int64 `json:"netTx"`
+}
+
+// target is a running node to collect the usage of
+type target struct {
+	usage  *NodeUsage
+	runner command.Runner
+	// ociBin is the container engine of kic nodes, empty for VMs, ssh and none
+	ociBin  string
+	machine string
+	first   *procSnapshot
+}
+
+// Nodes returns the resource usage of the running nodes of the given profiles
+func Nodes(api libmachine.API, profiles []*config.Profile) ([]*NodeUsage, error) {
+	ts := []*target{}
+	for _, p := range profiles {
+		if p.Config == nil {
+			continue
+		}
+		for _, n := range p.Config.Nodes {
+			t, err := newTarget(api, p.Config, n)
+			if err != nil {
+				klog.Warningf("skipping node %s of profile %s: %v", n.Name, p.Name, err)
+				continue
+			}
+			if t != nil {
+				ts = append(ts, t)
+			}
+		}
+	}
+
+	// take the first /proc sample of every node before sleeping once for all of them
+	vms := false
+	for _, t := range ts {
+		s, err := readProc(t.runner)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading /proc of %s", t.machine)
+		}
+		t.first = s
+		if t.ociBin == "" {
+			t.usage.CPUs = s.cpus
+		}
+		t.usage.MemoryUsed, t.usage.MemoryTotal = s.memTotal-s.memAvailable, s.memTotal
+		t.usage.DiskUsed, t.usage.DiskTotal = s.diskUsed, s.diskTotal
+		t.usage.NetRx, t.usage.NetTx = s.netRx, s.netTx
+		if t.ociBin == "" {
+			vms = true
+		}
+	}
+
+	if err := addContainerStats(ts); err != nil {
+		return nil, err
+	}
+
+	if vms {
+		time.Sleep(sampleInterval)
+		for _, t := range ts {
+			if t.ociBin != "" {
+				continue
+			}
+			s, err := readProc(t.runner)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading /proc of %s", t.machine)
+			}
+			t.usage.CPUPercent = cpuPercent(t.first, s)
+		}
+	}
+
+	us := []*NodeUsage{}
+	for _, t := range ts {
+		us = append(us, t.usage)
+	}
+	sort.SliceStable(us, func(i, j int) bool {
+		if us[i].Profile != us[j].Profile {
+			return us[i].Profile < us[j].Profile
+		}
+		return us[i].Node < us[j].Node
+	})
+	return us, nil
+}
+
+// newTarget returns the target of a node, or nil if the node is not running
+func newTarget(api libmachine.API, cc *config.ClusterConfig, n config.Node) (*target, error) {
+	name := config.MachineName(*cc, n)
+	st, err := machine.Status(api, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting status")
+	}
+	if st != state.Running.String() {
+		klog.Infof("skipping node %s: %s", name, st)
+		return nil, nil
+	}
+	host, err := machine.LoadHost(api, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading host")
+	}
+	cr, err := machine.CommandRunner(host)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting command runner")
+	}
+	t := &target{usage: &NodeUsage{Profile: cc.Name, Node: name}, runner: cr, machine: name}
+	if driver.IsKIC(cc.Driver) {
+		// the container sees all CPUs of the host, even if its usage is limited
+		t.ociBin = cc.Driver
+		t.usage.CPUs = cc.CPUs
+	}
+	return t, nil
+}
+
+// addContainerStats replaces the /proc usage of kic nodes with the usage of their containers, which honors the limits of the container
+func addContainerStats(ts []*target) error {
+	names := map[string][]string{}
+	for _, t := range ts {
+		if t.ociBin != "" {
+			names[t.ociBin] = append(names[t.ociBin], t.machine)
+		}
+	}
+	for bin, ns := range names {
+		stats, err := oci.Stats(bin, ns...)
+		if err != nil {
+			return errors.Wrapf(err, "%s stats", bin)
+		}
+		byName := map[string]oci.ContainerStats{}
+		for _, s := range stats {
+			byName[s.Name] = s
+		}
+		for _, t := range ts {
+			s, ok := byName[t.machine]
+			if t.ociBin != bin || !ok {
+				continue
+			}
+			t.usage.CPUPercent = s.CPUPercent
+			t.usage.MemoryUsed, t.usage.MemoryTotal = s.MemoryUsage, s.MemoryLimit
+			t.usage.NetRx, t.usage.NetTx = s.NetRx, s.NetTx
+		}
+	}
+	return nil
+}
+
+// Totals sums the usage of the nodes of each profile, with an empty node name
+func Totals(us []*NodeUsage) []*NodeUsage {
+	byProfile := map[string]*NodeUsage{}
+	totals := []*NodeUsage{}
+	for _, u := range us {
+		t, ok := byProfile[u.Profile]
+		if !ok {
+			t = &NodeUsage{Profile: u.Profile}
+			byProfile[u.Profile] = t
+			totals = append(totals, t)
+		}
+		t.CPUPercent += u.CPUPercent
+		t.CPUs += u.CPUs
+		t.MemoryUsed += u.MemoryUsed
+		t.MemoryTotal += u.MemoryTotal
+		t.DiskUsed += u.DiskUsed
+		t.DiskTotal += u.DiskTotal
+		t.NetRx += u.NetRx
+		t.NetTx += u.NetTx
+	}
+	return totals
+}
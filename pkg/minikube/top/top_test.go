@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package top
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const procOutput = `cpu  1000 50 400 8000 500 0 50 0 0 0
+4
+MemTotal:        8000000 kB
+MemAvailable:    6000000 kB
+    lo: 5000 50 0 0 0 0 0 0 5000 50 0 0 0 0 0 0
+  eth0: 123456 100 0 0 0 0 0 0 654321 90 0 0 0 0 0 0
+docker0:1000 10 0 0 0 0 0 0 2000 20 0 0 0 0 0 0
+17000000000 4000000000
+`
+
+func TestParseProc(t *testing.T) {
+	got, err := parseProc(procOutput)
+	if err != nil {
+		t.Fatalf("parseProc: %v", err)
+	}
+	want := &procSnapshot{cpuTotal: 10000, cpuIdle: 8500, cpus: 4, memTotal: 8000000 * 1024, memAvailable: 6000000 * 1024,
+		netRx: 124456, netTx: 656321, diskTotal: 17000000000, diskUsed: 4000000000}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(procSnapshot{})); diff != "" {
+		t.Errorf("unexpected snapshot (-want +got):\n%s", diff)
+	}
+
+	if _, err := parseProc("bash: nproc: command not found\n"); err == nil {
+		t.Errorf("expected an error for unexpected output")
+	}
+}
+
+func TestCPUPercent(t *testing.T) {
+	a := &procSnapshot{cpuTotal: 10000, cpuIdle: 8000, cpus: 4}
+	b := &procSnapshot{cpuTotal: 10400, cpuIdle: 8300, cpus: 4}
+	// 100 busy ticks out of 400 on 4 CPUs is one full CPU
+	if got := cpuPercent(a, b); got != 100 {
+		t.Errorf("cpuPercent() = %v, want 100", got)
+	}
+	if got := cpuPercent(b, a); got != 0 {
+		t.Errorf("cpuPercent() of a reset counter = %v, want 0", got)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	us := []*NodeUsage{
+		{Profile: "p1", Node: "p1", CPUPercent: 10, CPUs: 2, MemoryUsed: 1, MemoryTotal: 4, NetRx: 5},
+		{Profile: "p1", Node: "p1-m02", CPUPercent: 20, CPUs: 2, MemoryUsed: 2, MemoryTotal: 4, NetRx: 5},
+		{Profile: "p2", Node: "p2", CPUPercent: 5, CPUs: 4, MemoryUsed: 3, MemoryTotal: 8},
+	}
+	want := []*NodeUsage{
+		{Profile: "p1", CPUPercent: 30, CPUs: 4, MemoryUsed: 3, MemoryTotal: 8, NetRx: 10},
+		{Profile: "p2", CPUPercent: 5, CPUs: 4, MemoryUsed: 3, MemoryTotal: 8},
+	}
+	if diff := cmp.Diff(want, Totals(us)); diff != "" {
+		t.Errorf("unexpected totals (-want +got):\n%s", diff)
+	}
+}
+
+func TestParsePodMetrics(t *testing.T) {
+	b := []byte(`{"kind":"PodMetricsList","items":[
+{"metadata":{"name":"coredns","namespace":"kube-system"},"containers":[{"name":"coredns","usage":{"cpu":"3m","memory":"12Mi"}}]},
+{"metadata":{"name":"etcd","namespace":"kube-system"},"containers":[{"name":"etcd","usage":{"cpu":"25083631n","memory":"40Mi"}}]},
+{"metadata":{"name":"web","namespace":"default"},"containers":[{"name":"app","usage":{"cpu":"100m","memory":"1Gi"}},{"name":"sidecar","usage":{"cpu":"1m","memory":"8Mi"}}]}
+]}`)
+	got, err := parsePodMetrics("p1", b)
+	if err != nil {
+		t.Fatalf("parsePodMetrics: %v", err)
+	}
+	want := []*NamespaceUsage{
+		{Profile: "p1", Namespace: "default", Pods: 1, CPUMillis: 101, MemoryBytes: (1024 + 8) * 1024 * 1024},
+		{Profile: "p1", Namespace: "kube-system", Pods: 2, CPUMillis: 29, MemoryBytes: 52 * 1024 * 1024},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected namespace usage (-want +got):\n%s", diff)
+	}
+}
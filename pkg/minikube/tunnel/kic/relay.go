@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kic
+
+import (
+	"os/exec"
+	"path"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+
+	"k8s.io/minikube/pkg/minikube/assets"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/detect"
+	"k8s.io/minikube/pkg/minikube/download"
+	"k8s.io/minikube/pkg/minikube/tunnel/relay"
+	"k8s.io/minikube/pkg/version"
+)
+
+// fetchRelay returns the path on the host of the tunnel-relay agent released along with this minikube
+var fetchRelay = func(arch string) (string, error) {
+	v, err := version.GetSemverVersion()
+	if err != nil {
+		return "", errors.Wrap(err, "parsing minikube version")
+	}
+	return download.TunnelRelay(v, arch)
+}
+
+// InstallRelay copies the tunnel-relay agent into the node, unless its base image already ships it
+func InstallRelay(r command.Runner) error {
+	if _, err := r.RunCmd(exec.Command("test", "-x", relay.Path)); err == nil {
+		return nil
+	}
+	klog.Infof("%s is missing from the node, installing it", relay.Path)
+
+	// kic nodes run on the architecture of the host
+	src, err := fetchRelay(detect.EffectiveArch())
+	if err != nil {
+		return errors.Wrap(err, "downloading tunnel-relay")
+	}
+	f, err := assets.NewFileAsset(src, path.Dir(relay.Path), path.Base(relay.Path), "0755")
+	if err != nil {
+		return errors.Wrapf(err, "creating copyable file asset: %s", src)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			klog.Warningf("error closing the file %s: %v", f.GetSourcePath(), err)
+		}
+	}()
+	return r.Copy(f)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/minikube/pkg/minikube/command"
+)
+
+func TestInstallRelay(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "tunnel-relay")
+	if err := os.WriteFile(src, []byte("relay"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	fetched := 0
+	orig := fetchRelay
+	fetchRelay = func(arch string) (string, error) {
+		fetched++
+		return src, nil
+	}
+	defer func() { fetchRelay = orig }()
+
+	// the base image ships the agent
+	r := command.NewFakeCommandRunner()
+	r.SetCommandToOutput(map[string]string{"test -x /bin/tunnel-relay": ""})
+	if err := InstallRelay(r); err != nil {
+		t.Fatalf("InstallRelay: %v", err)
+	}
+	if fetched != 0 {
+		t.Errorf("downloaded tunnel-relay although the node has it")
+	}
+
+	// the base image predates the agent
+	// test -x fails as an unregistered command
+	r = command.NewFakeCommandRunner()
+	r.SetCommandToOutput(map[string]string{"uname -m": "x86_64"})
+	if err := InstallRelay(r); err != nil {
+		t.Fatalf("InstallRelay: %v", err)
+	}
+	if fetched != 1 {
+		t.Errorf("downloaded tunnel-relay %d times, want 1", fetched)
+	}
+	if got, err := r.GetFileToContents(src); err != nil || got != "relay" {
+		t.Errorf("copied tunnel-relay = %q, %v", got, err)
+	}
+}
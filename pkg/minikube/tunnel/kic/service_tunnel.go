@@ -30,18 +30,16 @@ import (
 
 // ServiceTunnel ...
 type ServiceTunnel struct {
-	sshPort        string
-	sshKey         string
+	client         *SSHClient
 	v1Core         typed_core.CoreV1Interface
 	sshConn        *sshConn
 	suppressStdOut bool
 }
 
 // NewServiceTunnel ...
-func NewServiceTunnel(sshPort, sshKey string, v1Core typed_core.CoreV1Interface, suppressStdOut bool) *ServiceTunnel {
+func NewServiceTunnel(client *SSHClient, v1Core typed_core.CoreV1Interface, suppressStdOut bool) *ServiceTunnel {
 	return &ServiceTunnel{
-		sshPort:        sshPort,
-		sshKey:         sshKey,
+		client:         client,
 		v1Core:         v1Core,
 		suppressStdOut: suppressStdOut,
 	}
@@ -54,7 +52,7 @@ func (t *ServiceTunnel) Start(svcName, namespace string) ([]string, error) {
 		return nil, errors.Wrapf(err, "Service %s was not found in %q namespace. You may select another namespace by using 'minikube service %s -n <namespace>", svcName, namespace, svcName)
 	}
 
-	t.sshConn, err = createSSHConnWithRandomPorts(svcName, t.sshPort, t.sshKey, svc)
+	t.sshConn, err = createSSHConnWithRandomPorts(svcName, t.client, svc)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating ssh conn")
 	}
@@ -0,0 +1,291 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kic
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"k8s.io/klog/v2"
+
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/tunnel/relay"
+	"k8s.io/minikube/pkg/util/retry"
+)
+
+// reconnectTimeout is how long reconnecting to a node is retried before a forwarded connection fails
+const reconnectTimeout = 30 * time.Second
+
+// SSHClient is a single SSH connection to a node, multiplexing every forwarded connection.
+// It reconnects with backoff when the connection drops.
+type SSHClient struct {
+	addr   string
+	key    string
+	config *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+	closed bool
+}
+
+// NewSSHClient returns a client for the SSH server of a node listening on 127.0.0.1:sshPort.
+// The server must present one of hostKeys, unless none is known.
+func NewSSHClient(sshPort, sshKey string, hostKeys []ssh.PublicKey) (*SSHClient, error) {
+	key, err := os.ReadFile(sshKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading ssh key")
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing ssh key")
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if len(hostKeys) > 0 {
+		hostKeyCallback = pinnedHostKeys(hostKeys)
+	} else {
+		klog.Warningf("no known host key for 127.0.0.1:%s, the host key will not be verified", sshPort)
+	}
+
+	return &SSHClient{
+		addr: net.JoinHostPort("127.0.0.1", sshPort),
+		key:  sshKey,
+		config: &ssh.ClientConfig{
+			User:            "docker",
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         10 * time.Second,
+		},
+	}, nil
+}
+
+// pinnedHostKeys accepts only servers presenting one of keys.
+func pinnedHostKeys(keys []ssh.PublicKey) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		for _, k := range keys {
+			if bytes.Equal(k.Marshal(), key.Marshal()) {
+				return nil
+			}
+		}
+		return errors.Errorf("ssh: host key of %s (%s %s) does not match the known keys of the node", hostname, key.Type(), ssh.FingerprintSHA256(key))
+	}
+}
+
+// sudoForward returns an ssh process run with sudo, forwarding the local ports to the same ports of ip from the node.
+// It is used for the ports below 1024, which minikube is not allowed to bind.
+func (c *SSHClient) sudoForward(ports []int32, ip string) *exec.Cmd {
+	host, port, _ := net.SplitHostPort(c.addr)
+	args := []string{
+		"ssh",
+		"-o", "UserKnownHostsFile=/dev/null",
+		"-o", "StrictHostKeyChecking=no",
+		"-N",
+		c.config.User + "@" + host,
+		"-p", port,
+		"-i", c.key,
+	}
+	for _, p := range ports {
+		args = append(args, "-L", fmt.Sprintf("%d:%s:%d", p, ip, p))
+	}
+	return exec.Command("sudo", args...)
+}
+
+// Dial opens a connection to addr from the node.
+func (c *SSHClient) Dial(network, addr string) (net.Conn, error) {
+	var conn net.Conn
+	err := c.withClient(addr, func(client *ssh.Client) (err error) {
+		conn, err = client.Dial(network, addr)
+		return err
+	})
+	return conn, err
+}
+
+// Relay starts the relay agent of the node, to exchange the datagrams framed on the returned stream with addr.
+func (c *SSHClient) Relay(network, addr string) (io.ReadWriteCloser, error) {
+	var session *ssh.Session
+	err := c.withClient(addr, func(client *ssh.Client) (err error) {
+		session, err = client.NewSession()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+	if err := session.Start(fmt.Sprintf("%s %s %s", relay.Path, network, addr)); err != nil {
+		session.Close()
+		return nil, errors.Wrap(err, "starting relay agent")
+	}
+
+	s := &relaySession{session: session, Reader: stdout, WriteCloser: stdin}
+	go func() {
+		if err := session.Wait(); err != nil {
+			klog.Warningf("relay agent for %s exited: %v: %s", addr, err, strings.TrimSpace(stderr.String()))
+		}
+	}()
+	return s, nil
+}
+
+// relaySession is the stream of datagrams exchanged with a relay agent.
+type relaySession struct {
+	session *ssh.Session
+	io.Reader
+	io.WriteCloser
+}
+
+// Close stops the agent.
+func (s *relaySession) Close() error {
+	s.WriteCloser.Close()
+	return s.session.Close()
+}
+
+// withClient runs f with the connection to the node, reconnecting once if it fails for
+// another reason than the target of addr being unreachable.
+func (c *SSHClient) withClient(addr string, f func(*ssh.Client) error) error {
+	client, err := c.connect()
+	if err != nil {
+		return err
+	}
+	err = f(client)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*ssh.OpenChannelError); ok {
+		// the node is reachable, but not the target
+		return err
+	}
+
+	// the connection to the node may have dropped without being noticed yet
+	klog.Warningf("dial %s through %s failed, reconnecting: %v", addr, c.addr, err)
+	c.reset(client)
+	if client, err = c.connect(); err != nil {
+		return err
+	}
+	return f(client)
+}
+
+// connect returns the connection to the node, establishing it if needed.
+func (c *SSHClient) connect() (*ssh.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, errors.New("ssh client is closed")
+	}
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	var client *ssh.Client
+	dial := func() (err error) {
+		client, err = ssh.Dial("tcp", c.addr, c.config)
+		if err != nil {
+			klog.Warningf("ssh dial %s failure (will retry): %v", c.addr, err)
+		}
+		return err
+	}
+	if err := retry.Expo(dial, 250*time.Millisecond, reconnectTimeout); err != nil {
+		return nil, errors.Wrapf(err, "connecting to %s", c.addr)
+	}
+	klog.Infof("connected to %s", c.addr)
+	c.client = client
+
+	go func() {
+		err := client.Wait()
+		klog.Infof("connection to %s closed: %v", c.addr, err)
+		c.reset(client)
+	}()
+	return client, nil
+}
+
+// reset forgets a connection which dropped, so that the next dial reconnects.
+func (c *SSHClient) reset(client *ssh.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client == client {
+		c.client = nil
+		client.Close()
+	}
+}
+
+// Close closes the connection to the node.
+func (c *SSHClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.client == nil {
+		return nil
+	}
+	err := c.client.Close()
+	c.client = nil
+	return err
+}
+
+// HostKeys returns the SSH host keys of a node, pinning them in keysFile the first time
+// they are read, so that later connections only trust the node they were read from.
+func HostKeys(r command.Runner, keysFile string) ([]ssh.PublicKey, error) {
+	data, err := os.ReadFile(keysFile)
+	if os.IsNotExist(err) {
+		rr, rerr := r.RunCmd(exec.Command("sh", "-c", "cat /etc/ssh/ssh_host_*_key.pub"))
+		if rerr != nil {
+			return nil, errors.Wrap(rerr, "reading host keys")
+		}
+		data = rr.Stdout.Bytes()
+		if werr := os.WriteFile(keysFile, data, 0600); werr != nil {
+			return nil, errors.Wrap(werr, "pinning host keys")
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return parseHostKeys(data)
+}
+
+// parseHostKeys parses public keys in the authorized_keys format.
+func parseHostKeys(data []byte) ([]ssh.PublicKey, error) {
+	var keys []ssh.PublicKey
+	for len(bytes.TrimSpace(data)) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing host key")
+		}
+		keys = append(keys, key)
+		data = rest
+	}
+	return keys, nil
+}
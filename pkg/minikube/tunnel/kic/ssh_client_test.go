@@ -0,0 +1,385 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kic
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/minikube/pkg/minikube/tunnel/relay"
+)
+
+func newHostKey(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestParseHostKeys(t *testing.T) {
+	a := newHostKey(t).PublicKey()
+	b := newHostKey(t).PublicKey()
+	data := append(ssh.MarshalAuthorizedKey(a), []byte("\n"+string(ssh.MarshalAuthorizedKey(b))+"\n")...)
+
+	keys, err := parseHostKeys(data)
+	if err != nil {
+		t.Fatalf("parseHostKeys: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("got %d keys, want 2", len(keys))
+	}
+
+	check := pinnedHostKeys(keys)
+	if err := check("node", nil, b); err != nil {
+		t.Errorf("known key rejected: %v", err)
+	}
+	if err := check("node", nil, newHostKey(t).PublicKey()); err == nil {
+		t.Errorf("unknown key accepted")
+	}
+
+	if _, err := parseHostKeys([]byte("not a key")); err == nil {
+		t.Errorf("expected an error parsing an invalid key")
+	}
+}
+
+// sshServer serves direct-tcpip channels on a local port, like the sshd of a node.
+func sshServer(t *testing.T, hostKey ssh.Signer, clientKey ssh.PublicKey) string {
+	t.Helper()
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, io.EOF
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for nc := range chans {
+					if nc.ChannelType() == "session" {
+						go serveSession(nc)
+						continue
+					}
+					var target struct {
+						Host       string
+						Port       uint32
+						OriginHost string
+						OriginPort uint32
+					}
+					if nc.ChannelType() != "direct-tcpip" || ssh.Unmarshal(nc.ExtraData(), &target) != nil {
+						_ = nc.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					remote, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+					if err != nil {
+						_ = nc.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					ch, creqs, err := nc.Accept()
+					if err != nil {
+						remote.Close()
+						continue
+					}
+					go ssh.DiscardRequests(creqs)
+					go func() {
+						_, _ = io.Copy(ch, remote)
+						ch.Close()
+					}()
+					go func() {
+						_, _ = io.Copy(remote, ch)
+						_ = remote.(*net.TCPConn).CloseWrite()
+					}()
+				}
+			}()
+		}
+	}()
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+}
+
+// serveSession runs the relay agent in process when it is executed by a session.
+func serveSession(nc ssh.NewChannel) {
+	ch, reqs, err := nc.Accept()
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+	for req := range reqs {
+		var exec struct{ Command string }
+		if req.Type != "exec" || ssh.Unmarshal(req.Payload, &exec) != nil {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		args := strings.Fields(exec.Command)
+		if len(args) != 3 || args[0] != relay.Path {
+			_ = req.Reply(false, nil)
+			return
+		}
+		_ = req.Reply(true, nil)
+		status := uint32(0)
+		conn, err := net.Dial(args[1], args[2])
+		if err == nil {
+			err = relay.Serve(conn, ch, ch)
+		}
+		if err != nil {
+			status = 1
+		}
+		_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+		return
+	}
+}
+
+// echoServer echoes what it reads back, returning its port.
+func echoServer(t *testing.T) int32 {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return int32(l.Addr().(*net.TCPAddr).Port)
+}
+
+// udpEchoServer echoes the datagrams it receives back, returning its port.
+func udpEchoServer(t *testing.T) int32 {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, relay.MaxDatagramSize)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = pc.WriteTo(buf[:n], addr)
+		}
+	}()
+	return int32(pc.LocalAddr().(*net.UDPAddr).Port)
+}
+
+func writeClientKey(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "id_rsa")
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, pub
+}
+
+// waitForStats waits for the counters of the first port of c, which are updated once data was relayed.
+func waitForStats(t *testing.T, c *sshConn, bytes int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sent, received := atomic.LoadInt64(&c.forwards[0].sent), atomic.LoadInt64(&c.forwards[0].received)
+		if sent == bytes && received == bytes {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d bytes sent and %d received, want %d", sent, received, bytes)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSSHConnForward(t *testing.T) {
+	keyPath, clientKey := writeClientKey(t)
+	hostKey := newHostKey(t)
+	sshPort := sshServer(t, hostKey, clientKey)
+
+	client, err := NewSSHClient(sshPort, keyPath, []ssh.PublicKey{hostKey.PublicKey()})
+	if err != nil {
+		t.Fatalf("NewSSHClient: %v", err)
+	}
+	defer client.Close()
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "echo"},
+		Spec: v1.ServiceSpec{
+			ClusterIP: "127.0.0.1",
+			Ports:     []v1.ServicePort{{Port: echoServer(t)}},
+		},
+	}
+	c, err := createSSHConnWithRandomPorts("echo", client, svc)
+	if err != nil {
+		t.Fatalf("createSSHConnWithRandomPorts: %v", err)
+	}
+	c.suppressStdOut = true
+	done := make(chan error, 1)
+	go func() { done <- c.startAndWait() }()
+
+	conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(c.ports[0])))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_ = conn.(*net.TCPConn).CloseWrite()
+	got, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	conn.Close()
+	if string(got) != "hello" {
+		t.Errorf("got %q, want %q", got, "hello")
+	}
+
+	waitForStats(t, c, 5)
+
+	if err := c.stop(); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("tunnel did not stop")
+	}
+}
+
+func TestSSHConnForwardUDP(t *testing.T) {
+	keyPath, clientKey := writeClientKey(t)
+	hostKey := newHostKey(t)
+	sshPort := sshServer(t, hostKey, clientKey)
+
+	client, err := NewSSHClient(sshPort, keyPath, []ssh.PublicKey{hostKey.PublicKey()})
+	if err != nil {
+		t.Fatalf("NewSSHClient: %v", err)
+	}
+	defer client.Close()
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns"},
+		Spec: v1.ServiceSpec{
+			ClusterIP: "127.0.0.1",
+			Ports: []v1.ServicePort{
+				{Port: udpEchoServer(t), Protocol: v1.ProtocolUDP},
+				{Port: 9, Protocol: v1.ProtocolSCTP},
+			},
+		},
+	}
+	c, err := createSSHConnWithRandomPorts("dns", client, svc)
+	if err != nil {
+		t.Fatalf("createSSHConnWithRandomPorts: %v", err)
+	}
+	if len(c.forwards) != 1 {
+		t.Fatalf("got %d forwarded ports, want only the UDP one", len(c.forwards))
+	}
+	c.suppressStdOut = true
+	done := make(chan error, 1)
+	go func() { done <- c.startAndWait() }()
+
+	// two clients, which must each get their own replies
+	for _, msg := range []string{"query", "another query"} {
+		conn, err := net.Dial("udp", net.JoinHostPort("127.0.0.1", strconv.Itoa(c.ports[0])))
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := conn.Write([]byte(msg)); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			buf := make([]byte, 512)
+			if err := conn.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
+				t.Fatal(err)
+			}
+			n, err := conn.Read(buf)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if string(buf[:n]) != msg {
+				t.Errorf("got %q, want %q", buf[:n], msg)
+			}
+		}
+		conn.Close()
+	}
+
+	waitForStats(t, c, int64(2*(len("query")+len("another query"))))
+
+	if err := c.stop(); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("tunnel did not stop")
+	}
+}
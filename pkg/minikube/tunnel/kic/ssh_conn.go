@@ -18,159 +18,418 @@ package kic
 
 import (
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/phayes/freeport"
+	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
 
 	"k8s.io/minikube/pkg/minikube/out"
 	"k8s.io/minikube/pkg/minikube/style"
+	"k8s.io/minikube/pkg/minikube/tunnel/relay"
 )
 
+// udpIdleTimeout is how long the relay of a UDP client is kept without any datagram
+const udpIdleTimeout = 2 * time.Minute
+
+// forward relays the connections accepted on a local listener, or the datagrams received on a
+// local socket, to a target reached from the node
+type forward struct {
+	protocol   v1.Protocol
+	listener   net.Listener
+	packetConn net.PacketConn
+	target     string
+	// sent and received count the bytes of every connection or client of the port
+	sent     int64
+	received int64
+}
+
+func (f *forward) addr() net.Addr {
+	if f.packetConn != nil {
+		return f.packetConn.LocalAddr()
+	}
+	return f.listener.Addr()
+}
+
+func (f *forward) close() error {
+	if f.packetConn != nil {
+		return f.packetConn.Close()
+	}
+	return f.listener.Close()
+}
+
+// portProtocol returns the protocol of a service port, which defaults to TCP.
+func portProtocol(port v1.ServicePort) v1.Protocol {
+	if port.Protocol == "" {
+		return v1.ProtocolTCP
+	}
+	return port.Protocol
+}
+
 type sshConn struct {
 	name           string
 	service        string
-	cmd            *exec.Cmd
+	client         *SSHClient
+	forwards       []*forward
 	ports          []int
 	activeConn     bool
 	suppressStdOut bool
+	// sudoCmd forwards the privileged ports which could not be bound by minikube
+	sudoCmd *exec.Cmd
+
+	mu    sync.Mutex
+	conns map[net.Conn]bool
 }
 
-func createSSHConn(name, sshPort, sshKey string, resourcePorts []int32, resourceIP string, resourceName string) *sshConn {
-	// extract sshArgs
-	sshArgs := []string{
-		// TODO: document the options here
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-o", "StrictHostKeyChecking=no",
-		"-N",
-		"docker@127.0.0.1",
-		"-p", sshPort,
-		"-i", sshKey,
+func createSSHConn(name string, client *SSHClient, resourcePorts []v1.ServicePort, resourceIP string, resourceName string) *sshConn {
+	c := &sshConn{
+		name:    name,
+		service: resourceName,
+		client:  client,
+		conns:   map[net.Conn]bool{},
 	}
 
-	askForSudo := false
-	var privilegedPorts []int32
+	var privilegedPorts, privilegedUDPPorts, sctpPorts []int32
 	for _, port := range resourcePorts {
-		arg := fmt.Sprintf(
-			"-L %d:%s:%d",
-			port,
-			resourceIP,
-			port,
-		)
-
-		// check if any port is privileged
-		if port < 1024 {
-			privilegedPorts = append(privilegedPorts, port)
-			askForSudo = true
+		protocol := portProtocol(port)
+		if protocol == v1.ProtocolSCTP {
+			sctpPorts = append(sctpPorts, port.Port)
+			continue
 		}
+		target := net.JoinHostPort(resourceIP, strconv.Itoa(int(port.Port)))
+		err := c.listen(protocol, fmt.Sprintf("127.0.0.1:%d", port.Port), target)
+		if err == nil {
+			continue
+		}
+		// ports below 1024 can only be bound by root on unix
+		if port.Port < 1024 && runtime.GOOS != "windows" && errors.Is(err, os.ErrPermission) {
+			if protocol == v1.ProtocolUDP {
+				privilegedUDPPorts = append(privilegedUDPPorts, port.Port)
+			} else {
+				privilegedPorts = append(privilegedPorts, port.Port)
+			}
+			continue
+		}
+		klog.Errorf("error forwarding port %d/%s of %s: %v", port.Port, protocol, resourceName, err)
+	}
 
-		sshArgs = append(sshArgs, arg)
+	if len(sctpPorts) > 0 {
+		out.WarningT("SCTP ports of {{.resource}} cannot be forwarded through the tunnel of this driver: {{.ports}}", out.V{"resource": resourceName, "ports": fmt.Sprintf("%v", sctpPorts)})
 	}
 
-	command := "ssh"
-	if askForSudo && runtime.GOOS != "windows" {
+	if len(privilegedPorts) > 0 {
 		out.Styled(
 			style.Warning,
 			"The service/ingress {{.resource}} requires privileged ports to be exposed: {{.ports}}",
 			out.V{"resource": resourceName, "ports": fmt.Sprintf("%v", privilegedPorts)},
 		)
-
 		out.Styled(style.Permissions, "sudo permission will be asked for it.")
+		c.sudoCmd = client.sudoForward(privilegedPorts, resourceIP)
+	}
+
+	// ssh can only forward TCP, so the UDP ports are only forwarded once minikube may bind them
+	if len(privilegedUDPPorts) > 0 {
+		out.ErrT(style.Failure, "The privileged UDP ports of {{.resource}} cannot be forwarded: {{.ports}}", out.V{"resource": resourceName, "ports": fmt.Sprintf("%v", privilegedUDPPorts)})
+		out.Styled(style.Permissions, "Allow minikube to bind privileged ports with: sudo setcap cap_net_bind_service=+ep $(which minikube)")
+	}
+
+	return c
+}
 
-		command = "sudo"
-		sshArgs = append([]string{"ssh"}, sshArgs...)
+func createSSHConnWithRandomPorts(name string, client *SSHClient, svc *v1.Service) (*sshConn, error) {
+	c := &sshConn{
+		name:    name,
+		service: svc.Name,
+		client:  client,
+		conns:   map[net.Conn]bool{},
 	}
 
-	if askForSudo && runtime.GOOS == "windows" {
-		out.WarningT("Access to ports below 1024 may fail on Windows with OpenSSH clients older than v8.1. For more information, see: https://minikube.sigs.k8s.io/docs/handbook/accessing/#access-to-ports-1024-on-windows-requires-root-permission")
+	for _, port := range svc.Spec.Ports {
+		protocol := portProtocol(port)
+		if protocol == v1.ProtocolSCTP {
+			out.WarningT("SCTP port {{.port}} of {{.service}} cannot be forwarded through the tunnel of this driver", out.V{"port": port.Port, "service": svc.Name})
+			continue
+		}
+		target := net.JoinHostPort(svc.Spec.ClusterIP, strconv.Itoa(int(port.Port)))
+		if err := c.listen(protocol, "127.0.0.1:0", target); err != nil {
+			c.closeListeners()
+			return nil, err
+		}
 	}
 
-	cmd := exec.Command(command, sshArgs...)
+	return c, nil
+}
 
-	return &sshConn{
-		name:       name,
-		service:    resourceName,
-		cmd:        cmd,
-		activeConn: false,
+// listen starts accepting connections or datagrams of protocol on local, to be forwarded to target.
+func (c *sshConn) listen(protocol v1.Protocol, local, target string) error {
+	f := &forward{protocol: protocol, target: target}
+	if protocol == v1.ProtocolUDP {
+		pc, err := net.ListenPacket("udp", local)
+		if err != nil {
+			return errors.Wrapf(err, "listen on %s/udp", local)
+		}
+		f.packetConn = pc
+		c.ports = append(c.ports, pc.LocalAddr().(*net.UDPAddr).Port)
+	} else {
+		l, err := net.Listen("tcp", local)
+		if err != nil {
+			return errors.Wrapf(err, "listen on %s", local)
+		}
+		f.listener = l
+		c.ports = append(c.ports, l.Addr().(*net.TCPAddr).Port)
 	}
+	c.forwards = append(c.forwards, f)
+	return nil
 }
 
-func createSSHConnWithRandomPorts(name, sshPort, sshKey string, svc *v1.Service) (*sshConn, error) {
-	// extract sshArgs
-	sshArgs := []string{
-		// TODO: document the options here
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-o", "StrictHostKeyChecking=no",
-		"-N",
-		"docker@127.0.0.1",
-		"-p", sshPort,
-		"-i", sshKey,
+func (c *sshConn) startAndWait() error {
+	if !c.suppressStdOut {
+		out.Step(style.Running, "Starting tunnel for service {{.service}}.", out.V{"service": c.service})
+	}
+
+	c.setActive(true)
+	var wg sync.WaitGroup
+	if c.sudoCmd != nil {
+		if err := c.sudoCmd.Start(); err != nil {
+			klog.Errorf("error forwarding the privileged ports of %s: %v", c.service, err)
+		} else {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// we ignore wait error because the process will be killed
+				_ = c.sudoCmd.Wait()
+			}()
+		}
+	}
+	for _, f := range c.forwards {
+		wg.Add(1)
+		go func(f *forward) {
+			defer wg.Done()
+			if f.packetConn != nil {
+				c.servePackets(f)
+				return
+			}
+			c.accept(f)
+		}(f)
 	}
+	// the listeners are closed when the tunnel is stopped
+	wg.Wait()
+
+	// Wait is finished for connection, mark false.
+	c.setActive(false)
 
-	usedPorts := make([]int, 0, len(svc.Spec.Ports))
+	return nil
+}
 
-	for _, port := range svc.Spec.Ports {
-		freeport, err := freeport.GetFreePort()
+// accept forwards the connections of a listener until it is closed.
+func (c *sshConn) accept(f *forward) {
+	for {
+		local, err := f.listener.Accept()
 		if err != nil {
-			return nil, err
+			return
 		}
+		go c.relay(f, local)
+	}
+}
 
-		arg := fmt.Sprintf(
-			"-L %d:%s:%d",
-			freeport,
-			svc.Spec.ClusterIP,
-			port.Port,
-		)
+// relay copies data between a local connection and its target until either side closes.
+func (c *sshConn) relay(f *forward, local net.Conn) {
+	defer local.Close()
+	remote, err := c.client.Dial("tcp", f.target)
+	if err != nil {
+		klog.Errorf("error forwarding %s to %s: %v", local.RemoteAddr(), f.target, err)
+		return
+	}
+	defer remote.Close()
 
-		sshArgs = append(sshArgs, arg)
-		usedPorts = append(usedPorts, freeport)
+	if !c.track(local, remote) {
+		return
 	}
+	defer c.untrack(local, remote)
 
-	cmd := exec.Command("ssh", sshArgs...)
+	var sent, received int64
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		sent, _ = io.Copy(remote, local)
+		atomic.AddInt64(&f.sent, sent)
+		closeWrite(remote)
+	}()
+	go func() {
+		defer wg.Done()
+		received, _ = io.Copy(local, remote)
+		atomic.AddInt64(&f.received, received)
+		closeWrite(local)
+	}()
+	wg.Wait()
+	klog.Infof("tunnel connection %s -> %s for service %s closed: sent %d bytes, received %d bytes", local.RemoteAddr(), f.target, c.service, sent, received)
+}
 
-	return &sshConn{
-		name:       name,
-		service:    svc.Name,
-		cmd:        cmd,
-		ports:      usedPorts,
-		activeConn: false,
-	}, nil
+// udpClient is a local UDP client, whose datagrams are relayed by an agent in the node.
+type udpClient struct {
+	peer     net.Addr
+	relay    io.ReadWriteCloser
+	idle     *time.Timer
+	sent     int64
+	received int64
 }
 
-func (c *sshConn) startAndWait() error {
-	if !c.suppressStdOut {
-		out.Step(style.Running, "Starting tunnel for service {{.service}}.", out.V{"service": c.service})
+// servePackets relays the datagrams received on a local socket until it is closed, starting a
+// relay agent in the node for each client so that replies are sent back to the right one.
+func (c *sshConn) servePackets(f *forward) {
+	var mu sync.Mutex
+	clients := map[string]*udpClient{}
+	remove := func(u *udpClient) {
+		mu.Lock()
+		defer mu.Unlock()
+		if clients[u.peer.String()] == u {
+			delete(clients, u.peer.String())
+			klog.Infof("tunnel client %s -> %s/udp for service %s closed: sent %d bytes, received %d bytes", u.peer, f.target, c.service, atomic.LoadInt64(&u.sent), atomic.LoadInt64(&u.received))
+		}
+		u.relay.Close()
 	}
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, u := range clients {
+			u.idle.Stop()
+			u.relay.Close()
+		}
+	}()
 
-	err := c.cmd.Start()
-	if err != nil {
-		return err
+	buf := make([]byte, relay.MaxDatagramSize)
+	for {
+		n, peer, err := f.packetConn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		u := clients[peer.String()]
+		mu.Unlock()
+		if u == nil {
+			r, err := c.client.Relay("udp", f.target)
+			if err != nil {
+				klog.Errorf("error relaying %s to %s: %v", peer, f.target, err)
+				continue
+			}
+			u = &udpClient{peer: peer, relay: r}
+			u.idle = time.AfterFunc(udpIdleTimeout, func() { remove(u) })
+			mu.Lock()
+			clients[peer.String()] = u
+			mu.Unlock()
+			go c.relayReplies(f, u, remove)
+		}
+
+		u.idle.Reset(udpIdleTimeout)
+		if err := relay.WriteFrame(u.relay, buf[:n]); err != nil {
+			klog.Errorf("error relaying %s to %s: %v", peer, f.target, err)
+			u.idle.Stop()
+			remove(u)
+			continue
+		}
+		atomic.AddInt64(&u.sent, int64(n))
+		atomic.AddInt64(&f.sent, int64(n))
 	}
+}
 
-	c.activeConn = true
-	// we ignore wait error because the process will be killed
-	_ = c.cmd.Wait()
+// relayReplies sends the datagrams coming back from the relay agent of a client to it.
+func (c *sshConn) relayReplies(f *forward, u *udpClient, remove func(*udpClient)) {
+	defer remove(u)
+	buf := make([]byte, relay.MaxDatagramSize)
+	for {
+		p, err := relay.ReadFrame(u.relay, buf)
+		if err != nil {
+			return
+		}
+		u.idle.Reset(udpIdleTimeout)
+		if _, err := f.packetConn.WriteTo(p, u.peer); err != nil {
+			return
+		}
+		atomic.AddInt64(&u.received, int64(len(p)))
+		atomic.AddInt64(&f.received, int64(len(p)))
+	}
+}
 
-	// Wait is finished for connection, mark false.
-	c.activeConn = false
+// closeWrite signals the end of the data to the peer, while still reading its reply.
+func closeWrite(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		if err := cw.CloseWrite(); err == nil {
+			return
+		}
+	}
+	conn.Close()
+}
 
-	return nil
+// track registers open connections so that stopping the tunnel closes them, returning false if it is stopped.
+func (c *sshConn) track(conns ...net.Conn) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conns == nil {
+		return false
+	}
+	for _, conn := range conns {
+		c.conns[conn] = true
+	}
+	return true
+}
+
+func (c *sshConn) untrack(conns ...net.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, conn := range conns {
+		delete(c.conns, conn)
+	}
+}
+
+func (c *sshConn) closeListeners() {
+	for _, f := range c.forwards {
+		if err := f.close(); err != nil {
+			klog.Warningf("error closing listener %s: %v", f.addr(), err)
+		}
+	}
+}
+
+func (c *sshConn) setActive(active bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.activeConn = active
 }
 
 func (c *sshConn) stop() error {
-	if c.activeConn {
-		c.activeConn = false
+	c.closeListeners()
+	c.mu.Lock()
+	for conn := range c.conns {
+		conn.Close()
+	}
+	c.conns = nil
+	active := c.activeConn
+	c.activeConn = false
+	c.mu.Unlock()
+
+	for _, f := range c.forwards {
+		klog.Infof("tunnel %s/%s -> %s for service %s: sent %d bytes, received %d bytes", f.addr(), f.protocol, f.target, c.service, atomic.LoadInt64(&f.sent), atomic.LoadInt64(&f.received))
+	}
+	if c.sudoCmd != nil && c.sudoCmd.Process != nil {
+		if err := c.sudoCmd.Process.Kill(); err != nil && err != os.ErrProcessDone {
+			klog.Warningf("error stopping the forwarding of the privileged ports of %s: %v", c.service, err)
+		}
+	}
+
+	if active {
 		if !c.suppressStdOut {
 			out.Step(style.Stopping, "Stopping tunnel for service {{.service}}.", out.V{"service": c.service})
 		}
-		err := c.cmd.Process.Kill()
-		if err == os.ErrProcessDone {
-			// No need to return an error here
-			return nil
-		}
-		return err
+		return nil
 	}
 	if !c.suppressStdOut {
 		out.Step(style.Stopping, "Stopped tunnel for service {{.service}}.", out.V{"service": c.service})
@@ -20,8 +20,11 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+
 	v1 "k8s.io/api/core/v1"
 	v1_networking "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,21 +38,27 @@ import (
 // SSHTunnel ...
 type SSHTunnel struct {
 	ctx                  context.Context
-	sshPort              string
-	sshKey               string
+	client               *SSHClient
 	v1Core               typed_core.CoreV1Interface
 	v1Networking         typed_networking.NetworkingV1Interface
 	LoadBalancerEmulator tunnel.LoadBalancerEmulator
 	conns                map[string]*sshConn
 	connsToStop          map[string]*sshConn
+
+	mu        sync.Mutex
+	protocols []tunnel.ProtocolStatus
+}
+
+// unsupportedProtocols are the protocols which can't be forwarded through SSH
+var unsupportedProtocols = map[v1.Protocol]error{
+	v1.ProtocolSCTP: errors.New("SCTP can not be forwarded through SSH"),
 }
 
 // NewSSHTunnel ...
-func NewSSHTunnel(ctx context.Context, sshPort, sshKey string, v1Core typed_core.CoreV1Interface, v1Networking typed_networking.NetworkingV1Interface) *SSHTunnel {
+func NewSSHTunnel(ctx context.Context, client *SSHClient, v1Core typed_core.CoreV1Interface, v1Networking typed_networking.NetworkingV1Interface) *SSHTunnel {
 	return &SSHTunnel{
 		ctx:                  ctx,
-		sshPort:              sshPort,
-		sshKey:               sshKey,
+		client:               client,
 		v1Core:               v1Core,
 		LoadBalancerEmulator: tunnel.NewLoadBalancerEmulator(v1Core),
 		v1Networking:         v1Networking,
@@ -84,11 +93,14 @@ func (t *SSHTunnel) Start() error {
 
 		t.markConnectionsToBeStopped()
 
+		var lbServices []v1.Service
 		for _, svc := range services.Items {
 			if svc.Spec.Type == v1.ServiceTypeLoadBalancer {
 				t.startConnection(svc)
+				lbServices = append(lbServices, svc)
 			}
 		}
+		t.setProtocols(tunnel.ProtocolStatuses(lbServices, unsupportedProtocols))
 
 		for _, ingress := range ingresses.Items {
 			t.startConnectionIngress(ingress)
@@ -101,6 +113,19 @@ func (t *SSHTunnel) Start() error {
 	}
 }
 
+// Protocols returns the LoadBalancer services forwarded over each protocol.
+func (t *SSHTunnel) Protocols() []tunnel.ProtocolStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.protocols
+}
+
+func (t *SSHTunnel) setProtocols(protocols []tunnel.ProtocolStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.protocols = protocols
+}
+
 func (t *SSHTunnel) markConnectionsToBeStopped() {
 	for _, conn := range t.conns {
 		t.connsToStop[conn.name] = conn
@@ -117,14 +142,8 @@ func (t *SSHTunnel) startConnection(svc v1.Service) {
 		return
 	}
 
-	resourcePorts := []int32{}
-
-	for _, port := range svc.Spec.Ports {
-		resourcePorts = append(resourcePorts, port.Port)
-	}
-
 	// create new ssh conn
-	newSSHConn := createSSHConn(uniqName, t.sshPort, t.sshKey, resourcePorts, svc.Spec.ClusterIP, svc.Name)
+	newSSHConn := createSSHConn(uniqName, t.client, svc.Spec.Ports, svc.Spec.ClusterIP, svc.Name)
 	t.conns[newSSHConn.name] = newSSHConn
 
 	go func() {
@@ -150,11 +169,11 @@ func (t *SSHTunnel) startConnectionIngress(ingress v1_networking.Ingress) {
 		return
 	}
 
-	resourcePorts := []int32{80, 443}
+	resourcePorts := []v1.ServicePort{{Port: 80}, {Port: 443}}
 	resourceIP := "127.0.0.1"
 
 	// create new ssh conn
-	newSSHConn := createSSHConn(uniqName, t.sshPort, t.sshKey, resourcePorts, resourceIP, ingress.Name)
+	newSSHConn := createSSHConn(uniqName, t.client, resourcePorts, resourceIP, ingress.Name)
 	t.conns[newSSHConn.name] = newSSHConn
 
 	go func() {
@@ -197,6 +216,9 @@ func sshConnUniqName(service v1.Service) string {
 
 	for _, port := range service.Spec.Ports {
 		n = append(n, fmt.Sprintf("-%d", port.Port))
+		if protocol := portProtocol(port); protocol != v1.ProtocolTCP {
+			n = append(n, "/"+strings.ToLower(string(protocol)))
+		}
 	}
 
 	return strings.Join(n, "")
@@ -43,6 +43,9 @@ type LoadBalancerEmulator struct {
 	coreV1Client   typed_core.CoreV1Interface
 	requestSender  requestSender
 	patchConverter patchConverter
+
+	// services are the LoadBalancer services found by the last patch or cleanup
+	services []core.Service
 }
 
 // PatchServices will update all load balancer services
@@ -71,6 +74,7 @@ func (l *LoadBalancerEmulator) applyOnLBServices(action func(restClient rest.Int
 	restClient := l.coreV1Client.RESTClient()
 
 	var managedServices []string
+	l.services = nil
 
 	for _, svc := range serviceList.Items {
 		if svc.Spec.Type != "LoadBalancer" {
@@ -79,6 +83,7 @@ func (l *LoadBalancerEmulator) applyOnLBServices(action func(restClient rest.Int
 		}
 		klog.Infof("%s is type LoadBalancer.", svc.Name)
 		managedServices = append(managedServices, svc.Name)
+		l.services = append(l.services, svc)
 		result, err := action(restClient, svc)
 		if err != nil {
 			klog.Errorf("%s", result)
@@ -147,6 +152,41 @@ func (l *LoadBalancerEmulator) cleanupService(restClient rest.Interface, svc cor
 
 }
 
+// protocols are the protocols of service ports, in the order they are reported
+var protocols = []core.Protocol{core.ProtocolTCP, core.ProtocolUDP, core.ProtocolSCTP}
+
+// ProtocolStatuses groups services by the protocols of their ports, reporting unsupported[protocol]
+// as the error of the protocols a tunnel cannot forward.
+func ProtocolStatuses(services []core.Service, unsupported map[core.Protocol]error) []ProtocolStatus {
+	var statuses []ProtocolStatus
+	for _, protocol := range protocols {
+		var names []string
+		for _, svc := range services {
+			if hasProtocol(svc, protocol) {
+				names = append(names, svc.Name)
+			}
+		}
+		if len(names) > 0 {
+			statuses = append(statuses, ProtocolStatus{Protocol: protocol, Services: names, Error: unsupported[protocol]})
+		}
+	}
+	return statuses
+}
+
+// hasProtocol returns whether a port of svc uses protocol, ports without one using TCP.
+func hasProtocol(svc core.Service, protocol core.Protocol) bool {
+	for _, port := range svc.Spec.Ports {
+		p := port.Protocol
+		if p == "" {
+			p = core.ProtocolTCP
+		}
+		if p == protocol {
+			return true
+		}
+	}
+	return false
+}
+
 // NewLoadBalancerEmulator creates a new LoadBalancerEmulator
 func NewLoadBalancerEmulator(corev1Client typed_core.CoreV1Interface) LoadBalancerEmulator {
 	return LoadBalancerEmulator{
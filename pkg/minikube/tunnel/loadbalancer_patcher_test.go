@@ -18,6 +18,7 @@ package tunnel
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"reflect"
@@ -346,3 +347,33 @@ func TestCleanupPatchedIPs(t *testing.T) {
 		t.Errorf("error in number of requests sent.\nExpected: %v, <nil>\nGot: %v", 2, requestSender.requests)
 	}
 }
+
+func TestProtocolStatuses(t *testing.T) {
+	service := func(name string, protocols ...core.Protocol) core.Service {
+		svc := core.Service{ObjectMeta: meta.ObjectMeta{Name: name}}
+		for _, p := range protocols {
+			svc.Spec.Ports = append(svc.Spec.Ports, core.ServicePort{Protocol: p})
+		}
+		return svc
+	}
+	sctpErr := errors.New("SCTP is not supported")
+
+	got := ProtocolStatuses([]core.Service{
+		service("web", ""),
+		service("dns", core.ProtocolUDP, core.ProtocolTCP),
+		service("signaling", core.ProtocolSCTP),
+	}, map[core.Protocol]error{core.ProtocolSCTP: sctpErr})
+
+	want := []ProtocolStatus{
+		{Protocol: core.ProtocolTCP, Services: []string{"web", "dns"}},
+		{Protocol: core.ProtocolUDP, Services: []string{"dns"}},
+		{Protocol: core.ProtocolSCTP, Services: []string{"signaling"}, Error: sctpErr},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProtocolStatuses() = %v, want %v", got, want)
+	}
+
+	if got := ProtocolStatuses(nil, nil); got != nil {
+		t.Errorf("ProtocolStatuses(nil) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package relay carries datagrams over a stream, such as an SSH session, to the tunnel-relay
+// agent of a node, which exchanges them with their target from inside the cluster network.
+package relay
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// Path is where the tunnel-relay agent is installed in the node
+const Path = "/bin/tunnel-relay"
+
+// MaxDatagramSize is the largest datagram a frame can hold
+const MaxDatagramSize = 65535
+
+// WriteFrame writes a datagram prefixed by its length.
+func WriteFrame(w io.Writer, p []byte) error {
+	if len(p) > MaxDatagramSize {
+		return errors.Errorf("datagram of %d bytes exceeds %d bytes", len(p), MaxDatagramSize)
+	}
+	frame := make([]byte, 2+len(p))
+	binary.BigEndian.PutUint16(frame, uint16(len(p)))
+	copy(frame[2:], p)
+	_, err := w.Write(frame)
+	return err
+}
+
+// ReadFrame reads a datagram written by WriteFrame into buf, which must hold MaxDatagramSize bytes.
+func ReadFrame(r io.Reader, buf []byte) ([]byte, error) {
+	var size [2]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	p := buf[:binary.BigEndian.Uint16(size[:])]
+	if _, err := io.ReadFull(r, p); err != nil {
+		return nil, errors.Wrap(err, "reading datagram")
+	}
+	return p, nil
+}
+
+// Serve sends the datagrams framed on in to conn, and writes the replies from conn framed on out,
+// until in is closed.
+func Serve(conn net.Conn, in io.Reader, out io.Writer) error {
+	done := make(chan error, 1)
+	go func() {
+		buf := make([]byte, MaxDatagramSize)
+		for {
+			n, err := conn.Read(buf)
+			if refused(err) {
+				continue
+			}
+			if err == nil {
+				err = WriteFrame(out, buf[:n])
+			}
+			if err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+
+	buf := make([]byte, MaxDatagramSize)
+	for {
+		p, err := ReadFrame(in, buf)
+		if err == io.EOF {
+			return conn.Close()
+		}
+		if err == nil {
+			_, err = conn.Write(p)
+		}
+		if err != nil && !refused(err) {
+			conn.Close()
+			return err
+		}
+
+		select {
+		case err := <-done:
+			conn.Close()
+			return errors.Wrap(err, "relaying replies")
+		default:
+		}
+	}
+}
+
+// refused reports whether the previous datagram was rejected by the target, which like for
+// any UDP client only loses that datagram.
+func refused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package relay
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestFrames(t *testing.T) {
+	var b bytes.Buffer
+	datagrams := [][]byte{[]byte("hello"), {}, bytes.Repeat([]byte{'x'}, MaxDatagramSize)}
+	for _, d := range datagrams {
+		if err := WriteFrame(&b, d); err != nil {
+			t.Fatalf("WriteFrame: %v", err)
+		}
+	}
+	if err := WriteFrame(&b, make([]byte, MaxDatagramSize+1)); err == nil {
+		t.Errorf("expected an error writing a datagram larger than %d bytes", MaxDatagramSize)
+	}
+
+	buf := make([]byte, MaxDatagramSize)
+	for _, want := range datagrams {
+		got, err := ReadFrame(&b, buf)
+		if err != nil {
+			t.Fatalf("ReadFrame: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("got a datagram of %d bytes, want %d bytes", len(got), len(want))
+		}
+	}
+	if _, err := ReadFrame(&b, buf); err != io.EOF {
+		t.Errorf("got %v at the end of the stream, want EOF", err)
+	}
+}
+
+func TestServe(t *testing.T) {
+	echo, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		buf := make([]byte, MaxDatagramSize)
+		for {
+			n, addr, err := echo.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = echo.WriteTo(bytes.ToUpper(buf[:n]), addr)
+		}
+	}()
+
+	conn, err := net.Dial("udp", echo.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- Serve(conn, inR, outW) }()
+
+	buf := make([]byte, MaxDatagramSize)
+	for _, msg := range []string{"ping", "pong"} {
+		if err := WriteFrame(inW, []byte(msg)); err != nil {
+			t.Fatalf("WriteFrame: %v", err)
+		}
+		got, err := ReadFrame(outR, buf)
+		if err != nil {
+			t.Fatalf("ReadFrame: %v", err)
+		}
+		if want := bytes.ToUpper([]byte(msg)); !bytes.Equal(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+
+	inW.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Serve did not return once its input was closed")
+	}
+}
@@ -45,6 +45,12 @@ func (r *simpleReporter) Report(tunnelState *Status) {
 	minikubeState := tunnelState.MinikubeState.String()
 
 	managedServices := fmt.Sprintf("[%s]", strings.Join(tunnelState.PatchedServices, ", "))
+	for _, p := range tunnelState.Protocols {
+		managedServices += fmt.Sprintf("\n\t\t%s: [%s]", p.Protocol, strings.Join(p.Services, ", "))
+		if p.Error != nil {
+			managedServices += fmt.Sprintf(" (%s)", p.Error)
+		}
+	}
 
 	lbError := noErrors
 	if tunnelState.LoadBalancerEmulatorError != nil {
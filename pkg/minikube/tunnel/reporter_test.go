@@ -55,6 +55,38 @@ func TestReporter(t *testing.T) {
 		minikube: no errors
 		router: no errors
 		loadbalancer emulator: no errors
+`,
+		},
+		{
+			name: "protocols",
+			tunnelState: &Status{
+				TunnelID: ID{
+					Route:       unsafeParseRoute("1.2.3.4", "10.96.0.0/12"),
+					MachineName: "testmachine",
+					Pid:         1234,
+				},
+				MinikubeState: Running,
+
+				PatchedServices: []string{"dns", "web"},
+				Protocols: []ProtocolStatus{
+					{Protocol: "TCP", Services: []string{"dns", "web"}},
+					{Protocol: "UDP", Services: []string{"dns"}},
+					{Protocol: "SCTP", Services: []string{"web"}, Error: errors.New("SCTP is not supported on darwin")},
+				},
+			},
+			expectedOutput: `Status:	
+	machine: testmachine
+	pid: 1234
+	route: 10.96.0.0/12 -> 1.2.3.4
+	minikube: Running
+	services: [dns, web]
+		TCP: [dns, web]
+		UDP: [dns]
+		SCTP: [web] (SCTP is not supported on darwin)
+    errors: 
+		minikube: no errors
+		router: no errors
+		loadbalancer emulator: no errors
 `,
 		},
 		{
@@ -22,10 +22,12 @@ import (
 
 	"os/exec"
 	"regexp"
+	"runtime"
 
 	"github.com/docker/machine/libmachine"
 	"github.com/docker/machine/libmachine/host"
 	"github.com/pkg/errors"
+	core "k8s.io/api/core/v1"
 	typed_core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/minikube/pkg/minikube/config"
@@ -123,6 +125,7 @@ func (t *tunnel) update() *Status {
 		setupRoute(t, h)
 		if t.status.RouteError == nil {
 			t.status.PatchedServices, t.status.LoadBalancerEmulatorError = t.LoadBalancerEmulator.PatchServices()
+			t.status.Protocols = ProtocolStatuses(t.LoadBalancerEmulator.services, routeUnsupportedProtocols())
 		}
 	}
 	klog.V(3).Infof("sending report %s", t.status)
@@ -130,6 +133,17 @@ func (t *tunnel) update() *Status {
 	return t.status
 }
 
+// routeUnsupportedProtocols returns the protocols the host can't send through the route, which
+// otherwise carries every protocol to the cluster IPs.
+func routeUnsupportedProtocols() map[core.Protocol]error {
+	if runtime.GOOS == "linux" || runtime.GOOS == "freebsd" {
+		return nil
+	}
+	return map[core.Protocol]error{
+		core.ProtocolSCTP: errors.Errorf("SCTP is not supported on %s", runtime.GOOS),
+	}
+}
+
 func setupRoute(t *tunnel, h *host.Host) {
 	exists, conflict, _, err := t.router.Inspect(t.status.TunnelID.Route)
 	if err != nil {
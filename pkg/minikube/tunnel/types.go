@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 
+	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -34,6 +35,22 @@ type Status struct {
 
 	PatchedServices           []string
 	LoadBalancerEmulatorError error
+
+	Protocols []ProtocolStatus
+}
+
+// ProtocolStatus represents the services whose ports of a protocol go through the tunnel
+type ProtocolStatus struct {
+	Protocol core.Protocol
+	Services []string
+	Error    error
+}
+
+func (p ProtocolStatus) String() string {
+	if p.Error != nil {
+		return fmt.Sprintf("%s(%s, e:%s)", p.Protocol, p.Services, p.Error)
+	}
+	return fmt.Sprintf("%s(%s)", p.Protocol, p.Services)
 }
 
 // Clone clones an existing Status
@@ -45,18 +62,20 @@ func (t *Status) Clone() *Status {
 		RouteError:                t.RouteError,
 		PatchedServices:           t.PatchedServices,
 		LoadBalancerEmulatorError: t.LoadBalancerEmulatorError,
+		Protocols:                 t.Protocols,
 	}
 }
 
 func (t *Status) String() string {
-	return fmt.Sprintf("id(%v), minikube(%s, e:%s), route(%s, e:%s), services(%s, e:%s)",
+	return fmt.Sprintf("id(%v), minikube(%s, e:%s), route(%s, e:%s), services(%s, e:%s), protocols%s",
 		t.TunnelID,
 		t.MinikubeState,
 		t.MinikubeError,
 		t.TunnelID.Route,
 		t.RouteError,
 		t.PatchedServices,
-		t.LoadBalancerEmulatorError)
+		t.LoadBalancerEmulatorError,
+		t.Protocols)
 }
 
 // Route represents a route
@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/bsutil"
+	"k8s.io/minikube/pkg/minikube/bootstrapper/k3s"
+	"k8s.io/minikube/pkg/minikube/cluster"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/sysinit"
+	"k8s.io/minikube/pkg/minikube/vmpath"
+)
+
+// kubeletConfig is rewritten by kubeadm upgrade from the kubelet config of the cluster
+const kubeletConfig = "/var/lib/kubelet/config.yaml"
+
+// BackupPath returns the path of the pre-upgrade backup of a node running Kubernetes version from
+func BackupPath(from string) string {
+	return path.Join(vmpath.GuestPersistentDir, "backup", "pre-upgrade-"+from+".tar.gz")
+}
+
+// backupPaths returns the paths kubeadm upgrade changes on a node, or the state of k3s, which migrates it when upgraded
+func backupPaths(cc config.ClusterConfig, n config.Node) []string {
+	if cc.KubernetesConfig.Bootstrapper == bootstrapper.K3s {
+		return []string{k3s.DataDir}
+	}
+	paths := []string{kubeletConfig}
+	if n.ControlPlane {
+		paths = append(paths, bsutil.EtcdDataDir(), vmpath.GuestManifestsDir)
+	}
+	return paths
+}
+
+// backup archives the etcd data and static pod manifests of control planes, and the kubelet config, on the node itself
+func backup(runner command.Runner, cr cruntime.Manager, cc config.ClusterConfig, n config.Node, from string) error {
+	dst := BackupPath(from)
+	if _, err := runner.RunCmd(exec.Command("sudo", "mkdir", "-p", path.Dir(dst))); err != nil {
+		return errors.Wrap(err, "creating backup dir")
+	}
+
+	if n.ControlPlane {
+		// pause the control plane, so that the etcd data is consistent
+		namespaces := []string{"kube-system"}
+		if _, err := cluster.Pause(cr, runner, namespaces); err != nil {
+			return errors.Wrap(err, "pause")
+		}
+		defer func() {
+			if _, err := cluster.Unpause(cr, runner, namespaces); err != nil {
+				klog.Errorf("failed to unpause control plane: %v", err)
+			}
+		}()
+	}
+
+	args := []string{"tar", "-C", "/", "-czf", dst}
+	for _, p := range backupPaths(cc, n) {
+		args = append(args, strings.TrimPrefix(p, "/"))
+	}
+	if _, err := runner.RunCmd(exec.Command("sudo", args...)); err != nil {
+		return errors.Wrap(err, "archiving")
+	}
+	return nil
+}
+
+// restore puts back the pre-upgrade backup of a node, and the kubelet of the Kubernetes version of cc
+func restore(runner command.Runner, cr cruntime.Manager, bs bootstrapper.Bootstrapper, cc config.ClusterConfig, n config.Node, from string) error {
+	sm := sysinit.New(runner)
+	if err := sm.Stop("kubelet"); err != nil {
+		return errors.Wrap(err, "stopping kubelet")
+	}
+
+	if n.ControlPlane {
+		ids, err := cr.ListContainers(cruntime.ListContainersOptions{State: cruntime.Running, Namespaces: []string{"kube-system"}})
+		if err != nil {
+			return errors.Wrap(err, "list running")
+		}
+		if err := cr.StopContainers(ids); err != nil {
+			return errors.Wrap(err, "stopping control plane")
+		}
+	}
+
+	args := []string{"rm", "-rf"}
+	args = append(args, backupPaths(cc, n)...)
+	if _, err := runner.RunCmd(exec.Command("sudo", args...)); err != nil {
+		return errors.Wrap(err, "removing upgraded files")
+	}
+	if _, err := runner.RunCmd(exec.Command("sudo", "tar", "-C", "/", "-xzf", BackupPath(from))); err != nil {
+		return errors.Wrap(err, "extracting backup")
+	}
+
+	// point the kubelet back at the binaries of the previous version
+	if err := bs.UpdateNode(cc, n, cr); err != nil {
+		return errors.Wrap(err, "updating node")
+	}
+	if n.ControlPlane && cc.KubernetesConfig.Bootstrapper != bootstrapper.K3s {
+		if _, err := runner.RunCmd(exec.Command("sudo", "cp", bsutil.KubeadmYamlPath+".new", bsutil.KubeadmYamlPath)); err != nil {
+			return errors.Wrap(err, "cp")
+		}
+	}
+	return sm.Start("kubelet")
+}
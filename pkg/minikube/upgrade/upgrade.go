@@ -0,0 +1,304 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package upgrade upgrades the Kubernetes version of a running cluster in place
+package upgrade
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/docker/machine/libmachine"
+	"github.com/docker/machine/libmachine/state"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/minikube/pkg/kapi"
+	"k8s.io/minikube/pkg/minikube/bootstrapper"
+	"k8s.io/minikube/pkg/minikube/cluster"
+	"k8s.io/minikube/pkg/minikube/command"
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/constants"
+	"k8s.io/minikube/pkg/minikube/cruntime"
+	"k8s.io/minikube/pkg/minikube/download"
+	"k8s.io/minikube/pkg/minikube/machine"
+	"k8s.io/minikube/pkg/minikube/out"
+	"k8s.io/minikube/pkg/minikube/style"
+	"k8s.io/minikube/pkg/minikube/sysinit"
+	"k8s.io/minikube/pkg/util"
+	"k8s.io/minikube/pkg/util/retry"
+	"k8s.io/minikube/pkg/version"
+)
+
+// nodeTimeout is how long an upgraded node may take to become ready
+const nodeTimeout = 6 * time.Minute
+
+// UnsupportedError is returned when a cluster can not be upgraded to a version
+type UnsupportedError struct {
+	From   string
+	To     string
+	Reason string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("unable to upgrade from %s to %s: %s", e.From, e.To, e.Reason)
+}
+
+// IsUnsupported returns whether the error is an UnsupportedError
+func IsUnsupported(err error) bool {
+	_, ok := errors.Cause(err).(*UnsupportedError)
+	return ok
+}
+
+// RollbackError is returned when an upgrade failed, and so did its rollback
+type RollbackError struct {
+	Err      error
+	Rollback error
+}
+
+func (e *RollbackError) Error() string {
+	return fmt.Sprintf("%v, and the rollback failed: %v", e.Err, e.Rollback)
+}
+
+// Plan is an upgrade of a cluster to another Kubernetes version
+type Plan struct {
+	From string
+	To   string
+	// Nodes are the nodes in the order they are upgraded: the primary control plane, the other control planes, then the workers
+	Nodes []config.Node
+}
+
+// NewPlan returns the plan to upgrade cc to k8sVersion, or an UnsupportedError if kubeadm can not upgrade it there.
+// Versions newer than the newest version supported by minikube require force.
+func NewPlan(cc *config.ClusterConfig, k8sVersion string, force bool) (*Plan, error) {
+	p := &Plan{From: cc.KubernetesConfig.KubernetesVersion, To: k8sVersion}
+	unsupported := func(format string, a ...interface{}) error {
+		return &UnsupportedError{From: p.From, To: p.To, Reason: fmt.Sprintf(format, a...)}
+	}
+
+	if p.From == constants.NoKubernetesVersion {
+		return nil, unsupported("the profile does not run Kubernetes")
+	}
+	from, err := util.ParseKubernetesVersion(p.From)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", p.From)
+	}
+	if !strings.HasPrefix(k8sVersion, version.VersionPrefix) {
+		return nil, unsupported("versions must start with %q", version.VersionPrefix)
+	}
+	to, err := util.ParseKubernetesVersion(k8sVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", k8sVersion)
+	}
+
+	switch {
+	case to.EQ(from):
+		return nil, unsupported("the cluster already runs %s", p.From)
+	case to.LT(from):
+		return nil, unsupported("kubeadm does not support downgrades")
+	case to.Major != from.Major || to.Minor > from.Minor+1:
+		return nil, unsupported("kubeadm only upgrades one minor version at a time, upgrade to v%d.%d first", from.Major, from.Minor+1)
+	case to.GT(semver.MustParse(strings.TrimPrefix(constants.NewestKubernetesVersion, version.VersionPrefix))) && !force:
+		return nil, unsupported("it is newer than %s, the newest version supported by minikube %s", constants.NewestKubernetesVersion, version.GetVersion())
+	}
+
+	cp, err := config.PrimaryControlPlane(cc)
+	if err != nil {
+		return nil, errors.Wrap(err, "primary control plane")
+	}
+	p.Nodes = append(p.Nodes, cp)
+	for _, worker := range []bool{false, true} {
+		for _, n := range cc.Nodes {
+			if n.Name != cp.Name && n.ControlPlane != worker {
+				p.Nodes = append(p.Nodes, n)
+			}
+		}
+	}
+	return p, nil
+}
+
+// target is a node being upgraded
+type target struct {
+	node   config.Node
+	runner command.Runner
+	bs     bootstrapper.Bootstrapper
+	cr     cruntime.Manager
+	// upgraded is true once the upgrade of the node has begun, so that it is rolled back on failure
+	upgraded bool
+}
+
+// Upgrade upgrades every node of a running cluster following p, and rolls the upgraded nodes back to their pre-upgrade backup on failure
+func Upgrade(api libmachine.API, cc *config.ClusterConfig, bsName string, p *Plan) error {
+	next := *cc
+	next.KubernetesConfig.KubernetesVersion = p.To
+
+	targets := []*target{}
+	for _, n := range p.Nodes {
+		t, err := newTarget(api, next, bsName, n)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, t)
+	}
+
+	out.Step(style.FileDownload, "Preparing Kubernetes {{.version}} ...", out.V{"version": p.To})
+	if err := cacheImages(next, bsName); err != nil {
+		return errors.Wrap(err, "caching images")
+	}
+	for _, t := range targets {
+		if err := t.bs.PrepareUpgrade(next, t.node, t.cr); err != nil {
+			return errors.Wrapf(err, "preflight checks of %s", config.MachineName(next, t.node))
+		}
+	}
+
+	out.Step(style.Caching, "Backing up the cluster ...")
+	for _, t := range targets {
+		if err := backup(t.runner, t.cr, next, t.node, p.From); err != nil {
+			return errors.Wrapf(err, "backing up %s", config.MachineName(next, t.node))
+		}
+	}
+
+	cp := targets[0]
+	for _, t := range targets {
+		t.upgraded = true
+		name := config.MachineName(next, t.node)
+		out.Step(style.Launch, "Upgrading node {{.name}} to Kubernetes {{.version}} ...", out.V{"name": name, "version": p.To})
+		if err := upgradeNode(cp.runner, next, p.From, t); err != nil {
+			err = errors.Wrapf(err, "upgrading %s", name)
+			out.FailureT("Upgrade failed: {{.error}}", out.V{"error": err})
+			out.Step(style.Resetting, "Rolling back to Kubernetes {{.version}} ...", out.V{"version": p.From})
+			if rerr := rollback(cp.runner, *cc, targets); rerr != nil {
+				return &RollbackError{Err: err, Rollback: rerr}
+			}
+			return err
+		}
+	}
+
+	cc.KubernetesConfig.KubernetesVersion = p.To
+	return config.SaveProfile(cc.Name, cc)
+}
+
+func newTarget(api libmachine.API, cc config.ClusterConfig, bsName string, n config.Node) (*target, error) {
+	name := config.MachineName(cc, n)
+	st, err := machine.Status(api, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "status of %s", name)
+	}
+	if st != state.Running.String() {
+		return nil, fmt.Errorf("node %s is not running (state=%s)", name, st)
+	}
+	h, err := machine.LoadHost(api, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading host %s", name)
+	}
+	runner, err := machine.CommandRunner(h)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := cluster.Bootstrapper(api, bsName, cc, runner)
+	if err != nil {
+		return nil, err
+	}
+	cr, err := cruntime.New(cruntime.Config{Type: cc.KubernetesConfig.ContainerRuntime, Runner: runner, Socket: cc.KubernetesConfig.CRISocket})
+	if err != nil {
+		return nil, errors.Wrap(err, "runtime")
+	}
+	return &target{node: n, runner: runner, bs: bs, cr: cr}, nil
+}
+
+// cacheImages caches the preload, or the images, of the Kubernetes version of cc on the host
+func cacheImages(cc config.ClusterConfig, bsName string) error {
+	k8s := cc.KubernetesConfig
+	if k8s.ImageRepository == "" && download.PreloadExists(k8s.KubernetesVersion, k8s.ContainerRuntime, cc.Driver) {
+		err := download.Preload(k8s.KubernetesVersion, k8s.ContainerRuntime, cc.Driver)
+		if err == nil {
+			return nil
+		}
+		klog.Warningf("Error downloading preloaded artifacts will continue without preload: %v", err)
+	}
+	if !k8s.ShouldLoadCachedImages {
+		return nil
+	}
+	return machine.CacheImagesForBootstrapper(k8s.ImageRepository, k8s.KubernetesVersion, bsName)
+}
+
+// upgradeNode upgrades a node with kubeadm, then drains it while its kubelet is upgraded
+func upgradeNode(cpRunner command.Runner, cc config.ClusterConfig, from string, t *target) error {
+	if err := t.bs.UpgradeNode(cc, t.node, t.cr); err != nil {
+		return err
+	}
+	if err := kubectl(cpRunner, from, "drain", config.MachineName(cc, t.node), "--ignore-daemonsets", "--delete-emptydir-data", "--force", "--timeout=2m"); err != nil {
+		return errors.Wrap(err, "drain")
+	}
+	if err := sysinit.New(t.runner).Restart("kubelet"); err != nil {
+		return errors.Wrap(err, "restarting kubelet")
+	}
+	// uncordon before waiting, as the system pods of a single node cluster were evicted
+	if err := uncordon(cpRunner, cc, from, t.node); err != nil {
+		return err
+	}
+	if err := t.bs.WaitForNode(cc, t.node, nodeTimeout); err != nil {
+		return errors.Wrap(err, "waiting for node")
+	}
+	return nil
+}
+
+// rollback restores the pre-upgrade backup of every node whose upgrade has begun, the primary control plane first
+func rollback(cpRunner command.Runner, cc config.ClusterConfig, targets []*target) error {
+	from := cc.KubernetesConfig.KubernetesVersion
+	var errs []string
+	for _, t := range targets {
+		if !t.upgraded {
+			continue
+		}
+		name := config.MachineName(cc, t.node)
+		if err := restore(t.runner, t.cr, t.bs, cc, t.node, from); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if err := uncordon(cpRunner, cc, from, t.node); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if err := t.bs.WaitForNode(cc, t.node, nodeTimeout); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: waiting for node: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// uncordon uncordons a node, retrying while the apiserver comes back up
+func uncordon(cpRunner command.Runner, cc config.ClusterConfig, version string, n config.Node) error {
+	uncordon := func() error {
+		return kubectl(cpRunner, version, "uncordon", config.MachineName(cc, n))
+	}
+	if err := retry.Expo(uncordon, time.Second, 2*time.Minute); err != nil {
+		return errors.Wrap(err, "uncordon")
+	}
+	return nil
+}
+
+// kubectl runs the kubectl of version on the control plane
+func kubectl(cpRunner command.Runner, version string, args ...string) error {
+	c := exec.Command("sudo", append([]string{"KUBECONFIG=/var/lib/minikube/kubeconfig", kapi.KubectlBinaryPath(version)}, args...)...)
+	_, err := cpRunner.RunCmd(c)
+	return err
+}
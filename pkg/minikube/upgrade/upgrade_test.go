@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"testing"
+
+	"k8s.io/minikube/pkg/minikube/config"
+	"k8s.io/minikube/pkg/minikube/constants"
+)
+
+func TestNewPlan(t *testing.T) {
+	tests := []struct {
+		from  string
+		to    string
+		force bool
+		want  bool
+	}{
+		{from: "v1.22.9", to: "v1.23.6", want: true},
+		{from: "v1.23.1", to: "v1.23.6", want: true},
+		{from: "v1.23.6", to: "v1.23.6"},
+		{from: "v1.23.6", to: "v1.22.9"},
+		{from: "v1.21.2", to: "v1.23.6"},
+		{from: "v1.22.9", to: "1.23.6"},
+		{from: constants.NoKubernetesVersion, to: "v1.23.6"},
+		{from: "v1.99.0", to: "v1.99.1"},
+		{from: "v1.99.0", to: "v1.99.1", force: true, want: true},
+	}
+	for _, tc := range tests {
+		cc := &config.ClusterConfig{
+			KubernetesConfig: config.KubernetesConfig{KubernetesVersion: tc.from},
+			Nodes:            []config.Node{{ControlPlane: true, Worker: true}},
+		}
+		_, err := NewPlan(cc, tc.to, tc.force)
+		if tc.want && err != nil {
+			t.Errorf("NewPlan(%s -> %s) returned %v", tc.from, tc.to, err)
+		}
+		if !tc.want && !IsUnsupported(err) {
+			t.Errorf("NewPlan(%s -> %s) = %v, want an UnsupportedError", tc.from, tc.to, err)
+		}
+	}
+}
+
+func TestNewPlanNodeOrder(t *testing.T) {
+	cc := &config.ClusterConfig{
+		KubernetesConfig: config.KubernetesConfig{KubernetesVersion: "v1.22.9"},
+		Nodes: []config.Node{
+			{Name: "", ControlPlane: true, Worker: true},
+			{Name: "m02", Worker: true},
+			{Name: "m03", ControlPlane: true},
+			{Name: "m04", Worker: true},
+		},
+	}
+	p, err := NewPlan(cc, "v1.23.6", false)
+	if err != nil {
+		t.Fatalf("NewPlan: %v", err)
+	}
+	var got []string
+	for _, n := range p.Nodes {
+		got = append(got, n.Name)
+	}
+	want := []string{"", "m03", "m02", "m04"}
+	if len(got) != len(want) {
+		t.Fatalf("nodes = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("nodes = %q, want %q", got, want)
+		}
+	}
+}
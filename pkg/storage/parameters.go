@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// StorageClass parameters understood by the hostpath provisioner
+const (
+	// paramQuota selects how the size of a volume is enforced: none, auto, project or loopback
+	paramQuota = "quota"
+	// paramMode is the octal permission mode of the volume directory
+	paramMode = "mode"
+	// paramUID and paramGID own the volume directory
+	paramUID = "uid"
+	paramGID = "gid"
+	// paramOnDelete selects what happens to the data of a deleted volume: delete or archive
+	paramOnDelete = "onDelete"
+)
+
+// Values of the onDelete parameter
+const (
+	onDeleteDelete  = "delete"
+	onDeleteArchive = "archive"
+)
+
+// volumeOptions are the settings of a volume, parsed from its StorageClass parameters
+type volumeOptions struct {
+	Quota    string
+	Mode     os.FileMode
+	UID      int
+	GID      int
+	OnDelete string
+}
+
+// parseParameters parses StorageClass parameters, applying the defaults
+// which match the behavior of the provisioner before they were introduced.
+func parseParameters(params map[string]string) (volumeOptions, error) {
+	opts := volumeOptions{
+		Quota:    quotaNone,
+		Mode:     0777,
+		UID:      -1,
+		GID:      -1,
+		OnDelete: onDeleteDelete,
+	}
+	for k, v := range params {
+		switch strings.ToLower(k) {
+		case strings.ToLower(paramQuota):
+			switch v {
+			case quotaNone, quotaAuto, quotaProject, quotaLoopback:
+				opts.Quota = v
+			default:
+				return opts, fmt.Errorf("invalid %s %q: must be one of %s, %s, %s or %s", paramQuota, v, quotaNone, quotaAuto, quotaProject, quotaLoopback)
+			}
+		case strings.ToLower(paramMode):
+			mode, err := strconv.ParseUint(v, 8, 32)
+			if err != nil || mode > 0777 {
+				return opts, fmt.Errorf("invalid %s %q: must be an octal permission mode such as 0770", paramMode, v)
+			}
+			opts.Mode = os.FileMode(mode)
+		case strings.ToLower(paramUID), strings.ToLower(paramGID):
+			id, err := strconv.Atoi(v)
+			if err != nil || id < 0 {
+				return opts, fmt.Errorf("invalid %s %q: must be a non-negative integer", k, v)
+			}
+			if strings.EqualFold(k, paramUID) {
+				opts.UID = id
+			} else {
+				opts.GID = id
+			}
+		case strings.ToLower(paramOnDelete):
+			switch v {
+			case onDeleteDelete, onDeleteArchive:
+				opts.OnDelete = v
+			default:
+				return opts, fmt.Errorf("invalid %s %q: must be %s or %s", paramOnDelete, v, onDeleteDelete, onDeleteArchive)
+			}
+		default:
+			return opts, fmt.Errorf("unknown StorageClass parameter %q", k)
+		}
+	}
+	return opts, nil
+}
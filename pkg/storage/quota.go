@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+)
+
+// Values of the quota parameter
+const (
+	quotaNone     = "none"
+	quotaAuto     = "auto"
+	quotaProject  = "project"
+	quotaLoopback = "loopback"
+)
+
+// mountsFile lists the mounted filesystems, replaced in tests
+var mountsFile = "/proc/mounts"
+
+// runner runs a host command, returning its combined output
+type runner func(name string, args ...string) (string, error)
+
+func execRunner(name string, args ...string) (string, error) {
+	klog.Infof("Running %s %s", name, strings.Join(args, " "))
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return string(out), errors.Wrapf(err, "%s %s: %s", name, strings.Join(args, " "), out)
+	}
+	return string(out), nil
+}
+
+// volume is a directory backing a persistent volume
+type volume struct {
+	// Name of the persistent volume
+	Name string
+	// Dir is the directory holding the data of the volume
+	Dir string
+	// Size of the volume in bytes
+	Size int64
+}
+
+// quota enforces the size of volumes
+type quota interface {
+	// Create prepares the directory of a new volume and limits it to its size
+	Create(v volume) error
+	// Expand raises the size limit of an existing volume
+	Expand(v volume) error
+	// Release removes the limit of a volume, returning the path which now holds its data
+	Release(v volume) (string, error)
+}
+
+// noQuota does not limit the size of volumes
+type noQuota struct{}
+
+func (noQuota) Create(v volume) error {
+	return os.MkdirAll(v.Dir, 0777)
+}
+
+func (noQuota) Expand(v volume) error {
+	return nil
+}
+
+func (noQuota) Release(v volume) (string, error) {
+	return v.Dir, nil
+}
+
+// mount is an entry of the mounts file
+type mount struct {
+	Device  string
+	Path    string
+	Type    string
+	Options []string
+}
+
+// mountOf returns the mount holding path, which is the one with the longest matching mount point.
+func mountOf(path string) (mount, error) {
+	f, err := os.Open(mountsFile)
+	if err != nil {
+		return mount{}, err
+	}
+	defer f.Close()
+
+	found := mount{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		m := mount{Device: fields[0], Path: fields[1], Type: fields[2], Options: strings.Split(fields[3], ",")}
+		if !isWithin(path, m.Path) || len(m.Path) < len(found.Path) {
+			continue
+		}
+		found = m
+	}
+	if err := s.Err(); err != nil {
+		return mount{}, err
+	}
+	if found.Path == "" {
+		return mount{}, fmt.Errorf("no mount found for %s", path)
+	}
+	return found, nil
+}
+
+// isMounted returns whether a filesystem is mounted at path.
+func isMounted(path string) (bool, error) {
+	m, err := mountOf(path)
+	if err != nil {
+		return false, err
+	}
+	return m.Path == filepath.Clean(path), nil
+}
+
+// isWithin returns whether path is dir or one of its descendants.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// supportsProjectQuota returns whether the filesystem holding dir enforces xfs project quotas.
+func supportsProjectQuota(dir string) bool {
+	m, err := mountOf(dir)
+	if err != nil {
+		klog.Warningf("unable to find the mount of %s: %v", dir, err)
+		return false
+	}
+	if m.Type != "xfs" {
+		return false
+	}
+	for _, o := range m.Options {
+		if o == "prjquota" || o == "pquota" {
+			return true
+		}
+	}
+	return false
+}
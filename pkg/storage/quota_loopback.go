@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+)
+
+// loopbackDir holds the image files of loopback volumes
+const loopbackDir = ".loopback"
+
+// loopbackQuota backs each volume with an ext4 image file of its size, mounted
+// through a loop device on the volume directory. Writing past the size fails
+// with ENOSPC, as on a full disk.
+type loopbackQuota struct {
+	pvDir string
+	run   runner
+}
+
+func (q *loopbackQuota) image(v volume) string {
+	return filepath.Join(q.pvDir, loopbackDir, v.Name+".img")
+}
+
+func (q *loopbackQuota) Create(v volume) error {
+	img := q.image(v)
+	if err := os.MkdirAll(filepath.Dir(img), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(img, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "creating image")
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Truncate(img, v.Size); err != nil {
+		return errors.Wrap(err, "sizing image")
+	}
+	if _, err := q.run("mkfs.ext4", "-q", "-F", "-m", "0", img); err != nil {
+		return errors.Wrap(err, "formatting image")
+	}
+	return q.mount(v)
+}
+
+// mount mounts the image of a volume on its directory, unless it already is.
+func (q *loopbackQuota) mount(v volume) error {
+	if err := os.MkdirAll(v.Dir, 0777); err != nil {
+		return err
+	}
+	mounted, err := isMounted(v.Dir)
+	if err != nil {
+		return err
+	}
+	if mounted {
+		return nil
+	}
+	if _, err := q.run("mount", "-o", "loop", q.image(v), v.Dir); err != nil {
+		return errors.Wrap(err, "mounting image")
+	}
+	return nil
+}
+
+func (q *loopbackQuota) Expand(v volume) error {
+	img := q.image(v)
+	fi, err := os.Stat(img)
+	if err != nil {
+		return err
+	}
+	if fi.Size() >= v.Size {
+		return nil
+	}
+	if err := os.Truncate(img, v.Size); err != nil {
+		return errors.Wrap(err, "sizing image")
+	}
+	out, err := q.run("losetup", "-j", img)
+	if err != nil {
+		return errors.Wrap(err, "finding loop device")
+	}
+	// losetup -j prints "/dev/loop0: [2049]:1234 (/path/to/image)"
+	dev := strings.SplitN(strings.TrimSpace(out), ":", 2)[0]
+	if dev == "" {
+		return errors.Errorf("image %s is not attached to a loop device", img)
+	}
+	if _, err := q.run("losetup", "-c", dev); err != nil {
+		return errors.Wrap(err, "refreshing loop device capacity")
+	}
+	if _, err := q.run("resize2fs", dev); err != nil {
+		return errors.Wrap(err, "growing filesystem")
+	}
+	return nil
+}
+
+func (q *loopbackQuota) Release(v volume) (string, error) {
+	mounted, err := isMounted(v.Dir)
+	if err != nil {
+		return "", err
+	}
+	if mounted {
+		if _, err := q.run("umount", v.Dir); err != nil {
+			return "", errors.Wrap(err, "unmounting image")
+		}
+	}
+	if err := os.Remove(v.Dir); err != nil && !os.IsNotExist(err) {
+		klog.Warningf("unable to remove %s: %v", v.Dir, err)
+	}
+	return q.image(v), nil
+}
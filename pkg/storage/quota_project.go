@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// projectsFile records the xfs project IDs assigned to volume directories, in the format of /etc/projects
+const projectsFile = ".projects"
+
+// projectQuota limits volumes with xfs project quotas, which require the filesystem
+// holding the volumes to be mounted with the prjquota option.
+type projectQuota struct {
+	pvDir string
+	run   runner
+
+	// mu guards the projects file
+	mu sync.Mutex
+}
+
+func (q *projectQuota) Create(v volume) error {
+	if err := os.MkdirAll(v.Dir, 0777); err != nil {
+		return err
+	}
+	m, err := mountOf(v.Dir)
+	if err != nil {
+		return errors.Wrap(err, "finding mount")
+	}
+	id, err := q.assign(v.Dir)
+	if err != nil {
+		return errors.Wrap(err, "assigning project")
+	}
+	if _, err := q.run("xfs_quota", "-x", "-c", fmt.Sprintf("project -s -p %s %d", v.Dir, id), m.Path); err != nil {
+		return errors.Wrap(err, "setting up project")
+	}
+	return q.limit(m.Path, id, v.Size)
+}
+
+func (q *projectQuota) Expand(v volume) error {
+	m, err := mountOf(v.Dir)
+	if err != nil {
+		return errors.Wrap(err, "finding mount")
+	}
+	id, err := q.lookup(v.Dir)
+	if err != nil {
+		return err
+	}
+	return q.limit(m.Path, id, v.Size)
+}
+
+func (q *projectQuota) Release(v volume) (string, error) {
+	m, err := mountOf(v.Dir)
+	if err != nil {
+		return "", errors.Wrap(err, "finding mount")
+	}
+	id, err := q.lookup(v.Dir)
+	if err != nil {
+		return "", err
+	}
+	if err := q.limit(m.Path, id, 0); err != nil {
+		return "", err
+	}
+	if _, err := q.run("xfs_quota", "-x", "-c", fmt.Sprintf("project -C -p %s %d", v.Dir, id), m.Path); err != nil {
+		return "", errors.Wrap(err, "clearing project")
+	}
+	return v.Dir, q.unassign(v.Dir)
+}
+
+func (q *projectQuota) limit(mountPath string, id int, size int64) error {
+	if _, err := q.run("xfs_quota", "-x", "-c", fmt.Sprintf("limit -p bhard=%d %d", size, id), mountPath); err != nil {
+		return errors.Wrap(err, "setting project limit")
+	}
+	return nil
+}
+
+// projects reads the project IDs assigned to directories.
+func (q *projectQuota) projects() (map[string]int, error) {
+	projects := map[string]int{}
+	f, err := os.Open(filepath.Join(q.pvDir, projectsFile))
+	if os.IsNotExist(err) {
+		return projects, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.SplitN(s.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", projectsFile)
+		}
+		projects[fields[1]] = id
+	}
+	return projects, s.Err()
+}
+
+func (q *projectQuota) save(projects map[string]int) error {
+	var b strings.Builder
+	for dir, id := range projects {
+		fmt.Fprintf(&b, "%d:%s\n", id, dir)
+	}
+	return os.WriteFile(filepath.Join(q.pvDir, projectsFile), []byte(b.String()), 0644)
+}
+
+// assign returns the project ID of dir, assigning the next free one if it has none.
+func (q *projectQuota) assign(dir string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	projects, err := q.projects()
+	if err != nil {
+		return 0, err
+	}
+	if id, ok := projects[dir]; ok {
+		return id, nil
+	}
+	id := 1
+	for _, used := range projects {
+		if used >= id {
+			id = used + 1
+		}
+	}
+	projects[dir] = id
+	return id, q.save(projects)
+}
+
+func (q *projectQuota) lookup(dir string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	projects, err := q.projects()
+	if err != nil {
+		return 0, err
+	}
+	id, ok := projects[dir]
+	if !ok {
+		return 0, fmt.Errorf("no project assigned to %s", dir)
+	}
+	return id, nil
+}
+
+func (q *projectQuota) unassign(dir string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	projects, err := q.projects()
+	if err != nil {
+		return err
+	}
+	delete(projects, dir)
+	return q.save(projects)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// resyncPeriod is how often claims are checked again for pending expansions
+const resyncPeriod = 5 * time.Minute
+
+// volumeResizer expands the volumes of claims which request more storage than
+// they have. Kubernetes leaves expanding volumes which are not backed by a CSI
+// driver or an in-tree plugin to an external controller such as this one.
+type volumeResizer struct {
+	client kubernetes.Interface
+	p      *hostPathProvisioner
+}
+
+func newVolumeResizer(client kubernetes.Interface, p *hostPathProvisioner) *volumeResizer {
+	return &volumeResizer{client: client, p: p}
+}
+
+// run watches claims until the context is done.
+func (r *volumeResizer) run(ctx context.Context) {
+	factory := informers.NewSharedInformerFactory(r.client, resyncPeriod)
+	informer := factory.Core().V1().PersistentVolumeClaims().Informer()
+	handle := func(obj interface{}) {
+		pvc, ok := obj.(*core.PersistentVolumeClaim)
+		if !ok {
+			return
+		}
+		if err := r.expand(ctx, pvc); err != nil {
+			klog.Errorf("expanding volume of claim %s/%s: %v", pvc.Namespace, pvc.Name, err)
+		}
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    handle,
+		UpdateFunc: func(_, obj interface{}) { handle(obj) },
+	})
+	klog.Info("Starting volume resizer")
+	factory.Start(ctx.Done())
+	<-ctx.Done()
+}
+
+// expand grows the volume bound to a claim to the storage it requests, if it is one of ours.
+func (r *volumeResizer) expand(ctx context.Context, pvc *core.PersistentVolumeClaim) error {
+	if pvc.Status.Phase != core.ClaimBound || pvc.Spec.VolumeName == "" {
+		return nil
+	}
+	requested := pvc.Spec.Resources.Requests[core.ResourceStorage]
+	capacity := pvc.Status.Capacity[core.ResourceStorage]
+	if requested.Cmp(capacity) <= 0 {
+		return nil
+	}
+
+	pv, err := r.client.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, meta.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "getting PV")
+	}
+	if pv.Annotations[provisionedByAnnotation] != provisionerName || pv.Spec.HostPath == nil {
+		return nil
+	}
+
+	current := pv.Spec.Capacity[core.ResourceStorage]
+	if requested.Cmp(current) > 0 {
+		klog.Infof("Expanding volume %s from %s to %s", pv.Name, current.String(), requested.String())
+		v := volumeOf(pv)
+		v.Size = requested.Value()
+		if err := r.p.quota(pv.Annotations[quotaAnnotation]).Expand(v); err != nil {
+			return errors.Wrap(err, "expanding quota")
+		}
+		pv = pv.DeepCopy()
+		pv.Spec.Capacity[core.ResourceStorage] = requested
+		if _, err := r.client.CoreV1().PersistentVolumes().Update(ctx, pv, meta.UpdateOptions{}); err != nil {
+			return errors.Wrap(err, "updating PV capacity")
+		}
+	}
+
+	pvc = pvc.DeepCopy()
+	if pvc.Status.Capacity == nil {
+		pvc.Status.Capacity = core.ResourceList{}
+	}
+	pvc.Status.Capacity[core.ResourceStorage] = requested
+	pvc.Status.Conditions = nil
+	if _, err := r.client.CoreV1().PersistentVolumeClaims(pvc.Namespace).UpdateStatus(ctx, pvc, meta.UpdateOptions{}); err != nil {
+		return errors.Wrap(err, "updating claim capacity")
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 
 	"github.com/pkg/errors"
 
@@ -34,7 +35,18 @@ import (
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v6/controller"
 )
 
-const provisionerName = "k8s.io/minikube-hostpath"
+const (
+	provisionerName = "k8s.io/minikube-hostpath"
+
+	// Annotations of the PVs created by the provisioner
+	identityAnnotation      = "hostPathProvisionerIdentity"
+	quotaAnnotation         = "hostPathProvisionerQuota"
+	onDeleteAnnotation      = "hostPathProvisionerOnDelete"
+	provisionedByAnnotation = "pv.kubernetes.io/provisioned-by"
+
+	// archiveDir holds the data of deleted volumes whose class sets onDelete to archive
+	archiveDir = ".archive"
+)
 
 type hostPathProvisioner struct {
 	// The directory to create PV-backing directories in
@@ -43,43 +55,98 @@ type hostPathProvisioner struct {
 	// Identity of this hostPathProvisioner, generated. Used to identify "this"
 	// provisioner's PVs.
 	identity types.UID
+
+	// quotas is set when the provisioner runs privileged, as required to enforce quotas
+	quotas   bool
+	project  *projectQuota
+	loopback *loopbackQuota
 }
 
 // NewHostPathProvisioner creates a new Provisioner using host paths
 func NewHostPathProvisioner(pvDir string) controller.Provisioner {
+	return newHostPathProvisioner(pvDir, execRunner, false)
+}
+
+func newHostPathProvisioner(pvDir string, run runner, quotas bool) *hostPathProvisioner {
 	return &hostPathProvisioner{
 		pvDir:    pvDir,
 		identity: uuid.NewUUID(),
+		quotas:   quotas,
+		project:  &projectQuota{pvDir: pvDir, run: run},
+		loopback: &loopbackQuota{pvDir: pvDir, run: run},
 	}
 }
 
 var _ controller.Provisioner = &hostPathProvisioner{}
 
+// resolveQuota picks the quota for auto: project quotas where the filesystem supports them, loopback files otherwise.
+func (p *hostPathProvisioner) resolveQuota(kind string) string {
+	if kind != quotaAuto {
+		return kind
+	}
+	if supportsProjectQuota(p.pvDir) {
+		return quotaProject
+	}
+	return quotaLoopback
+}
+
+// quota returns the implementation of a quota, PVs without one are not limited.
+func (p *hostPathProvisioner) quota(kind string) quota {
+	switch kind {
+	case quotaProject:
+		return p.project
+	case quotaLoopback:
+		return p.loopback
+	default:
+		return noQuota{}
+	}
+}
+
 // Provision creates a storage asset and returns a PV object representing it.
 func (p *hostPathProvisioner) Provision(ctx context.Context, options controller.ProvisionOptions) (*core.PersistentVolume, controller.ProvisioningState, error) {
-	path := path.Join(p.pvDir, options.PVC.Namespace, options.PVC.Name)
-	klog.Infof("Provisioning volume %v to %s", options, path)
-	if err := os.MkdirAll(path, 0777); err != nil {
+	opts, err := parseParameters(options.StorageClass.Parameters)
+	if err != nil {
 		return nil, controller.ProvisioningFinished, err
 	}
+	path := path.Join(p.pvDir, options.PVC.Namespace, options.PVC.Name)
+	capacity := options.PVC.Spec.Resources.Requests[core.ResourceStorage]
+	kind := p.resolveQuota(opts.Quota)
+	if kind != quotaNone && !p.quotas {
+		return nil, controller.ProvisioningFinished, fmt.Errorf("the %s quota of class %s is disabled, enable quotas with 'minikube addons configure storage-provisioner'", kind, options.StorageClass.Name)
+	}
+	if kind != quotaNone && capacity.Value() <= 0 {
+		return nil, controller.ProvisioningFinished, fmt.Errorf("claim %s/%s must request storage to be limited by a %s quota", options.PVC.Namespace, options.PVC.Name, kind)
+	}
 
-	// Explicitly chmod created dir, so we know mode is set to 0777 regardless of umask
-	if err := os.Chmod(path, 0777); err != nil {
+	klog.Infof("Provisioning volume %v to %s with %s quota", options, path, kind)
+	if err := p.quota(kind).Create(volume{Name: options.PVName, Dir: path, Size: capacity.Value()}); err != nil {
+		return nil, controller.ProvisioningFinished, errors.Wrapf(err, "creating %s volume", kind)
+	}
+
+	// Explicitly chmod created dir, so we know mode is set regardless of umask
+	if err := os.Chmod(path, opts.Mode); err != nil {
 		return nil, controller.ProvisioningFinished, err
 	}
+	if opts.UID >= 0 || opts.GID >= 0 {
+		if err := os.Chown(path, opts.UID, opts.GID); err != nil {
+			return nil, controller.ProvisioningFinished, err
+		}
+	}
 
 	pv := &core.PersistentVolume{
 		ObjectMeta: meta.ObjectMeta{
 			Name: options.PVName,
 			Annotations: map[string]string{
-				"hostPathProvisionerIdentity": string(p.identity),
+				identityAnnotation: string(p.identity),
+				quotaAnnotation:    kind,
+				onDeleteAnnotation: opts.OnDelete,
 			},
 		},
 		Spec: core.PersistentVolumeSpec{
 			PersistentVolumeReclaimPolicy: *options.StorageClass.ReclaimPolicy,
 			AccessModes:                   options.PVC.Spec.AccessModes,
 			Capacity: core.ResourceList{
-				core.ResourceStorage: options.PVC.Spec.Resources.Requests[core.ResourceStorage],
+				core.ResourceStorage: capacity,
 			},
 			PersistentVolumeSource: core.PersistentVolumeSource{
 				HostPath: &core.HostPathVolumeSource{
@@ -96,7 +163,7 @@ func (p *hostPathProvisioner) Provision(ctx context.Context, options controller.
 // by the given PV.
 func (p *hostPathProvisioner) Delete(ctx context.Context, volume *core.PersistentVolume) error {
 	klog.Infof("Deleting volume %v", volume)
-	ann, ok := volume.Annotations["hostPathProvisionerIdentity"]
+	ann, ok := volume.Annotations[identityAnnotation]
 	if !ok {
 		return errors.New("identity annotation not found on PV")
 	}
@@ -104,15 +171,78 @@ func (p *hostPathProvisioner) Delete(ctx context.Context, volume *core.Persisten
 		return &controller.IgnoredError{Reason: "identity annotation on PV does not match ours"}
 	}
 
-	if err := os.RemoveAll(volume.Spec.PersistentVolumeSource.HostPath.Path); err != nil {
+	path := volume.Spec.PersistentVolumeSource.HostPath.Path
+	data, err := p.quota(volume.Annotations[quotaAnnotation]).Release(volumeOf(volume))
+	if err != nil {
+		return errors.Wrap(err, "releasing quota")
+	}
+	if volume.Annotations[onDeleteAnnotation] == onDeleteArchive {
+		return p.archive(volume, data)
+	}
+
+	if err := os.RemoveAll(data); err != nil {
+		return errors.Wrap(err, "removing hostpath PV")
+	}
+	if err := os.RemoveAll(path); err != nil {
 		return errors.Wrap(err, "removing hostpath PV")
 	}
 
 	return nil
 }
 
-// StartStorageProvisioner will start storage provisioner server
-func StartStorageProvisioner(pvDir string) error {
+// archive moves the data of a deleted volume aside, under the archive directory.
+func (p *hostPathProvisioner) archive(pv *core.PersistentVolume, data string) error {
+	path := pv.Spec.PersistentVolumeSource.HostPath.Path
+	namespace, claim := filepath.Base(filepath.Dir(path)), filepath.Base(path)
+	if pv.Spec.ClaimRef != nil {
+		namespace, claim = pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name
+	}
+	dest := filepath.Join(p.pvDir, archiveDir, namespace, fmt.Sprintf("%s-%s", claim, pv.Name))
+	if data != path {
+		dest += filepath.Ext(data)
+	}
+
+	klog.Infof("Archiving the data of volume %s to %s", pv.Name, dest)
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return errors.Wrap(err, "creating archive")
+	}
+	if err := os.Rename(data, dest); err != nil {
+		return errors.Wrap(err, "archiving hostpath PV")
+	}
+	if data != path {
+		if err := os.RemoveAll(path); err != nil {
+			return errors.Wrap(err, "removing hostpath PV")
+		}
+	}
+	return nil
+}
+
+// volumeOf returns the volume backing a PV.
+func volumeOf(pv *core.PersistentVolume) volume {
+	size := pv.Spec.Capacity[core.ResourceStorage]
+	return volume{Name: pv.Name, Dir: pv.Spec.PersistentVolumeSource.HostPath.Path, Size: size.Value()}
+}
+
+// restoreMounts mounts the loopback volumes again, which do not survive a reboot.
+func (p *hostPathProvisioner) restoreMounts(ctx context.Context, client kubernetes.Interface) error {
+	pvs, err := client.CoreV1().PersistentVolumes().List(ctx, meta.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "listing PVs")
+	}
+	for i := range pvs.Items {
+		pv := &pvs.Items[i]
+		if pv.Annotations[provisionedByAnnotation] != provisionerName || pv.Annotations[quotaAnnotation] != quotaLoopback || pv.Spec.HostPath == nil {
+			continue
+		}
+		if err := p.loopback.mount(volumeOf(pv)); err != nil {
+			klog.Warningf("unable to mount volume %s: %v", pv.Name, err)
+		}
+	}
+	return nil
+}
+
+// StartStorageProvisioner will start storage provisioner server, enforcing quotas if it runs privileged
+func StartStorageProvisioner(pvDir string, quotas bool) error {
 	klog.Infof("Initializing the minikube storage provisioner...")
 	config, err := rest.InClusterConfig()
 	if err != nil {
@@ -132,7 +262,11 @@ func StartStorageProvisioner(pvDir string) error {
 
 	// Create the provisioner: it implements the Provisioner interface expected by
 	// the controller
-	hostPathProvisioner := NewHostPathProvisioner(pvDir)
+	hostPathProvisioner := newHostPathProvisioner(pvDir, execRunner, quotas)
+	if err := hostPathProvisioner.restoreMounts(context.Background(), clientset); err != nil {
+		klog.Warningf("unable to restore loopback volumes: %v", err)
+	}
+	go newVolumeResizer(clientset, hostPathProvisioner).run(context.Background())
 
 	// Start the provision controller which will dynamically provision hostPath
 	// PVs
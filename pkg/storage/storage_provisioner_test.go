@@ -0,0 +1,263 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	core "k8s.io/api/core/v1"
+	storageapi "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v6/controller"
+)
+
+// fakeRunner records the commands it runs, answering with canned outputs
+type fakeRunner struct {
+	cmds    []string
+	outputs map[string]string
+}
+
+func (f *fakeRunner) run(name string, args ...string) (string, error) {
+	cmd := strings.Join(append([]string{name}, args...), " ")
+	f.cmds = append(f.cmds, cmd)
+	return f.outputs[cmd], nil
+}
+
+func writeMounts(t *testing.T, lines ...string) {
+	t.Helper()
+	f := filepath.Join(t.TempDir(), "mounts")
+	if err := os.WriteFile(f, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("writing mounts: %v", err)
+	}
+	old := mountsFile
+	mountsFile = f
+	t.Cleanup(func() { mountsFile = old })
+}
+
+func provisionOptions(params map[string]string, size string) controller.ProvisionOptions {
+	reclaim := core.PersistentVolumeReclaimDelete
+	requests := core.ResourceList{}
+	if size != "" {
+		requests[core.ResourceStorage] = resource.MustParse(size)
+	}
+	return controller.ProvisionOptions{
+		StorageClass: &storageapi.StorageClass{ReclaimPolicy: &reclaim, Parameters: params},
+		PVName:       "pvc-1234",
+		PVC: &core.PersistentVolumeClaim{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "data"},
+			Spec: core.PersistentVolumeClaimSpec{
+				Resources: core.ResourceRequirements{Requests: requests},
+			},
+		},
+	}
+}
+
+func TestParseParameters(t *testing.T) {
+	tests := []struct {
+		params  map[string]string
+		want    volumeOptions
+		wantErr bool
+	}{
+		{nil, volumeOptions{Quota: quotaNone, Mode: 0777, UID: -1, GID: -1, OnDelete: onDeleteDelete}, false},
+		{map[string]string{"quota": "auto", "mode": "0750", "uid": "999", "gid": "0", "onDelete": "archive"}, volumeOptions{Quota: quotaAuto, Mode: 0750, UID: 999, GID: 0, OnDelete: onDeleteArchive}, false},
+		{map[string]string{"quota": "xfs"}, volumeOptions{}, true},
+		{map[string]string{"mode": "rwx"}, volumeOptions{}, true},
+		{map[string]string{"mode": "4777"}, volumeOptions{}, true},
+		{map[string]string{"uid": "-5"}, volumeOptions{}, true},
+		// keeping the data is the Retain reclaim policy, for which Delete is never called
+		{map[string]string{"onDelete": "retain"}, volumeOptions{}, true},
+		{map[string]string{"reclaim": "keep"}, volumeOptions{}, true},
+	}
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.params), func(t *testing.T) {
+			got, err := parseParameters(tc.params)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseParameters() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err == nil && got != tc.want {
+				t.Errorf("parseParameters() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProvisionAndArchive(t *testing.T) {
+	pvDir := t.TempDir()
+	p := newHostPathProvisioner(pvDir, (&fakeRunner{}).run, false)
+
+	pv, _, err := p.Provision(context.Background(), provisionOptions(map[string]string{"mode": "0750", "onDelete": "archive"}, "1Gi"))
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+	dir := filepath.Join(pvDir, "default", "data")
+	fi, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf("volume directory: %v", err)
+	}
+	if fi.Mode().Perm() != 0750 {
+		t.Errorf("volume mode = %v, want 0750", fi.Mode().Perm())
+	}
+	if pv.Annotations[quotaAnnotation] != quotaNone || pv.Annotations[onDeleteAnnotation] != onDeleteArchive {
+		t.Errorf("unexpected annotations: %v", pv.Annotations)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "file"), []byte("data"), 0644); err != nil {
+		t.Fatalf("writing to volume: %v", err)
+	}
+
+	pv.Spec.ClaimRef = &core.ObjectReference{Namespace: "default", Name: "data"}
+	if err := p.Delete(context.Background(), pv); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("volume directory still exists: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(pvDir, archiveDir, "default", "data-pvc-1234", "file")); err != nil {
+		t.Errorf("archived data: %v", err)
+	}
+}
+
+func TestProvisionProjectQuota(t *testing.T) {
+	pvDir := t.TempDir()
+	writeMounts(t, "/dev/sda1 / ext4 rw,relatime 0 0", fmt.Sprintf("/dev/sdb1 %s xfs rw,relatime,prjquota 0 0", pvDir))
+	r := &fakeRunner{}
+	p := newHostPathProvisioner(pvDir, r.run, true)
+
+	pv, _, err := p.Provision(context.Background(), provisionOptions(map[string]string{"quota": "auto"}, "1Mi"))
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+	if pv.Annotations[quotaAnnotation] != quotaProject {
+		t.Errorf("quota = %q, want %q", pv.Annotations[quotaAnnotation], quotaProject)
+	}
+	dir := filepath.Join(pvDir, "default", "data")
+	if err := p.project.Expand(volume{Dir: dir, Size: 2097152}); err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if err := p.Delete(context.Background(), pv); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	want := []string{
+		fmt.Sprintf("xfs_quota -x -c project -s -p %s 1 %s", dir, pvDir),
+		fmt.Sprintf("xfs_quota -x -c limit -p bhard=1048576 1 %s", pvDir),
+		fmt.Sprintf("xfs_quota -x -c limit -p bhard=2097152 1 %s", pvDir),
+		fmt.Sprintf("xfs_quota -x -c limit -p bhard=0 1 %s", pvDir),
+		fmt.Sprintf("xfs_quota -x -c project -C -p %s 1 %s", dir, pvDir),
+	}
+	if diff := cmp.Diff(want, r.cmds); diff != "" {
+		t.Errorf("unexpected commands (-want +got):\n%s", diff)
+	}
+	if projects, err := p.project.projects(); err != nil || len(projects) != 0 {
+		t.Errorf("projects after delete = %v, %v", projects, err)
+	}
+}
+
+func TestProvisionLoopbackQuota(t *testing.T) {
+	pvDir := t.TempDir()
+	writeMounts(t, "/dev/sda1 / ext4 rw,relatime 0 0")
+	img := filepath.Join(pvDir, loopbackDir, "pvc-1234.img")
+	r := &fakeRunner{outputs: map[string]string{"losetup -j " + img: fmt.Sprintf("/dev/loop3: [2049]:1234 (%s)\n", img)}}
+	p := newHostPathProvisioner(pvDir, r.run, true)
+
+	if _, _, err := p.Provision(context.Background(), provisionOptions(map[string]string{"quota": "auto"}, "")); err == nil {
+		t.Errorf("expected a claim without a storage request to be rejected")
+	}
+	if _, _, err := newHostPathProvisioner(pvDir, r.run, false).Provision(context.Background(), provisionOptions(map[string]string{"quota": "loopback"}, "1Mi")); err == nil {
+		t.Errorf("expected a quota to be rejected by an unprivileged provisioner")
+	}
+	pv, _, err := p.Provision(context.Background(), provisionOptions(map[string]string{"quota": "auto"}, "1Mi"))
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+	if pv.Annotations[quotaAnnotation] != quotaLoopback {
+		t.Errorf("quota = %q, want %q", pv.Annotations[quotaAnnotation], quotaLoopback)
+	}
+	dir := filepath.Join(pvDir, "default", "data")
+	if err := p.loopback.Expand(volume{Name: "pvc-1234", Dir: dir, Size: 2097152}); err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if fi, err := os.Stat(img); err != nil || fi.Size() != 2097152 {
+		t.Errorf("image after expansion: %v, %v", fi, err)
+	}
+
+	want := []string{
+		"mkfs.ext4 -q -F -m 0 " + img,
+		fmt.Sprintf("mount -o loop %s %s", img, dir),
+		"losetup -j " + img,
+		"losetup -c /dev/loop3",
+		"resize2fs /dev/loop3",
+	}
+	if diff := cmp.Diff(want, r.cmds); diff != "" {
+		t.Errorf("unexpected commands (-want +got):\n%s", diff)
+	}
+
+	if err := p.Delete(context.Background(), pv); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(img); !os.IsNotExist(err) {
+		t.Errorf("image still exists after delete: %v", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	pv := &core.PersistentVolume{
+		ObjectMeta: meta.ObjectMeta{Name: "pvc-1234", Annotations: map[string]string{provisionedByAnnotation: provisionerName, quotaAnnotation: quotaNone}},
+		Spec: core.PersistentVolumeSpec{
+			Capacity:               core.ResourceList{core.ResourceStorage: resource.MustParse("1Gi")},
+			PersistentVolumeSource: core.PersistentVolumeSource{HostPath: &core.HostPathVolumeSource{Path: "/tmp/hostpath-provisioner/default/data"}},
+		},
+	}
+	pvc := &core.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "data"},
+		Spec: core.PersistentVolumeClaimSpec{
+			VolumeName: "pvc-1234",
+			Resources:  core.ResourceRequirements{Requests: core.ResourceList{core.ResourceStorage: resource.MustParse("2Gi")}},
+		},
+		Status: core.PersistentVolumeClaimStatus{
+			Phase:    core.ClaimBound,
+			Capacity: core.ResourceList{core.ResourceStorage: resource.MustParse("1Gi")},
+		},
+	}
+	client := fake.NewSimpleClientset(pv, pvc)
+	r := newVolumeResizer(client, newHostPathProvisioner(t.TempDir(), (&fakeRunner{}).run, true))
+
+	if err := r.expand(context.Background(), pvc); err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	got, err := client.CoreV1().PersistentVolumes().Get(context.Background(), "pvc-1234", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("getting PV: %v", err)
+	}
+	if c := got.Spec.Capacity[core.ResourceStorage]; c.String() != "2Gi" {
+		t.Errorf("PV capacity = %s, want 2Gi", c.String())
+	}
+	gotPVC, err := client.CoreV1().PersistentVolumeClaims("default").Get(context.Background(), "data", meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("getting claim: %v", err)
+	}
+	if c := gotPVC.Status.Capacity[core.ResourceStorage]; c.String() != "2Gi" {
+		t.Errorf("claim capacity = %s, want 2Gi", c.String())
+	}
+}
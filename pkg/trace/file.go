@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"k8s.io/minikube/pkg/minikube/localpath"
+)
+
+// FileEnvVar is the name of the env variable which overrides the file spans are written to by the file tracer
+const FileEnvVar = "MINIKUBE_TRACE_FILE"
+
+// fileSpan is a span as written by the file exporter, one JSON object per line
+type fileSpan struct {
+	Name          string            `json:"name"`
+	TraceID       string            `json:"traceId"`
+	SpanID        string            `json:"spanId"`
+	ParentSpanID  string            `json:"parentSpanId,omitempty"`
+	StartTime     time.Time         `json:"startTime"`
+	EndTime       time.Time         `json:"endTime"`
+	DurationMs    float64           `json:"durationMs"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
+	Status        string            `json:"status"`
+	StatusMessage string            `json:"statusMessage,omitempty"`
+}
+
+// fileExporter appends spans as JSON lines to a file for offline analysis
+type fileExporter struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func initFileTracer() (*otelTracer, error) {
+	path := os.Getenv(FileEnvVar)
+	if path == "" {
+		path = filepath.Join(localpath.MiniPath(), "logs", "trace.json")
+	}
+	e, err := newFileExporter(path)
+	if err != nil {
+		return nil, err
+	}
+	return newOtelTracer(e)
+}
+
+func newFileExporter(path string) (*fileExporter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrapf(err, "creating %s", filepath.Dir(path))
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s", path)
+	}
+	return &fileExporter{f: f}, nil
+}
+
+func toFileSpan(s sdktrace.ReadOnlySpan) fileSpan {
+	fs := fileSpan{
+		Name:          s.Name(),
+		TraceID:       s.SpanContext().TraceID().String(),
+		SpanID:        s.SpanContext().SpanID().String(),
+		StartTime:     s.StartTime(),
+		EndTime:       s.EndTime(),
+		DurationMs:    float64(s.EndTime().Sub(s.StartTime())) / float64(time.Millisecond),
+		Status:        s.Status().Code.String(),
+		StatusMessage: s.Status().Description,
+	}
+	if s.Parent().IsValid() {
+		fs.ParentSpanID = s.Parent().SpanID().String()
+	}
+	if len(s.Attributes()) > 0 {
+		fs.Attributes = map[string]string{}
+		for _, kv := range s.Attributes() {
+			fs.Attributes[string(kv.Key)] = kv.Value.Emit()
+		}
+	}
+	return fs
+}
+
+// ExportSpans writes the spans to the file
+func (e *fileExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	enc := json.NewEncoder(e.f)
+	for _, s := range spans {
+		if err := enc.Encode(toFileSpan(s)); err != nil {
+			return errors.Wrap(err, "writing span")
+		}
+	}
+	return nil
+}
+
+// Shutdown closes the file
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.f.Close()
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trace
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/klog/v2"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// this is the name of the parent span to help identify it
+	// in the Cloud Trace UI.
+	parentSpanName = "minikube start"
+	// serviceName is reported as the service.name resource attribute unless overridden by OTEL_SERVICE_NAME
+	serviceName = "minikube"
+	// shutdownTimeout bounds how long flushing spans may delay exiting
+	shutdownTimeout = 10 * time.Second
+)
+
+// otelTracer sends the spans of `minikube start` to an OpenTelemetry exporter
+type otelTracer struct {
+	trace.Tracer
+	parentCtx context.Context
+	cleanup   func(context.Context) error
+
+	mu sync.Mutex
+	// spans are the started steps of `minikube start` which have not ended yet
+	spans map[string]trace.Span
+	// current is the name of the most recently started step, child spans are nested under it
+	current    string
+	currentCtx context.Context
+}
+
+// otelSpan is a child span started via an otelTracer
+type otelSpan struct {
+	trace.Span
+}
+
+func newOtelTracer(exporter sdktrace.SpanExporter) (*otelTracer, error) {
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(semconv.ServiceNameKey.String(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating resource")
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tp)
+
+	t := otel.Tracer(parentSpanName)
+
+	ctx, span := t.Start(context.Background(), parentSpanName)
+	return &otelTracer{
+		parentCtx: ctx,
+		cleanup:   tp.Shutdown,
+		Tracer:    t,
+		spans: map[string]trace.Span{
+			parentSpanName: span,
+		},
+	}, nil
+}
+
+// StartSpan starts a span for the next step of
+// `minikube start`
+func (t *otelTracer) StartSpan(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ctx, span := t.Tracer.Start(t.parentCtx, name)
+	t.spans[name] = span
+	t.current = name
+	t.currentCtx = ctx
+}
+
+// EndSpan ends the most recent span, indicating
+// that one step of `minikube start` has completed
+func (t *otelTracer) EndSpan(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span, ok := t.spans[name]
+	if !ok {
+		klog.Warningf("cannot end span %s as it was never started", name)
+		return
+	}
+	span.End()
+	delete(t.spans, name)
+	if t.current == name {
+		t.current = ""
+		t.currentCtx = nil
+	}
+}
+
+// StartChildSpan starts a span nested under the current step, or under the parent span between steps
+func (t *otelTracer) StartChildSpan(name string, attributes map[string]string) Span {
+	t.mu.Lock()
+	ctx := t.parentCtx
+	if t.currentCtx != nil {
+		ctx = t.currentCtx
+	}
+	t.mu.Unlock()
+
+	attrs := []attribute.KeyValue{}
+	for k, v := range attributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	_, span := t.Tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return &otelSpan{span}
+}
+
+// End ends the span, recording err if not nil
+func (s *otelSpan) End(err error) {
+	if err != nil {
+		s.Span.RecordError(err)
+		s.Span.SetStatus(codes.Error, err.Error())
+	}
+	s.Span.End()
+}
+
+// Cleanup ends the steps which are still running and the parent span, and flushes all spans to the exporter
+func (t *otelTracer) Cleanup() {
+	t.mu.Lock()
+	for name, span := range t.spans {
+		if name != parentSpanName {
+			span.End()
+		}
+	}
+	if span, ok := t.spans[parentSpanName]; ok {
+		span.End()
+	}
+	t.spans = map[string]trace.Span{}
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := t.cleanup(ctx); err != nil {
+		klog.Warningf("Fail to cleanup the trace: %s", err)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+)
+
+const (
+	otlpProtocolGRPC = "grpc"
+	otlpProtocolHTTP = "http/protobuf"
+)
+
+// otlpProtocol returns the protocol of the OTLP exporter, which the exporters leave to the caller to pick
+// from the OTEL_EXPORTER_OTLP_TRACES_PROTOCOL or OTEL_EXPORTER_OTLP_PROTOCOL env variables
+func otlpProtocol(getenv func(string) string) string {
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		if p := getenv(name); p != "" {
+			return p
+		}
+	}
+	return otlpProtocolGRPC
+}
+
+// initOTLPTracer exports spans to an OTLP collector, configured via the standard OTEL_EXPORTER_OTLP_* env variables
+func initOTLPTracer() (*otelTracer, error) {
+	var client otlptrace.Client
+	switch p := otlpProtocol(os.Getenv); p {
+	case otlpProtocolGRPC:
+		client = otlptracegrpc.NewClient()
+	case otlpProtocolHTTP:
+		client = otlptracehttp.NewClient()
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q, supported protocols include: [%s %s]", p, otlpProtocolGRPC, otlpProtocolHTTP)
+	}
+
+	exporter, err := otlptrace.New(context.Background(), client)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting OTLP exporter")
+	}
+	return newOtelTracer(exporter)
+}
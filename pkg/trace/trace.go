@@ -29,9 +29,21 @@ var (
 type minikubeTracer interface {
 	StartSpan(string)
 	EndSpan(string)
+	StartChildSpan(string, map[string]string) Span
 	Cleanup()
 }
 
+// Span is a span nested under the current step, started by StartChildSpan
+type Span interface {
+	// End ends the span, marking it as failed if err is not nil
+	End(err error)
+}
+
+// noopSpan is returned by StartChildSpan when tracing is disabled
+type noopSpan struct{}
+
+func (noopSpan) End(error) {}
+
 // Initialize intializes the global tracer variable
 func Initialize(t string) error {
 	tr, err := getTracer(t)
@@ -46,10 +58,14 @@ func getTracer(t string) (minikubeTracer, error) {
 	switch t {
 	case "gcp":
 		return initGCPTracer()
+	case "otlp":
+		return initOTLPTracer()
+	case "file":
+		return initFileTracer()
 	case "":
 		return nil, nil
 	}
-	return nil, fmt.Errorf("%s is not a valid tracer, valid tracers include: [gcp]", t)
+	return nil, fmt.Errorf("%s is not a valid tracer, valid tracers include: [gcp otlp file]", t)
 }
 
 // StartSpan starts a span with the given name
@@ -68,6 +84,15 @@ func EndSpan(name string) {
 	tracer.EndSpan(name)
 }
 
+// StartChildSpan starts a span with the given name and attributes nested under the current step,
+// such as a command run on a node. The span must be ended by the caller.
+func StartChildSpan(name string, attributes map[string]string) Span {
+	if tracer == nil {
+		return noopSpan{}
+	}
+	return tracer.StartChildSpan(name, attributes)
+}
+
 // Cleanup is responsible for trace related cleanup,
 // such as flushing all data
 func Cleanup() {
@@ -75,4 +100,5 @@ func Cleanup() {
 		return
 	}
 	tracer.Cleanup()
+	tracer = nil
 }
@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOTLPProtocol(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{nil, otlpProtocolGRPC},
+		{map[string]string{"OTEL_EXPORTER_OTLP_PROTOCOL": "http/protobuf"}, otlpProtocolHTTP},
+		{map[string]string{"OTEL_EXPORTER_OTLP_PROTOCOL": "grpc", "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL": "http/protobuf"}, otlpProtocolHTTP},
+	}
+	for _, tc := range tests {
+		if got := otlpProtocol(func(k string) string { return tc.env[k] }); got != tc.want {
+			t.Errorf("otlpProtocol(%v) = %q, want %q", tc.env, got, tc.want)
+		}
+	}
+}
+
+func TestFileTracer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.json")
+	t.Setenv(FileEnvVar, path)
+
+	if err := Initialize("file"); err != nil {
+		t.Fatalf("failed to initialize the file tracer: %v", err)
+	}
+	StartSpan("step")
+	StartChildSpan("RunCmd", map[string]string{"command": "true"}).End(nil)
+	StartChildSpan("LoadImage", nil).End(fmt.Errorf("no space left"))
+	// the step is never ended, Cleanup has to end it
+	Cleanup()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open trace file: %v", err)
+	}
+	defer f.Close()
+
+	spans := map[string]fileSpan{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var fs fileSpan
+		if err := json.Unmarshal(s.Bytes(), &fs); err != nil {
+			t.Fatalf("failed to decode %q: %v", s.Text(), err)
+		}
+		spans[fs.Name] = fs
+	}
+	if len(spans) != 4 {
+		t.Fatalf("got spans %v, want 4", spans)
+	}
+	if spans["step"].ParentSpanID != spans[parentSpanName].SpanID {
+		t.Errorf("step is not a child of %q", parentSpanName)
+	}
+	if spans["RunCmd"].ParentSpanID != spans["step"].SpanID || spans["RunCmd"].Attributes["command"] != "true" {
+		t.Errorf("unexpected RunCmd span: %+v", spans["RunCmd"])
+	}
+	if spans["LoadImage"].Status != "Error" || spans["LoadImage"].StatusMessage != "no space left" {
+		t.Errorf("unexpected LoadImage span: %+v", spans["LoadImage"])
+	}
+}
@@ -18,11 +18,13 @@ package util
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
 	"math/big"
 	"net"
 	"os"
@@ -86,7 +88,7 @@ func GenerateSignedCert(certPath, keyPath, cn string, ips []net.IP, alternateDNS
 	if decodedSignerKey == nil {
 		return errors.New("Unable to decode key")
 	}
-	signerKey, err := x509.ParsePKCS1PrivateKey(decodedSignerKey.Bytes)
+	signerKey, err := parsePrivateKey(decodedSignerKey)
 	if err != nil {
 		return errors.Wrap(err, "Error parsing private key: decodedSignerKey.Bytes")
 	}
@@ -134,7 +136,82 @@ func loadOrGeneratePrivateKey(keyPath string) (*rsa.PrivateKey, error) {
 	return priv, nil
 }
 
-func writeCertsAndKeys(template *x509.Certificate, certPath string, signeeKey *rsa.PrivateKey, keyPath string, parent *x509.Certificate, signingKey *rsa.PrivateKey) error {
+// ValidateCA checks that an external CA, or an intermediate CA, can sign the apiserver and client certs of a cluster:
+// its key must match the cert, which must be a CA currently valid for both server and client authentication, without name constraints.
+func ValidateCA(certPath, keyPath string) error {
+	certBytes, err := os.ReadFile(certPath)
+	if err != nil {
+		return errors.Wrap(err, "reading CA cert")
+	}
+	certBlock, _ := pem.Decode(certBytes)
+	if certBlock == nil || certBlock.Type != "CERTIFICATE" {
+		return fmt.Errorf("%s does not contain a PEM certificate", certPath)
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return errors.Wrapf(err, "parsing %s", certPath)
+	}
+	keyBytes, err := os.ReadFile(keyPath)
+	if err != nil {
+		return errors.Wrap(err, "reading CA key")
+	}
+	keyBlock, _ := pem.Decode(keyBytes)
+	if keyBlock == nil {
+		return fmt.Errorf("%s does not contain a PEM private key", keyPath)
+	}
+	key, err := parsePrivateKey(keyBlock)
+	if err != nil {
+		return errors.Wrapf(err, "parsing %s", keyPath)
+	}
+
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(cert.PublicKey) {
+		return fmt.Errorf("the key %s does not match the certificate %s", keyPath, certPath)
+	}
+	if !cert.BasicConstraintsValid || !cert.IsCA {
+		return fmt.Errorf("%s is not a CA certificate", certPath)
+	}
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return fmt.Errorf("%s is only valid from %s to %s", certPath, cert.NotBefore, cert.NotAfter)
+	}
+	if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return fmt.Errorf("%s is not allowed to sign certificates", certPath)
+	}
+	if len(cert.ExtKeyUsage) > 0 {
+		usages := map[x509.ExtKeyUsage]bool{}
+		for _, u := range cert.ExtKeyUsage {
+			usages[u] = true
+		}
+		if !usages[x509.ExtKeyUsageAny] && (!usages[x509.ExtKeyUsageServerAuth] || !usages[x509.ExtKeyUsageClientAuth]) {
+			return fmt.Errorf("%s is not allowed to sign certificates for both server and client authentication", certPath)
+		}
+	}
+	if len(cert.PermittedDNSDomains) > 0 || len(cert.ExcludedDNSDomains) > 0 || len(cert.PermittedIPRanges) > 0 || len(cert.ExcludedIPRanges) > 0 {
+		return fmt.Errorf("%s has name constraints, which the apiserver names and IPs may not satisfy", certPath)
+	}
+	return nil
+}
+
+// parsePrivateKey parses a PKCS#1, PKCS#8 or EC private key
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+func writeCertsAndKeys(template *x509.Certificate, certPath string, signeeKey *rsa.PrivateKey, keyPath string, parent *x509.Certificate, signingKey crypto.Signer) error {
 	derBytes, err := x509.CreateCertificate(rand.Reader, template, parent, &signeeKey.PublicKey, signingKey)
 	if err != nil {
 		return errors.Wrap(err, "Error creating certificate")
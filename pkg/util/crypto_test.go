@@ -17,12 +17,18 @@ limitations under the License.
 package util
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
+	"math/big"
 	"net"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"k8s.io/minikube/pkg/minikube/constants"
 )
@@ -133,3 +139,88 @@ func TestGenerateSignedCert(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateCA(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// writeCA writes a self-signed cert for an ECDSA key stored as PKCS#8, as corporate CAs often are
+	writeCA := func(name string, tmpl x509.Certificate) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl.SerialNumber = big.NewInt(1)
+		tmpl.Subject = pkix.Name{CommonName: name}
+		if tmpl.NotAfter.IsZero() {
+			tmpl.NotBefore = time.Now().Add(-time.Hour)
+			tmpl.NotAfter = time.Now().Add(time.Hour)
+		}
+		der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certPath := filepath.Join(tmpDir, name+".crt")
+		keyPath := filepath.Join(tmpDir, name+".key")
+		if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return certPath, keyPath
+	}
+
+	caCert, caKey := writeCA("corp-ca", x509.Certificate{IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign})
+	otherCert, otherKey := writeCA("other-ca", x509.Certificate{IsCA: true, BasicConstraintsValid: true})
+	leafCert, leafKey := writeCA("leaf", x509.Certificate{BasicConstraintsValid: true, KeyUsage: x509.KeyUsageDigitalSignature})
+	noSignCert, noSignKey := writeCA("no-sign", x509.Certificate{IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageDigitalSignature})
+	serverCert, serverKey := writeCA("server-only", x509.Certificate{IsCA: true, BasicConstraintsValid: true, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+	expiredCert, expiredKey := writeCA("expired", x509.Certificate{IsCA: true, BasicConstraintsValid: true, NotBefore: time.Now().Add(-2 * time.Hour), NotAfter: time.Now().Add(-time.Hour)})
+	constrainedCert, constrainedKey := writeCA("constrained", x509.Certificate{IsCA: true, BasicConstraintsValid: true, PermittedDNSDomains: []string{"corp.example.com"}})
+	minikubeCert := filepath.Join(tmpDir, "minikube.crt")
+	minikubeKey := filepath.Join(tmpDir, "minikube.key")
+	if err := GenerateCACert(minikubeCert, minikubeKey, "minikubeCA"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description string
+		certPath    string
+		keyPath     string
+		err         bool
+	}{
+		{description: "PKCS#8 ECDSA CA", certPath: caCert, keyPath: caKey},
+		{description: "CA without key usage", certPath: otherCert, keyPath: otherKey},
+		{description: "PKCS#1 RSA CA", certPath: minikubeCert, keyPath: minikubeKey},
+		{description: "key of another CA", certPath: caCert, keyPath: otherKey, err: true},
+		{description: "not a CA", certPath: leafCert, keyPath: leafKey, err: true},
+		{description: "not allowed to sign certs", certPath: noSignCert, keyPath: noSignKey, err: true},
+		{description: "server auth only", certPath: serverCert, keyPath: serverKey, err: true},
+		{description: "expired", certPath: expiredCert, keyPath: expiredKey, err: true},
+		{description: "name constraints", certPath: constrainedCert, keyPath: constrainedKey, err: true},
+		{description: "key given as cert", certPath: caKey, keyPath: caKey, err: true},
+		{description: "missing key", certPath: caCert, keyPath: filepath.Join(tmpDir, "missing.key"), err: true},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			err := ValidateCA(test.certPath, test.keyPath)
+			if err != nil && !test.err {
+				t.Errorf("ValidateCA() error = %v", err)
+			}
+			if err == nil && test.err {
+				t.Errorf("ValidateCA() should have returned error, but didn't")
+			}
+		})
+	}
+
+	// the cluster certs can be signed by an ECDSA CA
+	certPath := filepath.Join(tmpDir, "apiserver.crt")
+	if err := GenerateSignedCert(certPath, filepath.Join(tmpDir, "apiserver.key"), "minikube", nil, []string{"localhost"}, caCert, caKey, constants.DefaultCertExpiration); err != nil {
+		t.Fatalf("GenerateSignedCert() with an ECDSA CA error = %v", err)
+	}
+}